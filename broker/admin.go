@@ -0,0 +1,98 @@
+package broker
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotSupported 驱动不支持该操作
+	ErrNotSupported = errors.New("broker: operation not supported")
+)
+
+// Admin 可选的主题管理接口，由支持的驱动实现，通过 AsAdmin 获取。
+// 驱动无法完成的操作返回 ErrNotSupported。
+type Admin interface {
+	// CreateTopic 创建主题，主题已存在时不返回错误。partitions 和 replication 小于等于 0 时使用服务端默认值，
+	// config 为驱动相关的主题配置
+	CreateTopic(ctx context.Context, name string, partitions, replication int, config map[string]string) error
+
+	// DeleteTopic 删除主题
+	DeleteTopic(ctx context.Context, name string) error
+
+	// ListTopics 列出所有主题
+	ListTopics(ctx context.Context) ([]string, error)
+
+	// DescribeTopic 查询主题的分区和配置
+	DescribeTopic(ctx context.Context, name string) (*TopicInfo, error)
+
+	// ListConsumerGroups 列出所有消费组
+	ListConsumerGroups(ctx context.Context) ([]string, error)
+
+	// DescribeGroup 查询消费组的成员和消费延迟
+	DescribeGroup(ctx context.Context, group string) (*GroupInfo, error)
+}
+
+// TopicInfo 主题信息
+type TopicInfo struct {
+	Name       string
+	Partitions []PartitionInfo
+	Config     map[string]string
+	Messages   int64 // 未消费的消息数，驱动不支持时为 -1
+}
+
+// PartitionInfo 分区信息
+type PartitionInfo struct {
+	ID       int
+	Leader   string
+	Replicas []string
+}
+
+// GroupInfo 消费组信息
+type GroupInfo struct {
+	Name    string
+	State   string
+	Members []GroupMember
+	Lag     []PartitionLag
+}
+
+// TotalLag 消费组在所有分区上的延迟之和
+func (g *GroupInfo) TotalLag() int64 {
+	var total int64
+	for _, l := range g.Lag {
+		total += l.Lag
+	}
+	return total
+}
+
+// GroupMember 消费组成员
+type GroupMember struct {
+	ID       string
+	ClientID string
+	Host     string
+}
+
+// PartitionLag 消费组在一个分区上的消费进度
+type PartitionLag struct {
+	Topic     string
+	Partition int
+	Committed int64 // 已提交的位移，驱动不支持时为 -1
+	End       int64 // 分区末尾的位移，驱动不支持时为 -1
+	Lag       int64
+}
+
+// AsAdmin 返回 broker 的管理接口。
+// broker 为装饰器时通过 Unwrap() Broker 逐层查找内部的 broker。
+func AsAdmin(b Broker) (Admin, bool) {
+	for b != nil {
+		if admin, ok := b.(Admin); ok {
+			return admin, true
+		}
+		u, ok := b.(interface{ Unwrap() Broker })
+		if !ok {
+			return nil, false
+		}
+		b = u.Unwrap()
+	}
+	return nil, false
+}
@@ -0,0 +1,48 @@
+package broker
+
+import (
+	"context"
+	"testing"
+)
+
+type adminBroker struct {
+	Broker
+}
+
+func (adminBroker) CreateTopic(context.Context, string, int, int, map[string]string) error {
+	return nil
+}
+func (adminBroker) DeleteTopic(context.Context, string) error    { return nil }
+func (adminBroker) ListTopics(context.Context) ([]string, error) { return nil, nil }
+func (adminBroker) DescribeTopic(context.Context, string) (*TopicInfo, error) {
+	return nil, nil
+}
+func (adminBroker) ListConsumerGroups(context.Context) ([]string, error) { return nil, nil }
+func (adminBroker) DescribeGroup(context.Context, string) (*GroupInfo, error) {
+	return nil, nil
+}
+
+type wrapper struct {
+	Broker
+}
+
+func (w wrapper) Unwrap() Broker { return w.Broker }
+
+func TestAsAdmin(t *testing.T) {
+	if _, ok := AsAdmin(wrapper{wrapper{adminBroker{}}}); !ok {
+		t.Error("admin not found through wrappers")
+	}
+	if _, ok := AsAdmin(wrapper{}); ok {
+		t.Error("unexpected admin")
+	}
+	if _, ok := AsAdmin(nil); ok {
+		t.Error("unexpected admin for nil broker")
+	}
+}
+
+func TestGroupInfoTotalLag(t *testing.T) {
+	g := &GroupInfo{Lag: []PartitionLag{{Lag: 3}, {Lag: 4}}}
+	if g.TotalLag() != 7 {
+		t.Errorf("unexpected total lag: %d", g.TotalLag())
+	}
+}
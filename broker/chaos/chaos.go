@@ -0,0 +1,214 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var (
+	// ErrInjected 注入的发布或确认失败
+	ErrInjected = errors.New("chaos: injected fault")
+	// ErrDisconnected 注入的强制断开
+	ErrDisconnected = errors.New("chaos: injected disconnect")
+)
+
+// chaosBroker 在任意 broker.Broker 外层注入故障，用于测试处理器、重试与幂等逻辑。
+// 随机数使用固定种子时，相同的调用顺序会得到相同的故障序列，配合 memory broker 可以在 CI 中稳定复现。
+//
+//	b := chaos.NewBroker(memory.NewBroker(),
+//		chaos.WithSeed(42),
+//		chaos.WithRule("order", chaos.Rule{DuplicateRate: 0.2, ReorderRate: 0.1}),
+//	)
+type chaosBroker struct {
+	broker.Broker
+
+	options options
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	reconnectMu sync.Mutex
+	reconnect   *time.Timer
+
+	log *log.Helper
+}
+
+func NewBroker(b broker.Broker, opts ...Option) broker.Broker {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.seeded {
+		o.seed = time.Now().UnixNano()
+	}
+
+	cb := &chaosBroker{
+		Broker:  b,
+		options: o,
+		rand:    rand.New(rand.NewSource(o.seed)),
+		log:     newLoggerHelper(o.logger),
+	}
+	cb.log.Infof("fault injection enabled, seed: %d", o.seed)
+
+	return cb
+}
+
+// rule topic 对应的故障规则
+func (b *chaosBroker) rule(topic string) Rule {
+	if rule, ok := b.options.rules[topic]; ok {
+		return rule
+	}
+	return b.options.defaultRule
+}
+
+// hit 按概率判定是否注入故障，概率为 0 时不消耗随机数
+func (b *chaosBroker) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	b.randMu.Lock()
+	defer b.randMu.Unlock()
+
+	return b.rand.Float64() < rate
+}
+
+// delay 固定延迟加上 [0, jitter) 的随机延迟
+func (b *chaosBroker) delay(latency, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return latency
+	}
+
+	b.randMu.Lock()
+	defer b.randMu.Unlock()
+
+	return latency + time.Duration(b.rand.Int63n(int64(jitter)))
+}
+
+func (b *chaosBroker) sleep(ctx context.Context, latency, jitter time.Duration) error {
+	d := b.delay(latency, jitter)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Unwrap 返回被装饰的 broker
+func (b *chaosBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+func (b *chaosBroker) Disconnect() error {
+	b.reconnectMu.Lock()
+	if b.reconnect != nil {
+		b.reconnect.Stop()
+		b.reconnect = nil
+	}
+	b.reconnectMu.Unlock()
+
+	return b.Broker.Disconnect()
+}
+
+// disconnect 强制断开内部 broker，delay 大于 0 时在延迟之后自动重连
+func (b *chaosBroker) disconnect(topic string, delay time.Duration) {
+	b.log.Warnf("injected disconnect on publish [%s]", topic)
+
+	if err := b.Broker.Disconnect(); err != nil {
+		b.log.Errorf("injected disconnect failed: %s", err.Error())
+	}
+
+	if delay <= 0 {
+		return
+	}
+
+	b.reconnectMu.Lock()
+	defer b.reconnectMu.Unlock()
+
+	if b.reconnect != nil {
+		b.reconnect.Stop()
+	}
+	b.reconnect = time.AfterFunc(delay, func() {
+		if err := b.Broker.Connect(); err != nil {
+			b.log.Errorf("reconnect after injected disconnect failed: %s", err.Error())
+		}
+	})
+}
+
+// injectPublish 按规则注入发布前的断开、失败和延迟
+func (b *chaosBroker) injectPublish(ctx context.Context, topic string) error {
+	rule := b.rule(topic)
+
+	if b.hit(rule.DisconnectRate) {
+		b.disconnect(topic, rule.ReconnectDelay)
+		return ErrDisconnected
+	}
+	if b.hit(rule.PublishErrorRate) {
+		b.log.Warnf("injected publish error [%s]", topic)
+		return ErrInjected
+	}
+	return b.sleep(ctx, rule.PublishLatency, rule.PublishJitter)
+}
+
+func (b *chaosBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	if err := b.injectPublish(ctx, topic); err != nil {
+		return err
+	}
+
+	return b.Broker.Publish(ctx, topic, msg, opts...)
+}
+
+func (b *chaosBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	if err := b.injectPublish(ctx, topic); err != nil {
+		return nil, err
+	}
+
+	return broker.PublishWithResult(ctx, b.Broker, topic, msg, opts...)
+}
+
+func (b *chaosBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	sub := &subscriber{
+		b:       b,
+		topic:   topic,
+		handler: handler,
+	}
+
+	inner, err := b.Broker.Subscribe(topic, sub.handle, binder, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sub.Subscriber = inner
+
+	return sub, nil
+}
+
+// event 注入确认失败的事件
+type event struct {
+	broker.Event
+
+	b    *chaosBroker
+	rate float64
+}
+
+func (e *event) Ack() error {
+	if e.b.hit(e.rate) {
+		e.b.log.Warnf("injected ack error [%s]", e.Topic())
+		return ErrInjected
+	}
+	return e.Event.Ack()
+}
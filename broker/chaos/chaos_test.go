@@ -0,0 +1,170 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/memory"
+)
+
+func newTestBroker(t *testing.T, opts ...Option) broker.Broker {
+	b := NewBroker(memory.NewBroker(), opts...)
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// collect 订阅 topic 并按处理顺序记录消息体
+func collect(t *testing.T, b broker.Broker, topic string) *[]string {
+	var got []string
+	if _, err := b.Subscribe(topic, func(_ context.Context, evt broker.Event) error {
+		got = append(got, string(evt.Message().Body.([]byte)))
+		return nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	return &got
+}
+
+func publishN(b broker.Broker, topic string, n int) []error {
+	var errs []error
+	for i := 0; i < n; i++ {
+		errs = append(errs, b.Publish(context.Background(), topic, string(rune('a'+i))))
+	}
+	return errs
+}
+
+func TestDeterministicSeed(t *testing.T) {
+	rule := Rule{DropRate: 0.2, DuplicateRate: 0.2, ReorderRate: 0.2, PublishErrorRate: 0.1}
+
+	run := func() ([]string, []error) {
+		b := newTestBroker(t, WithSeed(42), WithDefaultRule(rule))
+		got := collect(t, b, "test")
+		errs := publishN(b, "test", 20)
+		return *got, errs
+	}
+
+	got1, errs1 := run()
+	got2, errs2 := run()
+	if !reflect.DeepEqual(got1, got2) || !reflect.DeepEqual(errs1, errs2) {
+		t.Errorf("same seed produced different faults:\n%v\n%v", got1, got2)
+	}
+}
+
+func TestPerTopicRule(t *testing.T) {
+	b := newTestBroker(t, WithRule("lossy", Rule{DropRate: 1}), WithRule("dup", Rule{DuplicateRate: 1}))
+
+	lossy := collect(t, b, "lossy")
+	dup := collect(t, b, "dup")
+	clean := collect(t, b, "clean")
+
+	publishN(b, "lossy", 2)
+	publishN(b, "dup", 2)
+	publishN(b, "clean", 2)
+
+	if len(*lossy) != 0 {
+		t.Errorf("messages not dropped: %v", *lossy)
+	}
+	if !reflect.DeepEqual(*dup, []string{"a", "a", "b", "b"}) {
+		t.Errorf("messages not duplicated: %v", *dup)
+	}
+	if !reflect.DeepEqual(*clean, []string{"a", "b"}) {
+		t.Errorf("unexpected faults on clean topic: %v", *clean)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	b := newTestBroker(t, WithSeed(1), WithRule("test", Rule{ReorderRate: 0.5, ReorderWindow: time.Hour}))
+	got := collect(t, b, "test")
+
+	publishN(b, "test", 10)
+
+	if len(*got) < 9 {
+		t.Fatalf("messages lost: %v", *got)
+	}
+	inOrder := true
+	for i := 1; i < len(*got); i++ {
+		if (*got)[i] < (*got)[i-1] {
+			inOrder = false
+		}
+	}
+	if inOrder {
+		t.Errorf("messages not reordered: %v", *got)
+	}
+}
+
+func TestReorderWindow(t *testing.T) {
+	b := newTestBroker(t, WithRule("test", Rule{ReorderRate: 1, ReorderWindow: 10 * time.Millisecond}))
+
+	done := make(chan string, 1)
+	_, _ = b.Subscribe("test", func(_ context.Context, evt broker.Event) error {
+		done <- string(evt.Message().Body.([]byte))
+		return nil
+	}, nil)
+
+	publishN(b, "test", 1)
+
+	select {
+	case body := <-done:
+		if body != "a" {
+			t.Errorf("unexpected message: %s", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("held message not delivered after window")
+	}
+}
+
+func TestPublishError(t *testing.T) {
+	b := newTestBroker(t, WithDefaultRule(Rule{PublishErrorRate: 1}))
+	got := collect(t, b, "test")
+
+	if errs := publishN(b, "test", 1); !errors.Is(errs[0], ErrInjected) {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	if len(*got) != 0 {
+		t.Errorf("failed publish delivered: %v", *got)
+	}
+}
+
+func TestAckError(t *testing.T) {
+	b := newTestBroker(t, WithDefaultRule(Rule{AckErrorRate: 1}))
+
+	var ackErr error
+	_, _ = b.Subscribe("test", func(_ context.Context, evt broker.Event) error {
+		ackErr = evt.Ack()
+		return nil
+	}, nil)
+
+	publishN(b, "test", 1)
+	if !errors.Is(ackErr, ErrInjected) {
+		t.Errorf("unexpected ack error: %v", ackErr)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	b := newTestBroker(t, WithRule("flaky", Rule{DisconnectRate: 1, ReconnectDelay: 10 * time.Millisecond}))
+	got := collect(t, b, "test")
+
+	if errs := publishN(b, "flaky", 1); !errors.Is(errs[0], ErrDisconnected) {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	if errs := publishN(b, "test", 1); !errors.Is(errs[0], memory.ErrNotConnected) {
+		t.Errorf("inner broker not disconnected: %v", errs[0])
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if err := b.Publish(context.Background(), "test", "a"); err == nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(*got) != 1 {
+		t.Errorf("not reconnected: %v", *got)
+	}
+}
@@ -0,0 +1,47 @@
+package chaos
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+type options struct {
+	seed        int64
+	seeded      bool
+	defaultRule Rule
+	rules       map[string]Rule
+	logger      log.Logger
+}
+
+type Option func(*options)
+
+// WithSeed 随机数种子，相同的种子和相同的调用顺序会注入相同的故障，默认使用当前时间
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
+
+// WithRule 指定 topic 的故障规则，优先于 WithDefaultRule
+func WithRule(topic string, rule Rule) Option {
+	return func(o *options) {
+		if o.rules == nil {
+			o.rules = make(map[string]Rule)
+		}
+		o.rules[topic] = rule
+	}
+}
+
+// WithDefaultRule 没有单独配置规则的 topic 使用的故障规则
+func WithDefaultRule(rule Rule) Option {
+	return func(o *options) {
+		o.defaultRule = rule
+	}
+}
+
+// WithLogger 日志器，为空时使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
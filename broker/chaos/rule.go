@@ -0,0 +1,46 @@
+package chaos
+
+import (
+	"time"
+)
+
+// Rule 故障注入规则，概率取值范围为 [0, 1]，为 0 时不注入对应的故障
+type Rule struct {
+	// PublishErrorRate 发布直接返回 ErrInjected 的概率
+	PublishErrorRate float64
+	// PublishLatency 发布前的固定延迟
+	PublishLatency time.Duration
+	// PublishJitter 发布前额外的随机延迟，取值范围为 [0, PublishJitter)
+	PublishJitter time.Duration
+
+	// DisconnectRate 发布时强制断开内部 broker 的概率，断开后本次发布返回 ErrDisconnected
+	DisconnectRate float64
+	// ReconnectDelay 强制断开之后自动重连的延迟，为 0 时不自动重连，需要调用 Connect
+	ReconnectDelay time.Duration
+
+	// DropRate 丢弃投递的概率，被丢弃的消息不会交给处理器，并视为处理成功
+	DropRate float64
+	// DuplicateRate 重复投递的概率，处理器会连续收到两次相同的消息
+	DuplicateRate float64
+	// ReorderRate 乱序投递的概率，消息会被暂存，在下一条消息之后或 ReorderWindow 超时后投递
+	ReorderRate float64
+	// ReorderWindow 乱序暂存的最长时间，为 0 时使用 defaultReorderWindow
+	ReorderWindow time.Duration
+
+	// DeliveryLatency 投递前的固定延迟
+	DeliveryLatency time.Duration
+	// DeliveryJitter 投递前额外的随机延迟，取值范围为 [0, DeliveryJitter)
+	DeliveryJitter time.Duration
+
+	// AckErrorRate 处理器调用 Event.Ack 时返回 ErrInjected 的概率，消息不会被确认
+	AckErrorRate float64
+}
+
+const defaultReorderWindow = 100 * time.Millisecond
+
+func (r Rule) reorderWindow() time.Duration {
+	if r.ReorderWindow > 0 {
+		return r.ReorderWindow
+	}
+	return defaultReorderWindow
+}
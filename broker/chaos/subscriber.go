@@ -0,0 +1,121 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// heldDelivery 为了乱序而暂存的投递
+type heldDelivery struct {
+	ctx   context.Context
+	evt   broker.Event
+	timer *time.Timer
+}
+
+type subscriber struct {
+	broker.Subscriber
+
+	b *chaosBroker
+
+	topic   string
+	handler broker.Handler
+
+	mu   sync.Mutex
+	held *heldDelivery
+}
+
+// handle 包装业务处理器：依次判定丢弃、乱序和重复，再注入投递延迟和确认失败
+func (s *subscriber) handle(ctx context.Context, evt broker.Event) error {
+	rule := s.b.rule(s.topic)
+
+	if s.b.hit(rule.DropRate) {
+		s.b.log.Warnf("injected drop [%s]", s.topic)
+		return nil
+	}
+
+	reorder := s.b.hit(rule.ReorderRate)
+	duplicate := s.b.hit(rule.DuplicateRate)
+
+	if err := s.b.sleep(ctx, rule.DeliveryLatency, rule.DeliveryJitter); err != nil {
+		return err
+	}
+
+	if rule.AckErrorRate > 0 {
+		evt = &event{Event: evt, b: s.b, rate: rule.AckErrorRate}
+	}
+
+	// 暂存的消息在下一条消息处理之后投递，处理结果只写入日志，原消息视为处理成功
+	if reorder && s.hold(ctx, evt, rule.reorderWindow()) {
+		s.b.log.Warnf("injected reorder [%s]", s.topic)
+		return nil
+	}
+
+	err := s.handler(ctx, evt)
+	if duplicate {
+		s.b.log.Warnf("injected duplicate [%s]", s.topic)
+		err = errors.Join(err, s.handler(ctx, evt))
+	}
+
+	s.flush()
+
+	return err
+}
+
+// hold 暂存一条投递，已有暂存的投递时返回 false
+func (s *subscriber) hold(ctx context.Context, evt broker.Event, window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.held != nil {
+		return false
+	}
+
+	held := &heldDelivery{ctx: context.WithoutCancel(ctx), evt: evt}
+	held.timer = time.AfterFunc(window, func() {
+		s.deliverHeld(held)
+	})
+	s.held = held
+
+	return true
+}
+
+// flush 投递暂存的消息
+func (s *subscriber) flush() {
+	s.mu.Lock()
+	held := s.held
+	s.mu.Unlock()
+
+	if held != nil && held.timer.Stop() {
+		s.deliverHeld(held)
+	}
+}
+
+func (s *subscriber) deliverHeld(held *heldDelivery) {
+	s.mu.Lock()
+	if s.held != held {
+		s.mu.Unlock()
+		return
+	}
+	s.held = nil
+	s.mu.Unlock()
+
+	if err := s.handler(held.ctx, held.evt); err != nil {
+		s.b.log.Errorf("reordered delivery [%s] failed: %s", s.topic, err.Error())
+	}
+}
+
+// Unsubscribe 丢弃暂存的投递并取消订阅
+func (s *subscriber) Unsubscribe(removeFromManager bool) error {
+	s.mu.Lock()
+	if s.held != nil {
+		s.held.timer.Stop()
+		s.held = nil
+	}
+	s.mu.Unlock()
+
+	return s.Subscriber.Unsubscribe(removeFromManager)
+}
@@ -0,0 +1,226 @@
+package claimcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	HeaderKey    = "x-claim-check-key"    // 对象在存储中的键
+	HeaderSHA256 = "x-claim-check-sha256" // 消息体的 SHA-256 校验和，十六进制
+	HeaderSize   = "x-claim-check-size"   // 消息体的字节数
+)
+
+var (
+	ErrNoHeaderOption   = errors.New("claimcheck: header option is required to publish large messages")
+	ErrChecksumMismatch = errors.New("claimcheck: checksum mismatch")
+)
+
+// claimCheckBroker 实现 Claim-Check 模式：编码后超过阈值的消息体写入对象存储，只通过 broker 发送引用消息头；
+// 订阅端在处理器之前取回对象并校验，再按订阅的 binder 解码。
+//
+// 订阅时内部 broker 使用空的 binder 以获得原始字节，因此解码失败会作为处理失败（ErrorStageHandle）交给 ErrorHandler。
+//
+//	b := claimcheck.NewBroker(kafka.NewBroker(...), store,
+//		claimcheck.WithHeaderOption(func(h broker.Headers) broker.PublishOption {
+//			return kafka.WithHeaders(map[string]interface{}{...})
+//		}),
+//	)
+type claimCheckBroker struct {
+	broker.Broker
+
+	store   Store
+	options options
+
+	log *log.Helper
+}
+
+func NewBroker(b broker.Broker, store Store, opts ...Option) broker.Broker {
+	o := options{
+		threshold: defaultThreshold,
+		ttl:       defaultTTL,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &claimCheckBroker{
+		Broker:  b,
+		store:   store,
+		options: o,
+		log:     newLoggerHelper(o.logger),
+	}
+}
+
+// Unwrap 返回被装饰的 broker
+func (b *claimCheckBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+func (b *claimCheckBroker) codec() encoding.Codec {
+	return b.Options().Codec
+}
+
+// placeholder 大消息的消息体，编码器为空时为空字节，否则为能被常用编码器编码的空消息
+func (b *claimCheckBroker) placeholder() broker.Any {
+	if b.codec() == nil {
+		return []byte{}
+	}
+	return &emptypb.Empty{}
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// publishFunc 实际发布消息的函数，不返回回执时 result 为 nil
+type publishFunc func(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error)
+
+func (b *claimCheckBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	_, err := b.publish(ctx, topic, msg, opts, func(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+		return nil, b.Broker.Publish(ctx, topic, msg, opts...)
+	})
+	return err
+}
+
+func (b *claimCheckBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	return b.publish(ctx, topic, msg, opts, func(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+		return broker.PublishWithResult(ctx, b.Broker, topic, msg, opts...)
+	})
+}
+
+func (b *claimCheckBroker) publish(ctx context.Context, topic string, msg broker.Any, opts []broker.PublishOption, send publishFunc) (*broker.PublishResult, error) {
+	data, err := broker.Marshal(b.codec(), msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) <= b.options.threshold {
+		return send(ctx, topic, msg, opts...)
+	}
+
+	if b.options.headerOption == nil {
+		return nil, ErrNoHeaderOption
+	}
+
+	key := uuid.New().String()
+	if err = b.store.Put(ctx, key, data, b.options.ttl); err != nil {
+		return nil, fmt.Errorf("claimcheck: store message body failed: %w", err)
+	}
+
+	headers := broker.Headers{
+		HeaderKey:    key,
+		HeaderSHA256: checksum(data),
+		HeaderSize:   strconv.Itoa(len(data)),
+	}
+
+	result, err := send(ctx, topic, b.placeholder(), append(opts, b.options.headerOption(headers))...)
+	if err != nil {
+		if delErr := b.store.Delete(ctx, key); delErr != nil {
+			b.log.Errorf("delete object [%s] after publish failure failed: %s", key, delErr.Error())
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (b *claimCheckBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	autoAck := broker.NewSubscribeOptions(opts...).AutoAck
+
+	return b.Broker.Subscribe(topic, func(ctx context.Context, evt broker.Event) error {
+		return b.handle(ctx, evt, handler, binder, autoAck)
+	}, nil, opts...)
+}
+
+func (b *claimCheckBroker) handle(ctx context.Context, evt broker.Event, handler broker.Handler, binder broker.Binder, autoAck bool) error {
+	m := evt.Message()
+	if m == nil {
+		return handler(ctx, evt)
+	}
+
+	key := m.GetHeader(HeaderKey)
+
+	data, ok := m.Body.([]byte)
+	if len(key) > 0 {
+		var err error
+		if data, err = b.fetch(ctx, key, m.Headers); err != nil {
+			return err
+		}
+	} else if !ok {
+		return handler(ctx, evt)
+	}
+
+	if binder != nil {
+		m.Body = binder()
+		if err := broker.Unmarshal(b.codec(), data, &m.Body); err != nil {
+			return err
+		}
+	} else {
+		m.Body = data
+	}
+
+	if len(key) == 0 || !b.options.deleteAfterAck {
+		return handler(ctx, evt)
+	}
+
+	if !autoAck {
+		return handler(ctx, &event{Event: evt, ack: func() { b.delete(ctx, key) }})
+	}
+
+	if err := handler(ctx, evt); err != nil {
+		return err
+	}
+	b.delete(ctx, key)
+
+	return nil
+}
+
+// fetch 取回对象并校验长度和校验和
+func (b *claimCheckBroker) fetch(ctx context.Context, key string, headers broker.Headers) ([]byte, error) {
+	data, err := b.store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("claimcheck: fetch object [%s] failed: %w", key, err)
+	}
+
+	if size := headers[HeaderSize]; len(size) > 0 && size != strconv.Itoa(len(data)) {
+		return nil, fmt.Errorf("%w: object [%s] size %d, want %s", ErrChecksumMismatch, key, len(data), size)
+	}
+	if sum := headers[HeaderSHA256]; len(sum) > 0 && sum != checksum(data) {
+		return nil, fmt.Errorf("%w: object [%s]", ErrChecksumMismatch, key)
+	}
+
+	return data, nil
+}
+
+func (b *claimCheckBroker) delete(ctx context.Context, key string) {
+	if err := b.store.Delete(ctx, key); err != nil {
+		b.log.Errorf("delete object [%s] failed: %s", key, err.Error())
+	}
+}
+
+// event 确认成功后删除对象
+type event struct {
+	broker.Event
+	ack func()
+}
+
+func (e *event) Ack() error {
+	if err := e.Event.Ack(); err != nil {
+		return err
+	}
+	e.ack()
+	return nil
+}
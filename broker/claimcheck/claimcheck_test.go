@@ -0,0 +1,164 @@
+package claimcheck
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/memory"
+)
+
+type document struct {
+	Content string `json:"content"`
+}
+
+func headerOption(h broker.Headers) broker.PublishOption {
+	return memory.WithHeaders(h)
+}
+
+func newTestBroker(t *testing.T, store Store, opts ...Option) broker.Broker {
+	inner := memory.NewBroker(broker.WithCodec("json"), broker.WithErrorHandler(
+		func(context.Context, broker.Event, broker.ErrorStage, error) broker.ErrorAction {
+			return broker.ErrorActionAck
+		},
+	))
+	if err := inner.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return NewBroker(inner, store, append([]Option{WithThreshold(32), WithHeaderOption(headerOption)}, opts...)...)
+}
+
+func subscribe(t *testing.T, b broker.Broker) *[]string {
+	var got []string
+	_, err := broker.Subscribe(b, "docs", func(_ context.Context, _ string, _ broker.Headers, d *document) error {
+		got = append(got, d.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &got
+}
+
+func TestClaimCheck(t *testing.T) {
+	store := NewMemoryStore()
+	b := newTestBroker(t, store)
+	got := subscribe(t, b)
+
+	large := strings.Repeat("x", 64)
+	for _, content := range []string{"small", large} {
+		if err := b.Publish(context.Background(), "docs", &document{Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(*got) != 2 || (*got)[0] != "small" || (*got)[1] != large {
+		t.Errorf("unexpected messages: %v", *got)
+	}
+	if store.Len() != 1 {
+		t.Errorf("large message not stored: %d", store.Len())
+	}
+}
+
+func TestClaimCheckRawBody(t *testing.T) {
+	b := newTestBroker(t, NewMemoryStore())
+
+	var got []string
+	_, _ = b.Subscribe("raw", func(_ context.Context, evt broker.Event) error {
+		got = append(got, string(evt.Message().Body.([]byte)))
+		return nil
+	}, nil)
+
+	_ = b.Publish(context.Background(), "raw", &document{Content: strings.Repeat("y", 32)})
+	if len(got) != 1 || !strings.Contains(got[0], "yyyy") {
+		t.Errorf("unexpected raw body: %v", got)
+	}
+}
+
+type tamperStore struct {
+	*MemoryStore
+}
+
+func (s tamperStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.MemoryStore.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data = append([]byte(nil), data...)
+	data[len(data)-2] = 'z'
+	return data, nil
+}
+
+func TestClaimCheckChecksumMismatch(t *testing.T) {
+	var handleErr error
+	inner := memory.NewBroker(broker.WithCodec("json"), broker.WithErrorHandler(
+		func(_ context.Context, _ broker.Event, _ broker.ErrorStage, err error) broker.ErrorAction {
+			handleErr = err
+			return broker.ErrorActionAck
+		},
+	))
+	_ = inner.Connect()
+	b := NewBroker(inner, tamperStore{NewMemoryStore()}, WithThreshold(32), WithHeaderOption(headerOption))
+
+	got := subscribe(t, b)
+	_ = b.Publish(context.Background(), "docs", &document{Content: strings.Repeat("x", 64)})
+
+	if !errors.Is(handleErr, ErrChecksumMismatch) {
+		t.Errorf("unexpected error: %v", handleErr)
+	}
+	if len(*got) != 0 {
+		t.Errorf("handler called with corrupted body: %v", *got)
+	}
+}
+
+func TestClaimCheckDeleteAfterAck(t *testing.T) {
+	store := NewMemoryStore()
+	b := newTestBroker(t, store, WithDeleteAfterAck(true))
+	subscribe(t, b)
+
+	_ = b.Publish(context.Background(), "docs", &document{Content: strings.Repeat("x", 64)})
+	if store.Len() != 0 {
+		t.Errorf("object not deleted after ack: %d", store.Len())
+	}
+
+	store = NewMemoryStore()
+	b = newTestBroker(t, store, WithDeleteAfterAck(true))
+	var evt broker.Event
+	_, _ = b.Subscribe("manual", func(_ context.Context, e broker.Event) error {
+		evt = e
+		return nil
+	}, nil, broker.DisableAutoAck())
+
+	_ = b.Publish(context.Background(), "manual", &document{Content: strings.Repeat("x", 64)})
+	if store.Len() != 1 {
+		t.Fatalf("object deleted before ack: %d", store.Len())
+	}
+	if err := evt.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 0 {
+		t.Errorf("object not deleted after manual ack: %d", store.Len())
+	}
+}
+
+func TestClaimCheckNoHeaderOption(t *testing.T) {
+	inner := memory.NewBroker()
+	_ = inner.Connect()
+	b := NewBroker(inner, NewMemoryStore(), WithThreshold(1))
+
+	if err := b.Publish(context.Background(), "docs", "hello"); !errors.Is(err, ErrNoHeaderOption) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	s := NewMemoryStore()
+	_ = s.Put(context.Background(), "k", []byte("v"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.Get(context.Background(), "k"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired object returned: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package claimcheck
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStore 基于本地或共享文件系统的对象存储。
+// 文件的前 8 个字节保存过期时间（Unix 纳秒，0 表示不过期），读取时检查，也可以定期调用 Purge 清理。
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore 在 dir 目录下保存对象，目录不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if len(key) == 0 || strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", fmt.Errorf("claimcheck: invalid key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+func (s *FileStore) Put(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免读到写了一半的对象
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	var header [headerSize]byte
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(header[:], uint64(time.Now().Add(ttl).UnixNano()))
+	}
+
+	if _, err = tmp.Write(append(header[:], data...)); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("claimcheck: corrupted object %q", key)
+	}
+
+	if expired(data[:headerSize]) {
+		_ = os.Remove(path)
+		return nil, ErrNotFound
+	}
+
+	return data[headerSize:], nil
+}
+
+func (s *FileStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Purge 删除所有已过期的对象
+func (s *FileStore) Purge(_ context.Context) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		if fileExpired(path) {
+			if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+const headerSize = 8
+
+func expired(header []byte) bool {
+	expireAt := int64(binary.BigEndian.Uint64(header))
+	return expireAt > 0 && time.Now().UnixNano() > expireAt
+}
+
+func fileExpired(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+
+	var header [headerSize]byte
+	if _, err = io.ReadFull(f, header[:]); err != nil {
+		return false
+	}
+	return expired(header[:])
+}
@@ -0,0 +1,66 @@
+package claimcheck
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Put(ctx, "k1", []byte("hello"), 0); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.Get(ctx, "k1")
+	if err != nil || string(data) != "hello" {
+		t.Errorf("unexpected object: %q, %v", data, err)
+	}
+
+	if err = s.Delete(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get(ctx, "k1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted object returned: %v", err)
+	}
+	if err = s.Delete(ctx, "k1"); err != nil {
+		t.Errorf("delete missing object failed: %v", err)
+	}
+
+	if err = s.Put(ctx, "../escape", []byte("x"), 0); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestFileStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, _ := NewFileStore(dir)
+
+	_ = s.Put(ctx, "expired", []byte("x"), time.Millisecond)
+	_ = s.Put(ctx, "kept", []byte("y"), time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := s.Get(ctx, "expired"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired object returned: %v", err)
+	}
+
+	_ = s.Put(ctx, "expired", []byte("x"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if err := s.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "kept" {
+		t.Errorf("unexpected files after purge: %v", entries)
+	}
+}
@@ -0,0 +1,65 @@
+package claimcheck
+
+import (
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	defaultThreshold = 256 * 1024
+	defaultTTL       = 24 * time.Hour
+)
+
+// HeaderOption 将消息头转换为驱动的发布选项，例如：
+//
+//	func(h broker.Headers) broker.PublishOption { return pulsar.WithHeaders(h) }
+type HeaderOption func(headers broker.Headers) broker.PublishOption
+
+type options struct {
+	threshold      int
+	ttl            time.Duration
+	headerOption   HeaderOption
+	deleteAfterAck bool
+	logger         log.Logger
+}
+
+type Option func(*options)
+
+// WithThreshold 编码后超过该字节数的消息体写入对象存储，默认为 256KB
+func WithThreshold(size int) Option {
+	return func(o *options) {
+		o.threshold = size
+	}
+}
+
+// WithTTL 对象的过期时间，为 0 时不过期，默认为 24 小时
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithHeaderOption 引用通过消息头发送，各驱动设置消息头的发布选项不同，发送大消息之前必须设置
+func WithHeaderOption(fn HeaderOption) Option {
+	return func(o *options) {
+		o.headerOption = fn
+	}
+}
+
+// WithDeleteAfterAck 消息确认后立即删除对象。
+// 只适用于每条消息只有一个消费者的场景，存在多个订阅者时应当依赖 TTL 过期。
+func WithDeleteAfterAck(enable bool) Option {
+	return func(o *options) {
+		o.deleteAfterAck = enable
+	}
+}
+
+// WithLogger 日志器，为空时使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
@@ -0,0 +1,86 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsS3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/tx7do/kratos-transport/broker/claimcheck"
+)
+
+var _ claimcheck.Store = (*Store)(nil)
+
+// Store 基于 S3 兼容对象存储（AWS S3、MinIO、OSS 等）的 Claim-Check 存储。
+// 过期时间写入对象的 Expires 属性，读取时检查；物理删除依赖存储桶的生命周期规则。
+type Store struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+}
+
+// NewStore 创建对象存储，prefix 为对象键的前缀
+func NewStore(client s3iface.S3API, bucket, prefix string) *Store {
+	return &Store{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (s *Store) Put(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	input := &awsS3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+		Body:   bytes.NewReader(data),
+	}
+	if ttl > 0 {
+		input.Expires = aws.Time(time.Now().Add(ttl))
+	}
+
+	_, err := s.client.PutObjectWithContext(ctx, input)
+	return err
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &awsS3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if isNotFound(err) {
+		return nil, claimcheck.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = out.Body.Close() }()
+
+	if expires := aws.StringValue(out.Expires); len(expires) > 0 {
+		if t, err := time.Parse(time.RFC1123, expires); err == nil && time.Now().After(t) {
+			return nil, claimcheck.ErrNotFound
+		}
+	}
+
+	return io.ReadAll(out.Body)
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &awsS3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func isNotFound(err error) bool {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		return aerr.Code() == awsS3.ErrCodeNoSuchKey || aerr.Code() == "NotFound"
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awsS3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker/claimcheck"
+)
+
+type fakeObject struct {
+	data    []byte
+	expires *time.Time
+}
+
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string]fakeObject
+}
+
+func (f *fakeS3) PutObjectWithContext(_ aws.Context, in *awsS3.PutObjectInput, _ ...request.Option) (*awsS3.PutObjectOutput, error) {
+	data, _ := io.ReadAll(in.Body)
+	f.objects[*in.Bucket+"/"+*in.Key] = fakeObject{data: data, expires: in.Expires}
+	return &awsS3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObjectWithContext(_ aws.Context, in *awsS3.GetObjectInput, _ ...request.Option) (*awsS3.GetObjectOutput, error) {
+	obj, ok := f.objects[*in.Bucket+"/"+*in.Key]
+	if !ok {
+		return nil, awserr.New(awsS3.ErrCodeNoSuchKey, "not found", nil)
+	}
+	out := &awsS3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(obj.data))}
+	if obj.expires != nil {
+		out.Expires = aws.String(obj.expires.UTC().Format(time.RFC1123))
+	}
+	return out, nil
+}
+
+func (f *fakeS3) DeleteObjectWithContext(_ aws.Context, in *awsS3.DeleteObjectInput, _ ...request.Option) (*awsS3.DeleteObjectOutput, error) {
+	delete(f.objects, *in.Bucket+"/"+*in.Key)
+	return &awsS3.DeleteObjectOutput{}, nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeS3{objects: make(map[string]fakeObject)}
+	s := NewStore(client, "bucket", "claims/")
+
+	assert.NoError(t, s.Put(ctx, "k1", []byte("hello"), time.Hour))
+	assert.Contains(t, client.objects, "bucket/claims/k1")
+
+	data, err := s.Get(ctx, "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	assert.NoError(t, s.Delete(ctx, "k1"))
+	_, err = s.Get(ctx, "k1")
+	assert.ErrorIs(t, err, claimcheck.ErrNotFound)
+
+	expired := time.Now().Add(-time.Hour)
+	client.objects["bucket/claims/k2"] = fakeObject{data: []byte("x"), expires: &expired}
+	_, err = s.Get(ctx, "k2")
+	assert.ErrorIs(t, err, claimcheck.ErrNotFound)
+}
@@ -0,0 +1,85 @@
+package claimcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound 对象不存在或已过期
+	ErrNotFound = errors.New("claimcheck: object not found")
+)
+
+// Store 保存大消息体的对象存储
+type Store interface {
+	// Put 保存对象，ttl 大于 0 时对象在过期后不可读取
+	Put(ctx context.Context, key string, data []byte, ttl time.Duration) error
+
+	// Get 读取对象，不存在或已过期时返回 ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryObject struct {
+	data     []byte
+	expireAt time.Time
+}
+
+// MemoryStore 进程内的对象存储，用于测试
+type MemoryStore struct {
+	sync.Mutex
+	objects map[string]memoryObject
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+func (s *MemoryStore) Put(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+
+	obj := memoryObject{data: append([]byte(nil), data...)}
+	if ttl > 0 {
+		obj.expireAt = time.Now().Add(ttl)
+	}
+	s.objects[key] = obj
+
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !obj.expireAt.IsZero() && time.Now().After(obj.expireAt) {
+		delete(s.objects, key)
+		return nil, ErrNotFound
+	}
+
+	return obj.data, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+// Len 对象数量，包括已过期但尚未清理的对象
+func (s *MemoryStore) Len() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return len(s.objects)
+}
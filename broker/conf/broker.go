@@ -0,0 +1,213 @@
+package conf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// URLOptionsFunc 驱动的 URL 解析函数，例如 kafka.URLOptions
+type URLOptionsFunc func(u *url.URL) ([]broker.Option, error)
+
+// Load 从 kratos 配置中读取 key 对应的 broker 配置，例如：
+//
+//	data:
+//	  kafka:
+//	    driver: kafka
+//	    addrs: [ "127.0.0.1:9092" ]
+//	    codec: json
+//	    params: { group: "order" }
+//
+// 使用 Load(c, "data.kafka") 读取。
+func Load(c config.Config, key string) (*Broker, error) {
+	var bc Broker
+	if err := c.Value(key).Scan(&bc); err != nil {
+		return nil, fmt.Errorf("broker conf: load %q failed: %w", key, err)
+	}
+	return &bc, nil
+}
+
+// Watch 监听 key 对应的 broker 配置，变化时回调 fn，解析失败时写入 logger（为空时使用 kratos 的全局日志器）。
+// 连接相关的配置需要重建 broker 才能生效，订阅列表可以通过 SubscriptionManager 在线更新。
+func Watch(c config.Config, key string, logger log.Logger, fn func(*Broker)) error {
+	l := broker.NewLoggerHelper(logger, "broker", "conf")
+	return c.Watch(key, func(_ string, value config.Value) {
+		var bc Broker
+		if err := value.Scan(&bc); err != nil {
+			l.Errorf("watch %q: scan failed: %s", key, err.Error())
+			return
+		}
+		fn(&bc)
+	})
+}
+
+// URL 将配置转换为连接 URL。
+// 配置了 url 时以其为基础，driver 与 addrs 非空时分别覆盖 scheme 与主机列表；
+// codec、params 写入查询参数，sasl 的用户名和密码写入 URL 的用户信息，认证机制写入 sasl 参数。
+func URL(c *Broker) (*url.URL, error) {
+	if c == nil {
+		return nil, errors.New("broker conf: config is nil")
+	}
+
+	u := &url.URL{}
+	if len(c.Url) > 0 {
+		var err error
+		if u, err = url.Parse(c.Url); err != nil {
+			return nil, fmt.Errorf("broker conf: invalid url: %w", err)
+		}
+	}
+
+	if len(c.Driver) > 0 {
+		u.Scheme = c.Driver
+	}
+	if len(u.Scheme) == 0 {
+		return nil, errors.New("broker conf: driver is empty")
+	}
+
+	if len(c.Addrs) > 0 {
+		u.Host = strings.Join(c.Addrs, ",")
+	}
+
+	query := u.Query()
+	if len(c.Codec) > 0 {
+		query.Set("codec", c.Codec)
+	}
+	for k, v := range c.Params {
+		query.Set(k, v)
+	}
+
+	if c.Sasl != nil {
+		if len(c.Sasl.Password) > 0 {
+			u.User = url.UserPassword(c.Sasl.Username, c.Sasl.Password)
+		} else if len(c.Sasl.Username) > 0 {
+			u.User = url.User(c.Sasl.Username)
+		}
+		if len(c.Sasl.Mechanism) > 0 {
+			query.Set("sasl", c.Sasl.Mechanism)
+		}
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u, nil
+}
+
+// TLSConfig 根据证书文件创建 tls.Config，c 为空时返回 nil
+func TLSConfig(c *TLS) (*tls.Config, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if len(c.CaFile) > 0 {
+		ca, err := os.ReadFile(c.CaFile)
+		if err != nil {
+			return nil, fmt.Errorf("broker conf: read ca file failed: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("broker conf: no certificate found in %s", c.CaFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(c.CertFile) > 0 || len(c.KeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("broker conf: load key pair failed: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// Options 将配置转换为 broker 选项。
+// parse 为驱动的 URL 解析函数，负责地址、编解码器、认证以及 params 中的驱动参数；TLS 与链路追踪由本函数处理。
+// schemes 非空时，配置的 driver 必须是其中之一，避免把其他驱动的配置交给 parse。
+func Options(c *Broker, parse URLOptionsFunc, schemes ...string) ([]broker.Option, error) {
+	u, err := URL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(schemes) > 0 && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+		return nil, fmt.Errorf("broker conf: driver %q is not one of %v", u.Scheme, schemes)
+	}
+
+	opts, err := parse(u)
+	if err != nil {
+		return nil, err
+	}
+
+	commonOpts, err := commonOptions(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, commonOpts...), nil
+}
+
+// NewBroker 通过 broker.Open 创建配置对应的 broker，驱动必须已经被导入。
+// opts 在配置之后应用，优先级更高。返回的 broker 尚未 Init 与 Connect。
+func NewBroker(c *Broker, opts ...broker.Option) (broker.Broker, error) {
+	u, err := URL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	commonOpts, err := commonOptions(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return broker.Open(u.String(), append(commonOpts, opts...)...)
+}
+
+// commonOptions 无法通过 URL 表达的配置：TLS 与链路追踪
+func commonOptions(c *Broker) ([]broker.Option, error) {
+	var opts []broker.Option
+
+	if c.Tls != nil {
+		tlsConfig, err := TLSConfig(c.Tls)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, broker.WithEnableSecure(true), broker.WithTLSConfig(tlsConfig))
+	}
+
+	if c.Tracing.GetEnable() {
+		opts = append(opts, broker.WithGlobalTracerProvider(), broker.WithGlobalPropagator())
+	}
+
+	return opts, nil
+}
+
+// SubscribeOptions 将订阅配置转换为订阅选项
+func SubscribeOptions(c *Subscription) []broker.SubscribeOption {
+	var opts []broker.SubscribeOption
+	if len(c.GetQueue()) > 0 {
+		opts = append(opts, broker.WithQueueName(c.GetQueue()))
+	}
+	if c.AutoAck != nil && !c.GetAutoAck() {
+		opts = append(opts, broker.DisableAutoAck())
+	}
+	if len(c.GetDeadLetterQueue()) > 0 {
+		opts = append(opts, broker.WithDeadLetterQueue(c.GetDeadLetterQueue()))
+	}
+	return opts
+}
@@ -0,0 +1,367 @@
+package conf
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+	_ "github.com/go-kratos/kratos/v2/encoding/yaml"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type fakeSubscriber struct {
+	b     *fakeBroker
+	topic string
+	opts  broker.SubscribeOptions
+}
+
+func (s *fakeSubscriber) Options() broker.SubscribeOptions { return s.opts }
+func (s *fakeSubscriber) Topic() string                    { return s.topic }
+func (s *fakeSubscriber) Unsubscribe(bool) error {
+	delete(s.b.subs, s.topic)
+	return nil
+}
+
+type fakeBroker struct {
+	options broker.Options
+	subs    map[string]*fakeSubscriber
+}
+
+func (b *fakeBroker) Name() string                { return "fake" }
+func (b *fakeBroker) Options() broker.Options     { return b.options }
+func (b *fakeBroker) Address() string             { return "" }
+func (b *fakeBroker) Init(...broker.Option) error { return nil }
+func (b *fakeBroker) Connect() error              { return nil }
+func (b *fakeBroker) Disconnect() error           { return nil }
+func (b *fakeBroker) Publish(context.Context, string, broker.Any, ...broker.PublishOption) error {
+	return nil
+}
+func (b *fakeBroker) Subscribe(topic string, _ broker.Handler, _ broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	sub := &fakeSubscriber{b: b, topic: topic, opts: broker.NewSubscribeOptions(opts...)}
+	b.subs[topic] = sub
+	return sub, nil
+}
+func (b *fakeBroker) Request(context.Context, string, broker.Any, ...broker.RequestOption) (broker.Any, error) {
+	return nil, nil
+}
+
+func init() {
+	broker.Register("conftest", func(u *url.URL, opts ...broker.Option) (broker.Broker, error) {
+		urlOpts, err := broker.ParseURL(u, broker.URLParams{
+			"group": broker.URLString(func(string) broker.Option { return func(*broker.Options) {} }),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &fakeBroker{
+			options: broker.NewOptionsAndApply(append(urlOpts, opts...)...),
+			subs:    make(map[string]*fakeSubscriber),
+		}, nil
+	})
+}
+
+const testConfig = `
+data:
+  broker:
+    driver: conftest
+    addrs: [ "host1:1234", "host2:1234" ]
+    codec: json
+    params:
+      group: order
+    tracing:
+      enable: true
+    subscriptions:
+      - topic: order.created
+        queue: order
+        auto_ack: false
+      - topic: order.paid
+        handler: paid
+`
+
+func loadTestConfig(t *testing.T) config.Config {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.New(config.WithSource(file.NewSource(path)))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestLoadAndNewBroker(t *testing.T) {
+	bc, err := Load(loadTestConfig(t), "data.broker")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	u, err := URL(bc)
+	if err != nil {
+		t.Fatalf("url failed: %v", err)
+	}
+	if u.String() != "conftest://host1:1234,host2:1234?codec=json&group=order" {
+		t.Errorf("unexpected url: %s", u)
+	}
+
+	b, err := NewBroker(bc)
+	if err != nil {
+		t.Fatalf("new broker failed: %v", err)
+	}
+	options := b.Options()
+	if !reflect.DeepEqual(options.Addrs, []string{"host1:1234", "host2:1234"}) {
+		t.Errorf("unexpected addrs: %v", options.Addrs)
+	}
+	if options.Codec == nil || options.Codec.Name() != "json" {
+		t.Errorf("unexpected codec: %v", options.Codec)
+	}
+	if len(options.Tracings) != 2 {
+		t.Errorf("tracing not applied: %d", len(options.Tracings))
+	}
+
+	if len(bc.Subscriptions) != 2 {
+		t.Fatalf("unexpected subscriptions: %v", bc.Subscriptions)
+	}
+	if so := broker.NewSubscribeOptions(SubscribeOptions(bc.Subscriptions[0])...); so.AutoAck || so.Queue != "order" {
+		t.Errorf("unexpected subscribe options: %+v", so)
+	}
+	if so := broker.NewSubscribeOptions(SubscribeOptions(bc.Subscriptions[1])...); !so.AutoAck {
+		t.Errorf("auto ack should keep the default: %+v", so)
+	}
+}
+
+func TestOptionsDriverMismatch(t *testing.T) {
+	parse := func(u *url.URL) ([]broker.Option, error) { return nil, nil }
+
+	if _, err := Options(&Broker{Driver: "amqp"}, parse, "kafka"); err == nil {
+		t.Error("expected error for mismatched driver")
+	}
+	if _, err := Options(&Broker{Driver: "KAFKA"}, parse, "kafka"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSubscriptionManagerApply(t *testing.T) {
+	b := &fakeBroker{subs: make(map[string]*fakeSubscriber)}
+
+	handler := func(context.Context, broker.Event) error { return nil }
+	mgr := NewSubscriptionManager(b, map[string]Route{
+		"a":    {Handler: handler},
+		"paid": {Handler: handler},
+	}, nil)
+
+	if err := mgr.Apply([]*Subscription{
+		{Topic: "a", Queue: "q1"},
+		{Topic: "b", Handler: "paid"},
+	}); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(b.subs) != 0 {
+		t.Fatalf("subscribed before start: %v", b.subs)
+	}
+
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if len(b.subs) != 2 {
+		t.Fatalf("unexpected subscriptions: %v", b.subs)
+	}
+	first := b.subs["a"]
+
+	autoAck := false
+	if err := mgr.Apply([]*Subscription{
+		{Topic: "a", Queue: "q1"},
+		{Topic: "b", Handler: "paid", AutoAck: &autoAck},
+		{Topic: "c", Handler: "unknown"},
+	}); err == nil {
+		t.Error("expected error for unknown handler")
+	}
+	if len(b.subs) != 2 {
+		t.Fatalf("unexpected subscriptions: %v", b.subs)
+	}
+	if b.subs["a"] != first {
+		t.Error("unchanged subscription should not be resubscribed")
+	}
+	if b.subs["b"].opts.AutoAck {
+		t.Error("changed subscription should be resubscribed")
+	}
+
+	if err := mgr.Apply(nil); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(b.subs) != 0 {
+		t.Errorf("subscriptions not removed: %v", b.subs)
+	}
+}
+
+func TestSubscriptionManagerDuplicateTopic(t *testing.T) {
+	b := &fakeBroker{subs: make(map[string]*fakeSubscriber)}
+
+	handler := func(context.Context, broker.Event) error { return nil }
+	mgr := NewSubscriptionManager(b, map[string]Route{"a": {Handler: handler}}, nil)
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	if err := mgr.Apply([]*Subscription{
+		{Topic: "a", Queue: "q1"},
+		{Topic: "a", Queue: "q2"},
+	}); err == nil {
+		t.Error("expected error for duplicate topic")
+	}
+	if sub, ok := b.subs["a"]; !ok || sub.opts.Queue != "q1" {
+		t.Errorf("first subscription should be kept: %v", b.subs)
+	}
+
+	if err := mgr.Stop(); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if len(b.subs) != 0 {
+		t.Errorf("subscriptions not removed: %v", b.subs)
+	}
+}
+
+type memorySource struct {
+	kvs chan []*config.KeyValue
+	yml string
+}
+
+func (s *memorySource) Load() ([]*config.KeyValue, error) {
+	return []*config.KeyValue{{Key: "config.yaml", Value: []byte(s.yml), Format: "yaml"}}, nil
+}
+
+func (s *memorySource) Watch() (config.Watcher, error) { return s, nil }
+
+func (s *memorySource) Next() ([]*config.KeyValue, error) {
+	kvs, ok := <-s.kvs
+	if !ok {
+		return nil, context.Canceled
+	}
+	return kvs, nil
+}
+
+func (s *memorySource) Stop() error { return nil }
+
+func TestWatch(t *testing.T) {
+	src := &memorySource{kvs: make(chan []*config.KeyValue), yml: testConfig}
+	c := config.New(config.WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		close(src.kvs)
+		_ = c.Close()
+	}()
+
+	changed := make(chan *Broker, 1)
+	if err := Watch(c, "data.broker", nil, func(bc *Broker) { changed <- bc }); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	src.kvs <- []*config.KeyValue{{Key: "config.yaml", Value: []byte(`
+data:
+  broker:
+    driver: conftest
+    subscriptions:
+      - topic: order.refunded
+`), Format: "yaml"}}
+
+	select {
+	case bc := <-changed:
+		if len(bc.Subscriptions) != 1 || bc.Subscriptions[0].Topic != "order.refunded" {
+			t.Errorf("unexpected subscriptions: %v", bc.Subscriptions)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("watch callback not called")
+	}
+}
+
+func TestSubscriptionManagerWatchStop(t *testing.T) {
+	src := &memorySource{kvs: make(chan []*config.KeyValue), yml: testConfig}
+	c := config.New(config.WithSource(src))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		close(src.kvs)
+		_ = c.Close()
+	}()
+
+	bc, err := Load(c, "data.broker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &fakeBroker{subs: make(map[string]*fakeSubscriber)}
+	var tracked []string
+	subscribe := func(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+		sub, err := b.Subscribe(topic, handler, binder, opts...)
+		if err != nil {
+			return nil, err
+		}
+		tracked = append(tracked, topic)
+		return TrackSubscriber(sub, func() {
+			tracked = slices.DeleteFunc(tracked, func(v string) bool { return v == topic })
+		}), nil
+	}
+
+	handler := func(context.Context, broker.Event) error { return nil }
+	mgr, err := NewServerSubscriptions(c, "data.broker", bc, map[string]Route{
+		"order.created":  {Handler: handler},
+		"paid":           {Handler: handler},
+		"order.refunded": {Handler: handler},
+	}, subscribe, nil)
+	if err != nil {
+		t.Fatalf("new server subscriptions failed: %v", err)
+	}
+	if err = mgr.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if len(b.subs) != 2 || len(tracked) != 2 {
+		t.Fatalf("unexpected subscriptions: %v %v", b.subs, tracked)
+	}
+
+	if err = mgr.Stop(); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if len(b.subs) != 0 || len(tracked) != 0 {
+		t.Fatalf("subscriptions not removed: %v %v", b.subs, tracked)
+	}
+
+	// 停止之后的配置变化被忽略
+	src.yml = `
+data:
+  broker:
+    driver: conftest
+    subscriptions:
+      - topic: order.refunded
+`
+	src.kvs <- []*config.KeyValue{{Key: "config.yaml", Value: []byte(src.yml), Format: "yaml"}}
+	time.Sleep(100 * time.Millisecond)
+	mgr.Lock()
+	wanted := len(mgr.wanted)
+	mgr.Unlock()
+	if wanted != 2 || len(b.subs) != 0 {
+		t.Fatalf("change applied after stop: %d %v", wanted, b.subs)
+	}
+
+	// 再次启动时读取最新的配置
+	if err = mgr.Start(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	if _, ok := b.subs["order.refunded"]; !ok || len(b.subs) != 1 {
+		t.Fatalf("latest config not applied: %v", b.subs)
+	}
+	_ = mgr.Stop()
+}
@@ -0,0 +1,3 @@
+package conf
+
+//go:generate protoc --proto_path=. --go_out=paths=source_relative:. ./conf.proto
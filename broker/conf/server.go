@@ -0,0 +1,53 @@
+package conf
+
+import (
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// LoadOptions 读取 key 对应的 broker 配置，并通过 parse 转换为驱动选项，driver 必须是 schemes 之一（为空时不检查）
+func LoadOptions(c config.Config, key string, parse URLOptionsFunc, schemes ...string) (*Broker, []broker.Option, error) {
+	bc, err := Load(c, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts, err := Options(bc, parse, schemes...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bc, opts, nil
+}
+
+// NewServerSubscriptions 创建传输层服务使用的订阅管理器：校验 bc 中的订阅列表，并监听 key 对应配置的变化。
+// subscribe 由服务提供，与服务的 RegisterSubscriber 一样注入订阅上下文、应用中间件，并记录订阅者。
+// 服务在 Start 中连接 broker 之后调用 Start，在 Stop 中调用 Stop。
+func NewServerSubscriptions(c config.Config, key string, bc *Broker, routes map[string]Route, subscribe SubscribeFunc, logger log.Logger) (*SubscriptionManager, error) {
+	m := NewSubscriptionManagerFunc(subscribe, routes, logger)
+	if err := m.Apply(bc.GetSubscriptions()); err != nil {
+		return nil, err
+	}
+	if err := m.Watch(c, key); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TrackSubscriber 包装 sub，取消订阅后回调 onUnsubscribe，服务用它同步自己记录的订阅者
+func TrackSubscriber(sub broker.Subscriber, onUnsubscribe func()) broker.Subscriber {
+	return &trackedSubscriber{Subscriber: sub, onUnsubscribe: onUnsubscribe}
+}
+
+type trackedSubscriber struct {
+	broker.Subscriber
+	onUnsubscribe func()
+}
+
+func (s *trackedSubscriber) Unsubscribe(removeFromManager bool) error {
+	err := s.Subscriber.Unsubscribe(removeFromManager)
+	s.onUnsubscribe()
+	return err
+}
@@ -0,0 +1,220 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// Route 代码中注册的订阅处理器
+type Route struct {
+	Handler broker.Handler
+	Binder  broker.Binder
+	Options []broker.SubscribeOption // 额外的订阅选项，在配置之后应用
+}
+
+// SubscribeFunc 订阅管理器使用的订阅函数，签名与 broker.Broker.Subscribe 相同
+type SubscribeFunc func(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error)
+
+type activeSubscription struct {
+	conf       *Subscription
+	subscriber broker.Subscriber
+}
+
+// SubscriptionManager 根据配置中的订阅列表管理订阅，配置变化时只对增删改的订阅生效。
+// 驱动按 topic 管理订阅者，因此同一个 topic 只允许配置一个订阅。
+//
+//	mgr := conf.NewSubscriptionManager(b, map[string]conf.Route{"order": {Handler: h, Binder: binder}}, logger)
+//	_ = mgr.Apply(bc.Subscriptions)
+//	_ = mgr.Start() // broker 连接成功之后
+//	_ = mgr.Watch(c, "data.kafka")
+type SubscriptionManager struct {
+	sync.Mutex
+
+	subscribe SubscribeFunc
+	routes    map[string]Route
+
+	started bool
+	wanted  []*Subscription
+	active  map[string]*activeSubscription
+
+	// config 与 key 在 Watch 时记录，watching 为 false 时忽略配置变化
+	config   config.Config
+	key      string
+	watching bool
+
+	logger log.Logger
+	log    *log.Helper
+}
+
+// NewSubscriptionManager 创建直接通过 broker 订阅的订阅管理器，logger 为空时使用 kratos 的全局日志器
+func NewSubscriptionManager(b broker.Broker, routes map[string]Route, logger log.Logger) *SubscriptionManager {
+	return NewSubscriptionManagerFunc(b.Subscribe, routes, logger)
+}
+
+// NewSubscriptionManagerFunc 创建通过 subscribe 订阅的订阅管理器，传输层服务用它让配置中的订阅经过自己的中间件
+func NewSubscriptionManagerFunc(subscribe SubscribeFunc, routes map[string]Route, logger log.Logger) *SubscriptionManager {
+	return &SubscriptionManager{
+		subscribe: subscribe,
+		routes:    routes,
+		active: make(map[string]*activeSubscription),
+		logger: logger,
+		log:    broker.NewLoggerHelper(logger, "broker", "conf"),
+	}
+}
+
+// handlerName 处理器名称，为空时使用 topic
+func handlerName(c *Subscription) string {
+	if len(c.GetHandler()) > 0 {
+		return c.GetHandler()
+	}
+	return c.GetTopic()
+}
+
+// Start 订阅当前的订阅列表，需要在 broker 连接成功之后调用。
+// 调用过 Watch 时先重新读取配置，并恢复对配置变化的监听。
+func (m *SubscriptionManager) Start() error {
+	m.Lock()
+	defer m.Unlock()
+
+	var errs []error
+	if m.config != nil {
+		if bc, err := Load(m.config, m.key); err != nil {
+			errs = append(errs, err)
+		} else {
+			m.wanted = bc.GetSubscriptions()
+		}
+		m.watching = true
+	}
+
+	m.started = true
+	return errors.Join(append(errs, m.apply())...)
+}
+
+// Stop 取消所有订阅并停止响应配置变化，之后的 Apply 只记录订阅列表，直到再次 Start
+func (m *SubscriptionManager) Stop() error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.started = false
+	m.watching = false
+
+	var errs []error
+	for topic, as := range m.active {
+		if err := as.subscriber.Unsubscribe(true); err != nil {
+			errs = append(errs, fmt.Errorf("broker conf: unsubscribe [%s] failed: %w", topic, err))
+		}
+		delete(m.active, topic)
+	}
+	return errors.Join(errs...)
+}
+
+// Apply 更新订阅列表。已启动时立即生效：取消已删除或已修改的订阅，再订阅新增或已修改的订阅；
+// 未启动时只做校验并记录，在 Start 时生效。单个订阅失败不影响其余订阅，所有错误合并后返回。
+func (m *SubscriptionManager) Apply(subs []*Subscription) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.wanted = subs
+	if !m.started {
+		_, errs := m.validate()
+		return errors.Join(errs...)
+	}
+	return m.apply()
+}
+
+// Watch 监听 key 对应的 broker 配置，订阅列表变化时调用 Apply，失败时写入日志。
+// kratos 的配置不能取消监听，Stop 之后的变化会被忽略，再次 Start 时重新读取配置。
+func (m *SubscriptionManager) Watch(c config.Config, key string) error {
+	m.Lock()
+	m.config = c
+	m.key = key
+	m.watching = true
+	m.Unlock()
+
+	return Watch(c, key, m.logger, func(bc *Broker) {
+		m.Lock()
+		defer m.Unlock()
+
+		if !m.watching {
+			return
+		}
+
+		m.wanted = bc.GetSubscriptions()
+		if !m.started {
+			return
+		}
+		if err := m.apply(); err != nil {
+			m.log.Errorf("apply subscriptions from %q failed: %s", key, err.Error())
+		}
+	})
+}
+
+// validate 按 topic 索引订阅列表，忽略无效的订阅
+func (m *SubscriptionManager) validate() (map[string]*Subscription, []error) {
+	var errs []error
+
+	wanted := make(map[string]*Subscription, len(m.wanted))
+	for _, sub := range m.wanted {
+		topic := sub.GetTopic()
+		if len(topic) == 0 {
+			errs = append(errs, errors.New("broker conf: subscription topic is empty"))
+			continue
+		}
+		if _, dup := wanted[topic]; dup {
+			errs = append(errs, fmt.Errorf("broker conf: duplicate subscription for topic [%s]", topic))
+			continue
+		}
+		if _, ok := m.routes[handlerName(sub)]; !ok {
+			errs = append(errs, fmt.Errorf("broker conf: handler %q not registered for topic [%s]", handlerName(sub), topic))
+			continue
+		}
+		wanted[topic] = sub
+	}
+
+	return wanted, errs
+}
+
+func (m *SubscriptionManager) apply() error {
+	wanted, errs := m.validate()
+
+	for topic, as := range m.active {
+		if sub, ok := wanted[topic]; ok && proto.Equal(sub, as.conf) {
+			continue
+		}
+		if err := as.subscriber.Unsubscribe(true); err != nil {
+			errs = append(errs, fmt.Errorf("broker conf: unsubscribe [%s] failed: %w", topic, err))
+			continue
+		}
+		delete(m.active, topic)
+		m.log.Infof("unsubscribed [%s]", topic)
+	}
+
+	for topic, sub := range wanted {
+		if _, ok := m.active[topic]; ok {
+			continue
+		}
+
+		route := m.routes[handlerName(sub)]
+		opts := append(SubscribeOptions(sub), route.Options...)
+		subscriber, err := m.subscribe(topic, route.Handler, route.Binder, opts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("broker conf: subscribe [%s] failed: %w", topic, err))
+			continue
+		}
+
+		m.active[topic] = &activeSubscription{
+			conf:       proto.Clone(sub).(*Subscription),
+			subscriber: subscriber,
+		}
+		m.log.Infof("subscribed [%s]", topic)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package broker
+
+import (
+	"context"
+)
+
+// ErrorStage 消息处理失败时所处的阶段
+type ErrorStage string
+
+const (
+	ErrorStageDecode ErrorStage = "decode" // 消息体解码失败
+	ErrorStageHandle ErrorStage = "handle" // 业务处理器返回错误
+	ErrorStageAck    ErrorStage = "ack"    // 消息确认失败
+)
+
+// ErrorAction 错误处理器对失败消息做出的决定
+type ErrorAction int
+
+const (
+	ErrorActionDefault    ErrorAction = iota // 按驱动的默认行为处理（记录日志后继续）
+	ErrorActionAck                           // 确认消息，不再投递
+	ErrorActionNack                          // 否定确认，要求重新投递
+	ErrorActionDeadLetter                    // 投递到死信队列后确认
+)
+
+func (a ErrorAction) String() string {
+	switch a {
+	case ErrorActionAck:
+		return "ack"
+	case ErrorActionNack:
+		return "nack"
+	case ErrorActionDeadLetter:
+		return "dead-letter"
+	default:
+		return "default"
+	}
+}
+
+// ErrorHandler 消息处理失败时的回调，返回值决定驱动如何处置该消息。
+type ErrorHandler func(ctx context.Context, evt Event, stage ErrorStage, err error) ErrorAction
+
+const (
+	HeaderDeadLetterOriginalTopic = "x-dead-letter-original-topic"
+	HeaderDeadLetterErrorStage    = "x-dead-letter-error-stage"
+	HeaderDeadLetterError         = "x-dead-letter-error"
+)
+
+// DefaultDeadLetterSuffix 未指定死信队列时，在原主题名后追加的后缀。
+var DefaultDeadLetterSuffix = ".dlq"
+
+// Nacker 由支持否定确认的事件实现
+type Nacker interface {
+	Nack() error
+}
+
+// HandleError 调用错误处理器，未设置处理器时返回 ErrorActionDefault。
+func HandleError(handler ErrorHandler, ctx context.Context, evt Event, stage ErrorStage, err error) ErrorAction {
+	if handler == nil {
+		return ErrorActionDefault
+	}
+	return handler(ctx, evt, stage, err)
+}
+
+// DeadLetterTopic 返回订阅所使用的死信队列名称
+func DeadLetterTopic(topic string, options SubscribeOptions) string {
+	if len(options.DeadLetterQueue) > 0 {
+		return options.DeadLetterQueue
+	}
+	return topic + DefaultDeadLetterSuffix
+}
+
+// DeadLetterHeaders 在原消息头的基础上追加死信相关的消息头
+func DeadLetterHeaders(headers Headers, topic string, stage ErrorStage, err error) Headers {
+	out := make(Headers, len(headers)+3)
+	for k, v := range headers {
+		out[k] = v
+	}
+	out[HeaderDeadLetterOriginalTopic] = topic
+	out[HeaderDeadLetterErrorStage] = string(stage)
+	if err != nil {
+		out[HeaderDeadLetterError] = err.Error()
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHandleErrorNilHandler(t *testing.T) {
+	if action := HandleError(nil, context.Background(), nil, ErrorStageHandle, errors.New("failed")); action != ErrorActionDefault {
+		t.Errorf("unexpected action: %s", action)
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	var gotStage ErrorStage
+	handler := func(_ context.Context, _ Event, stage ErrorStage, _ error) ErrorAction {
+		gotStage = stage
+		return ErrorActionDeadLetter
+	}
+
+	if action := HandleError(handler, context.Background(), nil, ErrorStageDecode, errors.New("failed")); action != ErrorActionDeadLetter {
+		t.Errorf("unexpected action: %s", action)
+	}
+	if gotStage != ErrorStageDecode {
+		t.Errorf("unexpected stage: %s", gotStage)
+	}
+}
+
+func TestDeadLetterTopic(t *testing.T) {
+	if topic := DeadLetterTopic("orders", NewSubscribeOptions()); topic != "orders"+DefaultDeadLetterSuffix {
+		t.Errorf("unexpected default dead letter topic: %s", topic)
+	}
+	if topic := DeadLetterTopic("orders", NewSubscribeOptions(WithDeadLetterQueue("orders.failed"))); topic != "orders.failed" {
+		t.Errorf("unexpected dead letter topic: %s", topic)
+	}
+}
+
+func TestDeadLetterHeaders(t *testing.T) {
+	headers := Headers{"trace-id": "abc"}
+
+	out := DeadLetterHeaders(headers, "orders", ErrorStageHandle, errors.New("boom"))
+
+	if out["trace-id"] != "abc" {
+		t.Errorf("original header not copied: %v", out)
+	}
+	if out[HeaderDeadLetterOriginalTopic] != "orders" ||
+		out[HeaderDeadLetterErrorStage] != string(ErrorStageHandle) ||
+		out[HeaderDeadLetterError] != "boom" {
+		t.Errorf("unexpected dead letter headers: %v", out)
+	}
+
+	if _, ok := headers[HeaderDeadLetterOriginalTopic]; ok {
+		t.Error("original headers must not be modified")
+	}
+
+	if out = DeadLetterHeaders(nil, "orders", ErrorStageDecode, nil); len(out) != 2 {
+		t.Errorf("unexpected headers without error: %v", out)
+	}
+}
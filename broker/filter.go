@@ -0,0 +1,601 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Filter 消息选择器，语法是 JMS 选择器（SQL92 条件表达式）的子集，作用于消息头：
+//
+//	type = 'order.created' AND region IN ('eu', 'us')
+//	priority >= 5 OR (retry IS NOT NULL AND NOT region LIKE 'cn-%')
+//
+// 支持 =、<>、!=、<、<=、>、>=、[NOT] IN、[NOT] LIKE、[NOT] BETWEEN、IS [NOT] NULL、AND、OR、NOT 和括号，
+// 关键字不区分大小写。标识符可以包含字母、数字、'_'、'-'、'.' 和 ':'，其他字符需要用双引号括起来。
+// 两边都能解析为数字时按数字比较，否则按字符串比较；消息头不存在时条件的结果为未知，与 SQL 的 NULL 相同，
+// 最终结果为真时消息才被投递。
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// ParseFilter 解析选择器表达式，表达式为空时返回 nil
+func ParseFilter(expr string) (*Filter, error) {
+	if len(strings.TrimSpace(expr)) == 0 {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err.Error())
+	}
+
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err.Error())
+	}
+
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// String 返回原始表达式
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// Match 判断消息头是否满足选择器，nil 选择器匹配所有消息
+func (f *Filter) Match(headers Headers) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.eval(headers) == filterTrue
+}
+
+// Equalities 选择器仅由等值条件通过 AND 或 OR 连接时，返回这些条件和连接方式（"all" 或 "any"），
+// 用于映射到 AMQP headers 交换机的绑定参数
+func (f *Filter) Equalities() (map[string]string, string, bool) {
+	if f == nil {
+		return nil, "", false
+	}
+
+	eqs := make(map[string]string)
+	mode := ""
+
+	var walk func(n filterNode) bool
+	walk = func(n filterNode) bool {
+		switch t := n.(type) {
+		case *filterCompare:
+			if t.op != "=" {
+				return false
+			}
+			if _, ok := eqs[t.key]; ok {
+				return false
+			}
+			eqs[t.key] = t.value
+			return true
+		case *filterLogic:
+			m := "all"
+			if t.or {
+				m = "any"
+			}
+			if len(mode) > 0 && mode != m {
+				return false
+			}
+			mode = m
+			return walk(t.left) && walk(t.right)
+		default:
+			return false
+		}
+	}
+
+	if !walk(f.root) {
+		return nil, "", false
+	}
+	if len(mode) == 0 {
+		mode = "all"
+	}
+	return eqs, mode, true
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+// filterResult SQL 的三值逻辑
+type filterResult int
+
+const (
+	filterFalse filterResult = iota
+	filterTrue
+	filterUnknown
+)
+
+func filterBool(b bool) filterResult {
+	if b {
+		return filterTrue
+	}
+	return filterFalse
+}
+
+type filterNode interface {
+	eval(headers Headers) filterResult
+}
+
+type filterLogic struct {
+	or          bool
+	left, right filterNode
+}
+
+func (n *filterLogic) eval(headers Headers) filterResult {
+	l, r := n.left.eval(headers), n.right.eval(headers)
+	if n.or {
+		switch {
+		case l == filterTrue || r == filterTrue:
+			return filterTrue
+		case l == filterUnknown || r == filterUnknown:
+			return filterUnknown
+		default:
+			return filterFalse
+		}
+	}
+	switch {
+	case l == filterFalse || r == filterFalse:
+		return filterFalse
+	case l == filterUnknown || r == filterUnknown:
+		return filterUnknown
+	default:
+		return filterTrue
+	}
+}
+
+type filterNot struct {
+	node filterNode
+}
+
+func (n *filterNot) eval(headers Headers) filterResult {
+	switch n.node.eval(headers) {
+	case filterTrue:
+		return filterFalse
+	case filterFalse:
+		return filterTrue
+	default:
+		return filterUnknown
+	}
+}
+
+type filterCompare struct {
+	key   string
+	op    string
+	value string
+}
+
+func (n *filterCompare) eval(headers Headers) filterResult {
+	v, ok := headers[n.key]
+	if !ok {
+		return filterUnknown
+	}
+
+	c := compareFilterValues(v, n.value)
+	switch n.op {
+	case "=":
+		return filterBool(c == 0)
+	case "<>":
+		return filterBool(c != 0)
+	case "<":
+		return filterBool(c < 0)
+	case "<=":
+		return filterBool(c <= 0)
+	case ">":
+		return filterBool(c > 0)
+	default:
+		return filterBool(c >= 0)
+	}
+}
+
+// compareFilterValues 两边都是数字时按数字比较，否则按字符串比较
+func compareFilterValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+type filterIn struct {
+	key    string
+	values []string
+}
+
+func (n *filterIn) eval(headers Headers) filterResult {
+	v, ok := headers[n.key]
+	if !ok {
+		return filterUnknown
+	}
+	for _, value := range n.values {
+		if compareFilterValues(v, value) == 0 {
+			return filterTrue
+		}
+	}
+	return filterFalse
+}
+
+type filterBetween struct {
+	key      string
+	low, top string
+}
+
+func (n *filterBetween) eval(headers Headers) filterResult {
+	v, ok := headers[n.key]
+	if !ok {
+		return filterUnknown
+	}
+	return filterBool(compareFilterValues(v, n.low) >= 0 && compareFilterValues(v, n.top) <= 0)
+}
+
+type filterLike struct {
+	key     string
+	pattern *regexp.Regexp
+}
+
+func (n *filterLike) eval(headers Headers) filterResult {
+	v, ok := headers[n.key]
+	if !ok {
+		return filterUnknown
+	}
+	return filterBool(n.pattern.MatchString(v))
+}
+
+type filterIsNull struct {
+	key string
+}
+
+func (n *filterIsNull) eval(headers Headers) filterResult {
+	_, ok := headers[n.key]
+	return filterBool(!ok)
+}
+
+// likePattern 将 LIKE 模式转换为正则表达式，'%' 匹配任意字符串，'_' 匹配单个字符
+func likePattern(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+///////////////////////////////////////////////////////////////////////////////
+
+type filterTokenKind int
+
+const (
+	filterIdent filterTokenKind = iota
+	filterKeyword
+	filterString
+	filterNumber
+	filterOperator
+	filterPunct
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+var filterKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "LIKE": true,
+	"BETWEEN": true, "IS": true, "NULL": true, "TRUE": true, "FALSE": true,
+}
+
+func isFilterIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == ':'
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, filterToken{kind: filterPunct, text: string(r)})
+			i++
+
+		case r == '=':
+			tokens = append(tokens, filterToken{kind: filterOperator, text: "="})
+			i++
+
+		case r == '<' || r == '>' || r == '!':
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				op += string(runes[i+1])
+			}
+			i += len(op)
+			switch op {
+			case "!":
+				return nil, fmt.Errorf("unexpected '!' at %d", i-1)
+			case "!=":
+				op = "<>"
+			}
+			tokens = append(tokens, filterToken{kind: filterOperator, text: op})
+
+		case r == '\'' || r == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					if j+1 < len(runes) && runes[j+1] == r {
+						sb.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote at %d", i)
+			}
+			kind := filterString
+			if r == '"' {
+				kind = filterIdent
+			}
+			tokens = append(tokens, filterToken{kind: kind, text: sb.String()})
+			i = j + 1
+
+		case isFilterIdentRune(r) || r == '+':
+			j := i + 1
+			for j < len(runes) && isFilterIdentRune(runes[j]) {
+				j++
+			}
+			text := string(runes[i:j])
+			switch {
+			case filterKeywords[strings.ToUpper(text)]:
+				tokens = append(tokens, filterToken{kind: filterKeyword, text: strings.ToUpper(text)})
+			case isFilterNumber(text):
+				tokens = append(tokens, filterToken{kind: filterNumber, text: text})
+			case r == '+':
+				return nil, fmt.Errorf("unexpected '+' at %d", i)
+			default:
+				tokens = append(tokens, filterToken{kind: filterIdent, text: text})
+			}
+			i = j
+
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", r, i)
+		}
+	}
+
+	return tokens, nil
+}
+
+// isFilterNumber 以数字、符号或小数点开头并且能解析为浮点数的词为数字，其余为标识符
+func isFilterNumber(text string) bool {
+	if !strings.ContainsRune("0123456789+-.", rune(text[0])) {
+		return false
+	}
+	_, err := strconv.ParseFloat(text, 64)
+	return err == nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{kind: filterPunct, text: "end of expression"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *filterParser) acceptKeyword(kw string) bool {
+	if t := p.peek(); !p.done() && t.kind == filterKeyword && t.text == kw {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expectPunct(punct string) error {
+	if t := p.next(); t.kind != filterPunct || t.text != punct {
+		return fmt.Errorf("expected %q, got %q", punct, t.text)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogic{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterLogic{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterNode, error) {
+	if p.acceptKeyword("NOT") {
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{node: node}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *filterParser) parseValue() (string, error) {
+	t := p.next()
+	switch {
+	case t.kind == filterString || t.kind == filterNumber:
+		return t.text, nil
+	case t.kind == filterKeyword && (t.text == "TRUE" || t.text == "FALSE"):
+		return strings.ToLower(t.text), nil
+	default:
+		return "", fmt.Errorf("expected literal, got %q", t.text)
+	}
+}
+
+func (p *filterParser) parsePredicate() (filterNode, error) {
+	if t := p.peek(); t.kind == filterPunct && t.text == "(" {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+
+	t := p.next()
+	if t.kind != filterIdent {
+		return nil, fmt.Errorf("expected header name, got %q", t.text)
+	}
+	key := t.text
+
+	if op := p.peek(); op.kind == filterOperator {
+		p.pos++
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &filterCompare{key: key, op: op.text, value: value}, nil
+	}
+
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") {
+			return nil, fmt.Errorf("expected NULL, got %q", p.peek().text)
+		}
+		var node filterNode = &filterIsNull{key: key}
+		if not {
+			node = &filterNot{node: node}
+		}
+		return node, nil
+	}
+
+	not := p.acceptKeyword("NOT")
+
+	var node filterNode
+	switch {
+	case p.acceptKeyword("IN"):
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		in := &filterIn{key: key}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			in.values = append(in.values, value)
+			if t := p.peek(); t.kind == filterPunct && t.text == "," {
+				p.pos++
+				continue
+			}
+			break
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		node = in
+
+	case p.acceptKeyword("LIKE"):
+		t := p.next()
+		if t.kind != filterString {
+			return nil, fmt.Errorf("expected pattern, got %q", t.text)
+		}
+		pattern, err := likePattern(t.text)
+		if err != nil {
+			return nil, err
+		}
+		node = &filterLike{key: key, pattern: pattern}
+
+	case p.acceptKeyword("BETWEEN"):
+		low, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptKeyword("AND") {
+			return nil, fmt.Errorf("expected AND, got %q", p.peek().text)
+		}
+		top, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node = &filterBetween{key: key, low: low, top: top}
+
+	default:
+		return nil, fmt.Errorf("expected operator after %q, got %q", key, p.peek().text)
+	}
+
+	if not {
+		node = &filterNot{node: node}
+	}
+	return node, nil
+}
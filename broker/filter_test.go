@@ -0,0 +1,104 @@
+package broker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	headers := Headers{
+		"type":         "order.created",
+		"region":       "eu",
+		"priority":     "7",
+		"x-tenant-id":  "acme",
+		"content type": "json",
+	}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{"type = 'order.created' AND region IN ('eu','us')", true},
+		{"type = 'order.created' AND region IN ('us', 'cn')", false},
+		{"type = 'order.cancelled' OR priority > 5", true},
+		{"priority >= 10", false},
+		{"priority BETWEEN 5 AND 10", true},
+		{"priority NOT BETWEEN 5 AND 10", false},
+		{"priority != 7", false},
+		{"priority <> 8", true},
+		{"type LIKE 'order.%'", true},
+		{"type NOT LIKE 'order._reated'", false},
+		{"x-tenant-id = 'acme'", true},
+		{"\"content type\" = 'json'", true},
+		{"missing IS NULL AND type IS NOT NULL", true},
+		{"missing = 'x'", false},
+		{"NOT missing = 'x'", false},
+		{"missing = 'x' OR region = 'eu'", true},
+		{"not (region = 'us') and TYPE_OK is null", true},
+		{"region = 'it''s'", false},
+	}
+
+	for _, c := range cases {
+		f, err := ParseFilter(c.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.expr, err)
+		}
+		if got := f.Match(headers); got != c.want {
+			t.Errorf("%q: got %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestFilterParseError(t *testing.T) {
+	for _, expr := range []string{
+		"type =",
+		"type = 'x' AND",
+		"type IN ('a'",
+		"'a' = type",
+		"type ! 'a'",
+		"type = 'unterminated",
+		"(type = 'a'",
+		"type = 'a' region = 'b'",
+		"type IS 'a'",
+	} {
+		if _, err := ParseFilter(expr); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%q: unexpected error: %v", expr, err)
+		}
+	}
+
+	f, err := ParseFilter("  ")
+	if f != nil || err != nil {
+		t.Errorf("empty filter: %v %v", f, err)
+	}
+	if !f.Match(nil) {
+		t.Error("nil filter must match")
+	}
+}
+
+func TestFilterEqualities(t *testing.T) {
+	cases := []struct {
+		expr string
+		eqs  map[string]string
+		mode string
+		ok   bool
+	}{
+		{"type = 'a'", map[string]string{"type": "a"}, "all", true},
+		{"type = 'a' AND region = 'eu'", map[string]string{"type": "a", "region": "eu"}, "all", true},
+		{"type = 'a' OR region = 'eu'", map[string]string{"type": "a", "region": "eu"}, "any", true},
+		{"type = 'a' AND region = 'eu' OR x = '1'", nil, "", false},
+		{"type = 'a' OR type = 'b'", nil, "", false},
+		{"priority > 1", nil, "", false},
+	}
+
+	for _, c := range cases {
+		f, err := ParseFilter(c.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		eqs, mode, ok := f.Equalities()
+		if ok != c.ok || mode != c.mode || (c.ok && !reflect.DeepEqual(eqs, c.eqs)) {
+			t.Errorf("%q: got %v %s %v", c.expr, eqs, mode, ok)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.Admin = (*kafkaBroker)(nil)
+
+// adminClient 使用与读写相同的地址、TLS 和 SASL 配置创建管理客户端
+func (b *kafkaBroker) adminClient() *kafkaGo.Client {
+	addrs := b.options.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{defaultAddr}
+	}
+
+	transport := &kafkaGo.Transport{
+		SASL: b.saslMechanism,
+		TLS:  b.tlsConfig(),
+	}
+
+	return &kafkaGo.Client{
+		Addr:      kafkaGo.TCP(addrs...),
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	}
+}
+
+func (b *kafkaBroker) CreateTopic(ctx context.Context, name string, partitions, replication int, config map[string]string) error {
+	if partitions <= 0 {
+		partitions = -1
+	}
+	if replication <= 0 {
+		replication = -1
+	}
+
+	topic := kafkaGo.TopicConfig{
+		Topic:             name,
+		NumPartitions:     partitions,
+		ReplicationFactor: replication,
+	}
+	for k, v := range config {
+		topic.ConfigEntries = append(topic.ConfigEntries, kafkaGo.ConfigEntry{ConfigName: k, ConfigValue: v})
+	}
+
+	resp, err := b.adminClient().CreateTopics(ctx, &kafkaGo.CreateTopicsRequest{
+		Topics: []kafkaGo.TopicConfig{topic},
+	})
+	if err != nil {
+		return err
+	}
+	if err = resp.Errors[name]; err != nil && !errors.Is(err, kafkaGo.TopicAlreadyExists) {
+		return err
+	}
+	return nil
+}
+
+func (b *kafkaBroker) DeleteTopic(ctx context.Context, name string) error {
+	resp, err := b.adminClient().DeleteTopics(ctx, &kafkaGo.DeleteTopicsRequest{
+		Topics: []string{name},
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Errors[name]
+}
+
+func (b *kafkaBroker) ListTopics(ctx context.Context) ([]string, error) {
+	resp, err := b.adminClient().Metadata(ctx, &kafkaGo.MetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	var topics []string
+	for _, t := range resp.Topics {
+		if t.Internal || t.Error != nil {
+			continue
+		}
+		topics = append(topics, t.Name)
+	}
+	sort.Strings(topics)
+
+	return topics, nil
+}
+
+func brokerAddr(b kafkaGo.Broker) string {
+	return net.JoinHostPort(b.Host, strconv.Itoa(b.Port))
+}
+
+func (b *kafkaBroker) DescribeTopic(ctx context.Context, name string) (*broker.TopicInfo, error) {
+	client := b.adminClient()
+
+	meta, err := client.Metadata(ctx, &kafkaGo.MetadataRequest{Topics: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.Topics) == 0 {
+		return nil, kafkaGo.UnknownTopicOrPartition
+	}
+	if err = meta.Topics[0].Error; err != nil {
+		return nil, err
+	}
+
+	info := &broker.TopicInfo{
+		Name:     name,
+		Config:   make(map[string]string),
+		Messages: -1,
+	}
+	for _, p := range meta.Topics[0].Partitions {
+		partition := broker.PartitionInfo{ID: p.ID, Leader: brokerAddr(p.Leader)}
+		for _, r := range p.Replicas {
+			partition.Replicas = append(partition.Replicas, brokerAddr(r))
+		}
+		info.Partitions = append(info.Partitions, partition)
+	}
+	sort.Slice(info.Partitions, func(i, j int) bool { return info.Partitions[i].ID < info.Partitions[j].ID })
+
+	configs, err := client.DescribeConfigs(ctx, &kafkaGo.DescribeConfigsRequest{
+		Resources: []kafkaGo.DescribeConfigRequestResource{{
+			ResourceType: kafkaGo.ResourceTypeTopic,
+			ResourceName: name,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range configs.Resources {
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		for _, entry := range res.ConfigEntries {
+			info.Config[entry.ConfigName] = entry.ConfigValue
+		}
+	}
+
+	return info, nil
+}
+
+func (b *kafkaBroker) ListConsumerGroups(ctx context.Context) ([]string, error) {
+	resp, err := b.adminClient().ListGroups(ctx, &kafkaGo.ListGroupsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	groups := make([]string, 0, len(resp.Groups))
+	for _, g := range resp.Groups {
+		groups = append(groups, g.GroupID)
+	}
+	sort.Strings(groups)
+
+	return groups, nil
+}
+
+// DescribeGroup 查询消费组的成员，以及已提交位移与分区末尾位移之差
+func (b *kafkaBroker) DescribeGroup(ctx context.Context, group string) (*broker.GroupInfo, error) {
+	client := b.adminClient()
+
+	resp, err := client.DescribeGroups(ctx, &kafkaGo.DescribeGroupsRequest{GroupIDs: []string{group}})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Groups) == 0 {
+		return nil, kafkaGo.GroupIdNotFound
+	}
+	g := resp.Groups[0]
+	if g.Error != nil {
+		return nil, g.Error
+	}
+
+	info := &broker.GroupInfo{Name: group, State: g.GroupState}
+	for _, m := range g.Members {
+		info.Members = append(info.Members, broker.GroupMember{ID: m.MemberID, ClientID: m.ClientID, Host: m.ClientHost})
+	}
+
+	committed, err := client.OffsetFetch(ctx, &kafkaGo.OffsetFetchRequest{GroupID: group})
+	if err != nil {
+		return nil, err
+	}
+	if committed.Error != nil {
+		return nil, committed.Error
+	}
+	if len(committed.Topics) == 0 {
+		return info, nil
+	}
+
+	request := make(map[string][]kafkaGo.OffsetRequest, len(committed.Topics))
+	for topic, partitions := range committed.Topics {
+		for _, p := range partitions {
+			request[topic] = append(request[topic], kafkaGo.LastOffsetOf(p.Partition))
+		}
+	}
+
+	ends, err := client.ListOffsets(ctx, &kafkaGo.ListOffsetsRequest{Topics: request})
+	if err != nil {
+		return nil, err
+	}
+
+	for topic, partitions := range committed.Topics {
+		last := make(map[int]int64)
+		for _, p := range ends.Topics[topic] {
+			if p.Error == nil {
+				last[p.Partition] = p.LastOffset
+			}
+		}
+
+		for _, p := range partitions {
+			if p.Error != nil {
+				return nil, fmt.Errorf("fetch committed offset of %s[%d] failed: %w", topic, p.Partition, p.Error)
+			}
+			end, ok := last[p.Partition]
+			if !ok {
+				end = -1
+			}
+			info.Lag = append(info.Lag, partitionLag(topic, p.Partition, p.CommittedOffset, end))
+		}
+	}
+	sort.Slice(info.Lag, func(i, j int) bool {
+		if info.Lag[i].Topic != info.Lag[j].Topic {
+			return info.Lag[i].Topic < info.Lag[j].Topic
+		}
+		return info.Lag[i].Partition < info.Lag[j].Partition
+	})
+
+	return info, nil
+}
+
+// partitionLag 未提交过位移（-1）时延迟为分区末尾位移
+func partitionLag(topic string, partition int, committed, end int64) broker.PartitionLag {
+	lag := broker.PartitionLag{Topic: topic, Partition: partition, Committed: committed, End: end}
+	switch {
+	case end < 0:
+	case committed < 0:
+		lag.Lag = end
+	case end > committed:
+		lag.Lag = end - committed
+	}
+	return lag
+}
@@ -0,0 +1,39 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"testing"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestAdminClientUsesBrokerSecurity(t *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "kafka"}
+	b := NewBroker(
+		broker.WithAddress("host1:9092", "host2:9092"),
+		broker.WithTLSConfig(tlsConfig),
+		WithPlainMechanism("user", "pass"),
+	).(*kafkaBroker)
+	assert.NoError(t, b.Init())
+
+	client := b.adminClient()
+	assert.Equal(t, "host1:9092,host2:9092", client.Addr.String())
+
+	transport := client.Transport.(*kafkaGo.Transport)
+	assert.Same(t, tlsConfig, transport.TLS)
+	assert.NotNil(t, transport.SASL)
+	assert.Equal(t, "PLAIN", transport.SASL.Name())
+
+	_, ok := broker.AsAdmin(b)
+	assert.True(t, ok)
+}
+
+func TestPartitionLag(t *testing.T) {
+	assert.Equal(t, int64(5), partitionLag("t", 0, 10, 15).Lag)
+	assert.Equal(t, int64(15), partitionLag("t", 0, -1, 15).Lag)
+	assert.Equal(t, int64(0), partitionLag("t", 0, 15, 15).Lag)
+	assert.Equal(t, int64(0), partitionLag("t", 0, 10, -1).Lag)
+}
@@ -0,0 +1,213 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// BatchHandler 批量消息处理器，一次接收整个批次。
+// 返回 nil 表示全部成功；返回 *BatchError 表示部分失败，其余错误视为整批失败。
+type BatchHandler func(ctx context.Context, events []broker.Event) error
+
+// BatchError 批量处理的部分失败结果，Errors 的键为失败消息在批次中的下标
+type BatchError struct {
+	Errors map[int]error
+}
+
+func NewBatchError() *BatchError {
+	return &BatchError{Errors: make(map[int]error)}
+}
+
+// Add 记录批次中第 index 条消息的失败原因
+func (e *BatchError) Add(index int, err error) {
+	e.Errors[index] = err
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	parts := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		parts = append(parts, fmt.Sprintf("#%d: %v", i, e.Errors[i]))
+	}
+	return fmt.Sprintf("kafka: %d messages of batch failed: %s", len(e.Errors), strings.Join(parts, "; "))
+}
+
+// batchCommitter 记录批次中每个分区已确认的最大位移，批次结束后统一提交一次
+type batchCommitter struct {
+	sync.Mutex
+
+	offsets map[int]kafkaGo.Message
+}
+
+func newBatchCommitter() *batchCommitter {
+	return &batchCommitter{offsets: make(map[int]kafkaGo.Message)}
+}
+
+func (c *batchCommitter) CommitMessages(_ context.Context, msgs ...kafkaGo.Message) error {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, m := range msgs {
+		if last, ok := c.offsets[m.Partition]; !ok || m.Offset > last.Offset {
+			c.offsets[m.Partition] = m
+		}
+	}
+	return nil
+}
+
+// messages 返回每个分区位移最大的消息
+func (c *batchCommitter) messages() []kafkaGo.Message {
+	c.Lock()
+	defer c.Unlock()
+
+	msgs := make([]kafkaGo.Message, 0, len(c.offsets))
+	for _, m := range c.offsets {
+		msgs = append(msgs, m)
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Partition < msgs[j].Partition })
+	return msgs
+}
+
+type batchItem struct {
+	pub  *publication
+	ctx  context.Context
+	span trace.Span
+}
+
+// handleBatch 将整个批次交给 BatchHandler，每个分区只通过 out 提交一次最大位移。
+// 失败的消息逐条交给 ErrorHandler 处置，Nack 的消息在 nackDelay 后组成新的批次重新投递。
+func (s *subscriber) handleBatch(out committer, messages []kafkaGo.Message) {
+	c := newBatchCommitter()
+
+	items := make([]*batchItem, 0, len(messages))
+	for _, km := range messages {
+		if item := s.prepareBatchItem(c, km); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	for len(items) > 0 {
+		items = s.dispatchBatch(items)
+		if len(items) == 0 {
+			break
+		}
+
+		s.log.Warnf("redeliver %d messages of batch", len(items))
+
+		timer := time.NewTimer(s.nackDelay)
+		select {
+		case <-s.options.Context.Done():
+			timer.Stop()
+			s.finishBatch(items, context.Canceled)
+			return
+		case <-s.done:
+			timer.Stop()
+			s.finishBatch(items, context.Canceled)
+			return
+		case <-timer.C:
+		}
+	}
+
+	if msgs := c.messages(); len(msgs) > 0 {
+		if err := out.CommitMessages(s.options.Context, msgs...); err != nil {
+			s.log.Errorf("unable to commit batch: %v", err)
+		}
+	}
+}
+
+// prepareBatchItem 过滤并解码消息，不需要交给处理器的消息返回 nil
+func (s *subscriber) prepareBatchItem(c committer, km kafkaGo.Message) *batchItem {
+	ctx, span := s.b.startConsumerSpan(s.options.Context, &km)
+
+	bm := &broker.Message{
+		Headers:   kafkaHeaderToMap(km.Headers),
+		Body:      nil,
+		Partition: km.Partition,
+		Offset:    km.Offset,
+	}
+
+	pub := newPublication(s.options.Context, c, km, bm)
+
+	if !s.filter.Match(bm.Headers) {
+		_ = pub.Ack()
+		s.b.finishConsumerSpan(span, nil)
+		return nil
+	}
+
+	if s.binder != nil {
+		bm.Body = s.binder()
+
+		if err := broker.Unmarshal(s.b.options.Codec, km.Value, &bm.Body); err != nil {
+			// 解码失败的消息无法重新投递成功，Nack 按默认行为处理
+			_ = s.handleError(ctx, pub, broker.ErrorStageDecode, err)
+			s.b.finishConsumerSpan(span, err)
+			return nil
+		}
+	} else {
+		bm.Body = km.Value
+	}
+
+	return &batchItem{pub: pub, ctx: ctx, span: span}
+}
+
+// dispatchBatch 调用一次 BatchHandler，返回需要重新投递的消息
+func (s *subscriber) dispatchBatch(items []*batchItem) []*batchItem {
+	events := make([]broker.Event, 0, len(items))
+	for _, item := range items {
+		events = append(events, item.pub)
+	}
+
+	err := s.batchHandler(s.options.Context, events)
+
+	failed := make(map[int]error)
+	if err != nil {
+		var be *BatchError
+		if errors.As(err, &be) {
+			failed = be.Errors
+		} else {
+			for i := range items {
+				failed[i] = err
+			}
+		}
+	}
+
+	var redeliver []*batchItem
+	for i, item := range items {
+		if ferr, ok := failed[i]; ok && ferr != nil {
+			if s.handleError(item.ctx, item.pub, broker.ErrorStageHandle, ferr) {
+				redeliver = append(redeliver, item)
+				continue
+			}
+			s.b.finishConsumerSpan(item.span, ferr)
+			continue
+		}
+
+		if s.options.AutoAck {
+			_ = item.pub.Ack()
+		}
+		s.b.finishConsumerSpan(item.span, nil)
+	}
+
+	return redeliver
+}
+
+func (s *subscriber) finishBatch(items []*batchItem, err error) {
+	for _, item := range items {
+		s.b.finishConsumerSpan(item.span, err)
+	}
+}
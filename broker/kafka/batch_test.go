@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type testCommitter struct {
+	calls [][]kafkaGo.Message
+}
+
+func (c *testCommitter) CommitMessages(_ context.Context, msgs ...kafkaGo.Message) error {
+	c.calls = append(c.calls, msgs)
+	return nil
+}
+
+func testBatch() []kafkaGo.Message {
+	return []kafkaGo.Message{
+		{Topic: "test", Partition: 0, Offset: 1, Value: []byte("a")},
+		{Topic: "test", Partition: 1, Offset: 7, Value: []byte("b")},
+		{Topic: "test", Partition: 0, Offset: 2, Value: []byte("c")},
+		{Topic: "test", Partition: 1, Offset: 8, Value: []byte("d")},
+	}
+}
+
+func TestBatchHandlerCommitsOncePerPartition(t *testing.T) {
+	b := NewBroker().(*kafkaBroker)
+
+	var bodies []string
+	sub := newSubscriber(b, "test", broker.NewSubscribeOptions(), testReaderConfig, nil, nil)
+	sub.batchHandler = func(_ context.Context, events []broker.Event) error {
+		for _, evt := range events {
+			bodies = append(bodies, string(evt.Message().Body.([]byte)))
+		}
+		return nil
+	}
+
+	c := &testCommitter{}
+	sub.handleBatch(c, testBatch())
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, bodies)
+	assert.Len(t, c.calls, 1)
+	assert.Equal(t, int64(2), c.calls[0][0].Offset)
+	assert.Equal(t, int64(8), c.calls[0][1].Offset)
+}
+
+func TestBatchHandlerPartialFailure(t *testing.T) {
+	var failed []int64
+	b := NewBroker(broker.WithErrorHandler(func(_ context.Context, evt broker.Event, _ broker.ErrorStage, _ error) broker.ErrorAction {
+		offset := evt.Message().Offset
+		failed = append(failed, offset)
+		if offset == 8 {
+			return broker.ErrorActionNack
+		}
+		return broker.ErrorActionDefault
+	})).(*kafkaBroker)
+
+	calls := 0
+	sub := newSubscriber(b, "test", broker.NewSubscribeOptions(), testReaderConfig, nil, nil)
+	sub.nackDelay = time.Millisecond
+	sub.batchHandler = func(_ context.Context, events []broker.Event) error {
+		calls++
+		if calls > 1 {
+			assert.Len(t, events, 1)
+			return nil
+		}
+		be := NewBatchError()
+		be.Add(2, errors.New("bad row"))
+		be.Add(3, errors.New("timeout"))
+		return be
+	}
+
+	c := &testCommitter{}
+	sub.handleBatch(c, testBatch())
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []int64{2, 8}, failed)
+	assert.Len(t, c.calls, 1)
+	assert.Equal(t, int64(1), c.calls[0][0].Offset)
+	assert.Equal(t, int64(8), c.calls[0][1].Offset)
+}
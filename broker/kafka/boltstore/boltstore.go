@@ -0,0 +1,120 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/tx7do/kratos-transport/broker/kafka"
+)
+
+var (
+	dataBucket   = []byte("data")
+	offsetBucket = []byte("offsets")
+)
+
+// Store 基于 bbolt 的 kafka.TableStore，消息和分区位移在同一个事务中写入，重启后从保存的位移继续消费。
+// 一个文件只保存一个 Table 的数据。
+type Store struct {
+	db *bolt.DB
+}
+
+var _ kafka.TableStore = (*Store)(nil)
+
+// Open 打开或创建 path 处的数据库文件
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(dataBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(offsetBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Get(key string) ([]byte, bool, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(dataBucket).Get([]byte(key)); v != nil {
+			// bbolt 返回的切片只在事务内有效
+			value = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return value, value != nil, err
+}
+
+func (s *Store) Range(fn func(key string, value []byte) bool) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(dataBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if !fn(string(k), append([]byte(nil), v...)) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// Apply 一批消息和分区位移在同一个事务中写入
+func (s *Store) Apply(partition int, offset int64, entries []kafka.TableEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		data := tx.Bucket(dataBucket)
+		for _, e := range entries {
+			if e.Value == nil {
+				if err := data.Delete([]byte(e.Key)); err != nil {
+					return err
+				}
+			} else if err := data.Put([]byte(e.Key), e.Value); err != nil {
+				return err
+			}
+		}
+
+		var k [4]byte
+		var v [8]byte
+		binary.BigEndian.PutUint32(k[:], uint32(partition))
+		binary.BigEndian.PutUint64(v[:], uint64(offset+1))
+		return tx.Bucket(offsetBucket).Put(k[:], v[:])
+	})
+}
+
+func (s *Store) Offsets() (map[int]int64, error) {
+	offsets := make(map[int]int64)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(offsetBucket).ForEach(func(k, v []byte) error {
+			offsets[int(binary.BigEndian.Uint32(k))] = int64(binary.BigEndian.Uint64(v))
+			return nil
+		})
+	})
+	return offsets, err
+}
+
+// Reset 删除并重建两个桶
+func (s *Store) Reset() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{dataBucket, offsetBucket} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,68 @@
+package boltstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker/kafka"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table.db")
+
+	s, err := Open(path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Apply(0, 10, []kafka.TableEntry{{Key: "a", Value: []byte("1")}}))
+	assert.Nil(t, s.Apply(1, 3, []kafka.TableEntry{{Key: "b", Value: []byte("2")}}))
+	assert.Nil(t, s.Apply(0, 12, []kafka.TableEntry{{Key: "a"}, {Key: "c", Value: []byte("3")}}))
+	assert.Nil(t, s.Close())
+
+	s, err = Open(path)
+	assert.Nil(t, err)
+	defer s.Close()
+
+	offsets, err := s.Offsets()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int64{0: 13, 1: 4}, offsets)
+
+	_, ok, err := s.Get("a")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	v, ok, err := s.Get("b")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), v)
+
+	var keys []string
+	assert.Nil(t, s.Range(func(key string, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"b", "c"}, keys)
+}
+
+func TestStoreReset(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "table.db"))
+	assert.Nil(t, err)
+	defer s.Close()
+
+	assert.Nil(t, s.Apply(0, 5, []kafka.TableEntry{{Key: "a", Value: []byte("1")}}))
+	assert.Nil(t, s.Reset())
+
+	offsets, err := s.Offsets()
+	assert.Nil(t, err)
+	assert.Empty(t, offsets)
+
+	_, ok, err := s.Get("a")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, s.Apply(0, 0, []kafka.TableEntry{{Key: "b", Value: []byte("2")}}))
+	offsets, err = s.Offsets()
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int64{0: 1}, offsets)
+}
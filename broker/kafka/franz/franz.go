@@ -0,0 +1,309 @@
+package franz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/tracing"
+)
+
+const (
+	defaultAddr = "127.0.0.1:9092"
+
+	defaultNackDelay = time.Second
+)
+
+// franzBroker 基于 franz-go 的 Kafka 驱动，支持幂等生产者、事务和消费-转换-生产的精确一次语义
+type franzBroker struct {
+	sync.RWMutex
+
+	options broker.Options
+
+	transactionalID    string
+	transactionTimeout time.Duration
+	clientOptions      []kgo.Opt
+
+	producer *kgo.Client
+	// txMu 事务期间独占生产者，franz-go 的客户端同时只能有一个事务
+	txMu sync.Mutex
+
+	connected bool
+
+	subscribers *broker.SubscriberSyncMap
+
+	producerTracer *tracing.Tracer
+	consumerTracer *tracing.Tracer
+
+	log *log.Helper
+}
+
+var _ broker.ResultPublisher = (*franzBroker)(nil)
+
+func NewBroker(opts ...broker.Option) broker.Broker {
+	options := broker.NewOptionsAndApply(opts...)
+
+	b := &franzBroker{
+		options:     options,
+		subscribers: broker.NewSubscriberSyncMap(),
+		log:         newLoggerHelper(options.Logger),
+	}
+
+	return b
+}
+
+func (b *franzBroker) Name() string {
+	return "kafka-franz"
+}
+
+func (b *franzBroker) Address() string {
+	if len(b.options.Addrs) > 0 {
+		return b.options.Addrs[0]
+	}
+	return defaultAddr
+}
+
+func (b *franzBroker) Options() broker.Options {
+	return b.options
+}
+
+func (b *franzBroker) Init(opts ...broker.Option) error {
+	b.options.Apply(opts...)
+
+	b.log = newLoggerHelper(b.options.Logger)
+
+	var addrs []string
+	for _, addr := range b.options.Addrs {
+		if len(addr) == 0 {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		addrs = []string{defaultAddr}
+	}
+	b.options.Addrs = addrs
+
+	if value, ok := b.options.Context.Value(transactionalIDKey{}).(string); ok {
+		b.transactionalID = value
+	}
+	if value, ok := b.options.Context.Value(transactionTimeoutKey{}).(time.Duration); ok {
+		b.transactionTimeout = value
+	}
+	if value, ok := b.options.Context.Value(clientOptionsKey{}).([]kgo.Opt); ok {
+		b.clientOptions = value
+	}
+
+	if len(b.options.Tracings) > 0 {
+		b.newProducerTracer()
+		b.newConsumerTracer()
+	}
+
+	return nil
+}
+
+// commonOptions 生产者和消费者共用的客户端选项
+func (b *franzBroker) commonOptions() []kgo.Opt {
+	opts := []kgo.Opt{kgo.SeedBrokers(b.options.Addrs...)}
+	if b.options.Secure || b.options.TLSConfig != nil {
+		opts = append(opts, kgo.DialTLSConfig(b.options.TLSConfig))
+	}
+	return append(opts, b.clientOptions...)
+}
+
+// producerOptions 生产者的客户端选项，设置了事务 ID 时开启事务
+func (b *franzBroker) producerOptions() []kgo.Opt {
+	opts := b.commonOptions()
+	if b.transactionalID != "" {
+		opts = append(opts, kgo.TransactionalID(b.transactionalID))
+		if b.transactionTimeout > 0 {
+			opts = append(opts, kgo.TransactionTimeout(b.transactionTimeout))
+		}
+	}
+	return opts
+}
+
+func (b *franzBroker) Connect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.connected {
+		return nil
+	}
+
+	producer, err := kgo.NewClient(b.producerOptions()...)
+	if err != nil {
+		return err
+	}
+
+	b.producer = producer
+	b.connected = true
+
+	return nil
+}
+
+func (b *franzBroker) Disconnect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if !b.connected {
+		return nil
+	}
+
+	b.subscribers.Clear()
+
+	b.producer.Close()
+	b.producer = nil
+	b.connected = false
+
+	return nil
+}
+
+func (b *franzBroker) Request(_ context.Context, _ string, _ broker.Any, _ ...broker.RequestOption) (broker.Any, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *franzBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	record, err := b.newPublishRecord(ctx, topic, msg, opts...)
+	if err != nil {
+		return err
+	}
+	return b.publishRecord(ctx, record)
+}
+
+// PublishWithResult 发布消息并返回写入的分区、位移和时间戳
+func (b *franzBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	record, err := b.newPublishRecord(ctx, topic, msg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.publishRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// ProduceSync 成功后 record 中已填写服务端分配的分区和位移
+	return &broker.PublishResult{
+		Topic:     record.Topic,
+		Partition: int(record.Partition),
+		Offset:    record.Offset,
+		Timestamp: record.Timestamp,
+	}, nil
+}
+
+func (b *franzBroker) publishRecord(ctx context.Context, record *kgo.Record) error {
+	if b.transactionalID == "" {
+		return b.produce(ctx, record)
+	}
+
+	// 事务生产者的消息必须在事务中发送，每条消息使用单独的事务
+	tx, err := b.beginTransaction()
+	if err != nil {
+		return err
+	}
+	if err = b.produce(ctx, record); err != nil {
+		_ = tx.Abort(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (b *franzBroker) newPublishRecord(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*kgo.Record, error) {
+	buf, err := broker.Marshal(b.options.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	options := broker.PublishOptions{
+		Context: ctx,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return newRecord(topic, buf, options), nil
+}
+
+// produce 同步发送消息
+func (b *franzBroker) produce(ctx context.Context, record *kgo.Record) error {
+	b.RLock()
+	producer := b.producer
+	b.RUnlock()
+
+	if producer == nil {
+		return errors.New("broker not connected")
+	}
+
+	span := b.startProducerSpan(ctx, record)
+
+	err := producer.ProduceSync(ctx, record).FirstErr()
+
+	b.finishProducerSpan(span, record, err)
+
+	return err
+}
+
+// publishDeadLetter 将原始消息连同失败信息投递到死信主题，send 为空时使用生产者发送
+func (b *franzBroker) publishDeadLetter(ctx context.Context, topic string, record *kgo.Record, stage broker.ErrorStage, cause error, send func(context.Context, *kgo.Record) error) error {
+	dl := &kgo.Record{Topic: topic, Key: record.Key, Value: record.Value}
+	for k, v := range broker.DeadLetterHeaders(recordHeaderToMap(record.Headers), record.Topic, stage, cause) {
+		dl.Headers = append(dl.Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	}
+
+	if send == nil {
+		send = b.produce
+	}
+	return send(ctx, dl)
+}
+
+func (b *franzBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := b.subscribeOptions(opts...)
+
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts := append(b.commonOptions(),
+		kgo.ConsumerGroup(options.Queue),
+		kgo.ConsumeTopics(topic),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.DisableAutoCommit(),
+	)
+
+	client, err := kgo.NewClient(clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := newSubscriber(b, topic, options, binder, filter)
+	sub.handler = handler
+	sub.client = client
+
+	sub.start(sub.consume)
+
+	b.subscribers.Add(topic, sub)
+
+	return sub, nil
+}
+
+func (b *franzBroker) subscribeOptions(opts ...broker.SubscribeOption) broker.SubscribeOptions {
+	options := broker.SubscribeOptions{
+		Context: context.Background(),
+		AutoAck: true,
+		Queue:   uuid.New().String(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
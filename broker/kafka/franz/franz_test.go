@@ -0,0 +1,110 @@
+package franz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/memory"
+)
+
+func TestNewRecord(t *testing.T) {
+	options := broker.NewPublishOptions(
+		WithHeaders(map[string]string{"type": "ledger.posted"}),
+		WithMessageKey([]byte("account-1")),
+	)
+
+	record := newRecord("ledger", []byte("{}"), options)
+	assert.Equal(t, "ledger", record.Topic)
+	assert.Equal(t, []byte("account-1"), record.Key)
+	assert.Equal(t, broker.Headers{"type": "ledger.posted"}, recordHeaderToMap(record.Headers))
+
+	carrier := NewRecordCarrier(record)
+	carrier.Set("type", "ledger.reversed")
+	assert.Equal(t, "ledger.reversed", carrier.Get("type"))
+	assert.Equal(t, []string{"type"}, carrier.Keys())
+}
+
+func TestTransactionRequiresTransactionalID(t *testing.T) {
+	_, err := BeginTransaction(memory.NewBroker())
+	assert.ErrorIs(t, err, broker.ErrNotSupported)
+
+	b := NewBroker()
+	assert.Nil(t, b.Init())
+
+	_, err = BeginTransaction(b)
+	assert.ErrorIs(t, err, ErrNotTransactional)
+
+	handler := func(context.Context, broker.Event, Producer) error { return nil }
+	_, err = SubscribeTransform(b, "ledger", handler, nil)
+	assert.ErrorIs(t, err, ErrNotTransactional)
+
+	_, err = SubscribeTransform(memory.NewBroker(), "ledger", handler, nil)
+	assert.ErrorIs(t, err, broker.ErrNotSupported)
+}
+
+func TestTransactionalHandleError(t *testing.T) {
+	action := broker.ErrorActionDefault
+	b := NewBroker(broker.WithErrorHandler(func(context.Context, broker.Event, broker.ErrorStage, error) broker.ErrorAction {
+		return action
+	})).(*franzBroker)
+	assert.Nil(t, b.Init())
+
+	sub := newSubscriber(b, "ledger", broker.NewSubscribeOptions(), nil, nil)
+
+	var sent []*kgo.Record
+	send := func(_ context.Context, r *kgo.Record) error {
+		sent = append(sent, r)
+		return nil
+	}
+
+	record := &kgo.Record{Topic: "ledger", Value: []byte("x"), Offset: 3}
+	pub := sub.newPublication(record)
+
+	// 处理器失败默认中止事务，解码失败默认跳过
+	assert.True(t, sub.handleError(context.Background(), pub, broker.ErrorStageHandle, errors.New("failed"), send))
+	assert.False(t, sub.handleError(context.Background(), pub, broker.ErrorStageDecode, errors.New("bad"), send))
+	assert.False(t, sub.handleError(context.Background(), pub, broker.ErrorStageHandle, errors.New("failed"), nil))
+
+	action = broker.ErrorActionDeadLetter
+	assert.False(t, sub.handleError(context.Background(), pub, broker.ErrorStageHandle, errors.New("failed"), send))
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "ledger.dlq", sent[0].Topic)
+	assert.Equal(t, "ledger", recordHeaderToMap(sent[0].Headers)[broker.HeaderDeadLetterOriginalTopic])
+
+	action = broker.ErrorActionNack
+	assert.True(t, sub.handleError(context.Background(), pub, broker.ErrorStageHandle, errors.New("failed"), send))
+}
+
+func TestTransformResetSession(t *testing.T) {
+	b := NewBroker().(*franzBroker)
+	assert.Nil(t, b.Init())
+
+	session, err := kgo.NewGroupTransactSession(
+		kgo.SeedBrokers("127.0.0.1:1"),
+		kgo.TransactionalID("ledger-tx"),
+		kgo.ConsumerGroup("ledger"),
+		kgo.ConsumeTopics("ledger"),
+	)
+	assert.Nil(t, err)
+
+	sub := newSubscriber(b, "ledger", broker.NewSubscribeOptions(), nil, nil)
+	sub.session = session
+	sub.nackDelay = time.Millisecond
+	b.subscribers.Add("ledger", sub)
+
+	// 缺少事务 ID 无法重建会话，订阅被关闭并移除
+	sub.sessionOpts = []kgo.Opt{kgo.SeedBrokers("127.0.0.1:1")}
+	assert.False(t, sub.resetSession())
+	assert.True(t, sub.IsClosed())
+	assert.Nil(t, sub.session)
+
+	count := 0
+	b.subscribers.Foreach(func(string, broker.Subscriber) { count++ })
+	assert.Zero(t, count)
+}
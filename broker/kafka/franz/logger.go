@@ -0,0 +1,11 @@
+package franz
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return broker.NewLoggerHelper(logger, "broker", "kafka-franz")
+}
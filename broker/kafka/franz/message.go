@@ -0,0 +1,72 @@
+package franz
+
+import (
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ propagation.TextMapCarrier = (*RecordCarrier)(nil)
+
+type RecordCarrier struct {
+	record *kgo.Record
+}
+
+func NewRecordCarrier(record *kgo.Record) RecordCarrier {
+	return RecordCarrier{record: record}
+}
+
+func (c RecordCarrier) Get(key string) string {
+	for _, h := range c.record.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c RecordCarrier) Set(key, val string) {
+	for i := 0; i < len(c.record.Headers); i++ {
+		if c.record.Headers[i].Key == key {
+			c.record.Headers = append(c.record.Headers[:i], c.record.Headers[i+1:]...)
+			i--
+		}
+	}
+	c.record.Headers = append(c.record.Headers, kgo.RecordHeader{
+		Key:   key,
+		Value: []byte(val),
+	})
+}
+
+func (c RecordCarrier) Keys() []string {
+	out := make([]string, len(c.record.Headers))
+	for i, h := range c.record.Headers {
+		out[i] = h.Key
+	}
+	return out
+}
+
+func recordHeaderToMap(headers []kgo.RecordHeader) broker.Headers {
+	m := broker.Headers{}
+	for _, h := range headers {
+		m[h.Key] = string(h.Value)
+	}
+	return m
+}
+
+// newRecord 根据发布选项创建消息记录
+func newRecord(topic string, buf []byte, options broker.PublishOptions) *kgo.Record {
+	record := &kgo.Record{Topic: topic, Value: buf}
+
+	if headers, ok := options.Context.Value(headersKey{}).(map[string]string); ok {
+		for k, v := range headers {
+			record.Headers = append(record.Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+		}
+	}
+	if key, ok := options.Context.Value(messageKeyKey{}).([]byte); ok {
+		record.Key = key
+	}
+
+	return record
+}
@@ -0,0 +1,68 @@
+package franz
+
+import (
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+///
+/// Option
+///
+
+type transactionalIDKey struct{}
+type transactionTimeoutKey struct{}
+type clientOptionsKey struct{}
+
+// WithTransactionalID 生产者的事务 ID，设置后生产者开启幂等和事务，Publish 在单独的事务中发送。
+// 同一事务 ID 同时只能有一个实例在线，旧实例会被隔离（fenced）。
+func WithTransactionalID(id string) broker.Option {
+	return broker.OptionContextWithValue(transactionalIDKey{}, id)
+}
+
+// WithTransactionTimeout 事务超时时间，超时未提交的事务由 Kafka 中止
+func WithTransactionTimeout(timeout time.Duration) broker.Option {
+	return broker.OptionContextWithValue(transactionTimeoutKey{}, timeout)
+}
+
+// WithClientOptions 追加 franz-go 客户端选项，例如 SASL、压缩和分区器，生产者和消费者共用
+func WithClientOptions(opts ...kgo.Opt) broker.Option {
+	return broker.OptionContextWithValue(clientOptionsKey{}, opts)
+}
+
+///
+/// PublishOption
+///
+
+type headersKey struct{}
+type messageKeyKey struct{}
+
+// WithHeaders 消息头
+func WithHeaders(headers map[string]string) broker.PublishOption {
+	return broker.PublishContextWithValue(headersKey{}, headers)
+}
+
+// WithMessageKey 消息键，决定消息所在的分区
+func WithMessageKey(key []byte) broker.PublishOption {
+	return broker.PublishContextWithValue(messageKeyKey{}, key)
+}
+
+///
+/// SubscribeOption
+///
+
+type subscribeTransactionalIDKey struct{}
+type nackDelayKey struct{}
+
+// WithSubscribeTransactionalID SubscribeTransform 使用的事务 ID，默认为 <broker 事务 ID>-<topic>。
+// 每个副本需要使用不同且重启后保持不变的事务 ID。
+func WithSubscribeTransactionalID(id string) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(subscribeTransactionalIDKey{}, id)
+}
+
+// WithNackDelay ErrorHandler 返回 Nack 时，重新投递之前的等待时间，默认为1秒
+func WithNackDelay(delay time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(nackDelayKey{}, delay)
+}
@@ -0,0 +1,41 @@
+package franz
+
+import (
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type publication struct {
+	topic string
+
+	bm     *broker.Message
+	record *kgo.Record
+
+	ack func() error
+	err error
+}
+
+func (p *publication) Topic() string {
+	return p.topic
+}
+
+func (p *publication) Message() *broker.Message {
+	return p.bm
+}
+
+func (p *publication) RawMessage() interface{} {
+	return p.record
+}
+
+// Ack 在事务消费中为空操作，消费位移随事务一起提交
+func (p *publication) Ack() error {
+	if p.ack == nil {
+		return nil
+	}
+	return p.ack()
+}
+
+func (p *publication) Error() error {
+	return p.err
+}
@@ -0,0 +1,267 @@
+package franz
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type subscriber struct {
+	sync.RWMutex
+
+	b *franzBroker
+
+	topic string
+
+	options   broker.SubscribeOptions
+	handler   broker.Handler
+	transform TransformHandler
+	binder    broker.Binder
+	filter    *broker.Filter
+
+	client      *kgo.Client
+	session     *kgo.GroupTransactSession
+	sessionOpts []kgo.Opt
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  bool
+	stopped chan struct{}
+
+	nackDelay time.Duration
+
+	log *log.Helper
+}
+
+func newSubscriber(b *franzBroker, topic string, options broker.SubscribeOptions, binder broker.Binder, filter *broker.Filter) *subscriber {
+	ctx, cancel := context.WithCancel(options.Context)
+
+	sub := &subscriber{
+		b:       b,
+		topic:   topic,
+		options: options,
+		binder:  binder,
+		filter:  filter,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+
+		nackDelay: defaultNackDelay,
+
+		log: log.NewHelper(log.With(b.log.Logger(), "topic", topic, "group", options.Queue)),
+	}
+
+	if value, ok := options.Context.Value(nackDelayKey{}).(time.Duration); ok {
+		sub.nackDelay = value
+	}
+
+	return sub
+}
+
+func (s *subscriber) Options() broker.SubscribeOptions {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.options
+}
+
+func (s *subscriber) Topic() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.topic
+}
+
+// Unsubscribe 停止拉取并等待正在处理的消息完成，未提交的事务被中止
+func (s *subscriber) Unsubscribe(removeFromManager bool) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.Unlock()
+
+	s.cancel()
+	<-s.stopped
+
+	if s.b != nil && s.b.subscribers != nil && removeFromManager {
+		_ = s.b.subscribers.RemoveOnly(s.topic)
+	}
+
+	return nil
+}
+
+// fail 消费循环无法继续时标记订阅已关闭，之后的 Unsubscribe 不再等待
+func (s *subscriber) fail() {
+	s.Lock()
+	s.closed = true
+	s.Unlock()
+
+	s.cancel()
+
+	if s.b != nil && s.b.subscribers != nil {
+		_ = s.b.subscribers.RemoveOnly(s.topic)
+	}
+}
+
+func (s *subscriber) IsClosed() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.closed
+}
+
+// start 在后台运行消费循环，循环退出后关闭客户端
+func (s *subscriber) start(loop func()) {
+	go func() {
+		defer close(s.stopped)
+		defer func() {
+			if s.session != nil {
+				s.session.Close()
+			} else if s.client != nil {
+				s.client.Close()
+			}
+		}()
+
+		loop()
+	}()
+}
+
+// wait 等待 nackDelay，订阅关闭时返回 false
+func (s *subscriber) wait() bool {
+	timer := time.NewTimer(s.nackDelay)
+	defer timer.Stop()
+
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// consume 普通消费：逐条处理，确认后同步提交位移
+func (s *subscriber) consume() {
+	for {
+		fetches := s.client.PollFetches(s.ctx)
+		if fetches.IsClientClosed() || s.ctx.Err() != nil {
+			return
+		}
+
+		fetches.EachError(func(topic string, partition int32, err error) {
+			s.log.Errorf("fetch %s[%d] error: %s", topic, partition, err.Error())
+		})
+
+		iter := fetches.RecordIter()
+		for !iter.Done() {
+			record := iter.Next()
+			for s.handleRecord(record) {
+				s.log.Warnf("redeliver message, partition: %d, offset: %d", record.Partition, record.Offset)
+				if !s.wait() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleRecord 处理一条消息，返回是否需要重新投递
+func (s *subscriber) handleRecord(record *kgo.Record) bool {
+	ctx, span := s.b.startConsumerSpan(s.ctx, record)
+
+	pub := s.newPublication(record)
+	pub.ack = func() error { return s.client.CommitRecords(s.ctx, record) }
+
+	if !s.filter.Match(pub.bm.Headers) {
+		if err := pub.Ack(); err != nil {
+			s.log.Errorf("unable to commit filtered message: %v", err)
+		}
+		s.b.finishConsumerSpan(span, nil)
+		return false
+	}
+
+	if err := s.decode(pub); err != nil {
+		redeliver := s.handleError(ctx, pub, broker.ErrorStageDecode, err, nil)
+		s.b.finishConsumerSpan(span, err)
+		return redeliver
+	}
+
+	if err := s.handler(ctx, pub); err != nil {
+		redeliver := s.handleError(ctx, pub, broker.ErrorStageHandle, err, nil)
+		s.b.finishConsumerSpan(span, err)
+		return redeliver
+	}
+
+	if s.options.AutoAck {
+		if err := pub.Ack(); err != nil {
+			redeliver := s.handleError(ctx, pub, broker.ErrorStageAck, err, nil)
+			s.b.finishConsumerSpan(span, err)
+			return redeliver
+		}
+	}
+
+	s.b.finishConsumerSpan(span, nil)
+
+	return false
+}
+
+func (s *subscriber) newPublication(record *kgo.Record) *publication {
+	return &publication{
+		topic:  record.Topic,
+		record: record,
+		bm: &broker.Message{
+			Headers:   recordHeaderToMap(record.Headers),
+			Partition: int(record.Partition),
+			Offset:    record.Offset,
+		},
+	}
+}
+
+func (s *subscriber) decode(pub *publication) error {
+	if s.binder == nil {
+		pub.bm.Body = pub.record.Value
+		return nil
+	}
+
+	pub.bm.Body = s.binder()
+	return broker.Unmarshal(s.b.options.Codec, pub.record.Value, &pub.bm.Body)
+}
+
+// handleError 将失败的消息交给 ErrorHandler 处置，返回是否需要重新投递。
+// send 不为空时为事务消费：死信消息通过 send 在当前事务中发送，处理器失败的默认行为是中止事务后重新投递，
+// 避免提交处理器已经发布的部分结果。其他情况的默认行为是记录日志后继续，不提交该消息的位移。
+func (s *subscriber) handleError(ctx context.Context, pub *publication, stage broker.ErrorStage, err error, send func(context.Context, *kgo.Record) error) bool {
+	pub.err = err
+
+	s.log.Errorw("msg", "message failed", "stage", stage, "partition", pub.record.Partition, "offset", pub.record.Offset, "error", err)
+
+	switch broker.HandleError(s.b.options.ErrorHandler, ctx, pub, stage, err) {
+	case broker.ErrorActionAck:
+		if err = pub.Ack(); err != nil {
+			s.log.Errorf("unable to commit message: %v", err)
+		}
+
+	case broker.ErrorActionNack:
+		return true
+
+	case broker.ErrorActionDeadLetter:
+		if err = s.b.publishDeadLetter(ctx, broker.DeadLetterTopic(s.topic, s.options), pub.record, stage, err, send); err != nil {
+			s.log.Errorf("publish dead letter failed: %v", err)
+			return send != nil
+		}
+		if err = pub.Ack(); err != nil {
+			s.log.Errorf("unable to commit message: %v", err)
+		}
+
+	default:
+		return send != nil && stage == broker.ErrorStageHandle
+	}
+
+	return false
+}
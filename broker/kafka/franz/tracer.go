@@ -0,0 +1,82 @@
+package franz
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"go.opentelemetry.io/otel/attribute"
+	semConv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tx7do/kratos-transport/tracing"
+)
+
+const (
+	TracerMessageSystemKey = "kafka"
+	SpanNameProducer       = "kafka-producer"
+	SpanNameConsumer       = "kafka-consumer"
+)
+
+func (b *franzBroker) newProducerTracer() {
+	b.producerTracer = tracing.NewTracer(trace.SpanKindProducer, SpanNameProducer, b.options.Tracings...)
+}
+
+func (b *franzBroker) newConsumerTracer() {
+	b.consumerTracer = tracing.NewTracer(trace.SpanKindConsumer, SpanNameConsumer, b.options.Tracings...)
+}
+
+func (b *franzBroker) startProducerSpan(ctx context.Context, record *kgo.Record) trace.Span {
+	if b.producerTracer == nil {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{
+		semConv.MessagingSystemKey.String(TracerMessageSystemKey),
+		semConv.MessagingDestinationKindTopic,
+		semConv.MessagingDestinationKey.String(record.Topic),
+	}
+
+	_, span := b.producerTracer.Start(ctx, NewRecordCarrier(record), attrs...)
+
+	return span
+}
+
+func (b *franzBroker) finishProducerSpan(span trace.Span, record *kgo.Record, err error) {
+	if b.producerTracer == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		semConv.MessagingMessageIDKey.String(strconv.FormatInt(record.Offset, 10)),
+		semConv.MessagingKafkaPartitionKey.Int64(int64(record.Partition)),
+	}
+
+	b.producerTracer.End(context.Background(), span, err, attrs...)
+}
+
+func (b *franzBroker) startConsumerSpan(ctx context.Context, record *kgo.Record) (context.Context, trace.Span) {
+	if b.consumerTracer == nil {
+		return ctx, nil
+	}
+
+	attrs := []attribute.KeyValue{
+		semConv.MessagingSystemKey.String(TracerMessageSystemKey),
+		semConv.MessagingDestinationKindTopic,
+		semConv.MessagingDestinationKey.String(record.Topic),
+		semConv.MessagingOperationReceive,
+		semConv.MessagingMessageIDKey.String(strconv.FormatInt(record.Offset, 10)),
+		semConv.MessagingKafkaPartitionKey.Int64(int64(record.Partition)),
+	}
+
+	return b.consumerTracer.Start(ctx, NewRecordCarrier(record), attrs...)
+}
+
+func (b *franzBroker) finishConsumerSpan(span trace.Span, err error) {
+	if b.consumerTracer == nil {
+		return
+	}
+
+	b.consumerTracer.End(context.Background(), span, err)
+}
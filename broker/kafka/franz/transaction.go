@@ -0,0 +1,142 @@
+package franz
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var (
+	// ErrNotTransactional broker 没有通过 WithTransactionalID 设置事务 ID
+	ErrNotTransactional = errors.New("kafka-franz: transactional id not set")
+
+	// ErrTransactionDone 事务已经提交或中止
+	ErrTransactionDone = errors.New("kafka-franz: transaction already ended")
+)
+
+// Producer 在事务中发布消息
+type Producer interface {
+	Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error
+}
+
+// Transaction 生产者事务，事务中发布的消息在 Commit 后才对 read_committed 的消费者可见。
+// 事务结束之前，同一 broker 上的其他 Publish 和 BeginTransaction 会等待。
+type Transaction struct {
+	sync.Mutex
+
+	b    *franzBroker
+	done bool
+}
+
+var _ Producer = (*Transaction)(nil)
+
+// BeginTransaction 开启生产者事务，b 必须是本包创建并设置了 WithTransactionalID 的 broker
+func BeginTransaction(b broker.Broker) (*Transaction, error) {
+	fb, ok := b.(*franzBroker)
+	if !ok {
+		return nil, broker.ErrNotSupported
+	}
+	return fb.beginTransaction()
+}
+
+func (b *franzBroker) beginTransaction() (*Transaction, error) {
+	if b.transactionalID == "" {
+		return nil, ErrNotTransactional
+	}
+
+	b.RLock()
+	producer := b.producer
+	b.RUnlock()
+	if producer == nil {
+		return nil, errors.New("broker not connected")
+	}
+
+	b.txMu.Lock()
+	if err := producer.BeginTransaction(); err != nil {
+		b.txMu.Unlock()
+		return nil, err
+	}
+
+	return &Transaction{b: b}, nil
+}
+
+func (t *Transaction) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	record, err := t.b.newPublishRecord(ctx, topic, msg, opts...)
+	if err != nil {
+		return err
+	}
+	return t.b.produce(ctx, record)
+}
+
+// Commit 提交事务
+func (t *Transaction) Commit(ctx context.Context) error {
+	return t.end(ctx, kgo.TryCommit)
+}
+
+// Abort 中止事务，事务中发布的消息被丢弃
+func (t *Transaction) Abort(ctx context.Context) error {
+	return t.end(ctx, kgo.TryAbort)
+}
+
+func (t *Transaction) end(ctx context.Context, commit kgo.TransactionEndTry) error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+	defer t.b.txMu.Unlock()
+
+	t.b.RLock()
+	producer := t.b.producer
+	t.b.RUnlock()
+	if producer == nil {
+		return errors.New("broker not connected")
+	}
+
+	if commit == kgo.TryAbort {
+		if err := producer.AbortBufferedRecords(ctx); err != nil {
+			return err
+		}
+	} else if err := producer.Flush(ctx); err != nil {
+		return err
+	}
+
+	return producer.EndTransaction(ctx, commit)
+}
+
+// sessionProducer 在消费-转换-生产事务中发布消息
+type sessionProducer struct {
+	b       *franzBroker
+	session *kgo.GroupTransactSession
+}
+
+func (p *sessionProducer) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	record, err := p.b.newPublishRecord(ctx, topic, msg, opts...)
+	if err != nil {
+		return err
+	}
+	return p.produce(ctx, record)
+}
+
+func (p *sessionProducer) produce(ctx context.Context, record *kgo.Record) error {
+	span := p.b.startProducerSpan(ctx, record)
+
+	err := p.session.ProduceSync(ctx, record).FirstErr()
+
+	p.b.finishProducerSpan(span, record, err)
+
+	return err
+}
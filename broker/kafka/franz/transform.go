@@ -0,0 +1,182 @@
+package franz
+
+import (
+	"context"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// TransformHandler 消费-转换-生产处理器，通过 producer 发布的消息与该消息的消费位移在同一个事务中提交
+type TransformHandler func(ctx context.Context, evt broker.Event, producer Producer) error
+
+// SubscribeTransform 以精确一次语义消费 topic：每次拉取的消息在一个事务中处理，
+// 处理器发布的消息和消费位移一起提交，任何一条消息需要重新投递或再平衡时整个事务中止，从上次提交的位置重新消费。
+// b 必须是本包创建的 broker，事务 ID 由 WithSubscribeTransactionalID 或 broker 的 WithTransactionalID 指定。
+func SubscribeTransform(b broker.Broker, topic string, handler TransformHandler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	fb, ok := b.(*franzBroker)
+	if !ok {
+		return nil, broker.ErrNotSupported
+	}
+	return fb.subscribeTransform(topic, handler, binder, opts...)
+}
+
+func (b *franzBroker) subscribeTransform(topic string, handler TransformHandler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := b.subscribeOptions(opts...)
+
+	if options.SingleActiveConsumer != nil {
+		return nil, broker.ErrNotSupported
+	}
+
+	transactionalID := ""
+	if b.transactionalID != "" {
+		transactionalID = b.transactionalID + "-" + topic
+	}
+	if value, ok := options.Context.Value(subscribeTransactionalIDKey{}).(string); ok {
+		transactionalID = value
+	}
+	if transactionalID == "" {
+		return nil, ErrNotTransactional
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionOpts := b.transformOptions(topic, transactionalID, options)
+	session, err := kgo.NewGroupTransactSession(sessionOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := newSubscriber(b, topic, options, binder, filter)
+	sub.transform = handler
+	sub.session = session
+	sub.sessionOpts = sessionOpts
+
+	sub.start(sub.consumeTransform)
+
+	b.subscribers.Add(topic, sub)
+
+	return sub, nil
+}
+
+func (b *franzBroker) transformOptions(topic, transactionalID string, options broker.SubscribeOptions) []kgo.Opt {
+	opts := append(b.commonOptions(),
+		kgo.TransactionalID(transactionalID),
+		kgo.ConsumerGroup(options.Queue),
+		kgo.ConsumeTopics(topic),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.RequireStableFetchOffsets(),
+	)
+	if b.transactionTimeout > 0 {
+		opts = append(opts, kgo.TransactionTimeout(b.transactionTimeout))
+	}
+	return opts
+}
+
+// consumeTransform 每次拉取的消息在一个事务中处理并提交
+func (s *subscriber) consumeTransform() {
+	for {
+		producer := &sessionProducer{b: s.b, session: s.session}
+
+		fetches := s.session.PollFetches(s.ctx)
+		if fetches.IsClientClosed() || s.ctx.Err() != nil {
+			return
+		}
+
+		fetches.EachError(func(topic string, partition int32, err error) {
+			s.log.Errorf("fetch %s[%d] error: %s", topic, partition, err.Error())
+		})
+
+		if fetches.Empty() {
+			continue
+		}
+
+		if err := s.session.Begin(); err != nil {
+			s.log.Errorf("begin transaction error: %s", err.Error())
+			if !s.resetSession() {
+				return
+			}
+			continue
+		}
+
+		commit := kgo.TryCommit
+		iter := fetches.RecordIter()
+		for !iter.Done() {
+			if s.transformRecord(iter.Next(), producer) {
+				commit = kgo.TryAbort
+				break
+			}
+		}
+
+		committed, err := s.session.End(s.ctx, commit)
+		if err != nil {
+			// End 返回的错误不可重试，事务 ID 可能已被隔离
+			s.log.Errorf("end transaction error: %s", err.Error())
+			if !s.resetSession() {
+				return
+			}
+			continue
+		}
+
+		if !committed {
+			s.log.Warnf("transaction aborted, redeliver from last committed offsets")
+			if !s.wait() {
+				return
+			}
+		}
+	}
+}
+
+// resetSession 事务出错后会话不可再用，等待 nackDelay 后重建会话，从上次提交的位移重新消费。
+// 订阅已关闭或无法重建时返回 false，无法重建的订阅被标记为关闭并从 broker 中移除。
+func (s *subscriber) resetSession() bool {
+	s.session.Close()
+	s.session = nil
+
+	if !s.wait() {
+		return false
+	}
+
+	session, err := kgo.NewGroupTransactSession(s.sessionOpts...)
+	if err != nil {
+		s.log.Errorf("recreate transact session error, subscriber closed: %s", err.Error())
+		s.fail()
+		return false
+	}
+
+	s.session = session
+
+	return true
+}
+
+// transformRecord 在当前事务中处理一条消息，返回是否需要中止事务
+func (s *subscriber) transformRecord(record *kgo.Record, producer *sessionProducer) bool {
+	ctx, span := s.b.startConsumerSpan(s.ctx, record)
+
+	pub := s.newPublication(record)
+
+	if !s.filter.Match(pub.bm.Headers) {
+		s.b.finishConsumerSpan(span, nil)
+		return false
+	}
+
+	if err := s.decode(pub); err != nil {
+		abort := s.handleError(ctx, pub, broker.ErrorStageDecode, err, producer.produce)
+		s.b.finishConsumerSpan(span, err)
+		return abort
+	}
+
+	if err := s.transform(ctx, pub, producer); err != nil {
+		abort := s.handleError(ctx, pub, broker.ErrorStageHandle, err, producer.produce)
+		s.b.finishConsumerSpan(span, err)
+		return abort
+	}
+
+	s.b.finishConsumerSpan(span, nil)
+
+	return false
+}
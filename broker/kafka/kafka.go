@@ -3,11 +3,13 @@ package kafka
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/gob"
 	"errors"
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
 
 	kafkaGo "github.com/segmentio/kafka-go"
@@ -19,6 +21,8 @@ import (
 
 const (
 	defaultAddr = "127.0.0.1:9092"
+
+	defaultNackDelay = time.Second
 )
 
 type kafkaBroker struct {
@@ -38,28 +42,33 @@ type kafkaBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
 	options := broker.NewOptionsAndApply(opts...)
 
+	logger := newLoggerHelper(options.Logger)
+
 	b := &kafkaBroker{
 		readerConfig: kafkaGo.ReaderConfig{
 			WatchPartitionChanges: true,
 			MaxWait:               500 * time.Millisecond,
 			Logger:                nil,
-			ErrorLogger:           ErrorLogger{},
+			ErrorLogger:           ErrorLogger{log: logger},
 		},
 		writerConfig: WriterConfig{
 			Balancer:     &kafkaGo.LeastBytes{},
 			Logger:       nil,
-			ErrorLogger:  ErrorLogger{},
+			ErrorLogger:  ErrorLogger{log: logger},
 			BatchTimeout: 10 * time.Millisecond, // 内部默认为1秒，那么会造成什么情况呢？同步发送的时候，发送一次要等待1秒的时间。
 			Async:        true,                  // 默认设置为异步发送，效率比较高。
 		},
 		options:      options,
 		retriesCount: 1,
 		subscribers:  broker.NewSubscriberSyncMap(),
+		log:          logger,
 	}
 
 	return b
@@ -83,6 +92,8 @@ func (b *kafkaBroker) Options() broker.Options {
 func (b *kafkaBroker) Init(opts ...broker.Option) error {
 	b.options.Apply(opts...)
 
+	b.log = newLoggerHelper(b.options.Logger)
+
 	if value, ok := b.options.Context.Value(writerConfigKey{}).(WriterConfig); ok {
 		b.writerConfig = value
 	}
@@ -119,33 +130,7 @@ func (b *kafkaBroker) Init(opts ...broker.Option) error {
 		b.readerConfig = value
 	}
 
-	if b.readerConfig.Dialer == nil {
-		b.readerConfig.Dialer = kafkaGo.DefaultDialer
-	}
-
-	if b.saslMechanism != nil {
-		if b.readerConfig.Dialer == nil {
-			dialer := &kafkaGo.Dialer{
-				Timeout:       10 * time.Second,
-				DualStack:     true,
-				SASLMechanism: b.saslMechanism,
-			}
-			b.readerConfig.Dialer = dialer
-		} else {
-			b.readerConfig.Dialer.SASLMechanism = b.saslMechanism
-		}
-	}
-
-	if b.options.Secure && b.options.TLSConfig != nil {
-		if b.readerConfig.Dialer == nil {
-			dialer := &kafkaGo.Dialer{
-				Timeout:   10 * time.Second,
-				DualStack: true,
-			}
-			b.readerConfig.Dialer = dialer
-		}
-		b.readerConfig.Dialer.TLS = b.options.TLSConfig
-	}
+	b.readerConfig.Dialer = b.newDialer(b.readerConfig.Dialer)
 
 	if cnt, ok := b.options.Context.Value(retriesCountKey{}).(int); ok {
 		b.retriesCount = cnt
@@ -156,6 +141,13 @@ func (b *kafkaBroker) Init(opts ...broker.Option) error {
 		b.newConsumerTracer()
 	}
 
+	if _, ok := b.readerConfig.ErrorLogger.(ErrorLogger); ok {
+		b.readerConfig.ErrorLogger = ErrorLogger{log: b.log}
+	}
+	if _, ok := b.writerConfig.ErrorLogger.(ErrorLogger); ok {
+		b.writerConfig.ErrorLogger = ErrorLogger{log: b.log}
+	}
+
 	if value, ok := b.options.Context.Value(loggerKey{}).(kafkaGo.Logger); ok {
 		b.readerConfig.Logger = value
 		b.writerConfig.Logger = value
@@ -167,8 +159,8 @@ func (b *kafkaBroker) Init(opts ...broker.Option) error {
 
 	if value, ok := b.options.Context.Value(enableLoggerKey{}).(bool); ok {
 		if value {
-			b.readerConfig.Logger = Logger{}
-			b.writerConfig.Logger = Logger{}
+			b.readerConfig.Logger = Logger{log: b.log}
+			b.writerConfig.Logger = Logger{log: b.log}
 		} else {
 			b.readerConfig.Logger = nil
 			b.writerConfig.Logger = nil
@@ -176,8 +168,8 @@ func (b *kafkaBroker) Init(opts ...broker.Option) error {
 	}
 	if value, ok := b.options.Context.Value(enableErrorLoggerKey{}).(bool); ok {
 		if value {
-			b.readerConfig.ErrorLogger = ErrorLogger{}
-			b.writerConfig.ErrorLogger = ErrorLogger{}
+			b.readerConfig.ErrorLogger = ErrorLogger{log: b.log}
+			b.writerConfig.ErrorLogger = ErrorLogger{log: b.log}
 		} else {
 			b.readerConfig.ErrorLogger = nil
 			b.writerConfig.ErrorLogger = nil
@@ -243,6 +235,38 @@ func (b *kafkaBroker) Init(opts ...broker.Option) error {
 	return nil
 }
 
+// tlsConfig 读取、写入和管理客户端共用的 TLS 配置，Secure 且没有设置 TLSConfig 时使用默认配置
+func (b *kafkaBroker) tlsConfig() *tls.Config {
+	if b.options.TLSConfig != nil {
+		return b.options.TLSConfig
+	}
+	if b.options.Secure {
+		return &tls.Config{}
+	}
+	return nil
+}
+
+// newDialer 复制 base（为空时使用与 kafkaGo.DefaultDialer 相同的配置），并设置 broker 的 SASL 和 TLS 配置
+func (b *kafkaBroker) newDialer(base *kafkaGo.Dialer) *kafkaGo.Dialer {
+	dialer := &kafkaGo.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+	if base != nil {
+		d := *base
+		dialer = &d
+	}
+
+	if b.saslMechanism != nil {
+		dialer.SASLMechanism = b.saslMechanism
+	}
+	if tlsConfig := b.tlsConfig(); tlsConfig != nil {
+		dialer.TLS = tlsConfig
+	}
+
+	return dialer
+}
+
 func (b *kafkaBroker) Connect() error {
 	b.RLock()
 	if b.connected {
@@ -332,6 +356,10 @@ func (b *kafkaBroker) Publish(ctx context.Context, topic string, msg broker.Any,
 		return err
 	}
 
+	return b.publish(ctx, topic, buf, opts...)
+}
+
+func (b *kafkaBroker) publish(ctx context.Context, topic string, buf []byte, opts ...broker.PublishOption) error {
 	if b.writer.EnableOneTopicOneWriter {
 		return b.publishMultipleWriter(ctx, topic, buf, opts...)
 	} else {
@@ -339,6 +367,21 @@ func (b *kafkaBroker) Publish(ctx context.Context, topic string, msg broker.Any,
 	}
 }
 
+// publishDeadLetter 将原始消息连同失败信息投递到死信主题
+func (b *kafkaBroker) publishDeadLetter(ctx context.Context, topic string, km kafkaGo.Message, stage broker.ErrorStage, cause error) error {
+	headers := make(map[string]interface{})
+	for k, v := range broker.DeadLetterHeaders(kafkaHeaderToMap(km.Headers), km.Topic, stage, cause) {
+		headers[k] = v
+	}
+
+	opts := []broker.PublishOption{WithHeaders(headers)}
+	if len(km.Key) > 0 {
+		opts = append(opts, WithMessageKey(km.Key))
+	}
+
+	return b.publish(ctx, topic, km.Value, opts...)
+}
+
 func (b *kafkaBroker) publishMultipleWriter(ctx context.Context, topic string, buf []byte, opts ...broker.PublishOption) error {
 	options := broker.PublishOptions{
 		Context: ctx,
@@ -384,7 +427,7 @@ func (b *kafkaBroker) publishMultipleWriter(ctx context.Context, topic string, b
 	b.Lock()
 	writer, ok := b.writer.Writers[topic]
 	if !ok {
-		writer = b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.options.TLSConfig)
+		writer = b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.tlsConfig())
 		b.initPublishOption(writer, options)
 		b.writer.Writers[topic] = writer
 	} else {
@@ -394,12 +437,11 @@ func (b *kafkaBroker) publishMultipleWriter(ctx context.Context, topic string, b
 
 	var err error
 
-	span := b.startProducerSpan(options.Context, &kMsg)
-	defer b.finishProducerSpan(span, int32(kMsg.Partition), kMsg.Offset, err)
+	p := b.newPublishing(options, &kMsg)
 
 	err = writer.WriteMessages(options.Context, kMsg)
 	if err != nil {
-		LogErrorf("WriteMessages error: %s", err.Error())
+		b.log.Errorw("msg", "WriteMessages error", "topic", topic, "error", err)
 		switch cached {
 		case false:
 			var kerr kafkaGo.Error
@@ -418,7 +460,7 @@ func (b *kafkaBroker) publishMultipleWriter(ctx context.Context, topic string, b
 			delete(b.writer.Writers, topic)
 			b.Unlock()
 
-			writer = b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.options.TLSConfig)
+			writer = b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.tlsConfig())
 			b.initPublishOption(writer, options)
 			for i := 0; i < b.retriesCount; i++ {
 				if err = writer.WriteMessages(options.Context, kMsg); err == nil {
@@ -431,6 +473,8 @@ func (b *kafkaBroker) publishMultipleWriter(ctx context.Context, topic string, b
 		}
 	}
 
+	p.written(kMsg, err)
+
 	return err
 }
 
@@ -478,7 +522,7 @@ func (b *kafkaBroker) publishOneWriter(ctx context.Context, topic string, buf []
 	var cached bool
 	b.Lock()
 	if b.writer.Writer == nil {
-		b.writer.Writer = b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.options.TLSConfig)
+		b.writer.Writer = b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.tlsConfig())
 		b.initPublishOption(b.writer.Writer, options)
 	} else {
 		cached = true
@@ -487,12 +531,11 @@ func (b *kafkaBroker) publishOneWriter(ctx context.Context, topic string, buf []
 
 	var err error
 
-	span := b.startProducerSpan(options.Context, &kMsg)
-	defer b.finishProducerSpan(span, int32(kMsg.Partition), kMsg.Offset, err)
+	p := b.newPublishing(options, &kMsg)
 
 	err = b.writer.Writer.WriteMessages(options.Context, kMsg)
 	if err != nil {
-		LogErrorf("WriteMessages error: %s", err.Error())
+		b.log.Errorw("msg", "WriteMessages error", "topic", topic, "error", err)
 		switch cached {
 		case false:
 			var kerr kafkaGo.Error
@@ -508,10 +551,10 @@ func (b *kafkaBroker) publishOneWriter(ctx context.Context, topic string, buf []
 				b.Unlock()
 				break
 			}
-			b.writer = nil
+			b.writer.Writer = nil
 			b.Unlock()
 
-			writer := b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.options.TLSConfig)
+			writer := b.writer.CreateProducer(b.writerConfig, b.saslMechanism, b.tlsConfig())
 			b.initPublishOption(writer, options)
 			for i := 0; i < b.retriesCount; i++ {
 				if err = writer.WriteMessages(options.Context, kMsg); err == nil {
@@ -524,6 +567,8 @@ func (b *kafkaBroker) publishOneWriter(ctx context.Context, topic string, buf []
 		}
 	}
 
+	p.written(kMsg, err)
+
 	return err
 }
 
@@ -538,10 +583,28 @@ func (b *kafkaBroker) Subscribe(
 		AutoAck: true,
 		Queue:   uuid.New().String(),
 	}
+	if value, ok := b.options.Context.Value(defaultGroupKey{}).(string); ok && len(value) > 0 {
+		options.Queue = value
+	}
 	for _, o := range opts {
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, ok := options.Context.Value(retryTopicKey{}).(RetryTopicConfig); ok {
+		if err = value.validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	readerConfig := b.readerConfig
 	readerConfig.Topic = topic
 	readerConfig.GroupID = options.Queue
@@ -549,13 +612,13 @@ func (b *kafkaBroker) Subscribe(
 	//LogInfof("topic: %s, group: %s, queue: %s", readerConfig.Topic, readerConfig.GroupID, options.Queue)
 
 	if value, ok := options.Context.Value(autoSubscribeCreateTopicKey{}).(*autoSubscribeCreateTopicValue); ok {
-		if err := CreateTopic(b.Address(), value.Topic, value.NumPartitions, value.ReplicationFactor); err != nil {
-			LogErrorf("create topic error: %s", err.Error())
+		if err := CreateTopicWithDialer(b.newDialer(nil), b.Address(), value.Topic, value.NumPartitions, value.ReplicationFactor); err != nil {
+			b.log.Errorf("create topic error: %s", err.Error())
 		}
 	}
 
 	if readerConfig.Dialer == nil {
-		readerConfig.Dialer = kafkaGo.DefaultDialer
+		readerConfig.Dialer = b.newDialer(nil)
 	}
 
 	if value, ok := b.options.Context.Value(queueCapacityKey{}).(int); ok {
@@ -601,12 +664,12 @@ func (b *kafkaBroker) Subscribe(
 		readerConfig.MaxAttempts = value
 	}
 	if value, ok := b.options.Context.Value(dialerConfigKey{}).(*kafkaGo.Dialer); ok {
-		readerConfig.Dialer = value
+		readerConfig.Dialer = b.newDialer(value)
 	}
 	if value, ok := b.options.Context.Value(dialerTimeoutKey{}).(time.Duration); ok {
-		if readerConfig.Dialer != nil {
-			readerConfig.Dialer.Timeout = value
-		}
+		dialer := *readerConfig.Dialer
+		dialer.Timeout = value
+		readerConfig.Dialer = &dialer
 	}
 
 	if value, ok := b.options.Context.Value(partitionKey{}).(int); ok {
@@ -622,7 +685,15 @@ func (b *kafkaBroker) Subscribe(
 		readerConfig.ReadBackoffMax = value
 	}
 
-	sub := newSubscriber(b, topic, options, readerConfig, handler, binder)
+	var sub *subscriber
+	if value, ok := options.Context.Value(partitionWorkersKey{}).(bool); ok && value {
+		if sub, err = newPartitionSubscriber(b, topic, options, readerConfig, handler, binder); err != nil {
+			return nil, err
+		}
+	} else {
+		sub = newSubscriber(b, topic, options, readerConfig, handler, binder)
+	}
+	sub.filter = filter
 
 	if value, ok := options.Context.Value(subscribeBatchSizeKey{}).(int); ok {
 		sub.batchSize = value
@@ -630,10 +701,26 @@ func (b *kafkaBroker) Subscribe(
 	if value, ok := options.Context.Value(subscribeBatchIntervalKey{}).(time.Duration); ok {
 		sub.batchInterval = value
 	}
+	if value, ok := options.Context.Value(nackDelayKey{}).(time.Duration); ok {
+		sub.nackDelay = value
+	}
+	if value, ok := options.Context.Value(batchHandlerKey{}).(BatchHandler); ok && value != nil {
+		if !sub.isBatchMode() || sub.group != nil {
+			_ = sub.close()
+			return nil, errors.New("kafka: batch handler requires subscribe batch size or interval and can not be used with partition workers")
+		}
+		sub.batchHandler = value
+	}
 
-	go func() {
-		sub.run()
-	}()
+	if value, ok := options.Context.Value(retryTopicKey{}).(RetryTopicConfig); ok {
+		sub.retry = &retryRouter{topic: topic, config: value, level: -1}
+		sub.retrySubs = b.newRetrySubscribers(sub, readerConfig)
+	}
+
+	sub.start()
+	for _, rs := range sub.retrySubs {
+		rs.start()
+	}
 
 	b.subscribers.Add(topic, sub)
 
@@ -2,33 +2,45 @@ package kafka
 
 import (
 	"fmt"
+
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
 )
 
 const (
 	logKey = "[kafka]"
 )
 
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return broker.NewLoggerHelper(logger, "broker", "kafka")
+}
+
 ///
 /// logger
 ///
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogDebug(args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogInfo(args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogWarn(args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogError(args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogFatal(args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprint(args...))
 }
@@ -37,22 +49,27 @@ func LogFatal(args ...interface{}) {
 /// logger
 ///
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogDebugf(format string, args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogInfof(format string, args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogWarnf(format string, args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogErrorf(format string, args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogFatalf(format string, args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
@@ -62,15 +79,25 @@ func LogFatalf(format string, args ...interface{}) {
 ///
 
 type Logger struct {
+	log *log.Helper
 }
 
 func (l Logger) Printf(msg string, args ...interface{}) {
-	log.Infof(msg, args...)
+	if l.log == nil {
+		log.Infof(msg, args...)
+		return
+	}
+	l.log.Infof(msg, args...)
 }
 
 type ErrorLogger struct {
+	log *log.Helper
 }
 
 func (l ErrorLogger) Printf(msg string, args ...interface{}) {
-	log.Errorf(msg, args...)
+	if l.log == nil {
+		log.Errorf(msg, args...)
+		return
+	}
+	l.log.Errorf(msg, args...)
 }
@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestOAuthBearerInitialResponse(t *testing.T) {
+	m := NewOAuthBearerMechanism(func(ctx context.Context) (OAuthBearerToken, error) {
+		return OAuthBearerToken{
+			Token:      "jwt",
+			Extensions: map[string]string{"logicalCluster": "lkc-1", "identityPoolId": "pool-1"},
+		}, nil
+	})
+	assert.Equal(t, "OAUTHBEARER", m.Name())
+
+	sm, ir, err := m.Start(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "n,,\x01auth=Bearer jwt\x01identityPoolId=pool-1\x01logicalCluster=lkc-1\x01\x01", string(ir))
+
+	done, resp, err := sm.Next(context.Background(), nil)
+	assert.True(t, done)
+	assert.Nil(t, resp)
+	assert.Nil(t, err)
+
+	done, _, err = sm.Next(context.Background(), []byte(`{"status":"invalid_token"}`))
+	assert.False(t, done)
+	assert.NotNil(t, err)
+}
+
+func TestOAuthBearerTokenRefresh(t *testing.T) {
+	now := time.Unix(1000, 0)
+	calls := 0
+	var fail error
+
+	m := NewOAuthBearerMechanism(func(ctx context.Context) (OAuthBearerToken, error) {
+		calls++
+		if fail != nil {
+			return OAuthBearerToken{}, fail
+		}
+		return OAuthBearerToken{Token: "token", Expiry: now.Add(100 * time.Second)}, nil
+	}).(*oauthBearerMechanism)
+	m.now = func() time.Time { return now }
+
+	_, err := m.getToken(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+
+	// 未到刷新时间（生命周期的 80%）时使用缓存
+	now = now.Add(79 * time.Second)
+	_, err = m.getToken(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+
+	// 刷新失败但旧令牌尚未过期时继续使用旧令牌
+	now = now.Add(2 * time.Second)
+	fail = errors.New("idp unavailable")
+	token, err := m.getToken(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "token", token.Token)
+	assert.Equal(t, 2, calls)
+
+	// 旧令牌过期后返回错误
+	now = now.Add(20 * time.Second)
+	_, err = m.getToken(context.Background())
+	assert.Equal(t, fail, err)
+
+	fail = nil
+	_, err = m.getToken(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 4, calls)
+}
+
+func TestSASLMechanismAppliedToDialers(t *testing.T) {
+	mechanism := NewOAuthBearerMechanism(func(ctx context.Context) (OAuthBearerToken, error) {
+		return OAuthBearerToken{Token: "jwt"}, nil
+	})
+	tlsConfig := &tls.Config{ServerName: "kafka"}
+
+	b := NewBroker(
+		WithSASLMechanism(mechanism),
+		broker.WithEnableSecure(true),
+		broker.WithTLSConfig(tlsConfig),
+	).(*kafkaBroker)
+	assert.Nil(t, b.Init())
+
+	assert.Equal(t, mechanism, b.readerConfig.Dialer.SASLMechanism)
+	assert.Equal(t, tlsConfig, b.readerConfig.Dialer.TLS)
+	assert.NotSame(t, kafkaGo.DefaultDialer, b.readerConfig.Dialer)
+	assert.Nil(t, kafkaGo.DefaultDialer.SASLMechanism)
+
+	transport := b.adminClient().Transport.(*kafkaGo.Transport)
+	assert.Equal(t, mechanism, transport.SASL)
+	assert.Equal(t, tlsConfig, transport.TLS)
+
+	dialer := b.newDialer(&kafkaGo.Dialer{ClientID: "custom"})
+	assert.Equal(t, "custom", dialer.ClientID)
+	assert.Equal(t, mechanism, dialer.SASLMechanism)
+}
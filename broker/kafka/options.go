@@ -5,6 +5,7 @@ import (
 	"time"
 
 	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
 	"github.com/segmentio/kafka-go/sasl/plain"
 	"github.com/segmentio/kafka-go/sasl/scram"
 
@@ -50,6 +51,7 @@ type readTimeoutKey struct{}
 type writeTimeoutKey struct{}
 type allowPublishAutoTopicCreationKey struct{}
 type completionKey struct{}
+type defaultGroupKey struct{}
 
 // WithReaderConfig .
 func WithReaderConfig(cfg kafkaGo.ReaderConfig) broker.Option {
@@ -93,6 +95,16 @@ func WithScramMechanism(algoName ScramAlgorithm, username, password string) brok
 	return broker.OptionContextWithValue(mechanismKey{}, mechanism)
 }
 
+// WithSASLMechanism 自定义SASL认证机制，同时用于读取、写入和管理客户端
+func WithSASLMechanism(mechanism sasl.Mechanism) broker.Option {
+	return broker.OptionContextWithValue(mechanismKey{}, mechanism)
+}
+
+// WithOAuthBearerMechanism OAUTHBEARER认证，source 返回的令牌被缓存并在过期之前自动刷新
+func WithOAuthBearerMechanism(source OAuthBearerTokenSource) broker.Option {
+	return WithSASLMechanism(NewOAuthBearerMechanism(source))
+}
+
 // WithMaxAttempts .
 func WithMaxAttempts(cnt int) broker.Option {
 	return broker.OptionContextWithValue(maxAttemptsKey{}, cnt)
@@ -169,6 +181,11 @@ func WithCompletion(completion func(messages []kafkaGo.Message, err error)) brok
 	return broker.OptionContextWithValue(completionKey{}, completion)
 }
 
+// WithDefaultGroup 订阅时未指定 Queue 所使用的消费者组，默认每次订阅生成一个随机的组
+func WithDefaultGroup(group string) broker.Option {
+	return broker.OptionContextWithValue(defaultGroupKey{}, group)
+}
+
 ///
 /// PublishOption
 ///
@@ -291,6 +308,12 @@ type readBackoffMax struct{}
 
 type subscribeBatchSizeKey struct{}
 type subscribeBatchIntervalKey struct{}
+type nackDelayKey struct{}
+type batchHandlerKey struct{}
+type retryTopicKey struct{}
+type partitionWorkersKey struct{}
+type partitionsAssignedKey struct{}
+type partitionsRevokedKey struct{}
 
 func WithSubscribeAutoCreateTopic(topic string, numPartitions, replicationFactor int) broker.SubscribeOption {
 	return broker.SubscribeContextWithValue(autoSubscribeCreateTopicKey{},
@@ -405,3 +428,65 @@ func WithSubscribeBatchSize(batchSize int) broker.SubscribeOption {
 func WithSubscribeBatchInterval(batchInterval time.Duration) broker.SubscribeOption {
 	return broker.SubscribeContextWithValue(subscribeBatchIntervalKey{}, batchInterval)
 }
+
+// WithRetryTopics 处理失败的消息按 delays 依次投递到重试主题，最后投递到 <topic>.dlt，并自动创建这些主题。
+// 仅在 ErrorHandler 未给出处置（ErrorActionDefault）时生效。
+func WithRetryTopics(delays ...time.Duration) broker.SubscribeOption {
+	return WithRetryTopicConfig(RetryTopicConfig{Delays: delays, AutoCreateTopics: true})
+}
+
+// WithRetryTopicConfig 非阻塞重试主题的完整配置
+func WithRetryTopicConfig(cfg RetryTopicConfig) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(retryTopicKey{}, cfg)
+}
+
+// WithBatchHandler 批量处理器，整个批次只调用一次处理器，成功后每个分区只提交一次最大位移。
+// 需要同时设置 WithSubscribeBatchSize 或 WithSubscribeBatchInterval，设置后忽略 Subscribe 的 handler 参数。
+func WithBatchHandler(handler BatchHandler) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(batchHandlerKey{}, handler)
+}
+
+// WithNackDelay ErrorHandler 返回 Nack 时，重新投递同一条消息之前的等待时间，默认为1秒
+func WithNackDelay(delay time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(nackDelayKey{}, delay)
+}
+
+// WithPartitionWorkers 每个分配到的分区使用独立的协程并发消费，分区内保持顺序，需要设置消费组
+func WithPartitionWorkers(enable bool) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(partitionWorkersKey{}, enable)
+}
+
+// WithOnPartitionsAssigned 分区分配后、开始消费前的回调，仅在分区工作模式下生效
+func WithOnPartitionsAssigned(cb PartitionsCallback) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(partitionsAssignedKey{}, cb)
+}
+
+// WithOnPartitionsRevoked 分区回收时的回调，在所有分区工作协程退出之后、提交位移之前调用，仅在分区工作模式下生效
+func WithOnPartitionsRevoked(cb PartitionsCallback) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(partitionsRevokedKey{}, cb)
+}
+
+///
+/// TableOption
+///
+
+type tableOptions struct {
+	store            TableStore
+	lagCheckInterval time.Duration
+}
+
+type TableOption func(*tableOptions)
+
+// WithTableStore Table 的存储，默认为内存存储，每次创建 Table 都从头消费
+func WithTableStore(store TableStore) TableOption {
+	return func(o *tableOptions) {
+		o.store = store
+	}
+}
+
+// WithTableLagCheckInterval 追上之前查询分区剩余消息数的间隔，默认为1秒
+func WithTableLagCheckInterval(interval time.Duration) TableOption {
+	return func(o *tableOptions) {
+		o.lagCheckInterval = interval
+	}
+}
@@ -0,0 +1,283 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kafkaGo "github.com/segmentio/kafka-go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// PartitionsCallback 分区分配变化时的回调，partitions 为本消费者在 topic 上分配到的分区
+type PartitionsCallback func(ctx context.Context, topic string, partitions []int)
+
+// partitionCommitter 分区工作模式下的位移提交：commitInterval 为 0 时每次确认同步提交，
+// 否则只记录位移，由定时器和分区回收时统一提交
+type partitionCommitter struct {
+	sync.Mutex
+
+	topic    string
+	interval time.Duration
+	commit   func(offsets map[string]map[int]int64) error
+
+	pending map[int]int64
+}
+
+func newPartitionCommitter(topic string, interval time.Duration, commit func(map[string]map[int]int64) error) *partitionCommitter {
+	return &partitionCommitter{
+		topic:    topic,
+		interval: interval,
+		commit:   commit,
+		pending:  make(map[int]int64),
+	}
+}
+
+func (c *partitionCommitter) CommitMessages(_ context.Context, msgs ...kafkaGo.Message) error {
+	c.Lock()
+	for _, m := range msgs {
+		if next, ok := c.pending[m.Partition]; !ok || m.Offset+1 > next {
+			c.pending[m.Partition] = m.Offset + 1
+		}
+	}
+	c.Unlock()
+
+	if c.interval > 0 {
+		return nil
+	}
+	return c.flush()
+}
+
+// flush 提交已确认的位移，失败时保留以便下次重试
+func (c *partitionCommitter) flush() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.pending) == 0 {
+		return nil
+	}
+
+	offsets := make(map[int]int64, len(c.pending))
+	for p, o := range c.pending {
+		offsets[p] = o
+	}
+
+	if err := c.commit(map[string]map[int]int64{c.topic: offsets}); err != nil {
+		return err
+	}
+
+	for p, o := range offsets {
+		if c.pending[p] == o {
+			delete(c.pending, p)
+		}
+	}
+	return nil
+}
+
+// consumerGroupConfig 由读取器配置生成消费组配置
+func consumerGroupConfig(cfg kafkaGo.ReaderConfig) kafkaGo.ConsumerGroupConfig {
+	return kafkaGo.ConsumerGroupConfig{
+		ID:                     cfg.GroupID,
+		Brokers:                cfg.Brokers,
+		Dialer:                 cfg.Dialer,
+		Topics:                 []string{cfg.Topic},
+		GroupBalancers:         cfg.GroupBalancers,
+		HeartbeatInterval:      cfg.HeartbeatInterval,
+		PartitionWatchInterval: cfg.PartitionWatchInterval,
+		WatchPartitionChanges:  cfg.WatchPartitionChanges,
+		SessionTimeout:         cfg.SessionTimeout,
+		RebalanceTimeout:       cfg.RebalanceTimeout,
+		RetentionTime:          cfg.RetentionTime,
+		StartOffset:            cfg.StartOffset,
+		Logger:                 cfg.Logger,
+		ErrorLogger:            cfg.ErrorLogger,
+	}
+}
+
+// partitionReaderConfig 单个分区的读取器配置，不加入消费组
+func partitionReaderConfig(cfg kafkaGo.ReaderConfig, partition int) kafkaGo.ReaderConfig {
+	cfg.GroupID = ""
+	cfg.GroupTopics = nil
+	cfg.Partition = partition
+	cfg.CommitInterval = 0
+	return cfg
+}
+
+// newPartitionSubscriber 创建分区工作模式的订阅者：使用消费组分配分区，每个分区由独立的协程按顺序处理
+func newPartitionSubscriber(
+	b *kafkaBroker,
+	topic string,
+	options broker.SubscribeOptions,
+	readerConfig kafkaGo.ReaderConfig,
+	handler broker.Handler,
+	binder broker.Binder,
+) (*subscriber, error) {
+	group, err := kafkaGo.NewConsumerGroup(consumerGroupConfig(readerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscriber{
+		b:            b,
+		options:      options,
+		topic:        topic,
+		handler:      handler,
+		binder:       binder,
+		group:        group,
+		readerConfig: readerConfig,
+		done:         make(chan struct{}),
+
+		nackDelay: defaultNackDelay,
+
+		log: log.NewHelper(log.With(b.log.Logger(), "topic", topic, "group", readerConfig.GroupID)),
+	}
+
+	if value, ok := options.Context.Value(partitionsAssignedKey{}).(PartitionsCallback); ok {
+		sub.onAssigned = value
+	}
+	if value, ok := options.Context.Value(partitionsRevokedKey{}).(PartitionsCallback); ok {
+		sub.onRevoked = value
+	}
+
+	return sub, nil
+}
+
+func (s *subscriber) processPartitions() {
+	for {
+		gen, err := s.group.Next(s.options.Context)
+		if err != nil {
+			if errors.Is(err, kafkaGo.ErrGroupClosed) {
+				return
+			}
+			if s.options.Context.Err() != nil {
+				_ = s.close()
+				return
+			}
+			s.log.Errorf("join consumer group error: %s", err.Error())
+			time.Sleep(1 * time.Second)
+			continue
+		}
+
+		s.runGeneration(gen)
+	}
+}
+
+// runGeneration 为本代分配到的每个分区启动一个工作协程。
+// 再平衡或关闭时等待所有工作协程退出，调用 onRevoked 后提交已确认的位移，然后才允许加入下一代。
+func (s *subscriber) runGeneration(gen *kafkaGo.Generation) {
+	assignments := gen.Assignments[s.topic]
+
+	partitions := make([]int, 0, len(assignments))
+	for _, a := range assignments {
+		partitions = append(partitions, a.ID)
+	}
+	sort.Ints(partitions)
+
+	c := newPartitionCommitter(s.topic, s.readerConfig.CommitInterval, gen.CommitOffsets)
+
+	gen.Start(func(ctx context.Context) {
+		s.log.Infof("partitions assigned: %v, generation: %d", partitions, gen.ID)
+		if s.onAssigned != nil {
+			s.onAssigned(s.options.Context, s.topic, partitions)
+		}
+
+		var wg sync.WaitGroup
+		for _, a := range assignments {
+			wg.Add(1)
+			go func(a kafkaGo.PartitionAssignment) {
+				defer wg.Done()
+				s.consumePartition(ctx, c, a)
+			}(a)
+		}
+
+		var tick <-chan time.Time
+		if c.interval > 0 {
+			ticker := time.NewTicker(c.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-tick:
+				if err := c.flush(); err != nil {
+					s.log.Errorf("commit offsets error: %s", err.Error())
+				}
+			}
+		}
+
+		wg.Wait()
+
+		s.log.Infof("partitions revoked: %v, generation: %d", partitions, gen.ID)
+		if s.onRevoked != nil {
+			s.onRevoked(s.options.Context, s.topic, partitions)
+		}
+
+		if err := c.flush(); err != nil {
+			s.log.Errorf("commit offsets on revoke error: %s", err.Error())
+		}
+	})
+}
+
+func (s *subscriber) consumePartition(ctx context.Context, c committer, a kafkaGo.PartitionAssignment) {
+	reader := kafkaGo.NewReader(partitionReaderConfig(s.readerConfig, a.ID))
+	defer func() { _ = reader.Close() }()
+
+	if err := reader.SetOffset(a.Offset); err != nil {
+		s.log.Errorf("set offset of partition %d error: %s", a.ID, err.Error())
+		return
+	}
+
+	var backoff time.Duration
+	for {
+		km, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
+			}
+			backoff = s.fetchBackoff(backoff)
+			s.log.Errorf("FetchMessage error, partition: %d, retry in %s: %s", a.ID, backoff, err.Error())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		if !s.settle(ctx, c, km) {
+			return
+		}
+	}
+}
+
+// fetchBackoff 读取失败后的等待时间，从 ReadBackoffMin 开始加倍，不超过 ReadBackoffMax
+func (s *subscriber) fetchBackoff(last time.Duration) time.Duration {
+	minBackoff, maxBackoff := s.readerConfig.ReadBackoffMin, s.readerConfig.ReadBackoffMax
+	if minBackoff <= 0 {
+		minBackoff = 100 * time.Millisecond
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = time.Second
+		if maxBackoff < minBackoff {
+			maxBackoff = minBackoff
+		}
+	}
+
+	if last < minBackoff {
+		return minBackoff
+	}
+	if last*2 > maxBackoff {
+		return maxBackoff
+	}
+	return last * 2
+}
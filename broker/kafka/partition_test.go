@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionCommitterSync(t *testing.T) {
+	var commits []map[string]map[int]int64
+	c := newPartitionCommitter("test", 0, func(offsets map[string]map[int]int64) error {
+		commits = append(commits, offsets)
+		return nil
+	})
+
+	assert.Nil(t, c.CommitMessages(context.Background(), kafkaGo.Message{Partition: 1, Offset: 9}))
+	assert.Nil(t, c.CommitMessages(context.Background(), kafkaGo.Message{Partition: 2, Offset: 3}))
+
+	assert.Equal(t, []map[string]map[int]int64{
+		{"test": {1: 10}},
+		{"test": {2: 4}},
+	}, commits)
+	assert.Empty(t, c.pending)
+}
+
+func TestPartitionCommitterInterval(t *testing.T) {
+	fail := true
+	var commits []map[string]map[int]int64
+	c := newPartitionCommitter("test", time.Second, func(offsets map[string]map[int]int64) error {
+		if fail {
+			return errors.New("rebalance in progress")
+		}
+		commits = append(commits, offsets)
+		return nil
+	})
+
+	assert.Nil(t, c.CommitMessages(context.Background(),
+		kafkaGo.Message{Partition: 0, Offset: 5},
+		kafkaGo.Message{Partition: 0, Offset: 4},
+		kafkaGo.Message{Partition: 1, Offset: 7},
+	))
+	assert.Empty(t, commits)
+
+	assert.NotNil(t, c.flush())
+	assert.Equal(t, map[int]int64{0: 6, 1: 8}, c.pending)
+
+	fail = false
+	assert.Nil(t, c.flush())
+	assert.Equal(t, []map[string]map[int]int64{{"test": {0: 6, 1: 8}}}, commits)
+
+	assert.Nil(t, c.flush())
+	assert.Len(t, commits, 1)
+}
+
+func TestPartitionReaderConfig(t *testing.T) {
+	cfg := kafkaGo.ReaderConfig{
+		Brokers:           []string{defaultAddr},
+		Topic:             "test",
+		GroupID:           "group",
+		CommitInterval:    time.Second,
+		HeartbeatInterval: 2 * time.Second,
+		StartOffset:       kafkaGo.FirstOffset,
+	}
+
+	gc := consumerGroupConfig(cfg)
+	assert.Equal(t, "group", gc.ID)
+	assert.Equal(t, []string{"test"}, gc.Topics)
+	assert.Equal(t, 2*time.Second, gc.HeartbeatInterval)
+	assert.Equal(t, kafkaGo.FirstOffset, gc.StartOffset)
+
+	rc := partitionReaderConfig(cfg, 3)
+	assert.Empty(t, rc.GroupID)
+	assert.Equal(t, 3, rc.Partition)
+	assert.Zero(t, rc.CommitInterval)
+	assert.Nil(t, rc.Validate())
+}
+
+func TestPartitionFetchBackoff(t *testing.T) {
+	s := &subscriber{readerConfig: kafkaGo.ReaderConfig{ReadBackoffMin: 100 * time.Millisecond, ReadBackoffMax: 500 * time.Millisecond}}
+
+	var backoff time.Duration
+	var got []time.Duration
+	for i := 0; i < 5; i++ {
+		backoff = s.fetchBackoff(backoff)
+		got = append(got, backoff)
+	}
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond}, got)
+
+	s = &subscriber{}
+	assert.Equal(t, 100*time.Millisecond, s.fetchBackoff(0))
+	assert.Equal(t, time.Second, s.fetchBackoff(800*time.Millisecond))
+}
@@ -9,19 +9,24 @@ import (
 	"github.com/tx7do/kratos-transport/broker"
 )
 
+// committer 提交消息位移，由 kafkaGo.Reader 或分区工作模式下的 partitionCommitter 实现
+type committer interface {
+	CommitMessages(ctx context.Context, msgs ...kafkaGo.Message) error
+}
+
 type publication struct {
 	topic string
 
 	bm *broker.Message
 	km kafkaGo.Message
 
-	reader *kafkaGo.Reader
+	reader committer
 
 	ctx context.Context
 	err error
 }
 
-func newPublication(ctx context.Context, reader *kafkaGo.Reader, km kafkaGo.Message, bm *broker.Message) *publication {
+func newPublication(ctx context.Context, reader committer, km kafkaGo.Message, bm *broker.Message) *publication {
 	pub := &publication{
 		topic:  km.Topic,
 		reader: reader,
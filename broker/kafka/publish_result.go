@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var (
+	_ broker.ResultPublisher = (*kafkaBroker)(nil)
+	_ broker.AsyncPublisher  = (*kafkaBroker)(nil)
+)
+
+type publishFutureKey struct{}
+
+// PublishWithResult 发布消息并等待写入结果，返回消息所在的分区和位移。异步写入模式下同样等待 Kafka 确认。
+func (b *kafkaBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	return b.PublishAsync(ctx, topic, msg, opts...).Get(ctx)
+}
+
+// PublishAsync 发布消息，写入结果通过返回的 PublishFuture 获取。同步写入模式下返回时已经完成。
+func (b *kafkaBroker) PublishAsync(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) *broker.PublishFuture {
+	buf, err := broker.Marshal(b.options.Codec, msg)
+	if err != nil {
+		return broker.NewFailedPublishFuture(err)
+	}
+
+	future := broker.NewPublishFuture()
+
+	opts = append(opts[:len(opts):len(opts)], broker.PublishContextWithValue(publishFutureKey{}, future))
+	if err = b.publish(ctx, topic, buf, opts...); err != nil {
+		future.Complete(nil, err)
+	}
+
+	return future
+}
+
+// publishing 单条消息的发布状态，通过 kafkaGo.Message.WriterData 带到 Completion 回调中，
+// 在得到真实的分区和位移后结束生产者 span 并完成 PublishFuture。
+type publishing struct {
+	b      *kafkaBroker
+	span   trace.Span
+	future *broker.PublishFuture
+	async  bool
+
+	once sync.Once
+
+	mu   sync.Mutex
+	sent *kafkaGo.Message
+}
+
+func (b *kafkaBroker) newPublishing(options broker.PublishOptions, msg *kafkaGo.Message) *publishing {
+	p := &publishing{
+		b:     b,
+		async: b.writerConfig.Async,
+		span:  b.startProducerSpan(options.Context, msg),
+	}
+	p.future, _ = options.Context.Value(publishFutureKey{}).(*broker.PublishFuture)
+
+	msg.WriterData = p
+
+	return p
+}
+
+// completed 由 Completion 回调调用。异步写入时直接结束；同步写入可能还会重试，只记录成功的结果，由 written 结束。
+func (p *publishing) completed(msg kafkaGo.Message, err error) {
+	if p.async {
+		p.finish(msg, err)
+		return
+	}
+
+	if err == nil {
+		p.mu.Lock()
+		p.sent = &msg
+		p.mu.Unlock()
+	}
+}
+
+// written 在 WriteMessages（包括重试）返回后调用
+func (p *publishing) written(msg kafkaGo.Message, err error) {
+	if err != nil {
+		p.finish(msg, err)
+		return
+	}
+	if p.async {
+		return
+	}
+
+	p.mu.Lock()
+	if p.sent != nil {
+		msg = *p.sent
+	}
+	p.mu.Unlock()
+
+	p.finish(msg, nil)
+}
+
+func (p *publishing) finish(msg kafkaGo.Message, err error) {
+	p.once.Do(func() {
+		p.b.finishProducerSpan(p.span, int32(msg.Partition), msg.Offset, err)
+
+		if p.future == nil {
+			return
+		}
+		if err != nil {
+			p.future.Complete(nil, err)
+			return
+		}
+		p.future.Complete(&broker.PublishResult{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Timestamp: msg.Time,
+		}, nil)
+	})
+}
+
+// publishCompletion 先回填每条消息的发布状态，再调用通过 WithCompletion 设置的回调
+func publishCompletion(completion func(messages []kafkaGo.Message, err error)) func(messages []kafkaGo.Message, err error) {
+	return func(messages []kafkaGo.Message, err error) {
+		for _, m := range messages {
+			if p, ok := m.WriterData.(*publishing); ok {
+				p.completed(m, err)
+			}
+		}
+
+		if completion != nil {
+			completion(messages, err)
+		}
+	}
+}
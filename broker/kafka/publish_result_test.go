@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func testPublishing(b *kafkaBroker) (*publishing, *broker.PublishFuture, kafkaGo.Message) {
+	future := broker.NewPublishFuture()
+	options := broker.NewPublishOptions(broker.PublishContextWithValue(publishFutureKey{}, future))
+
+	msg := kafkaGo.Message{Topic: "orders", Value: []byte("x")}
+	p := b.newPublishing(options, &msg)
+	return p, future, msg
+}
+
+func TestPublishingAsyncCompletion(t *testing.T) {
+	b := NewBroker().(*kafkaBroker)
+	b.writerConfig.Async = true
+
+	var called int
+	completion := publishCompletion(func([]kafkaGo.Message, error) { called++ })
+
+	p, future, msg := testPublishing(b)
+	assert.Same(t, p, msg.WriterData)
+
+	p.written(msg, nil)
+	select {
+	case <-future.Done():
+		t.Fatal("async publish completed before the writer callback")
+	default:
+	}
+
+	now := time.Now()
+	msg.Partition, msg.Offset, msg.Time = 3, 99, now
+	completion([]kafkaGo.Message{msg}, nil)
+
+	result, err := future.Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &broker.PublishResult{Topic: "orders", Partition: 3, Offset: 99, Timestamp: now}, result)
+	assert.Equal(t, 1, called)
+}
+
+func TestPublishingSyncRetry(t *testing.T) {
+	b := NewBroker().(*kafkaBroker)
+	b.writerConfig.Async = false
+
+	completion := publishCompletion(nil)
+
+	p, future, msg := testPublishing(b)
+
+	// 第一次写入失败后重试成功
+	completion([]kafkaGo.Message{msg}, errors.New("leader not available"))
+	sent := msg
+	sent.Partition, sent.Offset = 1, 7
+	completion([]kafkaGo.Message{sent}, nil)
+	p.written(msg, nil)
+
+	result, err := future.Get(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Partition)
+	assert.Equal(t, int64(7), result.Offset)
+
+	p, future, msg = testPublishing(b)
+	p.written(msg, errors.New("message too large"))
+
+	_, err = future.Get(context.Background())
+	assert.EqualError(t, err, "message too large")
+}
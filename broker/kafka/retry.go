@@ -0,0 +1,205 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	HeaderRetryOriginalTopic     = "x-retry-original-topic"
+	HeaderRetryOriginalPartition = "x-retry-original-partition"
+	HeaderRetryOriginalOffset    = "x-retry-original-offset"
+	HeaderRetryAttempt           = "x-retry-attempt"
+	HeaderRetryDue               = "x-retry-due" // 重试到期时间，毫秒时间戳
+	HeaderRetryException         = "x-retry-exception"
+)
+
+// DefaultDeadLetterTopicSuffix 重试耗尽后投递的死信主题后缀
+var DefaultDeadLetterTopicSuffix = ".dlt"
+
+// RetryTopicConfig 非阻塞重试主题的配置。
+// 处理失败的消息依次投递到 <topic>.retry.<delay毫秒> 主题，全部失败后投递到死信主题，主分区不会因重试而阻塞。
+type RetryTopicConfig struct {
+	// Delays 每一级重试的延迟，每一级对应一个重试主题，延迟按毫秒计不能重复
+	Delays []time.Duration
+
+	// DeadLetterTopic 重试耗尽后投递的主题，默认为订阅的 DeadLetterQueue 或 <topic>.dlt
+	DeadLetterTopic string
+
+	// AutoCreateTopics 订阅时自动创建重试主题和死信主题
+	AutoCreateTopics  bool
+	NumPartitions     int
+	ReplicationFactor int
+}
+
+// validate 重试主题名只由延迟决定，延迟重复时两级重试会共用同一个主题，因此要求延迟各不相同
+func (c RetryTopicConfig) validate() error {
+	seen := make(map[int64]bool, len(c.Delays))
+	for _, delay := range c.Delays {
+		ms := delay.Milliseconds()
+		if ms <= 0 {
+			return fmt.Errorf("kafka: retry delay %s must be at least 1ms", delay)
+		}
+		if seen[ms] {
+			return fmt.Errorf("kafka: duplicate retry delay %s", delay)
+		}
+		seen[ms] = true
+	}
+	return nil
+}
+
+// RetryTopicName 返回 topic 在 delay 这一级的重试主题名
+func RetryTopicName(topic string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%d", topic, delay.Milliseconds())
+}
+
+// retryRouter 将失败的消息投递到下一级重试主题或死信主题，level 为 -1 表示主主题
+type retryRouter struct {
+	topic  string
+	config RetryTopicConfig
+	level  int
+}
+
+func (r *retryRouter) deadLetterTopic(options broker.SubscribeOptions) string {
+	if r.config.DeadLetterTopic != "" {
+		return r.config.DeadLetterTopic
+	}
+	if options.DeadLetterQueue != "" {
+		return options.DeadLetterQueue
+	}
+	return r.topic + DefaultDeadLetterTopicSuffix
+}
+
+// topics 返回所有重试主题和死信主题
+func (r *retryRouter) topics(options broker.SubscribeOptions) []string {
+	topics := make([]string, 0, len(r.config.Delays)+1)
+	for _, delay := range r.config.Delays {
+		topics = append(topics, RetryTopicName(r.topic, delay))
+	}
+	return append(topics, r.deadLetterTopic(options))
+}
+
+// next 返回下一级的目标主题和消息头。解码失败无法通过重试恢复，直接投递到死信主题。
+func (r *retryRouter) next(km kafkaGo.Message, options broker.SubscribeOptions, stage broker.ErrorStage, cause error, now time.Time) (string, map[string]string) {
+	headers := kafkaHeaderToMap(km.Headers)
+
+	if _, ok := headers[HeaderRetryOriginalTopic]; !ok {
+		headers[HeaderRetryOriginalTopic] = km.Topic
+		headers[HeaderRetryOriginalPartition] = strconv.Itoa(km.Partition)
+		headers[HeaderRetryOriginalOffset] = strconv.FormatInt(km.Offset, 10)
+	}
+
+	attempt, _ := strconv.Atoi(headers[HeaderRetryAttempt])
+	headers[HeaderRetryAttempt] = strconv.Itoa(attempt + 1)
+	if cause != nil {
+		headers[HeaderRetryException] = cause.Error()
+	}
+
+	level := r.level + 1
+	if stage == broker.ErrorStageDecode || level >= len(r.config.Delays) {
+		delete(headers, HeaderRetryDue)
+		return r.deadLetterTopic(options), broker.DeadLetterHeaders(headers, headers[HeaderRetryOriginalTopic], stage, cause)
+	}
+
+	delay := r.config.Delays[level]
+	headers[HeaderRetryDue] = strconv.FormatInt(now.Add(delay).UnixMilli(), 10)
+
+	return RetryTopicName(r.topic, delay), headers
+}
+
+// routeRetry 将失败的消息投递到下一级主题，成功后提交原消息
+func (s *subscriber) routeRetry(ctx context.Context, pub *publication, stage broker.ErrorStage, cause error) {
+	topic, headers := s.retry.next(pub.km, s.options, stage, cause, time.Now())
+
+	values := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		values[k] = v
+	}
+
+	opts := []broker.PublishOption{WithHeaders(values)}
+	if len(pub.km.Key) > 0 {
+		opts = append(opts, WithMessageKey(pub.km.Key))
+	}
+
+	if err := s.b.publish(ctx, topic, pub.km.Value, opts...); err != nil {
+		s.log.Errorf("publish to retry topic %s failed: %v", topic, err)
+		return
+	}
+
+	if err := pub.Ack(); err != nil {
+		s.log.Errorf("unable to commit km: %v", err)
+	}
+}
+
+// waitRetryDue 重试主题中的消息在到期之前不处理。同一重试主题的延迟相同，等待不会越过更早到期的消息。
+func (s *subscriber) waitRetryDue(km kafkaGo.Message) bool {
+	if s.retry == nil || s.retry.level < 0 {
+		return true
+	}
+
+	for _, h := range km.Headers {
+		if h.Key != HeaderRetryDue {
+			continue
+		}
+
+		due, err := strconv.ParseInt(string(h.Value), 10, 64)
+		if err != nil {
+			return true
+		}
+
+		d := time.Until(time.UnixMilli(due))
+		if d <= 0 {
+			return true
+		}
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-s.options.Context.Done():
+			return false
+		case <-s.done:
+			return false
+		case <-timer.C:
+			return true
+		}
+	}
+
+	return true
+}
+
+// newRetrySubscribers 为每一级重试主题创建订阅者，随主订阅者一起关闭
+func (b *kafkaBroker) newRetrySubscribers(main *subscriber, readerConfig kafkaGo.ReaderConfig) []*subscriber {
+	config := main.retry.config
+
+	if config.AutoCreateTopics {
+		partitions, replication := config.NumPartitions, config.ReplicationFactor
+		for _, topic := range main.retry.topics(main.options) {
+			if err := b.CreateTopic(main.options.Context, topic, partitions, replication, nil); err != nil {
+				b.log.Errorf("create retry topic %s error: %s", topic, err.Error())
+			}
+		}
+	}
+
+	subs := make([]*subscriber, 0, len(config.Delays))
+	for level, delay := range config.Delays {
+		topic := RetryTopicName(main.topic, delay)
+
+		rc := readerConfig
+		rc.Topic = topic
+
+		sub := newSubscriber(b, topic, main.options, rc, main.handler, main.binder)
+		sub.filter = main.filter
+		sub.nackDelay = main.nackDelay
+		sub.retry = &retryRouter{topic: main.topic, config: config, level: level}
+
+		subs = append(subs, sub)
+	}
+	return subs
+}
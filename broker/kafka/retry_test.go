@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestRetryRouterNext(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	config := RetryTopicConfig{Delays: []time.Duration{time.Second, 10 * time.Second}}
+	options := broker.NewSubscribeOptions()
+
+	main := &retryRouter{topic: "orders", config: config, level: -1}
+	km := kafkaGo.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    41,
+		Headers:   []kafkaGo.Header{{Key: "type", Value: []byte("created")}},
+	}
+
+	topic, headers := main.next(km, options, broker.ErrorStageHandle, errors.New("db down"), now)
+	assert.Equal(t, "orders.retry.1000", topic)
+	assert.Equal(t, "created", headers["type"])
+	assert.Equal(t, "orders", headers[HeaderRetryOriginalTopic])
+	assert.Equal(t, "2", headers[HeaderRetryOriginalPartition])
+	assert.Equal(t, "41", headers[HeaderRetryOriginalOffset])
+	assert.Equal(t, "1", headers[HeaderRetryAttempt])
+	assert.Equal(t, "1700000001000", headers[HeaderRetryDue])
+	assert.Equal(t, "db down", headers[HeaderRetryException])
+
+	first := &retryRouter{topic: "orders", config: config, level: 0}
+	km = kafkaGo.Message{Topic: topic, Partition: 0, Offset: 3, Headers: mapToKafkaHeaders(headers)}
+
+	topic, headers = first.next(km, options, broker.ErrorStageHandle, errors.New("still down"), now)
+	assert.Equal(t, "orders.retry.10000", topic)
+	assert.Equal(t, "orders", headers[HeaderRetryOriginalTopic])
+	assert.Equal(t, "41", headers[HeaderRetryOriginalOffset])
+	assert.Equal(t, "2", headers[HeaderRetryAttempt])
+	assert.Equal(t, "1700000010000", headers[HeaderRetryDue])
+
+	last := &retryRouter{topic: "orders", config: config, level: 1}
+	km = kafkaGo.Message{Topic: topic, Headers: mapToKafkaHeaders(headers)}
+
+	topic, headers = last.next(km, options, broker.ErrorStageHandle, errors.New("gave up"), now)
+	assert.Equal(t, "orders.dlt", topic)
+	assert.Equal(t, "3", headers[HeaderRetryAttempt])
+	assert.Equal(t, "orders", headers[broker.HeaderDeadLetterOriginalTopic])
+	assert.Equal(t, "gave up", headers[broker.HeaderDeadLetterError])
+	assert.NotContains(t, headers, HeaderRetryDue)
+}
+
+func TestRetryRouterDecodeErrorGoesToDeadLetter(t *testing.T) {
+	r := &retryRouter{topic: "orders", config: RetryTopicConfig{Delays: []time.Duration{time.Second}}, level: -1}
+
+	topic, _ := r.next(kafkaGo.Message{Topic: "orders"}, broker.NewSubscribeOptions(), broker.ErrorStageDecode, errors.New("bad json"), time.Now())
+	assert.Equal(t, "orders.dlt", topic)
+
+	topic, _ = r.next(kafkaGo.Message{Topic: "orders"}, broker.NewSubscribeOptions(broker.WithDeadLetterQueue("orders.dead")), broker.ErrorStageDecode, nil, time.Now())
+	assert.Equal(t, "orders.dead", topic)
+
+	assert.Equal(t, []string{"orders.retry.1000", "orders.dlt"}, r.topics(broker.NewSubscribeOptions()))
+}
+
+func TestWaitRetryDue(t *testing.T) {
+	b := NewBroker().(*kafkaBroker)
+	sub := newSubscriber(b, "orders.retry.50", broker.NewSubscribeOptions(), testReaderConfig, nil, nil)
+	sub.retry = &retryRouter{topic: "orders", level: 0}
+
+	start := time.Now()
+	km := kafkaGo.Message{Headers: testRetryDue(start.Add(50 * time.Millisecond))}
+	assert.True(t, sub.waitRetryDue(km))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	km = kafkaGo.Message{Headers: testRetryDue(time.Now().Add(time.Hour))}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = sub.close()
+	}()
+	assert.False(t, sub.waitRetryDue(km))
+}
+
+func testRetryDue(due time.Time) []kafkaGo.Header {
+	return []kafkaGo.Header{{Key: HeaderRetryDue, Value: []byte(strconv.FormatInt(due.UnixMilli(), 10))}}
+}
+
+func mapToKafkaHeaders(headers map[string]string) []kafkaGo.Header {
+	out := make([]kafkaGo.Header, 0, len(headers))
+	for k, v := range headers {
+		out = append(out, kafkaGo.Header{Key: k, Value: []byte(v)})
+	}
+	return out
+}
+
+func TestRetryTopicConfigValidate(t *testing.T) {
+	assert.Nil(t, RetryTopicConfig{Delays: []time.Duration{time.Second, 5 * time.Second}}.validate())
+	assert.NotNil(t, RetryTopicConfig{Delays: []time.Duration{time.Second, 1000 * time.Millisecond}}.validate())
+	assert.NotNil(t, RetryTopicConfig{Delays: []time.Duration{time.Second, 500 * time.Microsecond}}.validate())
+	assert.NotNil(t, RetryTopicConfig{Delays: []time.Duration{0}}.validate())
+}
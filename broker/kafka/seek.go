@@ -0,0 +1,277 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	kafkaGo "github.com/segmentio/kafka-go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// ErrSubscriberClosed 订阅已经取消
+var ErrSubscriberClosed = errors.New("kafka: subscriber closed")
+
+// Seeker 重新定位运行中的订阅的消费位置，Subscribe 返回的订阅者实现了该接口。
+// 重新定位时订阅者先离开消费组，以消费组身份提交新的位移后再重新加入，正在处理的消息完成后才会生效。
+// Kafka 只允许在消费组没有其他成员时提交，多副本部署时需要先停止其他副本。
+type Seeker interface {
+	// SeekToOffset 将 partition 的消费位置设置为 offset
+	SeekToOffset(ctx context.Context, partition int, offset int64) error
+
+	// SeekToTimestamp 将所有分区的消费位置设置为 t 之后的第一条消息，t 之后没有消息的分区移动到末尾
+	SeekToTimestamp(ctx context.Context, t time.Time) error
+
+	// SeekToBeginning 从所有分区的最早消息开始重新消费
+	SeekToBeginning(ctx context.Context) error
+
+	// SeekToEnd 跳过所有分区中尚未消费的消息
+	SeekToEnd(ctx context.Context) error
+}
+
+var _ Seeker = (*subscriber)(nil)
+
+func (s *subscriber) SeekToOffset(ctx context.Context, partition int, offset int64) error {
+	return s.seek(ctx, map[int]int64{partition: offset})
+}
+
+func (s *subscriber) SeekToTimestamp(ctx context.Context, t time.Time) error {
+	offsets, err := s.b.offsetsAt(ctx, s.topic, t)
+	if err != nil {
+		return err
+	}
+	return s.seek(ctx, offsets)
+}
+
+func (s *subscriber) SeekToBeginning(ctx context.Context) error {
+	offsets, err := s.b.listOffsets(ctx, s.topic, kafkaGo.FirstOffsetOf)
+	if err != nil {
+		return err
+	}
+	return s.seek(ctx, offsets)
+}
+
+func (s *subscriber) SeekToEnd(ctx context.Context) error {
+	offsets, err := s.b.listOffsets(ctx, s.topic, kafkaGo.LastOffsetOf)
+	if err != nil {
+		return err
+	}
+	return s.seek(ctx, offsets)
+}
+
+// seek 停止消费并等待消费循环退出，提交新的位移后重新开始消费。提交失败时按原有位移继续消费。
+func (s *subscriber) seek(ctx context.Context, offsets map[int]int64) error {
+	s.seekMu.Lock()
+	defer s.seekMu.Unlock()
+
+	if s.IsClosed() {
+		return ErrSubscriberClosed
+	}
+
+	s.stopConsumer()
+
+	err := s.b.commitGroupOffsets(ctx, s.readerConfig.GroupID, s.topic, offsets)
+	if err != nil {
+		err = fmt.Errorf("kafka: seek %s: %w", s.topic, err)
+	} else {
+		s.log.Infof("seek to offsets: %v", offsets)
+	}
+
+	if rerr := s.restartConsumer(); rerr != nil {
+		return rerr
+	}
+
+	return err
+}
+
+// stopConsumer 关闭读取器或消费组，离开消费组并等待消费循环退出
+func (s *subscriber) stopConsumer() {
+	s.RLock()
+	reader, group, stopped := s.reader, s.group, s.stopped
+	s.RUnlock()
+
+	if reader != nil {
+		_ = reader.Close()
+	}
+	if group != nil {
+		_ = group.Close()
+	}
+	if stopped != nil {
+		<-stopped
+	}
+}
+
+// restartConsumer 使用原有配置重新创建读取器或消费组并开始消费
+func (s *subscriber) restartConsumer() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return ErrSubscriberClosed
+	}
+
+	if s.group != nil {
+		group, err := kafkaGo.NewConsumerGroup(consumerGroupConfig(s.readerConfig))
+		if err != nil {
+			return err
+		}
+		s.group = group
+	} else {
+		s.reader = kafkaGo.NewReader(s.readerConfig)
+	}
+
+	s.start()
+
+	return nil
+}
+
+// start 在后台运行消费循环，循环退出时关闭 stopped
+func (s *subscriber) start() {
+	stopped := make(chan struct{})
+	s.stopped = stopped
+
+	go func() {
+		defer close(stopped)
+		s.run()
+	}()
+}
+
+// listOffsets 查询 topic 所有分区的位移
+func (b *kafkaBroker) listOffsets(ctx context.Context, topic string, request func(partition int) kafkaGo.OffsetRequest) (map[int]int64, error) {
+	offsets, err := b.queryOffsets(ctx, topic, request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]int64, len(offsets))
+	for p, o := range offsets {
+		if o.FirstOffset >= 0 {
+			result[p] = o.FirstOffset
+		} else {
+			result[p] = o.LastOffset
+		}
+	}
+	return result, nil
+}
+
+// offsetsAt 查询 topic 所有分区在 t 之后的第一条消息的位移，没有时返回分区末尾
+func (b *kafkaBroker) offsetsAt(ctx context.Context, topic string, t time.Time) (map[int]int64, error) {
+	ends, err := b.listOffsets(ctx, topic, kafkaGo.LastOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := b.queryOffsets(ctx, topic, func(partition int) kafkaGo.OffsetRequest {
+		return kafkaGo.TimeOffsetOf(partition, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for p, o := range found {
+		for offset := range o.Offsets {
+			if offset >= 0 {
+				ends[p] = offset
+			}
+		}
+	}
+	return ends, nil
+}
+
+func (b *kafkaBroker) queryOffsets(ctx context.Context, topic string, request func(partition int) kafkaGo.OffsetRequest) (map[int]kafkaGo.PartitionOffsets, error) {
+	client := b.adminClient()
+
+	meta, err := client.Metadata(ctx, &kafkaGo.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.Topics) == 0 {
+		return nil, kafkaGo.UnknownTopicOrPartition
+	}
+	if meta.Topics[0].Error != nil {
+		return nil, meta.Topics[0].Error
+	}
+
+	requests := make([]kafkaGo.OffsetRequest, 0, len(meta.Topics[0].Partitions))
+	for _, p := range meta.Topics[0].Partitions {
+		requests = append(requests, request(p.ID))
+	}
+
+	resp, err := client.ListOffsets(ctx, &kafkaGo.ListOffsetsRequest{
+		Topics: map[string][]kafkaGo.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int]kafkaGo.PartitionOffsets, len(requests))
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("list offsets of %s[%d] failed: %w", topic, p.Partition, p.Error)
+		}
+		offsets[p.Partition] = p
+	}
+	return offsets, nil
+}
+
+// commitGroupOffsets 以消费组外部身份提交位移，消费组中有活跃成员时 Kafka 会拒绝
+func (b *kafkaBroker) commitGroupOffsets(ctx context.Context, group, topic string, offsets map[int]int64) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	commits := make([]kafkaGo.OffsetCommit, 0, len(offsets))
+	for p, o := range offsets {
+		commits = append(commits, kafkaGo.OffsetCommit{Partition: p, Offset: o})
+	}
+
+	resp, err := b.adminClient().OffsetCommit(ctx, &kafkaGo.OffsetCommitRequest{
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafkaGo.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return fmt.Errorf("commit offset of %s[%d] failed: %w", topic, p.Partition, p.Error)
+		}
+	}
+	return nil
+}
+
+// Replay 使用新的消费组从 from 时刻开始重放 topic，不影响其他消费组的位移。
+// 未通过 broker.WithQueueName 指定消费组时使用 replay- 前缀的随机消费组。
+func Replay(b broker.Broker, topic string, from time.Time, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	kb, ok := b.(*kafkaBroker)
+	if !ok {
+		return nil, broker.ErrNotSupported
+	}
+
+	options := broker.NewSubscribeOptions(opts...)
+	group := options.Queue
+	if group == "" {
+		group = "replay-" + uuid.New().String()
+		opts = append(opts, broker.WithQueueName(group))
+	}
+
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	offsets, err := kb.offsetsAt(ctx, topic, from)
+	if err != nil {
+		return nil, err
+	}
+	if err = kb.commitGroupOffsets(ctx, group, topic, offsets); err != nil {
+		return nil, fmt.Errorf("kafka: replay %s: %w", topic, err)
+	}
+
+	return kb.Subscribe(topic, handler, binder, opts...)
+}
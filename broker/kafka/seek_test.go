@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/memory"
+)
+
+func TestSubscriberRestartConsumer(t *testing.T) {
+	b := NewBroker().(*kafkaBroker)
+
+	handler := func(context.Context, broker.Event) error { return nil }
+	sub := newSubscriber(b, "test", broker.NewSubscribeOptions(), testReaderConfig, handler, nil)
+	sub.start()
+
+	first := sub.reader
+	stopped := sub.stopped
+
+	sub.stopConsumer()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("consumer loop not stopped")
+	}
+
+	assert.Nil(t, sub.restartConsumer())
+	assert.NotSame(t, first, sub.reader)
+	assert.False(t, sub.IsClosed())
+
+	assert.Nil(t, sub.close())
+	assert.ErrorIs(t, sub.restartConsumer(), ErrSubscriberClosed)
+	assert.ErrorIs(t, sub.SeekToOffset(context.Background(), 0, 0), ErrSubscriberClosed)
+}
+
+func TestReplayNotSupported(t *testing.T) {
+	_, err := Replay(memory.NewBroker(), "test", time.Now(), nil, nil)
+	assert.ErrorIs(t, err, broker.ErrNotSupported)
+}
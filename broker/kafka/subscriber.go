@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	kafkaGo "github.com/segmentio/kafka-go"
 
 	"github.com/tx7do/kratos-transport/broker"
@@ -22,14 +23,31 @@ type subscriber struct {
 	options broker.SubscribeOptions
 	handler broker.Handler
 	binder  broker.Binder
+	filter  *broker.Filter
+
+	batchHandler BatchHandler
+
+	retry     *retryRouter
+	retrySubs []*subscriber
 
 	reader *kafkaGo.Reader
 
-	closed bool
-	done   chan struct{}
+	group        *kafkaGo.ConsumerGroup
+	readerConfig kafkaGo.ReaderConfig
+	onAssigned   PartitionsCallback
+	onRevoked    PartitionsCallback
+
+	closed  bool
+	done    chan struct{}
+	stopped chan struct{}
+	seekMu  sync.Mutex
 
 	batchSize     int
 	batchInterval time.Duration
+
+	nackDelay time.Duration
+
+	log *log.Helper
 }
 
 func newSubscriber(
@@ -48,6 +66,12 @@ func newSubscriber(
 		binder:  binder,
 		reader:  kafkaGo.NewReader(readerConfig),
 		done:    make(chan struct{}),
+
+		readerConfig: readerConfig,
+
+		nackDelay: defaultNackDelay,
+
+		log: log.NewHelper(log.With(b.log.Logger(), "topic", topic, "group", readerConfig.GroupID)),
 	}
 	return sub
 }
@@ -93,10 +117,16 @@ func (s *subscriber) close() error {
 	if s.reader != nil {
 		err = s.reader.Close()
 	}
+	if s.group != nil {
+		err = s.group.Close()
+	}
+
+	for _, sub := range s.retrySubs {
+		_ = sub.close()
+	}
 
 	if s.done != nil {
 		close(s.done)
-		s.done = nil
 	}
 
 	return err
@@ -114,7 +144,9 @@ func (s *subscriber) isBatchMode() bool {
 }
 
 func (s *subscriber) run() {
-	if s.isBatchMode() {
+	if s.group != nil {
+		s.processPartitions()
+	} else if s.isBatchMode() {
 		s.processBatchMessage()
 	} else {
 		s.processSingleMessage()
@@ -124,8 +156,13 @@ func (s *subscriber) run() {
 func (s *subscriber) processBatchMessage() {
 	messageBuffer := make([]kafkaGo.Message, 0, s.batchSize)
 
-	ticker := time.NewTicker(s.batchInterval)
-	defer ticker.Stop()
+	// 只设置了批次大小时不按时间触发
+	var tick <-chan time.Time
+	if s.batchInterval > 0 {
+		ticker := time.NewTicker(s.batchInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 
 	for {
 		select {
@@ -137,7 +174,7 @@ func (s *subscriber) processBatchMessage() {
 			_ = s.close()
 			return
 
-		case <-ticker.C:
+		case <-tick:
 			// 定时触发批量处理
 			if len(messageBuffer) > 0 {
 				s.handleBatchMessage(messageBuffer)
@@ -159,7 +196,7 @@ func (s *subscriber) processBatchMessage() {
 					// 超时，继续循环
 					continue
 				}
-				LogErrorf("FetchMessage error: %s", err.Error())
+				s.log.Errorf("FetchMessage error: %s", err.Error())
 				time.Sleep(1 * time.Second)
 				continue
 			}
@@ -194,25 +231,60 @@ func (s *subscriber) processSingleMessage() {
 					return
 				}
 
-				LogErrorf("FetchMessage error: %s", err.Error())
+				s.log.Errorf("FetchMessage error: %s", err.Error())
 				continue
 			}
 
-			if done := s.handleMessage(km); done {
+			if !s.waitRetryDue(km) {
+				continue
 			}
+
+			s.handleMessageUntilSettled(km)
 		}
 	}
 }
 
 func (s *subscriber) handleBatchMessage(messages []kafkaGo.Message) {
+	if s.batchHandler != nil {
+		s.handleBatch(s.reader, messages)
+		return
+	}
+
 	for _, km := range messages {
-		if done := s.handleMessage(km); done {
-			//return true
+		if !s.handleMessageUntilSettled(km) {
+			return
+		}
+	}
+}
+
+// handleMessageUntilSettled 处理消息，ErrorHandler 返回 Nack 时等待 nackDelay 后重新投递同一条消息。
+// 消息按顺序处理，重投期间不会处理或提交后续消息，因此失败消息的位移不会被后续提交越过。
+// 订阅关闭时返回 false。
+func (s *subscriber) handleMessageUntilSettled(km kafkaGo.Message) bool {
+	return s.settle(s.options.Context, s.reader, km)
+}
+
+// settle 使用 c 提交位移处理消息，ctx 结束或订阅关闭时停止重投并返回 false
+func (s *subscriber) settle(ctx context.Context, c committer, km kafkaGo.Message) bool {
+	for s.handleMessage(c, km) {
+		s.log.Warnf("redeliver message, partition: %d, offset: %d", km.Partition, km.Offset)
+
+		timer := time.NewTimer(s.nackDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-s.done:
+			timer.Stop()
+			return false
+		case <-timer.C:
 		}
 	}
+	return true
 }
 
-func (s *subscriber) handleMessage(km kafkaGo.Message) bool {
+// handleMessage 处理一条消息，返回是否需要重新投递
+func (s *subscriber) handleMessage(c committer, km kafkaGo.Message) bool {
 	var err error
 
 	ctx, span := s.b.startConsumerSpan(s.options.Context, &km)
@@ -224,31 +296,40 @@ func (s *subscriber) handleMessage(km kafkaGo.Message) bool {
 		Offset:    km.Offset,
 	}
 
+	pub := newPublication(s.options.Context, c, km, bm)
+
+	// 不满足选择器的消息直接提交，不解码也不调用处理器
+	if !s.filter.Match(bm.Headers) {
+		if err = pub.Ack(); err != nil {
+			s.log.Errorf("unable to commit filtered km: %v", err)
+		}
+		s.b.finishConsumerSpan(span, nil)
+		return false
+	}
+
 	if s.binder != nil {
 		bm.Body = s.binder()
 
 		if err = broker.Unmarshal(s.b.options.Codec, km.Value, &bm.Body); err != nil {
-			LogErrorf("unmarshal message failed: %v", err)
+			redeliver := s.handleError(ctx, pub, broker.ErrorStageDecode, err)
 			s.b.finishConsumerSpan(span, err)
-			return true
+			return redeliver
 		}
 	} else {
 		bm.Body = km.Value
 	}
 
-	pub := newPublication(s.options.Context, s.reader, km, bm)
-
 	if err = s.handler(ctx, pub); err != nil {
-		LogErrorf("handle message failed: %v", err)
+		redeliver := s.handleError(ctx, pub, broker.ErrorStageHandle, err)
 		s.b.finishConsumerSpan(span, err)
-		return true
+		return redeliver
 	}
 
 	if s.options.AutoAck {
 		if err = pub.Ack(); err != nil {
-			LogErrorf("unable to commit km: %v", err)
+			redeliver := s.handleError(ctx, pub, broker.ErrorStageAck, err)
 			s.b.finishConsumerSpan(span, err)
-			return true
+			return redeliver
 		}
 	}
 
@@ -256,3 +337,38 @@ func (s *subscriber) handleMessage(km kafkaGo.Message) bool {
 
 	return false
 }
+
+// handleError 将失败的消息交给 ErrorHandler 处置，返回是否需要重新投递。
+// kafka-go 的消费组无法单独重投某条消息，Nack 由订阅者在本地重新投递：
+// 同一分区的后续消息在此期间不会被处理和提交。默认行为是记录日志后继续，不提交该消息的位移。
+func (s *subscriber) handleError(ctx context.Context, pub *publication, stage broker.ErrorStage, err error) bool {
+	pub.err = err
+
+	s.log.Errorw("msg", "message failed", "stage", stage, "partition", pub.km.Partition, "offset", pub.km.Offset, "error", err)
+
+	switch broker.HandleError(s.b.options.ErrorHandler, ctx, pub, stage, err) {
+	case broker.ErrorActionAck:
+		if err = pub.Ack(); err != nil {
+			s.log.Errorf("unable to commit km: %v", err)
+		}
+
+	case broker.ErrorActionNack:
+		return true
+
+	case broker.ErrorActionDeadLetter:
+		if err = s.b.publishDeadLetter(ctx, broker.DeadLetterTopic(s.topic, s.options), pub.km, stage, err); err != nil {
+			s.log.Errorf("publish dead letter failed: %v", err)
+			return false
+		}
+		if err = pub.Ack(); err != nil {
+			s.log.Errorf("unable to commit km: %v", err)
+		}
+
+	default:
+		if s.retry != nil {
+			s.routeRetry(ctx, pub, stage, err)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var testReaderConfig = kafkaGo.ReaderConfig{Brokers: []string{defaultAddr}, Topic: "test"}
+
+func TestSubscriberNackRedelivers(t *testing.T) {
+	var actions []broker.ErrorAction
+	b := NewBroker(broker.WithErrorHandler(func(context.Context, broker.Event, broker.ErrorStage, error) broker.ErrorAction {
+		actions = append(actions, broker.ErrorActionNack)
+		return broker.ErrorActionNack
+	})).(*kafkaBroker)
+
+	calls := 0
+	handler := func(context.Context, broker.Event) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}
+
+	options := broker.NewSubscribeOptions(broker.DisableAutoAck())
+	sub := newSubscriber(b, "test", options, testReaderConfig, handler, nil)
+	sub.nackDelay = time.Millisecond
+
+	assert.True(t, sub.handleMessageUntilSettled(kafkaGo.Message{Topic: "test", Offset: 10}))
+	assert.Equal(t, 3, calls)
+	assert.Len(t, actions, 2)
+}
+
+func TestSubscriberNackStopsOnClose(t *testing.T) {
+	b := NewBroker(broker.WithErrorHandler(func(context.Context, broker.Event, broker.ErrorStage, error) broker.ErrorAction {
+		return broker.ErrorActionNack
+	})).(*kafkaBroker)
+
+	handler := func(context.Context, broker.Event) error { return errors.New("permanent failure") }
+
+	sub := newSubscriber(b, "test", broker.NewSubscribeOptions(broker.DisableAutoAck()), testReaderConfig, handler, nil)
+	sub.nackDelay = time.Hour
+
+	done := make(chan bool)
+	go func() { done <- sub.handleMessageUntilSettled(kafkaGo.Message{Topic: "test"}) }()
+
+	time.Sleep(10 * time.Millisecond)
+	close(sub.done)
+
+	select {
+	case settled := <-done:
+		assert.False(t, settled)
+	case <-time.After(time.Second):
+		t.Fatal("redelivery not stopped")
+	}
+}
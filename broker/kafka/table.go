@@ -0,0 +1,460 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
+	kafkaGo "github.com/segmentio/kafka-go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	defaultTableLagCheckInterval = time.Second
+
+	tableBatchSize = 500                  // 一次写入存储的最大消息数
+	tableBatchWait = 5 * time.Millisecond // 等待下一条消息加入同一批的时间
+)
+
+// ErrTableClosed Table 已经关闭
+var ErrTableClosed = errors.New("kafka: table closed")
+
+// TableChange Table 中一个键的变化
+type TableChange[T any] struct {
+	Key       string
+	Value     T    // Deleted 为 true 时为零值
+	Deleted   bool // 收到墓碑消息（值为空），键被删除
+	Partition int
+	Offset    int64
+}
+
+// Table 压缩主题（cleanup.policy=compact）的本地键值视图：从头消费主题的所有分区，
+// 每个键保留最新的值，值为空的墓碑消息删除该键。值使用 broker 的 Codec 解码为 T，没有 Codec 时 T 只能是 []byte 或 string。
+// 主题的分区在创建 Table 时确定，之后新增的分区不会被消费。
+type Table[T any] struct {
+	b     *kafkaBroker
+	topic string
+	store TableStore
+	codec encoding.Codec
+
+	lagCheckInterval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	readyOnce sync.Once
+	ready     chan struct{}
+	readyMu   sync.Mutex
+	pending   map[int]int64 // 尚未追上的分区及创建时的末尾位移
+
+	watchMu  sync.RWMutex
+	watchers map[int]func(TableChange[T])
+	watchID  int
+
+	closeOnce sync.Once
+
+	log *log.Helper
+}
+
+// NewTable 创建 topic 的 Table 并在后台开始消费，使用持久化的 TableStore 时从上次保存的位移继续消费。
+// b 必须是本包创建的 broker，创建失败时存储会被关闭。
+func NewTable[T any](b broker.Broker, topic string, opts ...TableOption) (*Table[T], error) {
+	kb, ok := b.(*kafkaBroker)
+	if !ok {
+		return nil, broker.ErrNotSupported
+	}
+
+	options := tableOptions{
+		lagCheckInterval: defaultTableLagCheckInterval,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.store == nil {
+		options.store = NewMemoryTableStore()
+	}
+
+	t := newTable[T](kb, topic, options)
+
+	if err := t.start(); err != nil {
+		t.cancel()
+		_ = t.store.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func newTable[T any](b *kafkaBroker, topic string, options tableOptions) *Table[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Table[T]{
+		b:                b,
+		topic:            topic,
+		store:            options.store,
+		codec:            b.options.Codec,
+		lagCheckInterval: options.lagCheckInterval,
+		ctx:              ctx,
+		cancel:           cancel,
+		ready:            make(chan struct{}),
+		pending:          make(map[int]int64),
+		watchers:         make(map[int]func(TableChange[T])),
+		log:              log.NewHelper(log.With(b.log.Logger(), "table", topic)),
+	}
+}
+
+// start 查询各分区的起止位移，为每个分区启动一个读取器
+func (t *Table[T]) start() error {
+	starts, err := t.b.listOffsets(t.ctx, t.topic, kafkaGo.FirstOffsetOf)
+	if err != nil {
+		return err
+	}
+	ends, err := t.b.listOffsets(t.ctx, t.topic, kafkaGo.LastOffsetOf)
+	if err != nil {
+		return err
+	}
+
+	saved, err := t.store.Offsets()
+	if err != nil {
+		return err
+	}
+
+	// 存储中的数据不再可信，清空后从头消费
+	if offsetsOutOfRange(saved, starts, ends) {
+		t.log.Warnf("saved offsets %v are out of range, reset store", saved)
+		if err = t.store.Reset(); err != nil {
+			return err
+		}
+		saved = nil
+	}
+
+	offsets := make(map[int]int64, len(starts))
+	for p, start := range starts {
+		offset := start
+		if o, ok := saved[p]; ok {
+			offset = o
+		}
+		offsets[p] = offset
+		if offset < ends[p] {
+			t.pending[p] = ends[p]
+		}
+	}
+	t.checkReady()
+
+	for p, offset := range offsets {
+		reader, err := t.newReader(p, offset)
+		if err != nil {
+			t.cancel()
+			t.wg.Wait()
+			return err
+		}
+
+		t.wg.Add(1)
+		go func(p int) {
+			defer t.wg.Done()
+			defer func() { _ = reader.Close() }()
+			t.consume(p, reader)
+		}(p)
+	}
+
+	return nil
+}
+
+// offsetsOutOfRange 保存的位移已被清理或超出分区末尾（例如主题被重建）时返回 true
+func offsetsOutOfRange(saved, starts, ends map[int]int64) bool {
+	for p, o := range saved {
+		if start, ok := starts[p]; ok && (o < start || o > ends[p]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Table[T]) newReader(partition int, offset int64) (*kafkaGo.Reader, error) {
+	readerConfig := t.b.readerConfig
+	if len(readerConfig.Brokers) == 0 {
+		readerConfig.Brokers = []string{t.b.Address()}
+	}
+	readerConfig.Topic = t.topic
+	readerConfig.GroupID = ""
+	readerConfig.Partition = partition
+	readerConfig.GroupTopics = nil
+
+	reader := kafkaGo.NewReader(readerConfig)
+	if err := reader.SetOffset(offset); err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// consume 读取一个分区直到 Table 关闭，追上之前定期检查剩余的消息数
+func (t *Table[T]) consume(partition int, reader *kafkaGo.Reader) {
+	if t.isPending(partition) {
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.watchLag(partition, reader)
+		}()
+	}
+
+	batch := make([]kafkaGo.Message, 0, tableBatchSize)
+	for {
+		m, err := t.fetch(reader, len(batch) > 0)
+		if err != nil {
+			// 已读取的消息先写入存储
+			if len(batch) > 0 {
+				t.apply(partition, batch)
+				batch = batch[:0]
+				if errors.Is(err, context.DeadlineExceeded) && t.ctx.Err() == nil {
+					continue
+				}
+			}
+			if t.ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
+			}
+			t.log.Errorf("read partition %d error: %s", partition, err.Error())
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-time.After(t.lagCheckInterval):
+			}
+			continue
+		}
+
+		batch = append(batch, m)
+		if len(batch) >= tableBatchSize {
+			t.apply(partition, batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// fetch 读取下一条消息，batching 为 true 时最多等待 tableBatchWait，超时返回 context.DeadlineExceeded
+func (t *Table[T]) fetch(reader *kafkaGo.Reader, batching bool) (kafkaGo.Message, error) {
+	if !batching {
+		return reader.FetchMessage(t.ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, tableBatchWait)
+	defer cancel()
+
+	return reader.FetchMessage(ctx)
+}
+
+// watchLag 压缩或事务标记可能使分区最后一条消息的位移小于末尾位移减一，定期查询读取器的延迟作为补充
+func (t *Table[T]) watchLag(partition int, reader *kafkaGo.Reader) {
+	ticker := time.NewTicker(t.lagCheckInterval)
+	defer ticker.Stop()
+
+	for t.isPending(partition) {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		lag, err := reader.ReadLag(t.ctx)
+		if err != nil {
+			continue
+		}
+		if lag <= 0 {
+			t.caughtUp(partition)
+		}
+	}
+}
+
+// apply 将一个分区的一批消息写入存储并通知观察者，无法处理的消息被跳过但位移照常推进
+func (t *Table[T]) apply(partition int, msgs []kafkaGo.Message) {
+	if len(msgs) == 0 {
+		return
+	}
+
+	last := msgs[len(msgs)-1].Offset
+	defer func() {
+		if end, ok := t.pendingEnd(partition); ok && last+1 >= end {
+			t.caughtUp(partition)
+		}
+	}()
+
+	entries := make([]TableEntry, 0, len(msgs))
+	changes := make([]TableChange[T], 0, len(msgs))
+	for _, m := range msgs {
+		if len(m.Key) == 0 {
+			t.log.Warnf("skip message without key, partition: %d, offset: %d", m.Partition, m.Offset)
+			continue
+		}
+
+		change := TableChange[T]{
+			Key:       string(m.Key),
+			Partition: m.Partition,
+			Offset:    m.Offset,
+		}
+
+		var value []byte
+		if len(m.Value) == 0 {
+			change.Deleted = true
+		} else {
+			v, err := t.decode(m.Value)
+			if err != nil {
+				t.log.Errorf("skip undecodable message, key: %s, partition: %d, offset: %d, error: %s", change.Key, m.Partition, m.Offset, err.Error())
+				continue
+			}
+			change.Value = v
+			value = m.Value
+		}
+
+		entries = append(entries, TableEntry{Key: change.Key, Value: value})
+		changes = append(changes, change)
+	}
+
+	if err := t.store.Apply(partition, last, entries); err != nil {
+		t.log.Errorf("store messages error, partition: %d, offsets: [%d, %d], error: %s", partition, msgs[0].Offset, last, err.Error())
+		return
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	t.watchMu.RLock()
+	watchers := make([]func(TableChange[T]), 0, len(t.watchers))
+	for _, fn := range t.watchers {
+		watchers = append(watchers, fn)
+	}
+	t.watchMu.RUnlock()
+
+	for _, change := range changes {
+		for _, fn := range watchers {
+			fn(change)
+		}
+	}
+}
+
+func (t *Table[T]) decode(data []byte) (T, error) {
+	var v T
+	if t.codec != nil {
+		err := broker.Unmarshal(t.codec, data, &v)
+		return v, err
+	}
+
+	switch p := any(&v).(type) {
+	case *[]byte:
+		*p = append([]byte(nil), data...)
+	case *string:
+		*p = string(data)
+	default:
+		return v, fmt.Errorf("kafka: table value type %T requires a codec", v)
+	}
+	return v, nil
+}
+
+func (t *Table[T]) isPending(partition int) bool {
+	_, ok := t.pendingEnd(partition)
+	return ok
+}
+
+func (t *Table[T]) pendingEnd(partition int) (int64, bool) {
+	t.readyMu.Lock()
+	defer t.readyMu.Unlock()
+
+	end, ok := t.pending[partition]
+	return end, ok
+}
+
+func (t *Table[T]) caughtUp(partition int) {
+	t.readyMu.Lock()
+	delete(t.pending, partition)
+	t.readyMu.Unlock()
+
+	t.checkReady()
+}
+
+func (t *Table[T]) checkReady() {
+	t.readyMu.Lock()
+	done := len(t.pending) == 0
+	t.readyMu.Unlock()
+
+	if done {
+		t.readyOnce.Do(func() {
+			close(t.ready)
+		})
+	}
+}
+
+// Ready 所有分区都读到创建 Table 时的末尾位移后关闭
+func (t *Table[T]) Ready() <-chan struct{} {
+	return t.ready
+}
+
+// WaitReady 等待 Table 追上，ctx 结束或 Table 关闭时返回错误
+func (t *Table[T]) WaitReady(ctx context.Context) error {
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.ctx.Done():
+		return ErrTableClosed
+	}
+}
+
+// Get 返回键的当前值
+func (t *Table[T]) Get(key string) (T, bool, error) {
+	var zero T
+
+	data, ok, err := t.store.Get(key)
+	if err != nil || !ok {
+		return zero, false, err
+	}
+
+	v, err := t.decode(data)
+	if err != nil {
+		return zero, false, err
+	}
+	return v, true, nil
+}
+
+// Range 遍历所有键，fn 返回 false 时停止；无法解码的值被跳过
+func (t *Table[T]) Range(fn func(key string, value T) bool) error {
+	return t.store.Range(func(key string, data []byte) bool {
+		v, err := t.decode(data)
+		if err != nil {
+			return true
+		}
+		return fn(key, v)
+	})
+}
+
+// Watch 注册变化通知，返回取消函数。fn 在分区的读取协程中同步调用，不应阻塞
+func (t *Table[T]) Watch(fn func(change TableChange[T])) (cancel func()) {
+	t.watchMu.Lock()
+	t.watchID++
+	id := t.watchID
+	t.watchers[id] = fn
+	t.watchMu.Unlock()
+
+	return func() {
+		t.watchMu.Lock()
+		delete(t.watchers, id)
+		t.watchMu.Unlock()
+	}
+}
+
+// Close 停止消费并关闭存储
+func (t *Table[T]) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		t.cancel()
+		t.wg.Wait()
+		err = t.store.Close()
+	})
+	return err
+}
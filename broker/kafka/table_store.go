@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"sort"
+	"sync"
+)
+
+// TableEntry 写入存储的一个键，Value 为 nil 时删除该键
+type TableEntry struct {
+	Key   string
+	Value []byte
+}
+
+// TableStore Table 的底层存储，需要支持并发访问
+type TableStore interface {
+	// Get 返回键的原始值
+	Get(key string) ([]byte, bool, error)
+	// Range 按键的顺序遍历，fn 返回 false 时停止
+	Range(fn func(key string, value []byte) bool) error
+	// Apply 按顺序写入分区 partition 中一批消息的键，offset 为这批消息中最后一条的位移。
+	// 持久化存储应当在同一个事务中保存 offset+1 作为该分区下次读取的位移。
+	Apply(partition int, offset int64, entries []TableEntry) error
+	// Offsets 返回保存的各分区下次读取的位移，没有保存时返回空
+	Offsets() (map[int]int64, error)
+	// Reset 清空所有键和保存的位移，保存的位移已失效时 Table 从头重新消费之前调用
+	Reset() error
+	Close() error
+}
+
+// memoryTableStore 内存存储，不保存位移
+type memoryTableStore struct {
+	sync.RWMutex
+	data map[string][]byte
+}
+
+var _ TableStore = (*memoryTableStore)(nil)
+
+func NewMemoryTableStore() TableStore {
+	return &memoryTableStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryTableStore) Get(key string) ([]byte, bool, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	value, ok := s.data[key]
+	return value, ok, nil
+}
+
+func (s *memoryTableStore) Range(fn func(key string, value []byte) bool) error {
+	s.RLock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	s.RUnlock()
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		value, ok, _ := s.Get(k)
+		if !ok {
+			continue
+		}
+		if !fn(k, value) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memoryTableStore) Apply(_ int, _ int64, entries []TableEntry) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, e := range entries {
+		if e.Value == nil {
+			delete(s.data, e.Key)
+		} else {
+			s.data[e.Key] = e.Value
+		}
+	}
+	return nil
+}
+
+func (s *memoryTableStore) Offsets() (map[int]int64, error) {
+	return nil, nil
+}
+
+func (s *memoryTableStore) Reset() error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.data = make(map[string][]byte)
+	return nil
+}
+
+func (s *memoryTableStore) Close() error {
+	return nil
+}
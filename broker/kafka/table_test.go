@@ -0,0 +1,149 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type tableFlag struct {
+	Enabled bool `json:"enabled"`
+}
+
+func newTestTable[T any](pending map[int]int64, codec string) *Table[T] {
+	b := NewBroker().(*kafkaBroker)
+	b.options.Codec = encoding.GetCodec(codec)
+
+	t := newTable[T](b, "flags", tableOptions{store: NewMemoryTableStore(), lagCheckInterval: time.Second})
+	for p, end := range pending {
+		t.pending[p] = end
+	}
+	t.checkReady()
+	return t
+}
+
+func TestTableApply(t *testing.T) {
+	table := newTestTable[tableFlag](nil, "json")
+
+	var changes []TableChange[tableFlag]
+	cancel := table.Watch(func(change TableChange[tableFlag]) {
+		changes = append(changes, change)
+	})
+
+	table.apply(0, []kafkaGo.Message{{Partition: 0, Offset: 0, Key: []byte("a"), Value: []byte(`{"enabled":true}`)}})
+	table.apply(1, []kafkaGo.Message{{Partition: 1, Offset: 0, Key: []byte("b"), Value: []byte(`{"enabled":false}`)}})
+	table.apply(0, []kafkaGo.Message{
+		{Partition: 0, Offset: 1, Key: []byte("a"), Value: nil},
+		{Partition: 0, Offset: 2, Key: []byte("c"), Value: []byte(`not json`)},
+		{Partition: 0, Offset: 3, Value: []byte(`{"enabled":true}`)},
+	})
+
+	_, ok, err := table.Get("a")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	v, ok, err := table.Get("b")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, tableFlag{Enabled: false}, v)
+
+	_, ok, _ = table.Get("c")
+	assert.False(t, ok)
+
+	assert.Equal(t, []TableChange[tableFlag]{
+		{Key: "a", Value: tableFlag{Enabled: true}, Partition: 0, Offset: 0},
+		{Key: "b", Value: tableFlag{Enabled: false}, Partition: 1, Offset: 0},
+		{Key: "a", Deleted: true, Partition: 0, Offset: 1},
+	}, changes)
+
+	cancel()
+	table.apply(0, []kafkaGo.Message{{Partition: 0, Offset: 4, Key: []byte("d"), Value: []byte(`{"enabled":true}`)}})
+	assert.Len(t, changes, 3)
+
+	var keys []string
+	assert.Nil(t, table.Range(func(key string, value tableFlag) bool {
+		keys = append(keys, key)
+		return true
+	}))
+	assert.Equal(t, []string{"b", "d"}, keys)
+}
+
+func TestTableReady(t *testing.T) {
+	table := newTestTable[string](map[int]int64{0: 3, 1: 1}, "")
+
+	select {
+	case <-table.Ready():
+		t.Fatal("table should not be ready")
+	default:
+	}
+
+	table.apply(0, []kafkaGo.Message{{Partition: 0, Offset: 0, Key: []byte("a"), Value: []byte("1")}})
+	table.apply(1, []kafkaGo.Message{{Partition: 1, Offset: 0, Key: []byte("b"), Value: []byte("2")}})
+	assert.True(t, table.isPending(0))
+	assert.False(t, table.isPending(1))
+
+	// 最后一条消息没有键，跳过之后分区同样追上
+	table.apply(0, []kafkaGo.Message{
+		{Partition: 0, Offset: 1, Key: []byte("a"), Value: []byte("3")},
+		{Partition: 0, Offset: 2, Value: []byte("4")},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, table.WaitReady(ctx))
+
+	v, ok, err := table.Get("a")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "3", v)
+}
+
+func TestTableDecodeWithoutCodec(t *testing.T) {
+	table := newTestTable[tableFlag](nil, "")
+
+	_, err := table.decode([]byte(`{"enabled":true}`))
+	assert.NotNil(t, err)
+
+	raw := newTestTable[[]byte](nil, "")
+	v, err := raw.decode([]byte("raw"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("raw"), v)
+}
+
+func TestTableOffsetsOutOfRange(t *testing.T) {
+	starts := map[int]int64{0: 10, 1: 0}
+	ends := map[int]int64{0: 20, 1: 5}
+
+	assert.False(t, offsetsOutOfRange(nil, starts, ends))
+	assert.False(t, offsetsOutOfRange(map[int]int64{0: 10, 1: 5}, starts, ends))
+	assert.False(t, offsetsOutOfRange(map[int]int64{2: 100}, starts, ends))
+	assert.True(t, offsetsOutOfRange(map[int]int64{0: 9, 1: 3}, starts, ends))
+	assert.True(t, offsetsOutOfRange(map[int]int64{1: 6}, starts, ends))
+}
+
+// closeRecordingStore 记录是否被关闭
+type closeRecordingStore struct {
+	TableStore
+	closed bool
+}
+
+func (s *closeRecordingStore) Close() error {
+	s.closed = true
+	return s.TableStore.Close()
+}
+
+func TestNewTableClosesStoreOnError(t *testing.T) {
+	b := NewBroker(broker.WithAddress("127.0.0.1:1"))
+
+	store := &closeRecordingStore{TableStore: NewMemoryTableStore()}
+	_, err := NewTable[string](b, "flags", WithTableStore(store))
+	assert.NotNil(t, err)
+	assert.True(t, store.closed)
+}
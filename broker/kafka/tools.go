@@ -2,48 +2,53 @@ package kafka
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"strconv"
 
 	kafkaGo "github.com/segmentio/kafka-go"
 )
 
-func createConnection(addr string) (*kafkaGo.Conn, func()) {
-	conn, err := kafkaGo.Dial("tcp", addr)
+func createConnection(dialer *kafkaGo.Dialer, addr string) (*kafkaGo.Conn, func(), error) {
+	conn, err := dialer.Dial("tcp", addr)
 	if err != nil {
-		LogErrorf("create kafka connection failed: %s", err.Error())
-		return nil, nil
+		return nil, nil, fmt.Errorf("create kafka connection failed: %w", err)
 	}
 
 	controller, err := conn.Controller()
 	if err != nil {
-		LogErrorf("create kafka controller failed: %s", err.Error())
-		return nil, nil
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("create kafka controller failed: %w", err)
 	}
 
-	controllerConn, err := kafkaGo.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	controllerConn, err := dialer.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
 	if err != nil {
-		panic(err.Error())
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("create kafka controller connection failed: %w", err)
 	}
 
 	return controllerConn, func() {
-		if err = conn.Close(); err != nil {
-			LogFatalf("failed to close kafka connection: %v", err)
-		}
-		if err = controllerConn.Close(); err != nil {
-			LogFatalf("failed to close kafka controller connection: %v", err)
-		}
-	}
+		_ = conn.Close()
+		_ = controllerConn.Close()
+	}, nil
 }
 
+// CreateTopic 在第一个地址上创建主题，不支持 TLS 和 SASL，需要时使用 CreateTopicWithDialer。
+//
+// Deprecated: 使用 broker.AsAdmin 获取 broker.Admin，复用 broker 的连接配置。
 func CreateTopic(addr string, topic string, numPartitions, replicationFactor int) error {
-	conn, cleanFunc := createConnection(addr)
-	if conn == nil {
-		return errors.New("create kafka connection failed")
+	return CreateTopicWithDialer(kafkaGo.DefaultDialer, addr, topic, numPartitions, replicationFactor)
+}
+
+// CreateTopicWithDialer 使用 dialer 的 TLS 和 SASL 配置连接 addr 并创建主题，主题已存在时不返回错误
+func CreateTopicWithDialer(dialer *kafkaGo.Dialer, addr string, topic string, numPartitions, replicationFactor int) error {
+	conn, cleanFunc, err := createConnection(dialer, addr)
+	if err != nil {
+		return err
 	}
 	defer cleanFunc()
 
-	err := conn.CreateTopics(kafkaGo.TopicConfig{
+	err = conn.CreateTopics(kafkaGo.TopicConfig{
 		Topic:             topic,
 		NumPartitions:     numPartitions,
 		ReplicationFactor: replicationFactor,
@@ -55,10 +60,18 @@ func CreateTopic(addr string, topic string, numPartitions, replicationFactor int
 	return err
 }
 
+// DeleteTopic 在第一个地址上删除主题，不支持 TLS 和 SASL，需要时使用 DeleteTopicWithDialer。
+//
+// Deprecated: 使用 broker.AsAdmin 获取 broker.Admin，复用 broker 的连接配置。
 func DeleteTopic(addr string, topics ...string) error {
-	conn, cleanFunc := createConnection(addr)
-	if conn == nil {
-		return errors.New("create kafka connection failed")
+	return DeleteTopicWithDialer(kafkaGo.DefaultDialer, addr, topics...)
+}
+
+// DeleteTopicWithDialer 使用 dialer 的 TLS 和 SASL 配置连接 addr 并删除主题
+func DeleteTopicWithDialer(dialer *kafkaGo.Dialer, addr string, topics ...string) error {
+	conn, cleanFunc, err := createConnection(dialer, addr)
+	if err != nil {
+		return err
 	}
 	defer cleanFunc()
 
@@ -127,7 +127,7 @@ func (w *Writer) CreateProducer(writerConfig WriterConfig, saslMechanism sasl.Me
 		Logger:                 writerConfig.Logger,
 		ErrorLogger:            writerConfig.ErrorLogger,
 		AllowAutoTopicCreation: writerConfig.AllowAutoTopicCreation,
-		Completion:             writerConfig.Completion,
+		Completion:             publishCompletion(writerConfig.Completion),
 	}
 
 	return writer
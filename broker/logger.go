@@ -0,0 +1,27 @@
+package broker
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// NewLoggerHelper 创建带有结构化字段的日志助手。
+// logger 为空时写入 kratos 的全局日志器，并跟随 log.SetLogger 的变更。
+//
+// 各驱动包中的 LogDebug、LogInfof 等包级日志函数已废弃：它们总是写入全局日志器，
+// 请通过 broker.WithLogger 注入日志器，驱动内部使用由本函数创建的实例日志器。
+func NewLoggerHelper(logger log.Logger, kv ...interface{}) *log.Helper {
+	if logger == nil {
+		logger = globalLogger{}
+	}
+	if len(kv) > 0 {
+		logger = log.With(logger, kv...)
+	}
+	return log.NewHelper(logger)
+}
+
+type globalLogger struct{}
+
+func (globalLogger) Log(level log.Level, keyvals ...interface{}) error {
+	log.Log(level, keyvals...)
+	return nil
+}
@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	defaultMaxRedeliveries = 3
+)
+
+var (
+	ErrNotConnected = errors.New("memory: broker not connected")
+)
+
+// memoryBroker 进程内的消息代理，主要用于测试。
+// 发布是同步的：Publish 在所有订阅者的处理器返回之后才返回，投递顺序与发布顺序一致。
+// 相同 Queue 的订阅者组成一个消费组，组内轮流投递；Queue 为空的订阅者各自收到一份消息。
+type memoryBroker struct {
+	sync.RWMutex
+
+	options   broker.Options
+	connected bool
+
+	subscribers map[string][]*subscriber
+	cursors     map[string]int
+
+	maxRedeliveries int
+
+	log *log.Helper
+}
+
+func NewBroker(opts ...broker.Option) broker.Broker {
+	options := broker.NewOptionsAndApply(opts...)
+
+	b := &memoryBroker{
+		options:         options,
+		subscribers:     make(map[string][]*subscriber),
+		cursors:         make(map[string]int),
+		maxRedeliveries: defaultMaxRedeliveries,
+		log:             newLoggerHelper(options.Logger),
+	}
+	b.applyOptions()
+
+	return b
+}
+
+func (b *memoryBroker) Name() string {
+	return "memory"
+}
+
+func (b *memoryBroker) Options() broker.Options {
+	return b.options
+}
+
+func (b *memoryBroker) Address() string {
+	return "memory://"
+}
+
+func (b *memoryBroker) Init(opts ...broker.Option) error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.options.Apply(opts...)
+	b.log = newLoggerHelper(b.options.Logger)
+	b.applyOptions()
+
+	return nil
+}
+
+func (b *memoryBroker) applyOptions() {
+	if value, ok := b.options.Context.Value(maxRedeliveriesKey{}).(int); ok {
+		b.maxRedeliveries = value
+	}
+}
+
+// Connect 标记为已连接，之前的订阅依然有效
+func (b *memoryBroker) Connect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.connected = true
+	return nil
+}
+
+// Disconnect 标记为未连接，期间发布的消息返回 ErrNotConnected，订阅保留到下次 Connect
+func (b *memoryBroker) Disconnect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.connected = false
+	return nil
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	buf, err := broker.Marshal(b.options.Codec, msg)
+	if err != nil {
+		return err
+	}
+
+	options := broker.NewPublishOptions(opts...)
+
+	var headers broker.Headers
+	if value, ok := options.Context.Value(headersKey{}).(broker.Headers); ok {
+		headers = value
+	}
+
+	return b.publish(ctx, topic, headers, buf)
+}
+
+func (b *memoryBroker) publish(ctx context.Context, topic string, headers broker.Headers, buf []byte) error {
+	subs, err := b.route(topic)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		b.deliver(ctx, sub, headers, buf)
+	}
+
+	return nil
+}
+
+// route 选出需要投递的订阅者：Queue 为空的全部投递，相同 Queue 的轮流投递其中一个
+func (b *memoryBroker) route(topic string) ([]*subscriber, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if !b.connected {
+		return nil, ErrNotConnected
+	}
+
+	var subs []*subscriber
+	groups := make(map[string][]*subscriber)
+	var queues []string
+
+	for _, sub := range b.subscribers[topic] {
+		queue := sub.options.Queue
+		if len(queue) == 0 {
+			subs = append(subs, sub)
+			continue
+		}
+		if _, ok := groups[queue]; !ok {
+			queues = append(queues, queue)
+		}
+		groups[queue] = append(groups[queue], sub)
+	}
+
+	for _, queue := range queues {
+		key := topic + "|" + queue
+		members := groups[queue]
+		subs = append(subs, members[b.cursors[key]%len(members)])
+		b.cursors[key]++
+	}
+
+	return subs, nil
+}
+
+func (b *memoryBroker) deliver(ctx context.Context, sub *subscriber, headers broker.Headers, buf []byte) {
+	if !sub.filter.Match(headers) {
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+		if sub.IsClosed() {
+			return
+		}
+
+		m := &broker.Message{
+			Headers: make(broker.Headers, len(headers)),
+		}
+		for k, v := range headers {
+			m.Headers[k] = v
+		}
+
+		p := &publication{topic: sub.topic, m: m, data: buf}
+
+		var stage broker.ErrorStage
+		if sub.binder != nil {
+			m.Body = sub.binder()
+			if err := broker.Unmarshal(b.options.Codec, buf, &m.Body); err != nil {
+				p.err, stage = err, broker.ErrorStageDecode
+			}
+		} else {
+			m.Body = buf
+		}
+
+		if p.err == nil {
+			if err := sub.handler(ctx, p); err != nil {
+				p.err, stage = err, broker.ErrorStageHandle
+			}
+		}
+
+		if p.err == nil {
+			return
+		}
+
+		if !b.handleError(ctx, sub, p, stage) || attempt >= b.maxRedeliveries {
+			return
+		}
+	}
+}
+
+// handleError 将失败的消息交给 ErrorHandler 处置，返回是否需要重新投递
+func (b *memoryBroker) handleError(ctx context.Context, sub *subscriber, p *publication, stage broker.ErrorStage) bool {
+	b.log.Errorw("msg", "message failed", "stage", stage, "topic", sub.topic, "queue", sub.options.Queue, "error", p.err)
+
+	switch broker.HandleError(b.options.ErrorHandler, ctx, p, stage, p.err) {
+	case broker.ErrorActionNack:
+		return true
+
+	case broker.ErrorActionDeadLetter:
+		headers := broker.DeadLetterHeaders(p.m.Headers, sub.topic, stage, p.err)
+		if err := b.publish(ctx, broker.DeadLetterTopic(sub.topic, sub.options), headers, p.data); err != nil {
+			b.log.Errorf("publish dead letter failed: %v", err)
+		}
+
+	default:
+	}
+
+	return false
+}
+
+func (b *memoryBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.NewSubscribeOptions(opts...)
+
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscriber{
+		b:       b,
+		topic:   topic,
+		options: options,
+		handler: handler,
+		binder:  binder,
+		filter:  filter,
+	}
+
+	b.Lock()
+	b.subscribers[topic] = append(b.subscribers[topic], sub)
+	b.Unlock()
+
+	return sub, nil
+}
+
+func (b *memoryBroker) removeSubscriber(sub *subscriber, _ bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	subs := b.subscribers[sub.topic]
+	for i, s := range subs {
+		if s == sub {
+			b.subscribers[sub.topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(b.subscribers[sub.topic]) == 0 {
+		delete(b.subscribers, sub.topic)
+	}
+}
+
+func (b *memoryBroker) Request(_ context.Context, _ string, _ broker.Any, _ ...broker.RequestOption) (broker.Any, error) {
+	return nil, errors.New("memory: request is not supported")
+}
@@ -0,0 +1,301 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type order struct {
+	ID int `json:"id"`
+}
+
+func newTestBroker(t *testing.T, opts ...broker.Option) broker.Broker {
+	b := NewBroker(opts...)
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestPublishNotConnected(t *testing.T) {
+	b := NewBroker()
+	if err := b.Publish(context.Background(), "test", "hello"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := newTestBroker(t, broker.WithCodec("json"))
+
+	var got []int
+	_, err := broker.Subscribe(b, "order", func(_ context.Context, _ string, headers broker.Headers, o *order) error {
+		if headers["k"] != "v" {
+			t.Errorf("unexpected headers: %v", headers)
+		}
+		got = append(got, o.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err = b.Publish(context.Background(), "order", &order{ID: i}, WithHeaders(broker.Headers{"k": "v"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("unexpected deliveries: %v", got)
+	}
+}
+
+func TestQueueGroup(t *testing.T) {
+	b := newTestBroker(t)
+
+	counts := make(map[string]int)
+	handler := func(name string) broker.Handler {
+		return func(context.Context, broker.Event) error {
+			counts[name]++
+			return nil
+		}
+	}
+
+	_, _ = b.Subscribe("test", handler("a"), nil, broker.WithQueueName("group"))
+	_, _ = b.Subscribe("test", handler("b"), nil, broker.WithQueueName("group"))
+	sub, _ := b.Subscribe("test", handler("all"), nil)
+
+	for i := 0; i < 4; i++ {
+		_ = b.Publish(context.Background(), "test", "hello")
+	}
+	if counts["a"] != 2 || counts["b"] != 2 || counts["all"] != 4 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	_ = sub.Unsubscribe(true)
+	_ = b.Publish(context.Background(), "test", "hello")
+	if counts["all"] != 4 {
+		t.Errorf("unsubscribed handler called: %v", counts)
+	}
+}
+
+func TestErrorActions(t *testing.T) {
+	action := broker.ErrorActionNack
+	b := newTestBroker(t,
+		WithMaxRedeliveries(2),
+		broker.WithErrorHandler(func(context.Context, broker.Event, broker.ErrorStage, error) broker.ErrorAction {
+			return action
+		}),
+	)
+
+	calls := 0
+	_, _ = b.Subscribe("test", func(context.Context, broker.Event) error {
+		calls++
+		return errors.New("failed")
+	}, nil)
+
+	var deadLetters []broker.Headers
+	_, _ = b.Subscribe("test"+broker.DefaultDeadLetterSuffix, func(_ context.Context, evt broker.Event) error {
+		deadLetters = append(deadLetters, evt.Message().Headers)
+		return nil
+	}, nil)
+
+	_ = b.Publish(context.Background(), "test", "hello")
+	if calls != 3 {
+		t.Errorf("unexpected deliveries with nack: %d", calls)
+	}
+
+	action = broker.ErrorActionDeadLetter
+	_ = b.Publish(context.Background(), "test", "hello")
+	if len(deadLetters) != 1 || deadLetters[0][broker.HeaderDeadLetterOriginalTopic] != "test" {
+		t.Errorf("unexpected dead letters: %v", deadLetters)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	type refund struct {
+		Amount int `json:"amount"`
+	}
+
+	var deadLetters int
+	b := newTestBroker(t,
+		broker.WithCodec("json"),
+		broker.WithErrorHandler(func(_ context.Context, _ broker.Event, _ broker.ErrorStage, err error) broker.ErrorAction {
+			if errors.Is(err, broker.ErrUnknownMessageType) {
+				deadLetters++
+				return broker.ErrorActionDeadLetter
+			}
+			return broker.ErrorActionDefault
+		}),
+	)
+
+	var orders, refunds []int
+	r := broker.NewRouter()
+	broker.Route(r, "order", func(_ context.Context, _ string, _ broker.Headers, o *order) error {
+		orders = append(orders, o.ID)
+		return nil
+	})
+	broker.Route(r, "refund", func(_ context.Context, _ string, _ broker.Headers, rf *refund) error {
+		refunds = append(refunds, rf.Amount)
+		return nil
+	})
+
+	if _, err := r.Subscribe(b, "payments"); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = b.Publish(context.Background(), "payments", &order{ID: 1}, WithHeaders(broker.Headers{"type": "order"}))
+	_ = b.Publish(context.Background(), "payments", &refund{Amount: 10}, WithHeaders(broker.Headers{"type": "refund"}))
+	_ = b.Publish(context.Background(), "payments", &order{ID: 2}, WithHeaders(broker.Headers{"type": "chargeback"}))
+
+	if len(orders) != 1 || orders[0] != 1 {
+		t.Errorf("unexpected orders: %v", orders)
+	}
+	if len(refunds) != 1 || refunds[0] != 10 {
+		t.Errorf("unexpected refunds: %v", refunds)
+	}
+	if deadLetters != 1 {
+		t.Errorf("unexpected dead letters: %d", deadLetters)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	b := newTestBroker(t)
+
+	var got []string
+	_, err := b.Subscribe("orders", func(_ context.Context, evt broker.Event) error {
+		got = append(got, string(evt.Message().Body.([]byte)))
+		return nil
+	}, nil, broker.WithFilter("type = 'order.created' AND region IN ('eu', 'us')"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = b.Publish(context.Background(), "orders", "a", WithHeaders(broker.Headers{"type": "order.created", "region": "eu"}))
+	_ = b.Publish(context.Background(), "orders", "b", WithHeaders(broker.Headers{"type": "order.created", "region": "cn"}))
+	_ = b.Publish(context.Background(), "orders", "c", WithHeaders(broker.Headers{"type": "order.cancelled", "region": "us"}))
+	_ = b.Publish(context.Background(), "orders", "d")
+
+	if len(got) != 1 || got[0] != "a" {
+		t.Errorf("unexpected deliveries: %v", got)
+	}
+
+	if _, err = b.Subscribe("orders", func(context.Context, broker.Event) error { return nil }, nil,
+		broker.WithFilter("type =")); !errors.Is(err, broker.ErrInvalidFilter) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// testLease 进程内的互斥租约，revoke 模拟持有者失去租约
+type testLease struct {
+	held chan struct{}
+	lost chan struct{}
+}
+
+func newTestLease() *testLease {
+	return &testLease{held: make(chan struct{}, 1)}
+}
+
+func (l *testLease) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	select {
+	case l.held <- struct{}{}:
+		l.lost = make(chan struct{})
+		return l.lost, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *testLease) Release(context.Context) error {
+	<-l.held
+	return nil
+}
+
+func (l *testLease) revoke() {
+	close(l.lost)
+}
+
+func TestSingleActiveConsumer(t *testing.T) {
+	broker.DefaultLeaseRetryInterval = 10 * time.Millisecond
+
+	b := newTestBroker(t)
+	lease := newTestLease()
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	handler := func(name string) broker.Handler {
+		return func(context.Context, broker.Event) error {
+			mu.Lock()
+			counts[name]++
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	subA, err := b.Subscribe("projector", handler("a"), nil, broker.WithSingleActiveConsumer(lease))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitActive(t, subA)
+
+	subB, err := b.Subscribe("projector", handler("b"), nil, broker.WithSingleActiveConsumer(lease))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = b.Publish(context.Background(), "projector", "1")
+	_ = b.Publish(context.Background(), "projector", "2")
+
+	_ = subA.Unsubscribe(true)
+	waitActive(t, subB)
+
+	_ = b.Publish(context.Background(), "projector", "3")
+
+	mu.Lock()
+	if counts["a"] != 2 || counts["b"] != 1 {
+		t.Errorf("unexpected deliveries: %v", counts)
+	}
+	mu.Unlock()
+
+	lease.revoke()
+	time.Sleep(50 * time.Millisecond)
+	waitActive(t, subB)
+	_ = subB.Unsubscribe(true)
+
+	if _, err = b.Subscribe("projector", handler("c"), nil,
+		broker.WithSingleActiveConsumer(fakeNativeLease{testLease: newTestLease()})); !errors.Is(err, broker.ErrNotSupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type fakeNativeLease struct {
+	*testLease
+}
+
+func (fakeNativeLease) Native() string { return "rabbitmq" }
+
+func waitActive(t *testing.T, sub broker.Subscriber) {
+	active := sub.(interface{ Active() bool })
+	for i := 0; i < 100; i++ {
+		if active.Active() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("subscriber not active")
+}
+
+func TestOpen(t *testing.T) {
+	b, err := broker.Open("memory://?max_redeliveries=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.(*memoryBroker).maxRedeliveries != 5 {
+		t.Errorf("unexpected max redeliveries: %d", b.(*memoryBroker).maxRedeliveries)
+	}
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+///
+/// Option
+///
+
+type maxRedeliveriesKey struct{}
+
+// WithMaxRedeliveries ErrorHandler 返回 Nack 时的最大重投次数，超过后丢弃消息，默认为3次
+func WithMaxRedeliveries(n int) broker.Option {
+	return broker.OptionContextWithValue(maxRedeliveriesKey{}, n)
+}
+
+///
+/// PublishOption
+///
+
+type headersKey struct{}
+
+// WithHeaders 消息头
+func WithHeaders(headers broker.Headers) broker.PublishOption {
+	return broker.PublishContextWithValue(headersKey{}, headers)
+}
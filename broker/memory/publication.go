@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type publication struct {
+	topic string
+	m     *broker.Message
+	data  []byte
+	err   error
+}
+
+func (p *publication) Topic() string {
+	return p.topic
+}
+
+func (p *publication) Message() *broker.Message {
+	return p.m
+}
+
+// RawMessage 编码后的消息体
+func (p *publication) RawMessage() interface{} {
+	return p.data
+}
+
+// Ack 内存中的消息无需确认
+func (p *publication) Ack() error {
+	return nil
+}
+
+func (p *publication) Error() error {
+	return p.err
+}
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"net/url"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func init() {
+	broker.Register("memory", newBrokerFromURL)
+}
+
+func newBrokerFromURL(u *url.URL, opts ...broker.Option) (broker.Broker, error) {
+	urlOpts, err := URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewBroker(append(urlOpts, opts...)...), nil
+}
+
+// URLOptions 将连接 URL 解析为 broker 选项，例如：
+//
+//	memory://?codec=json&max_redeliveries=5
+func URLOptions(u *url.URL) ([]broker.Option, error) {
+	return broker.ParseURL(u, broker.URLParams{
+		"max_redeliveries": broker.URLInt(WithMaxRedeliveries),
+	})
+}
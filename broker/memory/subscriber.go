@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type subscriber struct {
+	sync.RWMutex
+
+	b *memoryBroker
+
+	topic   string
+	options broker.SubscribeOptions
+	handler broker.Handler
+	binder  broker.Binder
+	filter  *broker.Filter
+
+	closed bool
+}
+
+func (s *subscriber) Options() broker.SubscribeOptions {
+	return s.options
+}
+
+func (s *subscriber) Topic() string {
+	return s.topic
+}
+
+func (s *subscriber) Unsubscribe(removeFromManager bool) error {
+	s.Lock()
+	s.closed = true
+	s.Unlock()
+
+	if s.b != nil {
+		s.b.removeSubscriber(s, removeFromManager)
+	}
+	return nil
+}
+
+func (s *subscriber) IsClosed() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.closed
+}
@@ -2,33 +2,47 @@ package mqtt
 
 import (
 	"fmt"
+	"sync"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
 )
 
 const (
 	logKey = "[mqtt]"
 )
 
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return broker.NewLoggerHelper(logger, "broker", "mqtt")
+}
+
 ///
 /// logger
 ///
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogDebug(args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogInfo(args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogWarn(args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogError(args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogFatal(args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprint(args...))
 }
@@ -37,26 +51,67 @@ func LogFatal(args ...interface{}) {
 /// logger
 ///
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogDebugf(format string, args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogInfof(format string, args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogWarnf(format string, args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogErrorf(format string, args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogFatalf(format string, args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+///
+/// paho 日志器
+///
+/// paho 的 ERROR、CRITICAL、WARN、DEBUG 是进程级的全局变量，无法按 broker 实例区分，
+/// 因此这里的日志器写入 kratos 的全局日志器（log.SetLogger），而不是 broker.WithLogger 设置的实例日志器。
+///
+
+var installPahoLoggersOnce sync.Once
+
+// installPahoLoggers 安装 paho 的内部日志器。
+// 只有第一个启用了 paho 日志的 broker 生效，之后创建的 broker 不会再覆盖这些全局变量。
+func installPahoLoggers(opt LoggerOptions) {
+	if !opt.Error && !opt.Critical && !opt.Warn && !opt.Debug {
+		return
+	}
+
+	installPahoLoggersOnce.Do(func() {
+		if opt.Error {
+			paho.ERROR = ErrorLogger{}
+		}
+		if opt.Critical {
+			paho.CRITICAL = CriticalLogger{}
+		}
+		if opt.Warn {
+			paho.WARN = WarnLogger{}
+		}
+		if opt.Debug {
+			paho.DEBUG = DebugLogger{}
+		}
+	})
+}
+
+func pahoLog(level log.Level, msg string) {
+	log.Log(level, "broker", "mqtt", "component", "paho", log.DefaultMessageKey, msg)
+}
+
 ///
 /// ErrorLogger
 ///
@@ -64,25 +119,26 @@ func LogFatalf(format string, args ...interface{}) {
 type ErrorLogger struct{}
 
 func (ErrorLogger) Println(v ...interface{}) {
-	log.Error(v...)
+	pahoLog(log.LevelError, fmt.Sprint(v...))
 }
 
 func (ErrorLogger) Printf(format string, v ...interface{}) {
-	log.Errorf(format, v...)
+	pahoLog(log.LevelError, fmt.Sprintf(format, v...))
 }
 
 ///
 /// CriticalLogger
 ///
 
+// CriticalLogger 以 Fatal 级别记录日志，但不会退出进程
 type CriticalLogger struct{}
 
 func (CriticalLogger) Println(v ...interface{}) {
-	log.Fatal(v...)
+	pahoLog(log.LevelFatal, fmt.Sprint(v...))
 }
 
 func (CriticalLogger) Printf(format string, v ...interface{}) {
-	log.Fatalf(format, v...)
+	pahoLog(log.LevelFatal, fmt.Sprintf(format, v...))
 }
 
 ///
@@ -92,11 +148,11 @@ func (CriticalLogger) Printf(format string, v ...interface{}) {
 type WarnLogger struct{}
 
 func (WarnLogger) Println(v ...interface{}) {
-	log.Warn(v...)
+	pahoLog(log.LevelWarn, fmt.Sprint(v...))
 }
 
 func (WarnLogger) Printf(format string, v ...interface{}) {
-	log.Warnf(format, v...)
+	pahoLog(log.LevelWarn, fmt.Sprintf(format, v...))
 }
 
 ///
@@ -106,9 +162,9 @@ func (WarnLogger) Printf(format string, v ...interface{}) {
 type DebugLogger struct{}
 
 func (DebugLogger) Println(v ...interface{}) {
-	log.Debug(v...)
+	pahoLog(log.LevelDebug, fmt.Sprint(v...))
 }
 
 func (DebugLogger) Printf(format string, v ...interface{}) {
-	log.Debugf(format, v...)
+	pahoLog(log.LevelDebug, fmt.Sprintf(format, v...))
 }
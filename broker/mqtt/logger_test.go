@@ -0,0 +1,19 @@
+package mqtt
+
+import (
+	"testing"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallPahoLoggersOnce(t *testing.T) {
+	installPahoLoggers(LoggerOptions{})
+	assert.NotEqual(t, ErrorLogger{}, paho.ERROR, "nothing enabled, nothing installed")
+
+	installPahoLoggers(LoggerOptions{Error: true})
+	assert.Equal(t, ErrorLogger{}, paho.ERROR)
+
+	installPahoLoggers(LoggerOptions{Debug: true})
+	assert.NotEqual(t, DebugLogger{}, paho.DEBUG, "second broker must not overwrite paho loggers")
+}
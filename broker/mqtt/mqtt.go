@@ -3,10 +3,12 @@ package mqtt
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/tx7do/kratos-transport/broker"
 )
@@ -17,6 +19,8 @@ type mqttBroker struct {
 	client  paho.Client
 
 	subscribers *broker.SubscriberSyncMap
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -93,32 +97,24 @@ func newClient(addrs []string, opts broker.Options, b *mqttBroker) paho.Client {
 		cOpts.SetOrderMatters(enabled)
 	}
 
+	// paho 的内部日志器是进程级的全局变量，只在显式启用时才安装
+	var loggerOpts LoggerOptions
+	if opt, ok := opts.Context.Value(loggerKey{}).(LoggerOptions); ok {
+		loggerOpts = opt
+	}
 	if _, ok := opts.Context.Value(errorLoggerKey{}).(bool); ok {
-		paho.ERROR = ErrorLogger{}
+		loggerOpts.Error = true
 	}
 	if _, ok := opts.Context.Value(criticalLoggerKey{}).(bool); ok {
-		paho.CRITICAL = CriticalLogger{}
+		loggerOpts.Critical = true
 	}
 	if _, ok := opts.Context.Value(warnLoggerKey{}).(bool); ok {
-		paho.WARN = WarnLogger{}
+		loggerOpts.Warn = true
 	}
 	if _, ok := opts.Context.Value(debugLoggerKey{}).(bool); ok {
-		paho.DEBUG = DebugLogger{}
-	}
-	if opt, ok := opts.Context.Value(debugLoggerKey{}).(LoggerOptions); ok {
-		if opt.Error {
-			paho.ERROR = ErrorLogger{}
-		}
-		if opt.Critical {
-			paho.CRITICAL = CriticalLogger{}
-		}
-		if opt.Warn {
-			paho.WARN = WarnLogger{}
-		}
-		if opt.Debug {
-			paho.DEBUG = DebugLogger{}
-		}
+		loggerOpts.Debug = true
 	}
+	installPahoLoggers(loggerOpts)
 
 	return paho.NewClient(cOpts)
 }
@@ -130,6 +126,7 @@ func newBroker(opts ...broker.Option) broker.Broker {
 		options:     options,
 		addrs:       options.Addrs,
 		subscribers: broker.NewSubscriberSyncMap(),
+		log:         newLoggerHelper(options.Logger),
 	}
 
 	b.client = newClient(options.Addrs, options, b)
@@ -158,6 +155,8 @@ func (m *mqttBroker) Init(opts ...broker.Option) error {
 		o(&m.options)
 	}
 
+	m.log = newLoggerHelper(m.options.Logger)
+
 	m.addrs = setAddrs(m.options.Addrs)
 	m.client = newClient(m.addrs, m.options, m)
 	return nil
@@ -237,6 +236,15 @@ func (m *mqttBroker) Subscribe(topic string, handler broker.Handler, binder brok
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(m, topic, handler, binder, opts...)
+	}
+
+	// MQTT 3.1.1 的消息没有消息头，无法按选择器过滤
+	if len(options.Filter) > 0 {
+		return nil, fmt.Errorf("mqtt: message filter: %w", broker.ErrNotSupported)
+	}
+
 	var qos byte = 1
 	if value, ok := options.Context.Value(qosSubscribeKey{}).(byte); ok {
 		qos = value
@@ -251,8 +259,7 @@ func (m *mqttBroker) Subscribe(topic string, handler broker.Handler, binder brok
 			msg.Body = binder()
 
 			if err := broker.Unmarshal(m.options.Codec, mq.Payload(), &msg.Body); err != nil {
-				p.err = err
-				LogError("unmarshal message failed:", err)
+				m.handleError(m.options.Context, topic, options, p, mq, broker.ErrorStageDecode, err)
 				return
 			}
 		} else {
@@ -260,8 +267,7 @@ func (m *mqttBroker) Subscribe(topic string, handler broker.Handler, binder brok
 		}
 
 		if err := handler(m.options.Context, p); err != nil {
-			p.err = err
-			LogError("handle message failed:", err)
+			m.handleError(m.options.Context, topic, options, p, mq, broker.ErrorStageHandle, err)
 		}
 	}
 
@@ -293,18 +299,18 @@ func (m *mqttBroker) doSubscribe(topic string, qos byte, callback paho.MessageHa
 }
 
 func (m *mqttBroker) onConnect(_ paho.Client) {
-	LogDebug("on connect")
+	m.log.Debug("on connect")
 
 	m.subscribers.Foreach(func(topic string, sub broker.Subscriber) {
 		aSub := sub.(*subscriber)
 		if err := m.doSubscribe(aSub.topic, aSub.qos, aSub.callback); err != nil {
-			LogError("mqtt broker subscribe message failed:", err)
+			m.log.Error("mqtt broker subscribe message failed:", err)
 		}
 	})
 }
 
 func (m *mqttBroker) onConnectionLost(client paho.Client, _ error) {
-	LogDebug("on connect lost, try to reconnect")
+	m.log.Debug("on connect lost, try to reconnect")
 	m.loopConnect(client)
 }
 
@@ -312,10 +318,30 @@ func (m *mqttBroker) loopConnect(client paho.Client) {
 	for {
 		token := client.Connect()
 		if rs, err := checkClientToken(token); !rs {
-			LogErrorf("connect error: %s", err.Error())
+			m.log.Errorf("connect error: %s", err.Error())
 		} else {
 			break
 		}
 		time.Sleep(1 * time.Second)
 	}
 }
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// MQTT 3.1.1 的确认由客户端在回调返回后自动完成，因此只有死信动作会产生实际效果。
+func (m *mqttBroker) handleError(ctx context.Context, topic string, options broker.SubscribeOptions, p *publication, mq paho.Message, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	m.log.Errorw("msg", "message failed", "stage", stage, "topic", mq.Topic(), "error", err)
+
+	switch action := broker.HandleError(m.options.ErrorHandler, ctx, p, stage, err); action {
+	case broker.ErrorActionDeadLetter:
+		if err = m.publish(ctx, broker.DeadLetterTopic(topic, options), mq.Payload()); err != nil {
+			m.log.Errorf("publish dead letter failed: %v", err)
+		}
+
+	case broker.ErrorActionNack:
+		m.log.Warnf("error action %s is not supported by mqtt, message is already acknowledged", action)
+
+	default:
+	}
+}
@@ -0,0 +1,152 @@
+package mqttv5
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// ErrNoResponseTopic 回复的请求消息没有 Response Topic
+var ErrNoResponseTopic = errors.New("mqtt5: message has no response topic")
+
+// ReasonCodeError 服务端在 CONNACK、PUBACK、PUBREC、SUBACK 或 UNSUBACK 中返回的失败原因码（大于等于 0x80）
+type ReasonCodeError struct {
+	// Code 原因码
+	Code byte
+	// Reason 服务端返回的原因说明（Reason String 属性），可能为空
+	Reason string
+}
+
+func (e *ReasonCodeError) Error() string {
+	reason := e.Reason
+	if reason == "" {
+		reason = reasonCodeText(e.Code)
+	}
+	return fmt.Sprintf("mqtt5: reason code 0x%02x: %s", e.Code, reason)
+}
+
+// reasonCodeText MQTT 5.0 规范中的失败原因码
+func reasonCodeText(code byte) string {
+	switch code {
+	case 0x80:
+		return "unspecified error"
+	case 0x81:
+		return "malformed packet"
+	case 0x82:
+		return "protocol error"
+	case 0x83:
+		return "implementation specific error"
+	case 0x84:
+		return "unsupported protocol version"
+	case 0x85:
+		return "client identifier not valid"
+	case 0x86:
+		return "bad user name or password"
+	case 0x87:
+		return "not authorized"
+	case 0x88:
+		return "server unavailable"
+	case 0x89:
+		return "server busy"
+	case 0x8A:
+		return "banned"
+	case 0x8B:
+		return "server shutting down"
+	case 0x8C:
+		return "bad authentication method"
+	case 0x8D:
+		return "keep alive timeout"
+	case 0x8E:
+		return "session taken over"
+	case 0x8F:
+		return "topic filter invalid"
+	case 0x90:
+		return "topic name invalid"
+	case 0x91:
+		return "packet identifier in use"
+	case 0x93:
+		return "receive maximum exceeded"
+	case 0x94:
+		return "topic alias invalid"
+	case 0x95:
+		return "packet too large"
+	case 0x96:
+		return "message rate too high"
+	case 0x97:
+		return "quota exceeded"
+	case 0x98:
+		return "administrative action"
+	case 0x99:
+		return "payload format invalid"
+	case 0x9A:
+		return "retain not supported"
+	case 0x9B:
+		return "qos not supported"
+	case 0x9C:
+		return "use another server"
+	case 0x9D:
+		return "server moved"
+	case 0x9E:
+		return "shared subscriptions not supported"
+	case 0x9F:
+		return "connection rate exceeded"
+	case 0xA1:
+		return "subscription identifiers not supported"
+	case 0xA2:
+		return "wildcard subscriptions not supported"
+	default:
+		return "unknown reason code"
+	}
+}
+
+// publishError paho 只对 PUBACK 的失败原因码返回错误，QoS 2 的 PUBREC 需要单独检查
+func publishError(resp *paho.PublishResponse, err error) error {
+	if resp != nil && resp.ReasonCode >= 0x80 {
+		e := &ReasonCodeError{Code: resp.ReasonCode}
+		if resp.Properties != nil {
+			e.Reason = resp.Properties.ReasonString
+		}
+		return e
+	}
+	return err
+}
+
+func subscribeError(resp *paho.Suback, err error) error {
+	if resp != nil {
+		for _, code := range resp.Reasons {
+			if code >= 0x80 {
+				e := &ReasonCodeError{Code: code}
+				if resp.Properties != nil {
+					e.Reason = resp.Properties.ReasonString
+				}
+				return e
+			}
+		}
+	}
+	return err
+}
+
+func unsubscribeError(resp *paho.Unsuback, err error) error {
+	if resp != nil {
+		for _, code := range resp.Reasons {
+			if code >= 0x80 {
+				e := &ReasonCodeError{Code: code}
+				if resp.Properties != nil {
+					e.Reason = resp.Properties.ReasonString
+				}
+				return e
+			}
+		}
+	}
+	return err
+}
+
+func connectError(err error) error {
+	var ce *autopaho.ConnackError
+	if errors.As(err, &ce) {
+		return &ReasonCodeError{Code: ce.ReasonCode, Reason: ce.Reason}
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package mqttv5
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/eclipse/paho.golang/paho"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var _ propagation.TextMapCarrier = (*MessageCarrier)(nil)
+
+// MessageCarrier 在消息头中传递链路追踪上下文，消息头以用户属性的形式发送
+type MessageCarrier struct {
+	headers map[string]string
+}
+
+func NewMessageCarrier(headers map[string]string) MessageCarrier {
+	return MessageCarrier{headers: headers}
+}
+
+func (c MessageCarrier) Get(key string) string {
+	return c.headers[key]
+}
+
+func (c MessageCarrier) Set(key, val string) {
+	c.headers[key] = val
+}
+
+func (c MessageCarrier) Keys() []string {
+	out := make([]string, 0, len(c.headers))
+	for k := range c.headers {
+		out = append(out, k)
+	}
+	return out
+}
+
+// headersToUserProperties 消息头转换为用户属性
+func headersToUserProperties(headers map[string]string) paho.UserProperties {
+	if len(headers) == 0 {
+		return nil
+	}
+	props := make(paho.UserProperties, 0, len(headers))
+	for k, v := range headers {
+		props = append(props, paho.UserProperty{Key: k, Value: v})
+	}
+	return props
+}
+
+// userPropertiesToHeaders 用户属性转换为消息头，同名属性只保留最后一个
+func userPropertiesToHeaders(props paho.UserProperties) map[string]string {
+	if len(props) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(props))
+	for _, p := range props {
+		headers[p.Key] = p.Value
+	}
+	return headers
+}
+
+// sharedTopic 共享订阅的主题过滤器
+func sharedTopic(queue, topic string) string {
+	return "$share/" + queue + "/" + topic
+}
+
+// matchTopic 主题名是否与主题过滤器匹配，支持 + 和 # 通配符，通配符不匹配以 $ 开头的主题
+func matchTopic(filter, topic string) bool {
+	if filter == topic {
+		return true
+	}
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+
+	fs := strings.Split(filter, "/")
+	ts := strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) {
+			return false
+		}
+		if f != "+" && f != ts[i] {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
+
+// topicAliases 发布端的主题别名表，别名只在一个连接内有效，重新连接后清空。
+// 别名按先到先得分配，用完后其余主题不再使用别名。
+type topicAliases struct {
+	sync.Mutex
+
+	maximum uint16
+	limit   uint16
+	aliases map[string]uint16
+}
+
+func newTopicAliases(maximum uint16) *topicAliases {
+	return &topicAliases{
+		maximum: maximum,
+		aliases: make(map[string]uint16),
+	}
+}
+
+// reset 连接建立后根据服务端声明的上限重置别名表
+func (a *topicAliases) reset(serverMaximum uint16) {
+	a.Lock()
+	defer a.Unlock()
+
+	a.limit = a.maximum
+	if serverMaximum < a.limit {
+		a.limit = serverMaximum
+	}
+	a.aliases = make(map[string]uint16)
+}
+
+// apply 为消息设置主题别名，已经建立映射的主题不再发送主题名。调用方需要持有锁直到消息发出，保证映射先于别名到达服务端。
+func (a *topicAliases) apply(p *paho.Publish) {
+	if a.limit == 0 {
+		return
+	}
+
+	alias, ok := a.aliases[p.Topic]
+	if !ok {
+		if len(a.aliases) >= int(a.limit) {
+			return
+		}
+		alias = uint16(len(a.aliases) + 1)
+		a.aliases[p.Topic] = alias
+	} else {
+		p.Topic = ""
+	}
+
+	if p.Properties == nil {
+		p.Properties = &paho.PublishProperties{}
+	}
+	p.Properties.TopicAlias = &alias
+}
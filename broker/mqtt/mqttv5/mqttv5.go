@@ -0,0 +1,658 @@
+package mqttv5
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	semConv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/tracing"
+)
+
+const (
+	defaultAddr = "tcp://127.0.0.1:1883"
+
+	// maxSubscriptionID 订阅标识符的上限（变长整数的最大值）加一
+	maxSubscriptionID = 268435456
+)
+
+var clientIdPrefix = "kratos_server"
+
+type mqttBroker struct {
+	sync.RWMutex
+
+	options broker.Options
+	addrs   []string
+
+	clientId       string
+	connectTimeout time.Duration
+
+	cm     *autopaho.ConnectionManager
+	cancel context.CancelFunc
+
+	// lastConnectErr 最近一次连接失败的原因，Connect 超时时返回
+	lastConnectErr error
+	connectErrMu   sync.Mutex
+
+	subscribers *broker.SubscriberSyncMap
+	aliases     *topicAliases
+	responses   *responseRouter
+
+	// nextSubID 分配给订阅的订阅标识符，subIDEnabled 是否启用订阅标识符，subIDAvailable 服务端是否支持订阅标识符
+	nextSubID      atomic.Uint32
+	subIDEnabled   bool
+	subIDAvailable atomic.Bool
+
+	producerTracer *tracing.Tracer
+	consumerTracer *tracing.Tracer
+
+	log *log.Helper
+}
+
+// NewBroker 创建 MQTT 5.0 的 broker，地址支持 tcp、mqtt、ssl、tls、mqtts、ws 和 wss，省略协议时使用 tcp
+func NewBroker(opts ...broker.Option) broker.Broker {
+	options := broker.NewOptionsAndApply(opts...)
+
+	b := &mqttBroker{
+		options:     options,
+		subscribers: broker.NewSubscriberSyncMap(),
+	}
+	b.subIDAvailable.Store(true)
+	b.init()
+
+	return b
+}
+
+func (b *mqttBroker) init() {
+	b.log = newLoggerHelper(b.options.Logger)
+
+	b.addrs = setAddrs(b.options.Addrs)
+
+	b.clientId = generateClientId()
+	if v, ok := b.options.Context.Value(clientIdKey{}).(string); ok && v != "" {
+		b.clientId = v
+	}
+
+	b.connectTimeout = DefaultConnectTimeout
+	if v, ok := b.options.Context.Value(connectTimeoutKey{}).(time.Duration); ok && v > 0 {
+		b.connectTimeout = v
+	}
+
+	var aliasMaximum uint16
+	if v, ok := b.options.Context.Value(topicAliasMaximumKey{}).(uint16); ok {
+		aliasMaximum = v
+	}
+	b.aliases = newTopicAliases(aliasMaximum)
+
+	prefix := DefaultResponseTopicPrefix
+	if v, ok := b.options.Context.Value(responseTopicPrefixKey{}).(string); ok && v != "" {
+		prefix = v
+	}
+	b.responses = newResponseRouter(prefix + "/" + b.clientId)
+
+	b.subIDEnabled = true
+	if v, ok := b.options.Context.Value(subscriptionIdentifiersKey{}).(bool); ok {
+		b.subIDEnabled = v
+	}
+
+	b.producerTracer = nil
+	b.consumerTracer = nil
+	if len(b.options.Tracings) > 0 {
+		b.producerTracer = tracing.NewTracer(trace.SpanKindProducer, "mqtt5-producer", b.options.Tracings...)
+		b.consumerTracer = tracing.NewTracer(trace.SpanKindConsumer, "mqtt5-consumer", b.options.Tracings...)
+	}
+}
+
+func (b *mqttBroker) Name() string {
+	return "mqtt5"
+}
+
+func (b *mqttBroker) Options() broker.Options {
+	return b.options
+}
+
+func (b *mqttBroker) Address() string {
+	return strings.Join(b.addrs, ",")
+}
+
+func (b *mqttBroker) Init(opts ...broker.Option) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.cm != nil {
+		return errors.New("cannot init while connected")
+	}
+
+	for _, o := range opts {
+		o(&b.options)
+	}
+
+	b.init()
+
+	return nil
+}
+
+// connection 返回连接管理器，未连接时返回 nil
+func (b *mqttBroker) connection() *autopaho.ConnectionManager {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.cm
+}
+
+func (b *mqttBroker) clientConfig() (autopaho.ClientConfig, error) {
+	var urls []*url.URL
+	for _, addr := range b.addrs {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return autopaho.ClientConfig{}, fmt.Errorf("mqtt5: invalid address %q: %w", addr, err)
+		}
+		// 启用 TLS 时未加密的地址改用 tls 连接
+		if b.options.Secure || b.options.TLSConfig != nil {
+			switch u.Scheme {
+			case "tcp", "mqtt":
+				u.Scheme = "tls"
+			case "ws":
+				u.Scheme = "wss"
+			}
+		}
+		urls = append(urls, u)
+	}
+
+	cfg := autopaho.ClientConfig{
+		ServerUrls:                    urls,
+		TlsCfg:                        b.options.TLSConfig,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: true,
+		ConnectTimeout:                b.connectTimeout,
+		OnConnectionUp:                b.onConnectionUp,
+		OnConnectError:                b.onConnectError,
+		ClientConfig: paho.ClientConfig{
+			ClientID: b.clientId,
+			OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+				b.onPublishReceived,
+			},
+			OnClientError:      b.onClientError,
+			OnServerDisconnect: b.onServerDisconnect,
+		},
+	}
+
+	if auth, ok := b.options.Context.Value(authKey{}).(*AuthRecord); ok && auth != nil {
+		cfg.ConnectUsername = auth.Username
+		cfg.ConnectPassword = []byte(auth.Password)
+	}
+	if v, ok := b.options.Context.Value(cleanStartKey{}).(bool); ok {
+		cfg.CleanStartOnInitialConnection = v
+	}
+	if v, ok := b.options.Context.Value(sessionExpiryIntervalKey{}).(time.Duration); ok {
+		cfg.SessionExpiryInterval = uint32(v / time.Second)
+	}
+	if v, ok := b.options.Context.Value(keepAliveKey{}).(time.Duration); ok {
+		cfg.KeepAlive = uint16(v / time.Second)
+	}
+
+	return cfg, nil
+}
+
+// Connect 建立连接并等待第一次连接成功，之后连接断开时自动重连并恢复订阅
+func (b *mqttBroker) Connect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.cm != nil {
+		return nil
+	}
+
+	cfg, err := b.clientConfig()
+	if err != nil {
+		return err
+	}
+
+	b.setConnectError(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cm, err := autopaho.NewConnection(ctx, cfg)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, b.connectTimeout)
+	defer waitCancel()
+
+	if err = cm.AwaitConnection(waitCtx); err != nil {
+		cancel()
+		<-cm.Done()
+		if connectErr := b.connectError(); connectErr != nil {
+			return connectErr
+		}
+		return err
+	}
+
+	b.cm = cm
+	b.cancel = cancel
+
+	return nil
+}
+
+func (b *mqttBroker) Disconnect() error {
+	b.Lock()
+	cm, cancel := b.cm, b.cancel
+	b.Unlock()
+
+	if cm == nil {
+		return nil
+	}
+
+	b.subscribers.Clear()
+
+	ctx, waitCancel := context.WithTimeout(context.Background(), b.connectTimeout)
+	defer waitCancel()
+
+	err := cm.Disconnect(ctx)
+	cancel()
+
+	b.Lock()
+	b.cm = nil
+	b.cancel = nil
+	b.Unlock()
+
+	b.responses.Lock()
+	b.responses.subscribed = false
+	b.responses.Unlock()
+
+	return err
+}
+
+func (b *mqttBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	buf, err := broker.Marshal(b.options.Codec, msg)
+	if err != nil {
+		return err
+	}
+
+	return b.publish(ctx, topic, buf, opts...)
+}
+
+func (b *mqttBroker) publish(ctx context.Context, topic string, buf []byte, opts ...broker.PublishOption) error {
+	options := broker.PublishOptions{
+		Context: ctx,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	packet := &paho.Publish{
+		QoS:        1,
+		Topic:      topic,
+		Payload:    buf,
+		Properties: &paho.PublishProperties{},
+	}
+
+	if v, ok := options.Context.Value(qosPublishKey{}).(byte); ok {
+		packet.QoS = v
+	}
+	if v, ok := options.Context.Value(retainedPublishKey{}).(bool); ok {
+		packet.Retain = v
+	}
+	if v, ok := options.Context.Value(messageExpiryPublishKey{}).(time.Duration); ok && v > 0 {
+		expiry := uint32((v + time.Second - 1) / time.Second)
+		packet.Properties.MessageExpiry = &expiry
+	}
+	if v, ok := options.Context.Value(contentTypePublishKey{}).(string); ok {
+		packet.Properties.ContentType = v
+	}
+	if v, ok := options.Context.Value(responseTopicPublishKey{}).(string); ok {
+		packet.Properties.ResponseTopic = v
+	}
+	if v, ok := options.Context.Value(correlationDataPublishKey{}).([]byte); ok {
+		packet.Properties.CorrelationData = v
+	}
+
+	headers := make(map[string]string)
+	if v, ok := options.Context.Value(publishHeadersKey{}).(map[string]string); ok {
+		for k, val := range v {
+			headers[k] = val
+		}
+	}
+
+	span := b.startProducerSpan(options.Context, topic, headers)
+
+	packet.Properties.User = headersToUserProperties(headers)
+	err := b.publishPacket(ctx, packet)
+
+	b.finishProducerSpan(span, err)
+
+	return err
+}
+
+// publishPacket 发布消息，服务端返回失败的原因码时返回 *ReasonCodeError
+func (b *mqttBroker) publishPacket(ctx context.Context, packet *paho.Publish) error {
+	cm := b.connection()
+	if cm == nil {
+		return errors.New("not connected")
+	}
+
+	// QoS 1 和 2 的消息可能由会话重发，重发时不会处理别名，因此只为 QoS 0 的消息使用主题别名
+	if packet.QoS == 0 {
+		b.aliases.Lock()
+		defer b.aliases.Unlock()
+
+		b.aliases.apply(packet)
+	}
+
+	return publishError(cm.Publish(ctx, packet))
+}
+
+// Subscribe 订阅主题，SubscribeOptions.Queue 不为空时使用共享订阅 $share/<queue>/<topic>
+func (b *mqttBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	cm := b.connection()
+	if cm == nil {
+		return nil, errors.New("not connected")
+	}
+
+	options := broker.SubscribeOptions{
+		Context: context.Background(),
+		AutoAck: true,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	headerFilter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscriber{
+		b:            b,
+		id:           b.newSubscriptionID(),
+		topic:        topic,
+		filter:       topic,
+		qos:          1,
+		handler:      handler,
+		binder:       binder,
+		headerFilter: headerFilter,
+		options:      options,
+	}
+	if options.Queue != "" {
+		sub.filter = sharedTopic(options.Queue, topic)
+	}
+	if v, ok := options.Context.Value(qosSubscribeKey{}).(byte); ok {
+		sub.qos = v
+	}
+	if v, ok := options.Context.Value(noLocalSubscribeKey{}).(bool); ok {
+		sub.noLocal = v
+	}
+	if v, ok := options.Context.Value(retainHandlingSubscribeKey{}).(byte); ok {
+		sub.retainHandling = v
+	}
+
+	// 先登记订阅者，订阅成功后立即到达的保留消息才能被分发
+	b.subscribers.Add(sub.filter, sub)
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.connectTimeout)
+	defer cancel()
+
+	withID := b.useSubscriptionID()
+	suback, err := cm.Subscribe(ctx, sub.subscribePacket(withID))
+	if errors.Is(err, paho.ErrInvalidArguments) && withID {
+		// 连接建立的回调还没有更新服务端能力时，不支持订阅标识符的服务端会被客户端拒绝，改为按主题分发
+		b.subIDAvailable.Store(false)
+		suback, err = cm.Subscribe(ctx, sub.subscribePacket(false))
+	}
+	if err = subscribeError(suback, err); err != nil {
+		_ = b.subscribers.RemoveOnly(sub.filter)
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// useSubscriptionID 订阅时是否带上订阅标识符
+func (b *mqttBroker) useSubscriptionID() bool {
+	return b.subIDEnabled && b.subIDAvailable.Load()
+}
+
+// newSubscriptionID 分配订阅标识符，取值范围为 1 ~ 268435455
+func (b *mqttBroker) newSubscriptionID() int {
+	for {
+		if id := int(b.nextSubID.Add(1) % maxSubscriptionID); id != 0 {
+			return id
+		}
+	}
+}
+
+// onPublishReceived 将收到的消息分发给应答等待者或主题匹配的订阅者
+func (b *mqttBroker) onPublishReceived(pr paho.PublishReceived) (bool, error) {
+	packet := pr.Packet
+
+	if b.responses.deliver(packet) {
+		return true, nil
+	}
+
+	subs := b.dispatchTargets(packet)
+
+	for _, s := range subs {
+		if !s.IsClosed() {
+			s.onMessage(packet)
+		}
+	}
+
+	return len(subs) > 0, nil
+}
+
+// dispatchTargets 消息带有订阅标识符时只分发给该标识符对应的订阅者，重叠的订阅各自收到服务端发送的那一份，不会重复分发；
+// 没有订阅标识符或标识符未知时按主题匹配。
+// 服务端将重叠的订阅合并为一条消息时会带上多个标识符，paho 只保留最后一个，这类服务端需要通过 WithSubscriptionIdentifiers 关闭订阅标识符。
+func (b *mqttBroker) dispatchTargets(packet *paho.Publish) []*subscriber {
+	var id int
+	if packet.Properties != nil && packet.Properties.SubscriptionIdentifier != nil {
+		id = *packet.Properties.SubscriptionIdentifier
+	}
+
+	var byID, byTopic []*subscriber
+	b.subscribers.Foreach(func(_ string, sub broker.Subscriber) {
+		s, ok := sub.(*subscriber)
+		if !ok {
+			return
+		}
+		if id > 0 && s.id == id {
+			byID = append(byID, s)
+		}
+		if s.match(packet.Topic) {
+			byTopic = append(byTopic, s)
+		}
+	})
+
+	if len(byID) > 0 {
+		return byID
+	}
+	return byTopic
+}
+
+// onConnectionUp 连接建立后重置主题别名并恢复订阅
+func (b *mqttBroker) onConnectionUp(cm *autopaho.ConnectionManager, connack *paho.Connack) {
+	b.log.Debug("on connect")
+
+	var serverAliasMaximum uint16
+	if connack.Properties != nil && connack.Properties.TopicAliasMaximum != nil {
+		serverAliasMaximum = *connack.Properties.TopicAliasMaximum
+	}
+	b.aliases.reset(serverAliasMaximum)
+
+	b.subIDAvailable.Store(connack.Properties == nil || connack.Properties.SubIDAvailable)
+	withID := b.useSubscriptionID()
+
+	// 订阅标识符属于整个订阅请求，支持时每个订阅单独发送
+	var requests []*paho.Subscribe
+	var subscriptions []paho.SubscribeOptions
+	b.subscribers.Foreach(func(_ string, sub broker.Subscriber) {
+		s, ok := sub.(*subscriber)
+		if !ok {
+			return
+		}
+		if withID {
+			requests = append(requests, s.subscribePacket(true))
+		} else {
+			subscriptions = append(subscriptions, s.subscription())
+		}
+	})
+
+	b.responses.Lock()
+	if b.responses.subscribed {
+		subscriptions = append(subscriptions, paho.SubscribeOptions{Topic: b.responses.topic, QoS: 1})
+	}
+	b.responses.Unlock()
+
+	if len(subscriptions) > 0 {
+		requests = append(requests, &paho.Subscribe{Subscriptions: subscriptions})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.connectTimeout)
+	defer cancel()
+
+	for _, request := range requests {
+		if err := subscribeError(cm.Subscribe(ctx, request)); err != nil {
+			b.log.Errorf("resubscribe %s failed: %v", request.Subscriptions[0].Topic, err)
+		}
+	}
+}
+
+func (b *mqttBroker) onConnectError(err error) {
+	err = connectError(err)
+	b.setConnectError(err)
+
+	b.log.Errorf("connect error: %v", err)
+}
+
+// setConnectError Connect 等待连接时持有 broker 的锁，连接错误使用单独的锁
+func (b *mqttBroker) setConnectError(err error) {
+	b.connectErrMu.Lock()
+	defer b.connectErrMu.Unlock()
+
+	b.lastConnectErr = err
+}
+
+func (b *mqttBroker) connectError() error {
+	b.connectErrMu.Lock()
+	defer b.connectErrMu.Unlock()
+
+	return b.lastConnectErr
+}
+
+func (b *mqttBroker) onClientError(err error) {
+	b.log.Errorf("client error: %v", err)
+}
+
+func (b *mqttBroker) onServerDisconnect(d *paho.Disconnect) {
+	e := &ReasonCodeError{Code: d.ReasonCode}
+	if d.Properties != nil {
+		e.Reason = d.Properties.ReasonString
+	}
+	b.log.Warnf("server disconnect: %v", e)
+}
+
+// startProducerSpan 将链路追踪上下文写入 headers，随消息头作为用户属性发送
+func (b *mqttBroker) startProducerSpan(ctx context.Context, topic string, headers map[string]string) trace.Span {
+	if b.producerTracer == nil {
+		return nil
+	}
+
+	carrier := NewMessageCarrier(headers)
+
+	attrs := []attribute.KeyValue{
+		semConv.MessagingSystemKey.String("mqtt"),
+		semConv.MessagingProtocolKey.String("mqtt"),
+		semConv.MessagingProtocolVersionKey.String("5.0"),
+		semConv.MessagingDestinationKindTopic,
+		semConv.MessagingDestinationKey.String(topic),
+	}
+
+	var span trace.Span
+	ctx, span = b.producerTracer.Start(ctx, carrier, attrs...)
+
+	return span
+}
+
+func (b *mqttBroker) finishProducerSpan(span trace.Span, err error) {
+	if b.producerTracer == nil {
+		return
+	}
+
+	b.producerTracer.End(context.Background(), span, err)
+}
+
+func (b *mqttBroker) startConsumerSpan(ctx context.Context, topic string, headers map[string]string) (context.Context, trace.Span) {
+	if b.consumerTracer == nil {
+		return ctx, nil
+	}
+
+	carrier := NewMessageCarrier(headers)
+
+	attrs := []attribute.KeyValue{
+		semConv.MessagingSystemKey.String("mqtt"),
+		semConv.MessagingProtocolKey.String("mqtt"),
+		semConv.MessagingProtocolVersionKey.String("5.0"),
+		semConv.MessagingDestinationKindTopic,
+		semConv.MessagingDestinationKey.String(topic),
+		semConv.MessagingOperationReceive,
+	}
+
+	var span trace.Span
+	ctx, span = b.consumerTracer.Start(ctx, carrier, attrs...)
+
+	return ctx, span
+}
+
+func (b *mqttBroker) finishConsumerSpan(span trace.Span, err error) {
+	if b.consumerTracer == nil {
+		return
+	}
+
+	b.consumerTracer.End(context.Background(), span, err)
+}
+
+// setAddrs 省略协议的地址使用 tcp，没有地址时使用本机的 1883 端口
+func setAddrs(addrs []string) []string {
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !strings.Contains(addr, "://") {
+			addr = "tcp://" + addr
+		}
+		out = append(out, addr)
+	}
+	if len(out) == 0 {
+		out = []string{defaultAddr}
+	}
+	return out
+}
+
+// generateClientId 生成随机的客户端 ID
+func generateClientId() string {
+	return fmt.Sprintf("%s_%s", clientIdPrefix, uuid.New().String())
+}
+
+// SetClientIdPrefix 设置随机客户端 ID 的前缀
+func SetClientIdPrefix(prefix string) {
+	clientIdPrefix = prefix
+}
@@ -0,0 +1,444 @@
+package mqttv5
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// startServer 启动内嵌的 MQTT 服务端，用户 limited 不能向 denied/# 发布，用户 blocked 不能连接
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	server := mochi.New(&mochi.Options{InlineClient: true})
+	require.NoError(t, server.AddHook(new(auth.Hook), &auth.Options{
+		Ledger: &auth.Ledger{
+			Users: auth.Users{
+				"limited": {Username: "limited", Password: "secret", ACL: auth.Filters{"denied/#": auth.Deny}},
+			},
+			Auth: auth.AuthRules{{Username: "blocked", Allow: false}, {Allow: true}},
+			ACL:  auth.ACLRules{{}},
+		},
+	}))
+	require.NoError(t, server.AddListener(listeners.NewTCP(listeners.Config{Type: "tcp", ID: "t1", Address: addr})))
+	require.NoError(t, server.Serve())
+
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	return "tcp://" + addr
+}
+
+func newTestBroker(t *testing.T, addr string, opts ...broker.Option) broker.Broker {
+	t.Helper()
+
+	b := NewBroker(append([]broker.Option{broker.WithAddress(addr), WithConnectTimeout(3 * time.Second)}, opts...)...)
+	require.NoError(t, b.Connect())
+	t.Cleanup(func() {
+		_ = b.Disconnect()
+	})
+	return b
+}
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/#", "a", true},
+		{"a/#", "a/b/c", true},
+		{"+/b", "a/b", true},
+		{"#", "a/b", true},
+		{"#", "$SYS/a", false},
+		{"+/a", "$SYS/a", false},
+		{"$SYS/#", "$SYS/a", true},
+		{"a/b", "a/c", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchTopic(tt.filter, tt.topic), "%s %s", tt.filter, tt.topic)
+	}
+}
+
+func TestTopicAliases(t *testing.T) {
+	a := newTopicAliases(2)
+
+	// 连接建立前不使用别名
+	p := &paho.Publish{Topic: "a"}
+	a.apply(p)
+	assert.Nil(t, p.Properties)
+
+	a.reset(10)
+
+	p = &paho.Publish{Topic: "a"}
+	a.apply(p)
+	assert.Equal(t, "a", p.Topic)
+	assert.Equal(t, uint16(1), *p.Properties.TopicAlias)
+
+	p = &paho.Publish{Topic: "a"}
+	a.apply(p)
+	assert.Equal(t, "", p.Topic)
+	assert.Equal(t, uint16(1), *p.Properties.TopicAlias)
+
+	p = &paho.Publish{Topic: "b"}
+	a.apply(p)
+	assert.Equal(t, uint16(2), *p.Properties.TopicAlias)
+
+	// 别名已用完
+	p = &paho.Publish{Topic: "c"}
+	a.apply(p)
+	assert.Equal(t, "c", p.Topic)
+	assert.Nil(t, p.Properties)
+
+	// 服务端的上限更小
+	a.reset(1)
+	p = &paho.Publish{Topic: "b"}
+	a.apply(p)
+	assert.Equal(t, uint16(1), *p.Properties.TopicAlias)
+	p = &paho.Publish{Topic: "a"}
+	a.apply(p)
+	assert.Nil(t, p.Properties)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	addr := startServer(t)
+	b := newTestBroker(t, addr)
+
+	received := make(chan broker.Event, 1)
+	_, err := b.Subscribe("sensors/+/temperature", func(_ context.Context, evt broker.Event) error {
+		received <- evt
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	err = b.Publish(context.Background(), "sensors/s1/temperature", []byte("21.5"),
+		WithHeaders(map[string]string{"unit": "celsius"}),
+		WithContentType("text/plain"),
+		WithMessageExpiry(time.Minute),
+	)
+	require.NoError(t, err)
+
+	select {
+	case evt := <-received:
+		assert.Equal(t, "sensors/s1/temperature", evt.Topic())
+		assert.Equal(t, []byte("21.5"), evt.Message().Body)
+		assert.Equal(t, "celsius", evt.Message().GetHeader("unit"))
+
+		packet, ok := evt.RawMessage().(*paho.Publish)
+		require.True(t, ok)
+		assert.Equal(t, "text/plain", packet.Properties.ContentType)
+		require.NotNil(t, packet.Properties.MessageExpiry)
+		assert.LessOrEqual(t, *packet.Properties.MessageExpiry, uint32(60))
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestSubscribeFilter(t *testing.T) {
+	addr := startServer(t)
+	b := newTestBroker(t, addr)
+
+	received := make(chan string, 2)
+	_, err := b.Subscribe("orders", func(_ context.Context, evt broker.Event) error {
+		received <- string(evt.Message().Body.([]byte))
+		return nil
+	}, nil, broker.WithFilter("region = 'eu'"))
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(context.Background(), "orders", "us", WithHeaders(map[string]string{"region": "us"})))
+	require.NoError(t, b.Publish(context.Background(), "orders", "eu", WithHeaders(map[string]string{"region": "eu"})))
+
+	select {
+	case body := <-received:
+		assert.Equal(t, "eu", body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestSharedSubscription(t *testing.T) {
+	addr := startServer(t)
+	publisher := newTestBroker(t, addr)
+
+	const count = 20
+
+	var total int32
+	var wg sync.WaitGroup
+	wg.Add(count)
+
+	counts := make([]int32, 2)
+	for i := range counts {
+		i := i
+		b := newTestBroker(t, addr)
+		_, err := b.Subscribe("jobs", func(_ context.Context, _ broker.Event) error {
+			atomic.AddInt32(&counts[i], 1)
+			if atomic.AddInt32(&total, 1) <= count {
+				wg.Done()
+			}
+			return nil
+		}, nil, broker.WithQueueName("workers"))
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < count; i++ {
+		require.NoError(t, publisher.Publish(context.Background(), "jobs", fmt.Sprintf("job-%d", i)))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("messages not received")
+	}
+
+	// 每条消息只投递给共享组中的一个订阅者
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(count), atomic.LoadInt32(&total))
+	assert.Equal(t, int32(count), atomic.LoadInt32(&counts[0])+atomic.LoadInt32(&counts[1]))
+}
+
+func TestRequestReply(t *testing.T) {
+	addr := startServer(t)
+	server := newTestBroker(t, addr)
+	client := newTestBroker(t, addr)
+
+	_, err := server.Subscribe("rpc/echo", func(ctx context.Context, evt broker.Event) error {
+		body := evt.Message().Body.([]byte)
+		return Reply(ctx, server, evt, append([]byte("echo: "), body...),
+			WithHeaders(map[string]string{"trace": evt.Message().GetHeader("trace")}))
+	}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Request(context.Background(), "rpc/echo", fmt.Sprintf("hello %d", i),
+			WithRequestTimeout(3*time.Second),
+			WithRequestHeaders(map[string]string{"trace": fmt.Sprintf("t%d", i)}),
+		)
+		require.NoError(t, err)
+
+		msg, ok := resp.(*broker.Message)
+		require.True(t, ok)
+		assert.Equal(t, []byte(fmt.Sprintf("echo: hello %d", i)), msg.Body)
+		assert.Equal(t, fmt.Sprintf("t%d", i), msg.GetHeader("trace"))
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	addr := startServer(t)
+	client := newTestBroker(t, addr)
+
+	_, err := client.Request(context.Background(), "rpc/nobody", "ping", WithRequestTimeout(200*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestReplyWithoutResponseTopic(t *testing.T) {
+	addr := startServer(t)
+	b := newTestBroker(t, addr)
+
+	errs := make(chan error, 1)
+	_, err := b.Subscribe("plain", func(ctx context.Context, evt broker.Event) error {
+		errs <- Reply(ctx, b, evt, "pong")
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(context.Background(), "plain", "ping"))
+
+	select {
+	case err = <-errs:
+		assert.ErrorIs(t, err, ErrNoResponseTopic)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestTopicAliasPublish(t *testing.T) {
+	addr := startServer(t)
+	b := newTestBroker(t, addr, WithTopicAliasMaximum(4))
+
+	const count = 5
+	received := make(chan string, count)
+	_, err := b.Subscribe("alias/topic", func(_ context.Context, evt broker.Event) error {
+		received <- evt.Topic()
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < count; i++ {
+		require.NoError(t, b.Publish(context.Background(), "alias/topic", "v", WithPublishQos(0)))
+	}
+
+	for i := 0; i < count; i++ {
+		select {
+		case topic := <-received:
+			assert.Equal(t, "alias/topic", topic)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestReasonCodeErrors(t *testing.T) {
+	addr := startServer(t)
+
+	b := newTestBroker(t, addr, WithAuth("limited", "secret"))
+
+	err := b.Publish(context.Background(), "denied/topic", "x", WithPublishQos(1))
+	var rce *ReasonCodeError
+	require.True(t, errors.As(err, &rce), "unexpected error: %v", err)
+	assert.Equal(t, byte(0x87), rce.Code)
+
+	err = b.Publish(context.Background(), "denied/topic", "x", WithPublishQos(2))
+	require.True(t, errors.As(err, &rce), "unexpected error: %v", err)
+	assert.Equal(t, byte(0x87), rce.Code)
+
+	assert.NoError(t, b.Publish(context.Background(), "allowed/topic", "x"))
+
+	blocked := NewBroker(broker.WithAddress(addr), WithAuth("blocked", ""), WithConnectTimeout(time.Second))
+	err = blocked.Connect()
+	if err == nil {
+		_ = blocked.Disconnect()
+	}
+	require.True(t, errors.As(err, &rce), "unexpected error: %v", err)
+	assert.GreaterOrEqual(t, rce.Code, byte(0x80))
+}
+
+func TestDeadLetter(t *testing.T) {
+	addr := startServer(t)
+	b := newTestBroker(t, addr, broker.WithErrorHandler(func(context.Context, broker.Event, broker.ErrorStage, error) broker.ErrorAction {
+		return broker.ErrorActionDeadLetter
+	}))
+
+	dead := make(chan broker.Event, 1)
+	_, err := b.Subscribe("work/a/run"+broker.DefaultDeadLetterSuffix, func(_ context.Context, evt broker.Event) error {
+		dead <- evt
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = b.Subscribe("work/+/run", func(context.Context, broker.Event) error {
+		return errors.New("boom")
+	}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(context.Background(), "work/a/run", "payload"))
+
+	select {
+	case evt := <-dead:
+		assert.Equal(t, "work/a/run"+broker.DefaultDeadLetterSuffix, evt.Topic())
+		assert.Equal(t, "work/a/run", evt.Message().GetHeader(broker.HeaderDeadLetterOriginalTopic))
+		assert.Equal(t, "boom", evt.Message().GetHeader(broker.HeaderDeadLetterError))
+	case <-time.After(5 * time.Second):
+		t.Fatal("dead letter not received")
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	addr := startServer(t)
+	b := newTestBroker(t, addr,
+		broker.WithTracerProvider(sdkTrace.NewTracerProvider(), "mqtt5-test"),
+		broker.WithPropagator(propagation.TraceContext{}),
+	)
+
+	traceIds := make(chan trace.TraceID, 1)
+	_, err := b.Subscribe("traced", func(ctx context.Context, evt broker.Event) error {
+		assert.NotEmpty(t, evt.Message().GetHeader("traceparent"))
+		traceIds <- trace.SpanContextFromContext(ctx).TraceID()
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	tracer := sdkTrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(context.Background(), "parent")
+	require.NoError(t, b.Publish(ctx, "traced", "x"))
+	span.End()
+
+	select {
+	case traceId := <-traceIds:
+		assert.Equal(t, span.SpanContext().TraceID(), traceId)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestDispatchTargets(t *testing.T) {
+	b := NewBroker().(*mqttBroker)
+
+	wildcard := &subscriber{b: b, id: b.newSubscriptionID(), topic: "sensors/+/humidity", filter: "sensors/+/humidity"}
+	exact := &subscriber{b: b, id: b.newSubscriptionID(), topic: "sensors/s1/humidity", filter: "sensors/s1/humidity"}
+	b.subscribers.Add(wildcard.filter, wildcard)
+	b.subscribers.Add(exact.filter, exact)
+
+	withID := func(id int) *paho.Publish {
+		return &paho.Publish{Topic: "sensors/s1/humidity", Properties: &paho.PublishProperties{SubscriptionIdentifier: &id}}
+	}
+
+	// 服务端为每个订阅各发一份，每一份只分发给对应的订阅者
+	assert.Equal(t, []*subscriber{wildcard}, b.dispatchTargets(withID(wildcard.id)))
+	assert.Equal(t, []*subscriber{exact}, b.dispatchTargets(withID(exact.id)))
+
+	// 没有或未知的订阅标识符按主题分发
+	assert.ElementsMatch(t, []*subscriber{wildcard, exact}, b.dispatchTargets(&paho.Publish{Topic: "sensors/s1/humidity"}))
+	assert.ElementsMatch(t, []*subscriber{wildcard, exact}, b.dispatchTargets(withID(exact.id+100)))
+	assert.Equal(t, []*subscriber{wildcard}, b.dispatchTargets(&paho.Publish{Topic: "sensors/s2/humidity"}))
+}
+
+func TestOverlappingSubscriptions(t *testing.T) {
+	addr := startServer(t)
+	// mochi 将重叠的订阅合并为一条消息，关闭订阅标识符按主题分发
+	b := newTestBroker(t, addr, WithSubscriptionIdentifiers(false))
+
+	var wildcard, exact atomic.Int32
+	_, err := b.Subscribe("sensors/+/humidity", func(context.Context, broker.Event) error {
+		wildcard.Add(1)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	_, err = b.Subscribe("sensors/s1/humidity", func(context.Context, broker.Event) error {
+		exact.Add(1)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(context.Background(), "sensors/s1/humidity", []byte("40")))
+	require.NoError(t, b.Publish(context.Background(), "sensors/s2/humidity", []byte("41")))
+
+	assert.Eventually(t, func() bool {
+		return wildcard.Load() == 2 && exact.Load() == 1
+	}, 3*time.Second, 50*time.Millisecond)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(2), wildcard.Load())
+	assert.Equal(t, int32(1), exact.Load())
+}
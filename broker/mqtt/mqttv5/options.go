@@ -0,0 +1,172 @@
+package mqttv5
+
+import (
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	// DefaultResponseTopicPrefix 请求应答使用的应答主题前缀，完整的应答主题为 <prefix>/<client id>
+	DefaultResponseTopicPrefix = "kratos/response"
+	// DefaultRequestTimeout 请求的默认超时时间，ctx 带有截止时间时以 ctx 为准
+	DefaultRequestTimeout = 5 * time.Second
+	// DefaultConnectTimeout Connect 等待连接建立的默认时间
+	DefaultConnectTimeout = 10 * time.Second
+)
+
+///
+/// Option
+///
+
+type authKey struct{}
+type clientIdKey struct{}
+type cleanStartKey struct{}
+type sessionExpiryIntervalKey struct{}
+type keepAliveKey struct{}
+type connectTimeoutKey struct{}
+type topicAliasMaximumKey struct{}
+type responseTopicPrefixKey struct{}
+type subscriptionIdentifiersKey struct{}
+
+type AuthRecord struct {
+	Username string
+	Password string
+}
+
+// WithAuth 设置用户名和密码
+func WithAuth(username string, password string) broker.Option {
+	return broker.OptionContextWithValue(authKey{}, &AuthRecord{
+		Username: username,
+		Password: password,
+	})
+}
+
+// WithClientId 设置客户端 ID，默认随机生成
+func WithClientId(clientId string) broker.Option {
+	return broker.OptionContextWithValue(clientIdKey{}, clientId)
+}
+
+// WithCleanStart 首次连接时是否清除服务端保存的会话，默认 true
+func WithCleanStart(enable bool) broker.Option {
+	return broker.OptionContextWithValue(cleanStartKey{}, enable)
+}
+
+// WithSessionExpiryInterval 连接断开后服务端保留会话的时间，默认 0，即连接断开时会话结束
+func WithSessionExpiryInterval(d time.Duration) broker.Option {
+	return broker.OptionContextWithValue(sessionExpiryIntervalKey{}, d)
+}
+
+// WithKeepAlive 心跳间隔，默认 30 秒
+func WithKeepAlive(d time.Duration) broker.Option {
+	return broker.OptionContextWithValue(keepAliveKey{}, d)
+}
+
+// WithConnectTimeout 单次连接尝试以及 Connect 等待连接建立的超时时间，默认 10 秒
+func WithConnectTimeout(d time.Duration) broker.Option {
+	return broker.OptionContextWithValue(connectTimeoutKey{}, d)
+}
+
+// WithTopicAliasMaximum 发布时最多使用的主题别名数量，实际数量不超过服务端在 CONNACK 中声明的上限，默认 0，即不使用主题别名。
+// 主题别名只用于 QoS 0 的消息。
+func WithTopicAliasMaximum(n uint16) broker.Option {
+	return broker.OptionContextWithValue(topicAliasMaximumKey{}, n)
+}
+
+// WithResponseTopicPrefix 请求应答使用的应答主题前缀，默认 DefaultResponseTopicPrefix
+func WithResponseTopicPrefix(prefix string) broker.Option {
+	return broker.OptionContextWithValue(responseTopicPrefixKey{}, prefix)
+}
+
+// WithSubscriptionIdentifiers 是否为订阅带上订阅标识符并按标识符分发消息，默认 true，服务端不支持时按主题分发。
+// 服务端将重叠的订阅合并为一条消息时（例如 mochi、RabbitMQ），应关闭此选项，按主题分发。
+func WithSubscriptionIdentifiers(enable bool) broker.Option {
+	return broker.OptionContextWithValue(subscriptionIdentifiersKey{}, enable)
+}
+
+///
+/// SubscribeOption
+///
+
+type qosSubscribeKey struct{}
+type noLocalSubscribeKey struct{}
+type retainHandlingSubscribeKey struct{}
+
+// WithSubscribeQos 订阅的最大 QoS，默认 1
+func WithSubscribeQos(qos byte) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(qosSubscribeKey{}, qos)
+}
+
+// WithNoLocal 不接收本客户端发布的消息，共享订阅不支持该选项
+func WithNoLocal(enable bool) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(noLocalSubscribeKey{}, enable)
+}
+
+// WithRetainHandling 保留消息的发送方式：0 订阅时发送，1 仅在新建订阅时发送，2 不发送
+func WithRetainHandling(handling byte) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(retainHandlingSubscribeKey{}, handling)
+}
+
+///
+/// PublishOption
+///
+
+type qosPublishKey struct{}
+type retainedPublishKey struct{}
+type publishHeadersKey struct{}
+type messageExpiryPublishKey struct{}
+type contentTypePublishKey struct{}
+type responseTopicPublishKey struct{}
+type correlationDataPublishKey struct{}
+
+// WithPublishQos 发布的 QoS，默认 1
+func WithPublishQos(qos byte) broker.PublishOption {
+	return broker.PublishContextWithValue(qosPublishKey{}, qos)
+}
+
+// WithPublishRetained 是否作为保留消息发布
+func WithPublishRetained(retained bool) broker.PublishOption {
+	return broker.PublishContextWithValue(retainedPublishKey{}, retained)
+}
+
+// WithHeaders 消息头，作为用户属性（User Property）发布
+func WithHeaders(headers map[string]string) broker.PublishOption {
+	return broker.PublishContextWithValue(publishHeadersKey{}, headers)
+}
+
+// WithMessageExpiry 消息的过期时间，精确到秒，服务端不会投递已经过期的消息
+func WithMessageExpiry(d time.Duration) broker.PublishOption {
+	return broker.PublishContextWithValue(messageExpiryPublishKey{}, d)
+}
+
+// WithContentType 消息的内容类型（Content Type）
+func WithContentType(contentType string) broker.PublishOption {
+	return broker.PublishContextWithValue(contentTypePublishKey{}, contentType)
+}
+
+// WithResponseTopic 消息的应答主题（Response Topic）
+func WithResponseTopic(topic string) broker.PublishOption {
+	return broker.PublishContextWithValue(responseTopicPublishKey{}, topic)
+}
+
+// WithCorrelationData 消息的关联数据（Correlation Data），应答方原样带回
+func WithCorrelationData(data []byte) broker.PublishOption {
+	return broker.PublishContextWithValue(correlationDataPublishKey{}, data)
+}
+
+///
+/// RequestOption
+///
+
+type requestTimeoutKey struct{}
+type requestHeadersKey struct{}
+
+// WithRequestTimeout 请求的超时时间，ctx 没有截止时间时生效，默认 DefaultRequestTimeout
+func WithRequestTimeout(timeout time.Duration) broker.RequestOption {
+	return broker.RequestContextWithValue(requestTimeoutKey{}, timeout)
+}
+
+// WithRequestHeaders 请求消息的消息头
+func WithRequestHeaders(headers map[string]string) broker.RequestOption {
+	return broker.RequestContextWithValue(requestHeadersKey{}, headers)
+}
@@ -0,0 +1,35 @@
+package mqttv5
+
+import (
+	"github.com/eclipse/paho.golang/paho"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type publication struct {
+	topic  string
+	msg    *broker.Message
+	packet *paho.Publish
+	err    error
+}
+
+// Ack 消息在处理函数返回后由客户端自动确认
+func (p *publication) Ack() error {
+	return nil
+}
+
+func (p *publication) Error() error {
+	return p.err
+}
+
+func (p *publication) Topic() string {
+	return p.topic
+}
+
+func (p *publication) Message() *broker.Message {
+	return p.msg
+}
+
+func (p *publication) RawMessage() interface{} {
+	return p.packet
+}
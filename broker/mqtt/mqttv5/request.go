@@ -0,0 +1,192 @@
+package mqttv5
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/google/uuid"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// responseRouter 请求应答的应答主题和等待中的请求，应答按关联数据（Correlation Data）交给对应的请求
+type responseRouter struct {
+	sync.Mutex
+
+	topic      string
+	subscribed bool
+	pending    map[string]chan *paho.Publish
+}
+
+func newResponseRouter(topic string) *responseRouter {
+	return &responseRouter{
+		topic:   topic,
+		pending: make(map[string]chan *paho.Publish),
+	}
+}
+
+func (r *responseRouter) add(id string) chan *paho.Publish {
+	r.Lock()
+	defer r.Unlock()
+
+	ch := make(chan *paho.Publish, 1)
+	r.pending[id] = ch
+	return ch
+}
+
+func (r *responseRouter) remove(id string) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.pending, id)
+}
+
+// deliver 将应答交给等待中的请求，不是应答消息时返回 false
+func (r *responseRouter) deliver(packet *paho.Publish) bool {
+	if packet.Topic != r.topic {
+		return false
+	}
+
+	if packet.Properties == nil || len(packet.Properties.CorrelationData) == 0 {
+		return true
+	}
+
+	r.Lock()
+	ch, ok := r.pending[string(packet.Properties.CorrelationData)]
+	if ok {
+		delete(r.pending, string(packet.Properties.CorrelationData))
+	}
+	r.Unlock()
+
+	if ok {
+		ch <- packet
+	}
+	return true
+}
+
+// Request 发送请求并等待应答，应答为 *broker.Message，Body 是未解码的消息体，Msg 是原始的 *paho.Publish。
+// 应答与订阅的消息由同一个协程分发，不能在订阅的处理函数中调用 Request。
+func (b *mqttBroker) Request(ctx context.Context, topic string, msg broker.Any, opts ...broker.RequestOption) (broker.Any, error) {
+	buf, err := broker.Marshal(b.options.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.request(ctx, topic, buf, opts...)
+}
+
+func (b *mqttBroker) request(ctx context.Context, topic string, buf []byte, opts ...broker.RequestOption) (broker.Any, error) {
+	options := broker.RequestOptions{
+		Context: ctx,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := DefaultRequestTimeout
+		if v, ok := options.Context.Value(requestTimeoutKey{}).(time.Duration); ok && v > 0 {
+			timeout = v
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := b.subscribeResponses(ctx); err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	if v, ok := options.Context.Value(requestHeadersKey{}).(map[string]string); ok {
+		for k, val := range v {
+			headers[k] = val
+		}
+	}
+
+	id := uuid.New().String()
+	ch := b.responses.add(id)
+	defer b.responses.remove(id)
+
+	span := b.startProducerSpan(options.Context, topic, headers)
+
+	err := b.publishPacket(ctx, &paho.Publish{
+		QoS:     1,
+		Topic:   topic,
+		Payload: buf,
+		Properties: &paho.PublishProperties{
+			ResponseTopic:   b.responses.topic,
+			CorrelationData: []byte(id),
+			User:            headersToUserProperties(headers),
+		},
+	})
+	if err == nil {
+		select {
+		case packet := <-ch:
+			b.finishProducerSpan(span, nil)
+			return &broker.Message{
+				Headers: userPropertiesToHeaders(packet.Properties.User),
+				Body:    packet.Payload,
+				Msg:     packet,
+			}, nil
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	b.finishProducerSpan(span, err)
+
+	return nil, err
+}
+
+// subscribeResponses 第一次请求时订阅应答主题，重新连接后由 onConnectionUp 恢复订阅
+func (b *mqttBroker) subscribeResponses(ctx context.Context) error {
+	b.responses.Lock()
+	defer b.responses.Unlock()
+
+	if b.responses.subscribed {
+		return nil
+	}
+
+	cm := b.connection()
+	if cm == nil {
+		return errors.New("not connected")
+	}
+
+	if err := subscribeError(cm.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: b.responses.topic, QoS: 1}},
+	})); err != nil {
+		return err
+	}
+
+	b.responses.subscribed = true
+	return nil
+}
+
+// Reply 应答 Request 发送的请求：将 msg 发布到请求的应答主题，并带回请求的关联数据。
+// 请求没有应答主题时返回 ErrNoResponseTopic。
+func Reply(ctx context.Context, b broker.Broker, event broker.Event, msg broker.Any, opts ...broker.PublishOption) error {
+	mb, ok := b.(*mqttBroker)
+	if !ok {
+		return errors.New("mqtt5: broker is not an mqtt5 broker")
+	}
+
+	request, ok := event.RawMessage().(*paho.Publish)
+	if !ok || request.Properties == nil || request.Properties.ResponseTopic == "" {
+		return ErrNoResponseTopic
+	}
+
+	buf, err := broker.Marshal(mb.options.Codec, msg)
+	if err != nil {
+		return err
+	}
+
+	if len(request.Properties.CorrelationData) > 0 {
+		opts = append(opts, WithCorrelationData(request.Properties.CorrelationData))
+	}
+
+	return mb.publish(ctx, request.Properties.ResponseTopic, buf, opts...)
+}
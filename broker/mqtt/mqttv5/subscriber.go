@@ -0,0 +1,183 @@
+package mqttv5
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eclipse/paho.golang/paho"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+type subscriber struct {
+	sync.RWMutex
+
+	b *mqttBroker
+
+	// id 订阅标识符，服务端在投递的消息中带回，用于分发
+	id    int
+	topic string
+	// filter 实际订阅的主题过滤器，共享订阅时带有 $share/<queue>/ 前缀
+	filter string
+	qos    byte
+
+	noLocal        bool
+	retainHandling byte
+
+	handler      broker.Handler
+	binder       broker.Binder
+	headerFilter *broker.Filter
+	options      broker.SubscribeOptions
+
+	closed bool
+}
+
+// subscription 订阅请求中的订阅项
+func (s *subscriber) subscription() paho.SubscribeOptions {
+	return paho.SubscribeOptions{
+		Topic:          s.filter,
+		QoS:            s.qos,
+		NoLocal:        s.noLocal,
+		RetainHandling: s.retainHandling,
+	}
+}
+
+// subscribePacket 只包含该订阅的订阅请求，withID 为 true 时带上订阅标识符
+func (s *subscriber) subscribePacket(withID bool) *paho.Subscribe {
+	packet := &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{s.subscription()},
+	}
+	if withID {
+		id := s.id
+		packet.Properties = &paho.SubscribeProperties{SubscriptionIdentifier: &id}
+	}
+	return packet
+}
+
+// match 收到的消息是否属于该订阅
+func (s *subscriber) match(topic string) bool {
+	return matchTopic(s.topic, topic)
+}
+
+func (s *subscriber) onMessage(packet *paho.Publish) {
+	var headers map[string]string
+	if packet.Properties != nil {
+		headers = userPropertiesToHeaders(packet.Properties.User)
+	}
+
+	p := &publication{
+		topic: packet.Topic,
+		msg: &broker.Message{
+			Headers: headers,
+			Msg:     packet,
+		},
+		packet: packet,
+	}
+
+	if !s.headerFilter.Match(headers) {
+		return
+	}
+
+	ctx := s.options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if s.binder != nil {
+		p.msg.Body = s.binder()
+		if err := broker.Unmarshal(s.b.options.Codec, packet.Payload, &p.msg.Body); err != nil {
+			s.handleError(ctx, p, broker.ErrorStageDecode, err)
+			return
+		}
+	} else {
+		p.msg.Body = packet.Payload
+	}
+
+	spanHeaders := make(map[string]string, len(headers))
+	for k, v := range headers {
+		spanHeaders[k] = v
+	}
+	ctx, span := s.b.startConsumerSpan(ctx, packet.Topic, spanHeaders)
+
+	err := s.handler(ctx, p)
+	s.b.finishConsumerSpan(span, err)
+	if err != nil {
+		s.handleError(ctx, p, broker.ErrorStageHandle, err)
+	}
+}
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 消息由客户端在处理函数返回后自动确认，因此只有死信动作会产生实际效果。
+func (s *subscriber) handleError(ctx context.Context, p *publication, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	s.b.log.Errorw("msg", "message failed", "stage", stage, "topic", p.topic, "error", err)
+
+	switch action := broker.HandleError(s.b.options.ErrorHandler, ctx, p, stage, err); action {
+	case broker.ErrorActionDeadLetter:
+		// 订阅主题可能包含通配符，默认的死信主题以消息的实际主题为基础
+		dead := &paho.Publish{
+			QoS:     p.packet.QoS,
+			Topic:   broker.DeadLetterTopic(p.topic, s.options),
+			Payload: p.packet.Payload,
+			Properties: &paho.PublishProperties{
+				User: headersToUserProperties(broker.DeadLetterHeaders(p.msg.Headers, p.topic, stage, err)),
+			},
+		}
+		if p.packet.Properties != nil {
+			dead.Properties.ContentType = p.packet.Properties.ContentType
+		}
+		if err = s.b.publishPacket(context.Background(), dead); err != nil {
+			s.b.log.Errorf("publish dead letter failed: %v", err)
+		}
+
+	case broker.ErrorActionNack:
+		s.b.log.Warnf("error action %s is not supported by mqtt, message is already acknowledged", action)
+
+	default:
+	}
+}
+
+func (s *subscriber) Options() broker.SubscribeOptions {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.options
+}
+
+func (s *subscriber) Topic() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.topic
+}
+
+func (s *subscriber) Unsubscribe(removeFromManager bool) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var err error
+	if cm := s.b.connection(); cm != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), s.b.connectTimeout)
+		err = unsubscribeError(cm.Unsubscribe(ctx, &paho.Unsubscribe{Topics: []string{s.filter}}))
+		cancel()
+	}
+
+	if s.b.subscribers != nil && removeFromManager {
+		_ = s.b.subscribers.RemoveOnly(s.filter)
+	}
+
+	return err
+}
+
+func (s *subscriber) IsClosed() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.closed
+}
@@ -108,22 +108,27 @@ func WithProtocolVersion(pv uint) broker.Option {
 	return broker.OptionContextWithValue(protocolVersionKey{}, pv)
 }
 
+// WithErrorLogger 安装 paho 的 ERROR 日志器。paho 日志器是进程级的，写入 kratos 的全局日志器，只有第一个启用的 broker 生效
 func WithErrorLogger() broker.Option {
 	return broker.OptionContextWithValue(errorLoggerKey{}, true)
 }
 
+// WithCriticalLogger 安装 paho 的 CRITICAL 日志器，同 WithErrorLogger
 func WithCriticalLogger() broker.Option {
 	return broker.OptionContextWithValue(criticalLoggerKey{}, true)
 }
 
+// WithWarnLogger 安装 paho 的 WARN 日志器，同 WithErrorLogger
 func WithWarnLogger() broker.Option {
 	return broker.OptionContextWithValue(warnLoggerKey{}, true)
 }
 
+// WithDebugLogger 安装 paho 的 DEBUG 日志器，同 WithErrorLogger
 func WithDebugLogger() broker.Option {
 	return broker.OptionContextWithValue(debugLoggerKey{}, true)
 }
 
+// WithLogger 一次性设置需要安装的 paho 日志器，同 WithErrorLogger。实例日志器请使用 broker.WithLogger
 func WithLogger(opt LoggerOptions) broker.Option {
 	return broker.OptionContextWithValue(loggerKey{}, opt)
 }
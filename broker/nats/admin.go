@@ -0,0 +1,216 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	natsGo "github.com/nats-io/nats.go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.Admin = (*natsBroker)(nil)
+
+// NATS 中主题对应 JetStream 的流，消费组对应流上的持久化消费者。
+// 流没有分区，TopicInfo 和 GroupInfo 中只有一个 ID 为 0 的分区。
+
+func (b *natsBroker) jetStream(ctx context.Context) (natsGo.JetStreamContext, error) {
+	b.RLock()
+	conn := b.conn
+	b.RUnlock()
+
+	if conn == nil || !conn.IsConnected() {
+		return nil, errors.New("not connected")
+	}
+
+	return conn.JetStream(natsGo.Context(ctx))
+}
+
+// streamConfig 由 config 生成流配置，支持的键：
+// subjects（逗号分隔，默认为流名称）、max_msgs、max_bytes、max_age（time.Duration 格式）、
+// retention（limits/interest/workqueue）、storage（file/memory）
+func streamConfig(name string, replication int, config map[string]string) (*natsGo.StreamConfig, error) {
+	cfg := &natsGo.StreamConfig{
+		Name:     name,
+		Subjects: []string{name},
+		Replicas: replication,
+	}
+	if cfg.Replicas <= 0 {
+		cfg.Replicas = 1
+	}
+
+	for k, v := range config {
+		var err error
+		switch k {
+		case "subjects":
+			cfg.Subjects = strings.Split(v, ",")
+		case "max_msgs":
+			cfg.MaxMsgs, err = strconv.ParseInt(v, 10, 64)
+		case "max_bytes":
+			cfg.MaxBytes, err = strconv.ParseInt(v, 10, 64)
+		case "max_age":
+			cfg.MaxAge, err = time.ParseDuration(v)
+		case "retention":
+			err = cfg.Retention.UnmarshalJSON([]byte(strconv.Quote(v)))
+		case "storage":
+			err = cfg.Storage.UnmarshalJSON([]byte(strconv.Quote(v)))
+		default:
+			err = errors.New("unsupported key")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("nats: invalid stream config %s=%s: %w", k, v, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// CreateTopic 创建 JetStream 流，流已存在时视为成功；partitions 被忽略
+func (b *natsBroker) CreateTopic(ctx context.Context, name string, _, replication int, config map[string]string) error {
+	cfg, err := streamConfig(name, replication, config)
+	if err != nil {
+		return err
+	}
+
+	js, err := b.jetStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = js.AddStream(cfg); err != nil && !errors.Is(err, natsGo.ErrStreamNameAlreadyInUse) {
+		return err
+	}
+	return nil
+}
+
+func (b *natsBroker) DeleteTopic(ctx context.Context, name string) error {
+	js, err := b.jetStream(ctx)
+	if err != nil {
+		return err
+	}
+	return js.DeleteStream(name)
+}
+
+func (b *natsBroker) ListTopics(ctx context.Context) ([]string, error) {
+	js, err := b.jetStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range js.StreamNames() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (b *natsBroker) DescribeTopic(ctx context.Context, name string) (*broker.TopicInfo, error) {
+	js, err := b.jetStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	si, err := js.StreamInfo(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &broker.TopicInfo{
+		Name: si.Config.Name,
+		Config: map[string]string{
+			"subjects":  strings.Join(si.Config.Subjects, ","),
+			"max_msgs":  strconv.FormatInt(si.Config.MaxMsgs, 10),
+			"max_bytes": strconv.FormatInt(si.Config.MaxBytes, 10),
+			"max_age":   si.Config.MaxAge.String(),
+			"retention": si.Config.Retention.String(),
+			"storage":   si.Config.Storage.String(),
+		},
+		Messages: int64(si.State.Msgs),
+	}
+
+	partition := broker.PartitionInfo{ID: 0}
+	if si.Cluster != nil {
+		partition.Leader = si.Cluster.Leader
+		for _, r := range si.Cluster.Replicas {
+			partition.Replicas = append(partition.Replicas, r.Name)
+		}
+	}
+	info.Partitions = []broker.PartitionInfo{partition}
+
+	return info, nil
+}
+
+// ListConsumerGroups 列出所有流上的消费者名称
+func (b *natsBroker) ListConsumerGroups(ctx context.Context) ([]string, error) {
+	js, err := b.jetStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var groups []string
+	for stream := range js.StreamNames() {
+		for name := range js.ConsumerNames(stream) {
+			if !seen[name] {
+				seen[name] = true
+				groups = append(groups, name)
+			}
+		}
+	}
+	sort.Strings(groups)
+
+	return groups, nil
+}
+
+// DescribeGroup 汇总所有流上名为 group 的消费者，Committed 为已确认的流序号，End 为流的最新序号
+func (b *natsBroker) DescribeGroup(ctx context.Context, group string) (*broker.GroupInfo, error) {
+	js, err := b.jetStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &broker.GroupInfo{Name: group, State: "Empty"}
+	found := false
+
+	for stream := range js.StreamNames() {
+		ci, err := js.ConsumerInfo(stream, group)
+		if errors.Is(err, natsGo.ErrConsumerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		info.Lag = append(info.Lag, consumerLag(ci))
+		if ci.PushBound || ci.NumWaiting > 0 {
+			info.State = "Stable"
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("nats: consumer [%s] not found", group)
+	}
+	sort.Slice(info.Lag, func(i, j int) bool { return info.Lag[i].Topic < info.Lag[j].Topic })
+
+	return info, nil
+}
+
+func consumerLag(ci *natsGo.ConsumerInfo) broker.PartitionLag {
+	committed := int64(ci.AckFloor.Stream)
+	lag := int64(ci.NumPending + uint64(ci.NumAckPending))
+	return broker.PartitionLag{
+		Topic:     ci.Stream,
+		Partition: 0,
+		Committed: committed,
+		End:       committed + lag,
+		Lag:       lag,
+	}
+}
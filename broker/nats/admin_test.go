@@ -0,0 +1,58 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsGo "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestStreamConfig(t *testing.T) {
+	cfg, err := streamConfig("orders", 0, map[string]string{
+		"subjects":  "orders.*,refunds.*",
+		"max_msgs":  "1000",
+		"max_age":   "1h",
+		"retention": "workqueue",
+		"storage":   "memory",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", cfg.Name)
+	assert.Equal(t, []string{"orders.*", "refunds.*"}, cfg.Subjects)
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.Equal(t, int64(1000), cfg.MaxMsgs)
+	assert.Equal(t, time.Hour, cfg.MaxAge)
+	assert.Equal(t, natsGo.WorkQueuePolicy, cfg.Retention)
+	assert.Equal(t, natsGo.MemoryStorage, cfg.Storage)
+
+	cfg, err = streamConfig("orders", 3, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orders"}, cfg.Subjects)
+	assert.Equal(t, 3, cfg.Replicas)
+
+	_, err = streamConfig("orders", 1, map[string]string{"unknown": "1"})
+	assert.NotNil(t, err)
+}
+
+func TestConsumerLag(t *testing.T) {
+	lag := consumerLag(&natsGo.ConsumerInfo{
+		Stream:        "orders",
+		AckFloor:      natsGo.SequenceInfo{Stream: 10},
+		NumPending:    5,
+		NumAckPending: 2,
+	})
+	assert.Equal(t, broker.PartitionLag{Topic: "orders", Committed: 10, End: 17, Lag: 7}, lag)
+}
+
+func TestAdminNotConnected(t *testing.T) {
+	b := NewBroker()
+
+	admin, ok := broker.AsAdmin(b)
+	assert.True(t, ok)
+
+	_, err := admin.ListTopics(context.Background())
+	assert.NotNil(t, err)
+}
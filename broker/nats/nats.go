@@ -8,6 +8,7 @@ import (
 	"time"
 
 	kProto "github.com/go-kratos/kratos/v2/encoding/proto"
+	"github.com/go-kratos/kratos/v2/log"
 	"google.golang.org/protobuf/proto"
 
 	natsGo "github.com/nats-io/nats.go"
@@ -42,6 +43,8 @@ type natsBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -50,6 +53,7 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 	b := &natsBroker{
 		options:     options,
 		subscribers: broker.NewSubscriberSyncMap(),
+		log:         newLoggerHelper(options.Logger),
 	}
 
 	return b
@@ -109,6 +113,8 @@ func (b *natsBroker) setOption(opts ...broker.Option) {
 		o(&b.options)
 	}
 
+	b.log = newLoggerHelper(b.options.Logger)
+
 	b.Once.Do(func() {
 		b.natsOpts = natsGo.GetDefaultOptions()
 	})
@@ -258,6 +264,15 @@ func (b *natsBroker) Subscribe(topic string, handler broker.Handler, binder brok
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
 	subs := &subscriber{
 		n:       b,
 		s:       nil,
@@ -277,7 +292,14 @@ func (b *natsBroker) Subscribe(topic string, handler broker.Handler, binder brok
 
 		ctx, span := b.startConsumerSpan(options.Context, msg)
 
-		eh := b.options.ErrorHandler
+		// 不满足选择器的消息直接确认，不解码也不调用处理器
+		if !filter.Match(m.Headers) {
+			if errSub = pub.Ack(); errSub != nil {
+				b.log.Errorf("unable to ack filtered msg: %v", errSub)
+			}
+			b.finishConsumerSpan(span, nil)
+			return
+		}
 
 		if binder != nil {
 			if b.options.Codec.Name() == kProto.Name {
@@ -287,12 +309,7 @@ func (b *natsBroker) Subscribe(topic string, handler broker.Handler, binder brok
 			}
 
 			if errSub = broker.Unmarshal(b.options.Codec, msg.Data, &m.Body); errSub != nil {
-				pub.err = errSub
-				LogErrorf("unmarshal message failed: %v", errSub)
-				if eh != nil {
-					_ = eh(b.options.Context, pub)
-				}
-
+				b.handleError(ctx, topic, options, pub, msg, broker.ErrorStageDecode, errSub)
 				b.finishConsumerSpan(span, errSub)
 				return
 			}
@@ -301,19 +318,14 @@ func (b *natsBroker) Subscribe(topic string, handler broker.Handler, binder brok
 		}
 
 		if errSub = handler(ctx, pub); errSub != nil {
-			pub.err = errSub
-			LogErrorf("handle message failed: %v", errSub)
-			if eh != nil {
-				_ = eh(b.options.Context, pub)
-			}
-
+			b.handleError(ctx, topic, options, pub, msg, broker.ErrorStageHandle, errSub)
 			b.finishConsumerSpan(span, errSub)
 			return
 		}
 
 		if options.AutoAck {
 			if errSub = pub.Ack(); errSub != nil {
-				LogErrorf("unable to commit msg: %v", errSub)
+				b.handleError(ctx, topic, options, pub, msg, broker.ErrorStageAck, errSub)
 			}
 		}
 
@@ -321,7 +333,6 @@ func (b *natsBroker) Subscribe(topic string, handler broker.Handler, binder brok
 	}
 
 	var sub *natsGo.Subscription
-	var err error
 
 	b.RLock()
 	if len(options.Queue) > 0 {
@@ -401,6 +412,40 @@ func (b *natsBroker) onDisconnectedError(_ *natsGo.Conn, err error) {
 	b.closeCh <- err
 }
 
+// handleError 将失败的消息交给 ErrorHandler 处置
+func (b *natsBroker) handleError(ctx context.Context, topic string, options broker.SubscribeOptions, pub *publication, msg *natsGo.Msg, stage broker.ErrorStage, err error) {
+	pub.err = err
+
+	b.log.Errorw("msg", "message failed", "stage", stage, "subject", msg.Subject, "queue", options.Queue, "error", err)
+
+	switch broker.HandleError(b.options.ErrorHandler, ctx, pub, stage, err) {
+	case broker.ErrorActionAck:
+		if err = pub.Ack(); err != nil {
+			b.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	case broker.ErrorActionNack:
+		if err = pub.Nack(); err != nil {
+			b.log.Errorf("unable to nack msg: %v", err)
+		}
+
+	case broker.ErrorActionDeadLetter:
+		headers := make(map[string][]string)
+		for k, v := range broker.DeadLetterHeaders(natsHeaderToMap(msg.Header), msg.Subject, stage, err) {
+			headers[k] = []string{v}
+		}
+		if err = b.publish(ctx, broker.DeadLetterTopic(topic, options), msg.Data, WithHeaders(headers)); err != nil {
+			b.log.Errorf("publish dead letter failed: %v", err)
+			return
+		}
+		if err = pub.Ack(); err != nil {
+			b.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	default:
+	}
+}
+
 func (b *natsBroker) startProducerSpan(ctx context.Context, msg *natsGo.Msg) trace.Span {
 	if b.producerTracer == nil {
 		return nil
@@ -1,6 +1,12 @@
 package nats
 
-import "github.com/tx7do/kratos-transport/broker"
+import (
+	"errors"
+
+	natsGo "github.com/nats-io/nats.go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
 
 type publication struct {
 	t   string
@@ -27,3 +33,12 @@ func (p *publication) Ack() error {
 func (p *publication) Error() error {
 	return p.err
 }
+
+// Nack 仅对 JetStream 消息有效
+func (p *publication) Nack() error {
+	msg, ok := p.m.Msg.(*natsGo.Msg)
+	if !ok || msg == nil {
+		return errors.New("nats message is nil")
+	}
+	return msg.Nak()
+}
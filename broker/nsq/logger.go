@@ -3,32 +3,43 @@ package nsq
 import (
 	"fmt"
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
 )
 
 const (
 	logKey = "[nsq]"
 )
 
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return broker.NewLoggerHelper(logger, "broker", "nsq")
+}
+
 ///
 /// logger
 ///
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogDebug(args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogInfo(args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogWarn(args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogError(args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogFatal(args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprint(args...))
 }
@@ -37,22 +48,27 @@ func LogFatal(args ...interface{}) {
 /// logger
 ///
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogDebugf(format string, args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogInfof(format string, args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogWarnf(format string, args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogErrorf(format string, args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 broker.NewLoggerHelper。
 func LogFatalf(format string, args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
@@ -3,10 +3,12 @@ package nsq
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
 	NSQ "github.com/nsqio/go-nsq"
 	"github.com/tx7do/kratos-transport/broker"
@@ -34,6 +36,8 @@ type nsqBroker struct {
 	producers []*NSQ.Producer
 
 	subscribers *broker.SubscriberSyncMap
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -46,6 +50,8 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 		producers: make([]*NSQ.Producer, 0),
 
 		subscribers: broker.NewSubscriberSyncMap(),
+
+		log: newLoggerHelper(options.Logger),
 	}
 
 	return b
@@ -72,6 +78,8 @@ func (b *nsqBroker) Init(opts ...broker.Option) error {
 		o(&b.options)
 	}
 
+	b.log = newLoggerHelper(b.options.Logger)
+
 	var addrs []string
 
 	for _, addr := range b.options.Addrs {
@@ -262,6 +270,15 @@ func (b *nsqBroker) Subscribe(topic string, handler broker.Handler, binder broke
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	// NSQ 的消息没有消息头，无法按选择器过滤
+	if len(options.Filter) > 0 {
+		return nil, fmt.Errorf("nsq: message filter: %w", broker.ErrNotSupported)
+	}
+
 	concurrency, maxInFlight := DefaultConcurrentHandlers, DefaultConcurrentHandlers
 	if options.Context != nil {
 		if v, ok := options.Context.Value(concurrentHandlerKey{}).(int); ok {
@@ -295,26 +312,25 @@ func (b *nsqBroker) Subscribe(topic string, handler broker.Handler, binder broke
 		var m broker.Message
 		var errSub error
 
+		p := &publication{topic: topic, nsqMsg: nm, msg: &m}
+
 		if binder != nil {
 			m.Body = binder()
 
 			if errSub = broker.Unmarshal(b.options.Codec, nm.Body, &m.Body); errSub != nil {
-				return errSub
+				return b.handleError(topic, options, p, broker.ErrorStageDecode, errSub)
 			}
 		} else {
 			m.Body = nm.Body
 		}
 
-		p := &publication{topic: topic, nsqMsg: nm, msg: &m}
-
 		if errSub = handler(b.options.Context, p); errSub != nil {
-			p.err = errSub
-			return errSub
+			return b.handleError(topic, options, p, broker.ErrorStageHandle, errSub)
 		}
 
 		if options.AutoAck {
-			if errSub = p.Ack(); err != nil {
-				LogErrorf("unable to commit msg: %v", errSub)
+			if errSub = p.Ack(); errSub != nil {
+				return b.handleError(topic, options, p, broker.ErrorStageAck, errSub)
 			}
 		}
 
@@ -345,3 +361,34 @@ func (b *nsqBroker) Subscribe(topic string, handler broker.Handler, binder broke
 
 	return sub, nil
 }
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 返回的错误会交给 go-nsq，在自动应答模式下触发重新入队。
+func (b *nsqBroker) handleError(topic string, options broker.SubscribeOptions, p *publication, stage broker.ErrorStage, err error) error {
+	p.err = err
+
+	b.log.Errorw("msg", "message failed", "stage", stage, "topic", topic, "channel", options.Queue, "error", err)
+
+	switch broker.HandleError(b.options.ErrorHandler, b.options.Context, p, stage, err) {
+	case broker.ErrorActionAck:
+		p.nsqMsg.Finish()
+		return nil
+
+	case broker.ErrorActionNack:
+		if err = p.Nack(); err != nil {
+			b.log.Errorf("unable to requeue msg: %v", err)
+		}
+		return nil
+
+	case broker.ErrorActionDeadLetter:
+		if err = b.publish(b.options.Context, broker.DeadLetterTopic(topic, options), p.nsqMsg.Body); err != nil {
+			b.log.Errorf("publish dead letter failed: %v", err)
+			return err
+		}
+		p.nsqMsg.Finish()
+		return nil
+
+	default:
+		return err
+	}
+}
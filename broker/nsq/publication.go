@@ -37,6 +37,16 @@ func (p *publication) Ack() error {
 	return nil
 }
 
+func (p *publication) Nack() error {
+	if p.nsqMsg == nil {
+		p.err = errors.New("nsq message is nil")
+		return p.err
+	}
+
+	p.nsqMsg.Requeue(-1)
+	return nil
+}
+
 func (p *publication) Error() error {
 	return p.err
 }
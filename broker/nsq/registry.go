@@ -0,0 +1,33 @@
+package nsq
+
+import (
+	"net/url"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func init() {
+	broker.Register("nsq", newBrokerFromURL)
+}
+
+func newBrokerFromURL(u *url.URL, opts ...broker.Option) (broker.Broker, error) {
+	urlOpts, err := URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewBroker(append(urlOpts, opts...)...), nil
+}
+
+// URLOptions 将连接 URL 解析为 broker 选项，主机列表为 nsqd 地址，例如：
+//
+//	nsq://host1:4150,host2:4150/?lookupd=host3:4161,host4:4161
+func URLOptions(u *url.URL) ([]broker.Option, error) {
+	urlOpts, err := broker.ParseURL(u, broker.URLParams{
+		"lookupd": broker.URLStrings(WithLookupdAddress),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return urlOpts, nil
+}
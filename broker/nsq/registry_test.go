@@ -0,0 +1,44 @@
+package nsq
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestURLOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		addrs   []string
+		lookupd []string
+		wantErr bool
+	}{
+		{name: "nsqd and lookupd", url: "nsq://host1:4150,host2:4150/?lookupd=host3:4161,host4:4161",
+			addrs: []string{"host1:4150", "host2:4150"}, lookupd: []string{"host3:4161", "host4:4161"}},
+		{name: "unknown parameter", url: "nsq://host1:4150/?unknown=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			assert.Nil(t, err)
+
+			opts, err := URLOptions(u)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			options := broker.NewOptionsAndApply(opts...)
+			assert.Equal(t, tt.addrs, options.Addrs)
+
+			lookupd, _ := options.Context.Value(lookupdAddrsKey{}).([]string)
+			assert.Equal(t, tt.lookupd, lookupd)
+		})
+	}
+}
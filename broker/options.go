@@ -8,6 +8,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/tx7do/kratos-transport/tracing"
 )
@@ -23,13 +24,15 @@ type Options struct {
 
 	Codec encoding.Codec
 
-	ErrorHandler Handler
+	ErrorHandler ErrorHandler
 
 	Secure    bool
 	TLSConfig *tls.Config
 
 	Context context.Context
 
+	Logger log.Logger
+
 	Tracings []tracing.Option
 }
 
@@ -53,6 +56,8 @@ func NewOptions() Options {
 
 		Context: context.Background(),
 
+		Logger: nil,
+
 		Tracings: []tracing.Option{},
 	}
 
@@ -96,12 +101,20 @@ func WithCodec(name string) Option {
 	}
 }
 
-func WithErrorHandler(handler Handler) Option {
+// WithErrorHandler set the callback for decode, handle and ack failures
+func WithErrorHandler(handler ErrorHandler) Option {
 	return func(o *Options) {
 		o.ErrorHandler = handler
 	}
 }
 
+// WithLogger set the logger of the broker instance, default is the kratos global logger
+func WithLogger(logger log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
 func WithEnableSecure(enable bool) Option {
 	return func(o *Options) {
 		o.Secure = enable
@@ -185,6 +198,16 @@ func WithPublishContext(ctx context.Context) PublishOption {
 type SubscribeOptions struct {
 	AutoAck bool
 	Queue   string
+
+	// DeadLetterQueue receives messages for which the ErrorHandler returned ErrorActionDeadLetter
+	DeadLetterQueue string
+
+	// Filter selector expression evaluated against message headers, see ParseFilter
+	Filter string
+
+	// SingleActiveConsumer only the holder of the lease consumes, see SubscribeSingleActive
+	SingleActiveConsumer Lease
+
 	Context context.Context
 }
 
@@ -229,6 +252,33 @@ func WithQueueName(name string) SubscribeOption {
 	}
 }
 
+// WithDeadLetterQueue set the dead letter queue of the subscription
+func WithDeadLetterQueue(name string) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.DeadLetterQueue = name
+	}
+}
+
+// WithFilter set the selector expression of the subscription, for example:
+//
+//	type = 'order.created' AND region IN ('eu', 'us')
+//
+// Drivers map it to native filtering where available and evaluate it before decoding otherwise;
+// filtered messages are acknowledged without calling the handler.
+func WithFilter(expr string) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.Filter = expr
+	}
+}
+
+// WithSingleActiveConsumer consume only while holding the lease, so that exactly one replica
+// consumes at a time and another replica takes over when the holder dies.
+func WithSingleActiveConsumer(lease Lease) SubscribeOption {
+	return func(o *SubscribeOptions) {
+		o.SingleActiveConsumer = lease
+	}
+}
+
 func WithSubscribeContext(ctx context.Context) SubscribeOption {
 	return func(o *SubscribeOptions) {
 		o.Context = ctx
@@ -0,0 +1,78 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PublishResult 消息发布回执，各驱动按能力填写
+type PublishResult struct {
+	Topic     string
+	Partition int       // Kafka 分区、RocketMQ 队列 ID
+	Offset    int64     // Kafka 位移、RocketMQ 队列位移、RabbitMQ 确认的投递序号，不支持时为 -1
+	MessageID string    // Pulsar MessageID、RocketMQ MsgId 等服务端分配的消息 ID
+	Timestamp time.Time // 服务端记录的时间，不支持时为发送完成的本地时间
+}
+
+// ResultPublisher 支持返回发布回执的 broker，回执在服务端确认写入后返回
+type ResultPublisher interface {
+	PublishWithResult(ctx context.Context, topic string, msg Any, opts ...PublishOption) (*PublishResult, error)
+}
+
+// AsyncPublisher 支持异步发布的 broker，每次发布返回独立的 PublishFuture
+type AsyncPublisher interface {
+	PublishAsync(ctx context.Context, topic string, msg Any, opts ...PublishOption) *PublishFuture
+}
+
+// PublishWithResult 发布消息并等待回执，b 不支持时返回 ErrNotSupported
+func PublishWithResult(ctx context.Context, b Broker, topic string, msg Any, opts ...PublishOption) (*PublishResult, error) {
+	if p, ok := b.(ResultPublisher); ok {
+		return p.PublishWithResult(ctx, topic, msg, opts...)
+	}
+	return nil, ErrNotSupported
+}
+
+// PublishFuture 单次异步发布的回执
+type PublishFuture struct {
+	once sync.Once
+	done chan struct{}
+
+	result *PublishResult
+	err    error
+}
+
+func NewPublishFuture() *PublishFuture {
+	return &PublishFuture{done: make(chan struct{})}
+}
+
+// NewFailedPublishFuture 返回已经失败的回执，用于发送之前就出错的情况
+func NewFailedPublishFuture(err error) *PublishFuture {
+	f := NewPublishFuture()
+	f.Complete(nil, err)
+	return f
+}
+
+// Complete 设置发布结果，只有第一次调用生效
+func (f *PublishFuture) Complete(result *PublishResult, err error) {
+	f.once.Do(func() {
+		f.result = result
+		f.err = err
+		close(f.done)
+	})
+}
+
+// Done 发布完成后关闭
+func (f *PublishFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get 等待发布完成并返回回执，ctx 结束时返回 ctx 的错误，不影响发布本身
+func (f *PublishFuture) Get(ctx context.Context) (*PublishResult, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
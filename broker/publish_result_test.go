@@ -0,0 +1,42 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPublishFuture(t *testing.T) {
+	f := NewPublishFuture()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	go f.Complete(&PublishResult{Topic: "orders", Partition: 1, Offset: 42}, nil)
+
+	result, err := f.Get(context.Background())
+	if err != nil || result.Offset != 42 || result.Partition != 1 {
+		t.Fatalf("unexpected result: %+v %v", result, err)
+	}
+
+	f.Complete(nil, errors.New("late"))
+	if _, err = f.Get(context.Background()); err != nil {
+		t.Fatalf("second Complete must be ignored: %v", err)
+	}
+
+	failed := NewFailedPublishFuture(ErrNotSupported)
+	<-failed.Done()
+	if _, err = failed.Get(context.Background()); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPublishWithResultNotSupported(t *testing.T) {
+	if _, err := PublishWithResult(context.Background(), nil, "orders", nil); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
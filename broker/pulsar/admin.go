@@ -0,0 +1,341 @@
+package pulsar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/apache/pulsar-client-go/pulsaradmin"
+	"github.com/apache/pulsar-client-go/pulsaradmin/pkg/rest"
+	"github.com/apache/pulsar-client-go/pulsaradmin/pkg/utils"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.Admin = (*pulsarBroker)(nil)
+
+const (
+	defaultAdminNamespace = "public/default"
+)
+
+// adminURL 由服务地址推导管理接口地址
+func adminURL(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil || len(u.Host) == 0 {
+		return "http://127.0.0.1:8080"
+	}
+
+	scheme, port := "http", "8080"
+	if u.Scheme == "pulsar+ssl" {
+		scheme, port = "https", "8443"
+	}
+
+	host := u.Hostname()
+	if strings.Contains(host, ",") {
+		host = strings.Split(host, ",")[0]
+	}
+
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+func (pb *pulsarBroker) adminNamespace() string {
+	if v, ok := pb.options.Context.Value(adminNamespaceKey{}).(string); ok && len(v) > 0 {
+		return v
+	}
+	return defaultAdminNamespace
+}
+
+// adminClient 使用与客户端相同的 TLS 配置创建管理客户端
+func (pb *pulsarBroker) adminClient() (pulsaradmin.Client, error) {
+	cfg := &pulsaradmin.Config{}
+
+	if v, ok := pb.options.Context.Value(adminURLKey{}).(string); ok && len(v) > 0 {
+		cfg.WebServiceURL = v
+	} else {
+		cfg.WebServiceURL = adminURL(pb.Address())
+	}
+
+	if v, ok := pb.options.Context.Value(tlsKey{}).(tlsConfig); ok {
+		cfg.TLSTrustCertsFilePath = v.CaCertsPath
+		cfg.TLSCertFile = v.ClientCertPath
+		cfg.TLSKeyFile = v.ClientKeyPath
+		cfg.TLSAllowInsecureConnection = v.AllowInsecureConnection
+		cfg.TLSEnableHostnameVerification = v.ValidateHostname
+	}
+
+	return pulsaradmin.NewClient(cfg)
+}
+
+// topicName 短名称补全为管理命名空间下的持久化主题
+func (pb *pulsarBroker) topicName(name string) (*utils.TopicName, error) {
+	if !strings.Contains(name, "://") && !strings.Contains(name, "/") {
+		name = "persistent://" + pb.adminNamespace() + "/" + name
+	}
+	return utils.GetTopicName(name)
+}
+
+func isStatus(err error, code int) bool {
+	var e rest.Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// CreateTopic partitions 小于等于 0 时创建非分区主题，config 作为主题属性保存；replication 由命名空间策略决定，被忽略
+func (pb *pulsarBroker) CreateTopic(_ context.Context, name string, partitions, _ int, config map[string]string) error {
+	admin, err := pb.adminClient()
+	if err != nil {
+		return err
+	}
+	topic, err := pb.topicName(name)
+	if err != nil {
+		return err
+	}
+
+	if partitions < 0 {
+		partitions = 0
+	}
+
+	if err = admin.Topics().CreateWithProperties(*topic, partitions, config); err != nil && !isStatus(err, http.StatusConflict) {
+		return err
+	}
+	return nil
+}
+
+func (pb *pulsarBroker) DeleteTopic(_ context.Context, name string) error {
+	admin, err := pb.adminClient()
+	if err != nil {
+		return err
+	}
+	topic, err := pb.topicName(name)
+	if err != nil {
+		return err
+	}
+
+	meta, err := admin.Topics().GetMetadata(*topic)
+	if err != nil {
+		return err
+	}
+
+	return admin.Topics().Delete(*topic, false, meta.Partitions == 0)
+}
+
+func (pb *pulsarBroker) ListTopics(_ context.Context) ([]string, error) {
+	admin, err := pb.adminClient()
+	if err != nil {
+		return nil, err
+	}
+	return pb.listTopics(admin)
+}
+
+// listTopics 列出命名空间下的主题，分区主题只返回主题本身
+func (pb *pulsarBroker) listTopics(admin pulsaradmin.Client) ([]string, error) {
+	ns, err := utils.GetNamespaceName(pb.adminNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	partitioned, nonPartitioned, err := admin.Topics().List(*ns)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeTopics(partitioned, nonPartitioned), nil
+}
+
+func mergeTopics(partitioned, nonPartitioned []string) []string {
+	seen := make(map[string]bool, len(partitioned))
+	topics := make([]string, 0, len(partitioned)+len(nonPartitioned))
+	for _, t := range partitioned {
+		seen[t] = true
+		topics = append(topics, t)
+	}
+	for _, t := range nonPartitioned {
+		if i := strings.LastIndex(t, utils.PARTITIONEDTOPICSUFFIX); i > 0 && seen[t[:i]] {
+			continue
+		}
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func (pb *pulsarBroker) DescribeTopic(_ context.Context, name string) (*broker.TopicInfo, error) {
+	admin, err := pb.adminClient()
+	if err != nil {
+		return nil, err
+	}
+	topic, err := pb.topicName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := admin.Topics().GetMetadata(*topic)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := admin.Topics().GetProperties(*topic)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &broker.TopicInfo{
+		Name:     topic.String(),
+		Config:   properties,
+		Messages: -1,
+	}
+
+	partitions := []*utils.TopicName{topic}
+	if meta.Partitions > 0 {
+		partitions = partitions[:0]
+		for i := 0; i < meta.Partitions; i++ {
+			p, err := topic.GetPartition(i)
+			if err != nil {
+				return nil, err
+			}
+			partitions = append(partitions, p)
+		}
+	}
+
+	for i, p := range partitions {
+		lookup, err := admin.Topics().Lookup(*p)
+		if err != nil {
+			return nil, err
+		}
+		info.Partitions = append(info.Partitions, broker.PartitionInfo{ID: i, Leader: lookup.BrokerURL})
+	}
+
+	return info, nil
+}
+
+// ListConsumerGroups 列出命名空间下所有主题的订阅名称
+func (pb *pulsarBroker) ListConsumerGroups(_ context.Context) ([]string, error) {
+	admin, err := pb.adminClient()
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := pb.listTopics(admin)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var groups []string
+	for _, t := range topics {
+		topic, err := utils.GetTopicName(t)
+		if err != nil {
+			return nil, err
+		}
+		subs, err := admin.Subscriptions().List(*topic)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range subs {
+			if !seen[s] {
+				seen[s] = true
+				groups = append(groups, s)
+			}
+		}
+	}
+	sort.Strings(groups)
+
+	return groups, nil
+}
+
+// DescribeGroup group 为订阅名称，查询命名空间下所有带有该订阅的主题，延迟为订阅的积压消息数
+func (pb *pulsarBroker) DescribeGroup(_ context.Context, group string) (*broker.GroupInfo, error) {
+	admin, err := pb.adminClient()
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := pb.listTopics(admin)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &broker.GroupInfo{Name: group, State: "Empty"}
+	found := false
+
+	for _, t := range topics {
+		topic, err := utils.GetTopicName(t)
+		if err != nil {
+			return nil, err
+		}
+
+		stats, err := pb.partitionStats(admin, topic)
+		if err != nil {
+			return nil, err
+		}
+
+		for partition, s := range stats {
+			sub, ok := s.Subscriptions[group]
+			if !ok {
+				continue
+			}
+			found = true
+
+			info.Lag = append(info.Lag, broker.PartitionLag{
+				Topic:     t,
+				Partition: partition,
+				Committed: -1,
+				End:       -1,
+				Lag:       sub.MsgBacklog,
+			})
+			for _, c := range sub.Consumers {
+				info.Members = append(info.Members, broker.GroupMember{ID: c.ConsumerName, ClientID: c.ConsumerName, Host: c.Address})
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("pulsar: subscription [%s] not found in namespace %s", group, pb.adminNamespace())
+	}
+	if len(info.Members) > 0 {
+		info.State = "Stable"
+	}
+	sort.Slice(info.Lag, func(i, j int) bool {
+		if info.Lag[i].Topic != info.Lag[j].Topic {
+			return info.Lag[i].Topic < info.Lag[j].Topic
+		}
+		return info.Lag[i].Partition < info.Lag[j].Partition
+	})
+
+	return info, nil
+}
+
+// partitionStats 按分区返回主题统计，非分区主题的分区号为 0
+func (pb *pulsarBroker) partitionStats(admin pulsaradmin.Client, topic *utils.TopicName) (map[int]utils.TopicStats, error) {
+	meta, err := admin.Topics().GetMetadata(*topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Partitions == 0 {
+		stats, err := admin.Topics().GetStats(*topic)
+		if err != nil {
+			return nil, err
+		}
+		return map[int]utils.TopicStats{0: stats}, nil
+	}
+
+	stats, err := admin.Topics().GetPartitionedStats(*topic, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]utils.TopicStats, len(stats.Partitions))
+	for name, s := range stats.Partitions {
+		p, err := utils.GetTopicName(name)
+		if err != nil {
+			return nil, err
+		}
+		result[p.GetPartitionIndex()] = s
+	}
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package pulsar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestAdminURL(t *testing.T) {
+	assert.Equal(t, "http://127.0.0.1:8080", adminURL("pulsar://127.0.0.1:6650"))
+	assert.Equal(t, "https://pulsar.example.com:8443", adminURL("pulsar+ssl://pulsar.example.com:6651"))
+	assert.Equal(t, "http://127.0.0.1:8080", adminURL("::bad"))
+}
+
+func TestAdminTopicName(t *testing.T) {
+	b := NewBroker(WithAdminNamespace("tenant/ns")).(*pulsarBroker)
+
+	topic, err := b.topicName("orders")
+	assert.Nil(t, err)
+	assert.Equal(t, "persistent://tenant/ns/orders", topic.String())
+
+	topic, err = b.topicName("non-persistent://public/default/events")
+	assert.Nil(t, err)
+	assert.Equal(t, "non-persistent://public/default/events", topic.String())
+
+	_, ok := broker.AsAdmin(b)
+	assert.True(t, ok)
+}
+
+func TestMergeTopics(t *testing.T) {
+	topics := mergeTopics(
+		[]string{"persistent://public/default/a"},
+		[]string{
+			"persistent://public/default/a-partition-0",
+			"persistent://public/default/a-partition-1",
+			"persistent://public/default/b",
+		},
+	)
+	assert.Equal(t, []string{"persistent://public/default/a", "persistent://public/default/b"}, topics)
+}
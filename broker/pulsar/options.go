@@ -15,6 +15,8 @@ type listenerNameKey struct{}
 type maxConnectionsPerBrokerKey struct{}
 type customMetricsLabelsKey struct{}
 type tlsKey struct{}
+type adminURLKey struct{}
+type adminNamespaceKey struct{}
 
 // WithConnectionTimeout ClientOptions.ConnectionTimeout
 func WithConnectionTimeout(timeout time.Duration) broker.Option {
@@ -41,6 +43,17 @@ func WithCustomMetricsLabels(labels map[string]string) broker.Option {
 	return broker.OptionContextWithValue(customMetricsLabelsKey{}, labels)
 }
 
+// WithAdminURL 管理接口的 Web 服务地址，默认由服务地址推导：pulsar://host:6650 对应 http://host:8080，
+// pulsar+ssl://host:6651 对应 https://host:8443
+func WithAdminURL(url string) broker.Option {
+	return broker.OptionContextWithValue(adminURLKey{}, url)
+}
+
+// WithAdminNamespace ListTopics 和消费组查询所在的命名空间，默认为 public/default
+func WithAdminNamespace(namespace string) broker.Option {
+	return broker.OptionContextWithValue(adminNamespaceKey{}, namespace)
+}
+
 type tlsConfig struct {
 	ClientCertPath          string
 	ClientKeyPath           string
@@ -35,6 +35,14 @@ func (p *publication) Ack() error {
 	return p.reader.Ack(*p.pulsarMsg)
 }
 
+func (p *publication) Nack() error {
+	if p.reader == nil {
+		return errors.New("reader is nil")
+	}
+	p.reader.Nack(*p.pulsarMsg)
+	return nil
+}
+
 func (p *publication) Error() error {
 	return p.err
 }
@@ -0,0 +1,38 @@
+package pulsar
+
+import (
+	"context"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.ResultPublisher = (*pulsarBroker)(nil)
+
+// PublishWithResult 发布消息并等待服务端确认，回执的 MessageID 为 pulsar.MessageID 的字符串形式，
+// Partition 为分区序号（非分区主题为 -1），Offset 为 EntryID。
+func (pb *pulsarBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	buf, err := broker.Marshal(pb.options.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	messageId, err := pb.publish(ctx, topic, buf, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &broker.PublishResult{
+		Topic:     topic,
+		Partition: -1,
+		Offset:    -1,
+		Timestamp: time.Now(),
+	}
+	if messageId != nil {
+		result.MessageID = messageId.String()
+		result.Partition = int(messageId.PartitionIdx())
+		result.Offset = messageId.EntryID()
+	}
+
+	return result, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
 
 	"github.com/tx7do/kratos-transport/broker"
@@ -35,6 +36,8 @@ type pulsarBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -44,6 +47,8 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 		options:     options,
 		producers:   make(map[string]pulsar.Producer),
 		subscribers: broker.NewSubscriberSyncMap(),
+
+		log: newLoggerHelper(options.Logger),
 	}
 
 	return b
@@ -67,6 +72,8 @@ func (pb *pulsarBroker) Options() broker.Options {
 func (pb *pulsarBroker) Init(opts ...broker.Option) error {
 	pb.options.Apply(opts...)
 
+	pb.log = newLoggerHelper(pb.options.Logger)
+
 	pulsarOptions := pulsar.ClientOptions{
 		URL:               defaultAddr,
 		OperationTimeout:  30 * time.Second,
@@ -118,7 +125,7 @@ func (pb *pulsarBroker) Init(opts ...broker.Option) error {
 	var err error
 	pb.client, err = pulsar.NewClient(pulsarOptions)
 	if err != nil {
-		LogErrorf("Could not instantiate Pulsar client: %v", err)
+		pb.log.Errorf("Could not instantiate Pulsar client: %v", err)
 		return err
 	}
 
@@ -178,10 +185,11 @@ func (pb *pulsarBroker) Publish(ctx context.Context, topic string, msg broker.An
 		return err
 	}
 
-	return pb.publish(ctx, topic, buf, opts...)
+	_, err = pb.publish(ctx, topic, buf, opts...)
+	return err
 }
 
-func (pb *pulsarBroker) publish(ctx context.Context, topic string, msg []byte, opts ...broker.PublishOption) error {
+func (pb *pulsarBroker) publish(ctx context.Context, topic string, msg []byte, opts ...broker.PublishOption) (pulsar.MessageID, error) {
 	options := broker.PublishOptions{
 		Context: ctx,
 	}
@@ -224,7 +232,7 @@ func (pb *pulsarBroker) publish(ctx context.Context, topic string, msg []byte, o
 		producer, err = pb.client.CreateProducer(pulsarOptions)
 		if err != nil {
 			pb.Unlock()
-			return err
+			return nil, err
 		}
 
 		pb.producers[topic] = producer
@@ -269,7 +277,7 @@ func (pb *pulsarBroker) publish(ctx context.Context, topic string, msg []byte, o
 	var messageId pulsar.MessageID
 	messageId, err = producer.Send(pb.options.Context, &pulsarMsg)
 	if err != nil {
-		LogErrorf("send message error: %s\n", err)
+		pb.log.Errorf("send message error: %s\n", err)
 		switch cached {
 		case false:
 		case true:
@@ -283,7 +291,7 @@ func (pb *pulsarBroker) publish(ctx context.Context, topic string, msg []byte, o
 				pb.Unlock()
 				break
 			}
-			if _, err = producer.Send(pb.options.Context, &pulsarMsg); err == nil {
+			if messageId, err = producer.Send(pb.options.Context, &pulsarMsg); err == nil {
 				pb.Lock()
 				pb.producers[topic] = producer
 				pb.Unlock()
@@ -298,7 +306,7 @@ func (pb *pulsarBroker) publish(ctx context.Context, topic string, msg []byte, o
 
 	pb.finishProducerSpan(span, msgId, err)
 
-	return err
+	return messageId, err
 }
 
 func (pb *pulsarBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -311,12 +319,30 @@ func (pb *pulsarBroker) Subscribe(topic string, handler broker.Handler, binder b
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(pb, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
 	pulsarOptions := pulsar.ConsumerOptions{
 		Topic:            topic,
 		SubscriptionName: "my-subscription",
 		Type:             pulsar.Shared,
 	}
 
+	// 服务端安装了读取 jms.selector 订阅属性的 entry filter（例如 Starlight for JMS）时由服务端过滤，
+	// 否则只在客户端过滤
+	if filter != nil {
+		pulsarOptions.SubscriptionProperties = map[string]string{
+			"jms.filtering": "true",
+			"jms.selector":  filter.String(),
+		}
+	}
+
 	channel := make(chan pulsar.ConsumerMessage, 100)
 	pulsarOptions.MessageChannel = channel
 
@@ -368,11 +394,20 @@ func (pb *pulsarBroker) Subscribe(topic string, handler broker.Handler, binder b
 
 			ctx, span := pb.startConsumerSpan(sub.options.Context, &cm)
 
+			// 不满足选择器的消息直接确认，不解码也不调用处理器
+			if !filter.Match(m.Headers) {
+				if err = p.Ack(); err != nil {
+					pb.log.Errorf("unable to ack filtered msg: %v", err)
+				}
+				pb.finishConsumerSpan(span, nil)
+				continue
+			}
+
 			if binder != nil {
 				m.Body = binder()
 
 				if err = broker.Unmarshal(pb.options.Codec, cm.Payload(), &m.Body); err != nil {
-					LogErrorf("unmarshal message failed: %v", err)
+					pb.handleError(ctx, topic, sub.options, p, broker.ErrorStageDecode, err)
 					pb.finishConsumerSpan(span, err)
 					continue
 				}
@@ -381,16 +416,14 @@ func (pb *pulsarBroker) Subscribe(topic string, handler broker.Handler, binder b
 			}
 
 			if err = sub.handler(ctx, p); err != nil {
-				p.err = err
-				LogErrorf("handle message failed: %v", err)
+				pb.handleError(ctx, topic, sub.options, p, broker.ErrorStageHandle, err)
 				pb.finishConsumerSpan(span, err)
 				continue
 			}
 
 			if sub.options.AutoAck {
 				if err = p.Ack(); err != nil {
-					p.err = err
-					LogErrorf("unable to commit msg: %v", err)
+					pb.handleError(ctx, topic, sub.options, p, broker.ErrorStageAck, err)
 				}
 			}
 
@@ -403,6 +436,38 @@ func (pb *pulsarBroker) Subscribe(topic string, handler broker.Handler, binder b
 	return sub, nil
 }
 
+// handleError 将失败的消息交给 ErrorHandler 处置
+func (pb *pulsarBroker) handleError(ctx context.Context, topic string, options broker.SubscribeOptions, p *publication, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	pb.log.Errorw("msg", "message failed", "stage", stage, "topic", p.topic, "subscription", options.Queue, "error", err)
+
+	switch broker.HandleError(pb.options.ErrorHandler, ctx, p, stage, err) {
+	case broker.ErrorActionAck:
+		if err = p.Ack(); err != nil {
+			pb.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	case broker.ErrorActionNack:
+		if err = p.Nack(); err != nil {
+			pb.log.Errorf("unable to nack msg: %v", err)
+		}
+
+	case broker.ErrorActionDeadLetter:
+		msg := *p.pulsarMsg
+		headers := broker.DeadLetterHeaders(msg.Properties(), msg.Topic(), stage, err)
+		if _, err = pb.publish(ctx, broker.DeadLetterTopic(topic, options), msg.Payload(), WithHeaders(map[string]string(headers))); err != nil {
+			pb.log.Errorf("publish dead letter failed: %v", err)
+			return
+		}
+		if err = p.Ack(); err != nil {
+			pb.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	default:
+	}
+}
+
 func (pb *pulsarBroker) startProducerSpan(ctx context.Context, topic string, msg *pulsar.ProducerMessage) trace.Span {
 	if pb.producerTracer == nil {
 		return nil
@@ -0,0 +1,43 @@
+package pulsar
+
+import (
+	"net/url"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func init() {
+	broker.Register("pulsar", newBrokerFromURL)
+	broker.Register("pulsar+ssl", newBrokerFromURL)
+}
+
+func newBrokerFromURL(u *url.URL, opts ...broker.Option) (broker.Broker, error) {
+	urlOpts, err := URLOptions(u)
+	if err != nil {
+		return nil, err
+	}
+	return NewBroker(append(urlOpts, opts...)...), nil
+}
+
+// URLOptions 将连接 URL 解析为 broker 选项，例如：
+//
+//	pulsar://host1:6650,host2:6650/?operation_timeout=30s
+//
+// pulsar 客户端本身支持多主机的服务地址，因此主机列表会作为一个地址整体传入。
+func URLOptions(u *url.URL) ([]broker.Option, error) {
+	urlOpts, err := broker.ParseURL(u, broker.URLParams{
+		"connection_timeout":         broker.URLDuration(WithConnectionTimeout),
+		"operation_timeout":          broker.URLDuration(WithOperationTimeout),
+		"listener_name":              broker.URLString(WithListenerName),
+		"max_connections_per_broker": broker.URLInt(WithMaxConnectionsPerBroker),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(u.Host) > 0 {
+		urlOpts = append(urlOpts, broker.WithAddress(u.Scheme+"://"+u.Host))
+	}
+
+	return urlOpts, nil
+}
@@ -0,0 +1,45 @@
+package pulsar
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestURLOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		addrs    []string
+		listener string
+		wantErr  bool
+	}{
+		{name: "service url keeps host list", url: "pulsar://host1:6650,host2:6650/?listener_name=external",
+			addrs: []string{"pulsar://host1:6650,host2:6650"}, listener: "external"},
+		{name: "tls", url: "pulsar+ssl://host1:6651", addrs: []string{"pulsar+ssl://host1:6651"}},
+		{name: "invalid timeout", url: "pulsar://host1:6650/?operation_timeout=x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			assert.Nil(t, err)
+
+			opts, err := URLOptions(u)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			options := broker.NewOptionsAndApply(opts...)
+			assert.Equal(t, tt.addrs, options.Addrs)
+
+			listener, _ := options.Context.Value(listenerNameKey{}).(string)
+			assert.Equal(t, tt.listener, listener)
+		})
+	}
+}
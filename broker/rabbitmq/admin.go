@@ -0,0 +1,133 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.Admin = (*rabbitBroker)(nil)
+
+// RabbitMQ 中主题对应绑定到交换机的队列，消费组对应共享同一个队列的消费者。
+// AMQP 协议无法列出队列和消费者，ListTopics 和 ListConsumerGroups 返回 broker.ErrNotSupported。
+
+// withAdminChannel 在独立的通道上执行管理操作，被动声明失败等通道级错误不会影响收发消息的通道
+func (b *rabbitBroker) withAdminChannel(fn func(ch *amqp.Channel) error) error {
+	if b.conn == nil || b.conn.Connection == nil {
+		return errors.New("not connected")
+	}
+
+	ch, err := b.conn.Connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = ch.Close() }()
+
+	return fn(ch)
+}
+
+// queueArguments 以 "x-" 开头的配置作为队列参数，整数值按整数传递；
+// replication 大于 1 且未指定 x-queue-type 时声明为仲裁队列
+func queueArguments(replication int, config map[string]string) amqp.Table {
+	args := amqp.Table{}
+	for k, v := range config {
+		if !strings.HasPrefix(k, "x-") {
+			continue
+		}
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			args[k] = n
+		} else {
+			args[k] = v
+		}
+	}
+	if _, ok := args["x-queue-type"]; !ok && replication > 1 {
+		args["x-queue-type"] = "quorum"
+	}
+	return args
+}
+
+// CreateTopic 声明持久化队列并绑定到交换机，路由键默认为队列名，可以通过 config["routing_key"] 指定；
+// partitions 被忽略
+func (b *rabbitBroker) CreateTopic(_ context.Context, name string, _, replication int, config map[string]string) error {
+	routingKey := name
+	if key, ok := config["routing_key"]; ok {
+		routingKey = key
+	}
+
+	return b.withAdminChannel(func(ch *amqp.Channel) error {
+		if _, err := ch.QueueDeclare(name, true, false, false, false, queueArguments(replication, config)); err != nil {
+			return err
+		}
+		return ch.QueueBind(name, routingKey, b.conn.exchange.Name, false, nil)
+	})
+}
+
+func (b *rabbitBroker) DeleteTopic(_ context.Context, name string) error {
+	return b.withAdminChannel(func(ch *amqp.Channel) error {
+		_, err := ch.QueueDelete(name, false, false, false)
+		return err
+	})
+}
+
+func (b *rabbitBroker) ListTopics(_ context.Context) ([]string, error) {
+	return nil, broker.ErrNotSupported
+}
+
+func (b *rabbitBroker) inspectQueue(name string) (amqp.Queue, error) {
+	var q amqp.Queue
+	err := b.withAdminChannel(func(ch *amqp.Channel) error {
+		var err error
+		q, err = ch.QueueDeclarePassive(name, false, false, false, false, nil)
+		return err
+	})
+	return q, err
+}
+
+func (b *rabbitBroker) DescribeTopic(_ context.Context, name string) (*broker.TopicInfo, error) {
+	q, err := b.inspectQueue(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &broker.TopicInfo{
+		Name:       q.Name,
+		Partitions: []broker.PartitionInfo{{ID: 0}},
+		Config: map[string]string{
+			"consumers": strconv.Itoa(q.Consumers),
+		},
+		Messages: int64(q.Messages),
+	}, nil
+}
+
+func (b *rabbitBroker) ListConsumerGroups(_ context.Context) ([]string, error) {
+	return nil, broker.ErrNotSupported
+}
+
+// DescribeGroup group 为队列名，延迟为队列中未投递的消息数
+func (b *rabbitBroker) DescribeGroup(_ context.Context, group string) (*broker.GroupInfo, error) {
+	q, err := b.inspectQueue(group)
+	if err != nil {
+		return nil, err
+	}
+
+	state := "Empty"
+	if q.Consumers > 0 {
+		state = "Stable"
+	}
+
+	return &broker.GroupInfo{
+		Name:  q.Name,
+		State: state,
+		Lag: []broker.PartitionLag{{
+			Topic:     q.Name,
+			Committed: -1,
+			End:       -1,
+			Lag:       int64(q.Messages),
+		}},
+	}, nil
+}
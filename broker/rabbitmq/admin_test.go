@@ -0,0 +1,37 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestQueueArguments(t *testing.T) {
+	args := queueArguments(3, map[string]string{
+		"x-message-ttl":          "60000",
+		"x-dead-letter-exchange": "dlx",
+		"routing_key":            "ignored",
+	})
+	assert.Equal(t, int64(60000), args["x-message-ttl"])
+	assert.Equal(t, "dlx", args["x-dead-letter-exchange"])
+	assert.Equal(t, "quorum", args["x-queue-type"])
+	assert.NotContains(t, args, "routing_key")
+
+	args = queueArguments(3, map[string]string{"x-queue-type": "stream"})
+	assert.Equal(t, "stream", args["x-queue-type"])
+
+	assert.NotContains(t, queueArguments(1, nil), "x-queue-type")
+}
+
+func TestAdminNotConnected(t *testing.T) {
+	admin, ok := broker.AsAdmin(NewBroker())
+	assert.True(t, ok)
+
+	assert.Error(t, admin.CreateTopic(context.Background(), "test", 1, 1, nil))
+
+	_, err := admin.ListTopics(context.Background())
+	assert.ErrorIs(t, err, broker.ErrNotSupported)
+}
@@ -3,6 +3,7 @@ package rabbitmq
 import (
 	"context"
 	"errors"
+	"sync"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -11,6 +12,9 @@ type rabbitChannel struct {
 	uuid       string
 	connection *amqp.Connection
 	channel    *amqp.Channel
+
+	confirmOnce sync.Once
+	confirmErr  error
 }
 
 func newRabbitChannel(conn *amqp.Connection, qos Qos) (*rabbitChannel, error) {
@@ -51,6 +55,36 @@ func (r *rabbitChannel) Publish(ctx context.Context, exchangeName, key string, m
 	return r.channel.PublishWithContext(ctx, exchangeName, key, false, false, message)
 }
 
+// PublishWithConfirm 发布消息并等待服务端确认，返回确认的投递序号。
+// 第一次调用时将通道切换为 confirm 模式，之后的 Publish 不受影响。
+func (r *rabbitChannel) PublishWithConfirm(ctx context.Context, exchangeName, key string, message amqp.Publishing) (uint64, error) {
+	if r.channel == nil {
+		return 0, errors.New("channel is nil")
+	}
+
+	r.confirmOnce.Do(func() {
+		r.confirmErr = r.channel.Confirm(false)
+	})
+	if r.confirmErr != nil {
+		return 0, r.confirmErr
+	}
+
+	confirmation, err := r.channel.PublishWithDeferredConfirmWithContext(ctx, exchangeName, key, false, false, message)
+	if err != nil {
+		return 0, err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !acked {
+		return confirmation.DeliveryTag, ErrPublishNacked
+	}
+
+	return confirmation.DeliveryTag, nil
+}
+
 func (r *rabbitChannel) DeclareExchange(exchangeName, kind string, durable, autoDelete bool) error {
 	return r.channel.ExchangeDeclare(
 		exchangeName,
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/tx7do/kratos-transport/broker"
 )
@@ -67,6 +68,8 @@ type rabbitConnection struct {
 	connected      bool
 	close          chan bool
 	waitConnection chan struct{}
+
+	log *log.Helper
 }
 
 func newRabbitMQConnection(opts broker.Options) *rabbitConnection {
@@ -77,6 +80,8 @@ func newRabbitMQConnection(opts broker.Options) *rabbitConnection {
 		exchange:       DefaultExchange,
 		close:          make(chan bool),
 		waitConnection: make(chan struct{}),
+
+		log: newLoggerHelper(opts.Logger),
 	}
 
 	conn.init()
@@ -161,19 +166,19 @@ func (r *rabbitConnection) reconnect(secure bool, config *amqp.Config) {
 				// Channel closed, probably also the channel or connection.
 				return
 			}
-			LogErrorf("notify error reason: %s, description: %s", result.ReplyText, result.Exchange)
+			r.log.Errorf("notify error reason: %s, description: %s", result.ReplyText, result.Exchange)
 		case err := <-chanNotifyClose:
-			LogError(err)
+			r.log.Error(err)
 			r.Lock()
 			r.connected = false
 			r.waitConnection = make(chan struct{})
 			r.Unlock()
 		case err := <-notifyClose:
-			LogError(err)
+			r.log.Error(err)
 
 			select {
 			case errs := <-chanNotifyClose:
-				LogError(errs)
+				r.log.Error(errs)
 			case <-time.After(time.Second):
 			}
 
@@ -308,6 +313,14 @@ func (r *rabbitConnection) Publish(ctx context.Context, exchangeName, routingKey
 	return r.ExchangeChannel.Publish(ctx, exchangeName, routingKey, msg)
 }
 
+func (r *rabbitConnection) PublishWithConfirm(ctx context.Context, exchangeName, routingKey string, msg amqp.Publishing) (uint64, error) {
+	if err := r.lazyInitPublishChannel(); err != nil {
+		return 0, err
+	}
+
+	return r.ExchangeChannel.PublishWithConfirm(ctx, exchangeName, routingKey, msg)
+}
+
 func (r *rabbitConnection) lazyInitPublishChannel() error {
 	r.Lock()
 	defer r.Unlock()
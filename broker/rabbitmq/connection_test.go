@@ -81,3 +81,19 @@ func TestHasRabbitUrlPrefix(t *testing.T) {
 	assert.False(t, hasUrlPrefix("https://example.com"))
 	assert.False(t, hasUrlPrefix("example.com"))
 }
+
+func TestHeadersBindArgs(t *testing.T) {
+	filter, err := broker.ParseFilter("type = 'order.created' OR region = 'eu'")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"x-match": "any", "type": "order.created", "region": "eu"}, headersBindArgs(filter, nil))
+
+	filter, err = broker.ParseFilter("type = 'order.created'")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"x-match": "all", "type": "order.created", "region": "us"},
+		headersBindArgs(filter, map[string]interface{}{"region": "us"}))
+
+	filter, err = broker.ParseFilter("priority > 5")
+	assert.Nil(t, err)
+	assert.Nil(t, headersBindArgs(filter, nil))
+	assert.Nil(t, headersBindArgs(nil, nil))
+}
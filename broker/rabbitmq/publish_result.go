@@ -0,0 +1,56 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.ResultPublisher = (*rabbitBroker)(nil)
+
+// ErrPublishNacked 服务端拒绝了消息（publisher confirm 返回 nack）
+var ErrPublishNacked = errors.New("rabbitmq: message nacked by broker")
+
+// PublishWithResult 发布消息并等待 publisher confirm，回执的 Offset 为确认的投递序号。
+// RabbitMQ 不为消息分配 ID，MessageID 为通过 WithMessageId 设置的值。
+func (b *rabbitBroker) PublishWithResult(ctx context.Context, routingKey string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	if b.conn == nil {
+		return nil, errors.New("connection is nil")
+	}
+
+	buf, err := broker.Marshal(b.options.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	options := broker.PublishOptions{
+		Context: ctx,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	pub, err := b.newPublishing(routingKey, buf, options)
+	if err != nil {
+		return nil, err
+	}
+
+	span := b.startProducerSpan(options.Context, routingKey, &pub)
+
+	tag, err := b.conn.PublishWithConfirm(ctx, b.conn.exchange.Name, routingKey, pub)
+
+	b.finishProducerSpan(span, routingKey, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &broker.PublishResult{
+		Topic:     routingKey,
+		Offset:    int64(tag),
+		MessageID: pub.MessageId,
+		Timestamp: time.Now(),
+	}, nil
+}
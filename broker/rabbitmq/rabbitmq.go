@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/tx7do/kratos-transport/broker"
 	"github.com/tx7do/kratos-transport/tracing"
@@ -26,6 +27,8 @@ type rabbitBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -34,6 +37,8 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 	b := &rabbitBroker{
 		options:     options,
 		subscribers: broker.NewSubscriberSyncMap(),
+
+		log: newLoggerHelper(options.Logger),
 	}
 
 	return b
@@ -57,6 +62,8 @@ func (b *rabbitBroker) Address() string {
 func (b *rabbitBroker) Init(opts ...broker.Option) error {
 	b.options.Apply(opts...)
 
+	b.log = newLoggerHelper(b.options.Logger)
+
 	var addrs []string
 	for _, addr := range b.options.Addrs {
 		if len(addr) == 0 {
@@ -134,6 +141,22 @@ func (b *rabbitBroker) publish(ctx context.Context, routingKey string, buf []byt
 		o(&options)
 	}
 
+	msg, err := b.newPublishing(routingKey, buf, options)
+	if err != nil {
+		return err
+	}
+
+	span := b.startProducerSpan(options.Context, routingKey, &msg)
+
+	err = b.conn.Publish(ctx, b.conn.exchange.Name, routingKey, msg)
+
+	b.finishProducerSpan(span, routingKey, err)
+
+	return err
+}
+
+// newPublishing 根据发布选项创建消息，需要时声明发布队列
+func (b *rabbitBroker) newPublishing(routingKey string, buf []byte, options broker.PublishOptions) (amqp.Publishing, error) {
 	msg := amqp.Publishing{
 		Body:    buf,
 		Headers: amqp.Table{},
@@ -198,17 +221,11 @@ func (b *rabbitBroker) publish(ctx context.Context, routingKey string, buf []byt
 			val.AutoDelete = false
 		}
 		if err := b.conn.DeclarePublishQueue(val.Queue, routingKey, val.BindArguments, val.QueueArguments, val.Durable, val.AutoDelete); err != nil {
-			return err
+			return msg, err
 		}
 	}
 
-	span := b.startProducerSpan(options.Context, routingKey, &msg)
-
-	err := b.conn.Publish(ctx, b.conn.exchange.Name, routingKey, msg)
-
-	b.finishProducerSpan(span, routingKey, err)
-
-	return nil
+	return msg, nil
 }
 
 func (b *rabbitBroker) Subscribe(routingKey string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -224,6 +241,18 @@ func (b *rabbitBroker) Subscribe(routingKey string, handler broker.Handler, bind
 		o(&options)
 	}
 
+	var singleActive bool
+	if lease, ok := options.SingleActiveConsumer.(broker.NativeLease); ok && lease.Native() == b.Name() {
+		singleActive = true
+	} else if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, routingKey, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
 	var requeueOnError = false
 	if val, ok := options.Context.Value(requeueOnErrorKey{}).(bool); ok {
 		requeueOnError = val
@@ -245,21 +274,36 @@ func (b *rabbitBroker) Subscribe(routingKey string, handler broker.Handler, bind
 
 		p := &publication{d: msg, message: m, topic: msg.RoutingKey}
 
+		// 不满足选择器的消息直接确认，不解码也不调用处理器
+		if !filter.Match(m.Headers) {
+			if !options.AutoAck {
+				if err := msg.Ack(false); err != nil {
+					b.log.Errorf("unable to ack filtered msg: %v", err)
+				}
+			}
+			b.finishConsumerSpan(span, nil)
+			return
+		}
+
 		if binder != nil {
 			m.Body = binder()
 
 			if p.err = broker.Unmarshal(b.options.Codec, msg.Body, &m.Body); p.err != nil {
-				LogErrorf("unmarshal message failed: %v", p.err)
+				b.handleError(ctx, routingKey, options, p, broker.ErrorStageDecode, p.err, requeueOnError)
+				b.finishConsumerSpan(span, p.err)
+				return
 			}
 		} else {
 			m.Body = msg.Body
 		}
 
 		p.err = handler(ctx, p)
-		if p.err == nil && ackSuccess && !options.AutoAck {
-			_ = msg.Ack(false)
-		} else if p.err != nil && !options.AutoAck {
-			_ = msg.Nack(false, requeueOnError)
+		if p.err != nil {
+			b.handleError(ctx, routingKey, options, p, broker.ErrorStageHandle, p.err, requeueOnError)
+		} else if ackSuccess && !options.AutoAck {
+			if p.err = msg.Ack(false); p.err != nil {
+				b.handleError(ctx, routingKey, options, p, broker.ErrorStageAck, p.err, requeueOnError)
+			}
 		}
 
 		b.finishConsumerSpan(span, p.err)
@@ -294,6 +338,18 @@ func (b *rabbitBroker) Subscribe(routingKey string, handler broker.Handler, bind
 		sub.queueArgs = val
 	}
 
+	if singleActive {
+		args := map[string]interface{}{"x-single-active-consumer": true}
+		for k, v := range sub.queueArgs {
+			args[k] = v
+		}
+		sub.queueArgs = args
+	}
+
+	if b.conn.exchange.Type == "headers" {
+		sub.headers = headersBindArgs(filter, sub.headers)
+	}
+
 	b.subscribers.Add(routingKey, sub)
 
 	go sub.resubscribe()
@@ -301,6 +357,71 @@ func (b *rabbitBroker) Subscribe(routingKey string, handler broker.Handler, bind
 	return sub, nil
 }
 
+// headersBindArgs 选择器仅由等值条件组成时映射为 headers 交换机的绑定参数，由服务端完成过滤；
+// 其他选择器只在客户端过滤。已有的绑定参数优先。
+func headersBindArgs(filter *broker.Filter, args map[string]interface{}) map[string]interface{} {
+	eqs, mode, ok := filter.Equalities()
+	if !ok {
+		return args
+	}
+
+	out := map[string]interface{}{"x-match": mode}
+	for k, v := range eqs {
+		out[k] = v
+	}
+	for k, v := range args {
+		out[k] = v
+	}
+	return out
+}
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 未指定死信队列时，死信通过 Nack(requeue=false) 交由队列上配置的 DLX 处理。
+func (b *rabbitBroker) handleError(ctx context.Context, routingKey string, options broker.SubscribeOptions, p *publication, stage broker.ErrorStage, err error, requeueOnError bool) {
+	p.err = err
+
+	b.log.Errorw("msg", "message failed", "stage", stage, "routing_key", p.d.RoutingKey, "queue", options.Queue, "error", err)
+
+	action := broker.HandleError(b.options.ErrorHandler, ctx, p, stage, err)
+
+	if action == broker.ErrorActionDeadLetter && len(options.DeadLetterQueue) > 0 {
+		headers := make(map[string]interface{})
+		for k, v := range broker.DeadLetterHeaders(rabbitHeaderToMap(p.d.Headers), p.d.RoutingKey, stage, err) {
+			headers[k] = v
+		}
+		if err = b.publish(ctx, options.DeadLetterQueue, p.d.Body, WithPublishHeaders(headers)); err != nil {
+			b.log.Errorf("publish dead letter failed: %v", err)
+			return
+		}
+		action = broker.ErrorActionAck
+	}
+
+	// 自动确认模式下消息已被服务端确认，无法再 Ack 或 Nack
+	if options.AutoAck {
+		if action == broker.ErrorActionNack || action == broker.ErrorActionDeadLetter {
+			b.log.Warnf("error action %s cannot be applied in auto-ack mode, message is already acknowledged", action)
+		}
+		return
+	}
+
+	switch action {
+	case broker.ErrorActionAck:
+		err = p.d.Ack(false)
+	case broker.ErrorActionNack:
+		err = p.d.Nack(false, true)
+	case broker.ErrorActionDeadLetter:
+		err = p.d.Nack(false, false)
+	default:
+		if stage == broker.ErrorStageAck {
+			return
+		}
+		err = p.d.Nack(false, requeueOnError)
+	}
+	if err != nil {
+		b.log.Errorf("unable to settle msg: %v", err)
+	}
+}
+
 func (b *rabbitBroker) startProducerSpan(ctx context.Context, routingKey string, msg *amqp.Publishing) trace.Span {
 	if b.producerTracer == nil {
 		return nil
@@ -0,0 +1,31 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// SingleActiveConsumer 使用队列参数 x-single-active-consumer 实现单活消费：
+// 同一队列的多个消费者中只有一个接收消息，它断开后服务端自动切换到下一个消费者，无需外部选主。
+// 只能在 x-single-active-consumer 尚未生效的新队列上使用，已存在的队列无法修改参数。
+//
+//	srv.RegisterSubscriber(ctx, "projector", "projector", false, handler, binder,
+//		broker.WithSingleActiveConsumer(rabbitmq.SingleActiveConsumer()))
+func SingleActiveConsumer() broker.NativeLease {
+	return nativeLease{}
+}
+
+type nativeLease struct{}
+
+func (nativeLease) Native() string {
+	return "rabbitmq"
+}
+
+func (nativeLease) Acquire(context.Context) (<-chan struct{}, error) {
+	return nil, broker.ErrNotSupported
+}
+
+func (nativeLease) Release(context.Context) error {
+	return nil
+}
@@ -0,0 +1,83 @@
+package record
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+///
+/// Option
+///
+
+type options struct {
+	directions map[Direction]bool
+	topics     map[string]bool
+	logger     log.Logger
+}
+
+func newOptions(defaults []Direction, opts []Option) options {
+	o := options{directions: make(map[Direction]bool)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.directions) == 0 {
+		for _, d := range defaults {
+			o.directions[d] = true
+		}
+	}
+	return o
+}
+
+// match 是否处理该方向和 topic 的消息
+func (o *options) match(direction Direction, topic string) bool {
+	if !o.directions[direction] {
+		return false
+	}
+	return len(o.topics) == 0 || o.topics[topic]
+}
+
+type Option func(*options)
+
+// WithDirections 录制或回放的消息方向，录制默认为全部，回放默认只回放 Consumed
+func WithDirections(directions ...Direction) Option {
+	return func(o *options) {
+		for _, d := range directions {
+			o.directions[d] = true
+		}
+	}
+}
+
+// WithTopics 只录制或回放指定的 topic，默认为全部
+func WithTopics(topics ...string) Option {
+	return func(o *options) {
+		if o.topics == nil {
+			o.topics = make(map[string]bool)
+		}
+		for _, t := range topics {
+			o.topics[t] = true
+		}
+	}
+}
+
+// WithLogger 日志器，为空时使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+///
+/// ReplayOption
+///
+
+type replayOptions struct {
+	speed float64
+}
+
+type ReplayOption func(*replayOptions)
+
+// WithSpeed 回放速度，1 为原始速度，2 为两倍速，小于等于 0 时不等待，默认为 1
+func WithSpeed(speed float64) ReplayOption {
+	return func(o *replayOptions) {
+		o.speed = speed
+	}
+}
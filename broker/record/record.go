@@ -0,0 +1,64 @@
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// Direction 消息的方向
+type Direction string
+
+const (
+	Published Direction = "publish" // 通过 Publish 发出的消息
+	Consumed  Direction = "consume" // 订阅者收到的消息
+)
+
+// Record 录制的一条消息，以 JSON Lines 格式逐行写入，Data 以 base64 编码
+type Record struct {
+	Time      time.Time      `json:"time"`
+	Direction Direction      `json:"direction"`
+	Topic     string         `json:"topic"`
+	Headers   broker.Headers `json:"headers,omitempty"`
+	Codec     string         `json:"codec,omitempty"`
+	Data      []byte         `json:"data"`
+}
+
+// Writer 以 JSON Lines 格式写入录制，可以并发使用
+type Writer struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+func (w *Writer) Write(r *Record) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.enc.Encode(r)
+}
+
+// Reader 逐条读取 JSON Lines 格式的录制
+type Reader struct {
+	dec *json.Decoder
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// Next 读取下一条录制，读完时返回 io.EOF
+func (r *Reader) Next() (*Record, error) {
+	var rec Record
+	if err := r.dec.Decode(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
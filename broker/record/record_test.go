@@ -0,0 +1,175 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/memory"
+)
+
+type order struct {
+	ID int `json:"id"`
+}
+
+func newMemoryBroker(t *testing.T) broker.Broker {
+	b := memory.NewBroker(broker.WithCodec("json"))
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBroker(newMemoryBroker(t), &buf)
+
+	_, err := b.Subscribe("order", func(context.Context, broker.Event) error { return nil }, func() broker.Any { return &order{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Publish(context.Background(), "order", &order{ID: 1}, memory.WithHeaders(broker.Headers{"k": "v"})); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(&buf)
+	var recs []*Record
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		recs = append(recs, rec)
+	}
+
+	if len(recs) != 2 {
+		t.Fatalf("unexpected records: %v", recs)
+	}
+	consumed, published := recs[0], recs[1]
+	if consumed.Direction != Consumed || consumed.Headers["k"] != "v" || string(consumed.Data) != `{"id":1}` {
+		t.Errorf("unexpected consumed record: %+v", consumed)
+	}
+	if published.Direction != Published || published.Topic != "order" || published.Codec != "json" || string(published.Data) != `{"id":1}` {
+		t.Errorf("unexpected published record: %+v", published)
+	}
+}
+
+func TestRecordPublishFailed(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBroker(memory.NewBroker(), &buf)
+
+	if err := b.Publish(context.Background(), "order", "hello"); err == nil {
+		t.Fatal("expected publish error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("failed publish recorded: %s", buf.String())
+	}
+}
+
+func writeRecording(t *testing.T, recs ...*Record) *bytes.Buffer {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, rec := range recs {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf
+}
+
+func TestReplay(t *testing.T) {
+	now := time.Now()
+	buf := writeRecording(t,
+		&Record{Time: now, Direction: Consumed, Topic: "order", Codec: "json", Headers: broker.Headers{"k": "v"}, Data: []byte(`{"id":1}`)},
+		&Record{Time: now, Direction: Published, Topic: "order", Codec: "json", Data: []byte(`{"id":2}`)},
+		&Record{Time: now.Add(50 * time.Millisecond), Direction: Consumed, Topic: "order", Codec: "json", Data: []byte(`{"id":3}`)},
+		&Record{Time: now.Add(50 * time.Millisecond), Direction: Consumed, Topic: "other", Data: []byte("raw")},
+	)
+
+	r := NewReplayer(newMemoryBroker(t))
+
+	var ids []int
+	_, err := broker.Subscribe(r, "order", func(_ context.Context, _ string, headers broker.Headers, o *order) error {
+		if o.ID == 1 && headers["k"] != "v" {
+			t.Errorf("headers not replayed: %v", headers)
+		}
+		ids = append(ids, o.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw []string
+	_, _ = r.Subscribe("other", func(_ context.Context, evt broker.Event) error {
+		raw = append(raw, string(evt.Message().Body.([]byte)))
+		return errors.New("failed")
+	}, nil)
+
+	start := time.Now()
+	err = r.Replay(context.Background(), buf)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("original speed not kept: %s", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("handler error not returned: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("unexpected replayed orders: %v", ids)
+	}
+	if len(raw) != 1 || raw[0] != "raw" {
+		t.Errorf("unexpected replayed raw messages: %v", raw)
+	}
+}
+
+func TestReplayOptions(t *testing.T) {
+	now := time.Now()
+	recs := []*Record{
+		{Time: now, Direction: Published, Topic: "a", Data: []byte("1")},
+		{Time: now.Add(time.Hour), Direction: Consumed, Topic: "b", Data: []byte("2")},
+		{Time: now.Add(2 * time.Hour), Direction: Consumed, Topic: "a", Data: []byte("3")},
+	}
+
+	r := NewReplayer(newMemoryBroker(t), WithDirections(Published, Consumed), WithTopics("a"))
+
+	var got []string
+	sub, _ := r.Subscribe("a", func(_ context.Context, evt broker.Event) error {
+		got = append(got, string(evt.Message().Body.([]byte)))
+		return nil
+	}, nil)
+
+	if err := r.Replay(context.Background(), writeRecording(t, recs...), WithSpeed(0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "1" || got[1] != "3" {
+		t.Errorf("unexpected replayed messages: %v", got)
+	}
+
+	_ = sub.Unsubscribe(true)
+	if err := r.Replay(context.Background(), writeRecording(t, recs...), WithSpeed(0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("unsubscribed handler called: %v", got)
+	}
+}
+
+func TestReplayCanceled(t *testing.T) {
+	now := time.Now()
+	buf := writeRecording(t,
+		&Record{Time: now, Direction: Consumed, Topic: "a"},
+		&Record{Time: now.Add(time.Hour), Direction: Consumed, Topic: "a"},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := NewReplayer(newMemoryBroker(t)).Replay(ctx, buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
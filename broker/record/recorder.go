@@ -0,0 +1,125 @@
+package record
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// recordBroker 将经过 broker 的消息录制到 io.Writer。
+// 发布的消息使用 broker 的编解码器编码后录制，驱动专有的发布选项（例如消息头）不会被录制；
+// 收到的消息录制消息头和消息体，消息体为 []byte 时原样录制，否则使用编解码器重新编码。
+//
+// 传输层的 Server 内嵌了 broker.Broker，可以直接替换：
+//
+//	srv := kafka.NewServer(...)
+//	srv.Broker = record.NewBroker(srv.Broker, file)
+type recordBroker struct {
+	broker.Broker
+
+	w       *Writer
+	options options
+	log     *log.Helper
+}
+
+func NewBroker(b broker.Broker, w io.Writer, opts ...Option) broker.Broker {
+	o := newOptions([]Direction{Published, Consumed}, opts)
+
+	return &recordBroker{
+		Broker:  b,
+		w:       NewWriter(w),
+		options: o,
+		log:     newLoggerHelper(o.logger),
+	}
+}
+
+// Unwrap 返回被装饰的 broker
+func (b *recordBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+func (b *recordBroker) codecName() string {
+	if codec := b.Options().Codec; codec != nil {
+		return codec.Name()
+	}
+	return ""
+}
+
+func (b *recordBroker) write(direction Direction, topic string, headers broker.Headers, data []byte) {
+	if err := b.w.Write(&Record{
+		Time:      time.Now(),
+		Direction: direction,
+		Topic:     topic,
+		Headers:   headers,
+		Codec:     b.codecName(),
+		Data:      data,
+	}); err != nil {
+		b.log.Errorf("record [%s] message on [%s] failed: %s", direction, topic, err.Error())
+	}
+}
+
+func (b *recordBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	if err := b.Broker.Publish(ctx, topic, msg, opts...); err != nil {
+		return err
+	}
+
+	b.recordPublished(topic, msg)
+
+	return nil
+}
+
+func (b *recordBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	result, err := broker.PublishWithResult(ctx, b.Broker, topic, msg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	b.recordPublished(topic, msg)
+
+	return result, nil
+}
+
+// recordPublished 录制发布成功的消息
+func (b *recordBroker) recordPublished(topic string, msg broker.Any) {
+	if !b.options.match(Published, topic) {
+		return
+	}
+
+	data, err := broker.Marshal(b.Options().Codec, msg)
+	if err != nil {
+		b.log.Errorf("marshal published message on [%s] failed: %s", topic, err.Error())
+		return
+	}
+	b.write(Published, topic, nil, data)
+}
+
+func (b *recordBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	return b.Broker.Subscribe(topic, func(ctx context.Context, evt broker.Event) error {
+		if b.options.match(Consumed, evt.Topic()) {
+			b.recordEvent(evt)
+		}
+		return handler(ctx, evt)
+	}, binder, opts...)
+}
+
+func (b *recordBroker) recordEvent(evt broker.Event) {
+	m := evt.Message()
+	if m == nil {
+		return
+	}
+
+	data, ok := m.Body.([]byte)
+	if !ok {
+		var err error
+		if data, err = broker.Marshal(b.Options().Codec, m.Body); err != nil {
+			b.log.Errorf("marshal consumed message on [%s] failed: %s", evt.Topic(), err.Error())
+			return
+		}
+	}
+
+	b.write(Consumed, evt.Topic(), m.Headers, data)
+}
@@ -0,0 +1,221 @@
+package record
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// Replayer 记录在 broker 上注册的订阅者，并把录制的消息直接交给这些订阅者处理。
+// 订阅同时转发给内部的 broker，回放不经过网络，可以配合 memory broker 在测试中使用：
+//
+//	r := record.NewReplayer(memory.NewBroker())
+//	srv := kafka.NewServer(...)
+//	srv.Broker = r
+//	_ = srv.Start(ctx)
+//	err := r.Replay(ctx, file, record.WithSpeed(0))
+type Replayer struct {
+	broker.Broker
+
+	sync.RWMutex
+	subscribers map[string][]*replaySubscriber
+
+	options options
+	log     *log.Helper
+}
+
+func NewReplayer(b broker.Broker, opts ...Option) *Replayer {
+	o := newOptions([]Direction{Consumed}, opts)
+
+	return &Replayer{
+		Broker:      b,
+		subscribers: make(map[string][]*replaySubscriber),
+		options:     o,
+		log:         newLoggerHelper(o.logger),
+	}
+}
+
+// Unwrap 返回被装饰的 broker
+func (r *Replayer) Unwrap() broker.Broker {
+	return r.Broker
+}
+
+func (r *Replayer) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	inner, err := r.Broker.Subscribe(topic, handler, binder, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &replaySubscriber{
+		Subscriber: inner,
+		r:          r,
+		topic:      topic,
+		handler:    handler,
+		binder:     binder,
+	}
+
+	r.Lock()
+	r.subscribers[topic] = append(r.subscribers[topic], sub)
+	r.Unlock()
+
+	return sub, nil
+}
+
+func (r *Replayer) removeSubscriber(sub *replaySubscriber) {
+	r.Lock()
+	defer r.Unlock()
+
+	subs := r.subscribers[sub.topic]
+	for i, s := range subs {
+		if s == sub {
+			r.subscribers[sub.topic] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(r.subscribers[sub.topic]) == 0 {
+		delete(r.subscribers, sub.topic)
+	}
+}
+
+// Replay 按录制顺序回放消息，并按录制的时间间隔等待。
+// 处理器返回的错误不会中断回放，全部合并后返回；ctx 取消或读取失败时立即返回。
+func (r *Replayer) Replay(ctx context.Context, rd io.Reader, opts ...ReplayOption) error {
+	o := replayOptions{speed: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	reader := NewReader(rd)
+
+	var (
+		errs  []error
+		first time.Time
+		start time.Time
+	)
+
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("record: read recording failed: %w", err))...)
+		}
+
+		if !r.options.match(rec.Direction, rec.Topic) {
+			continue
+		}
+
+		if first.IsZero() {
+			first, start = rec.Time, time.Now()
+		} else if o.speed > 0 {
+			offset := time.Duration(float64(rec.Time.Sub(first)) / o.speed)
+			if err = sleepUntil(ctx, start.Add(offset)); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+		}
+
+		if err = ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		errs = append(errs, r.dispatch(ctx, rec)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Replayer) dispatch(ctx context.Context, rec *Record) []error {
+	r.RLock()
+	subs := append([]*replaySubscriber(nil), r.subscribers[rec.Topic]...)
+	r.RUnlock()
+
+	if len(subs) == 0 {
+		r.log.Debugf("no subscriber for replayed message on [%s]", rec.Topic)
+		return nil
+	}
+
+	var codec encoding.Codec
+	if len(rec.Codec) > 0 {
+		if codec = encoding.GetCodec(rec.Codec); codec == nil {
+			return []error{fmt.Errorf("record: codec %q not registered", rec.Codec)}
+		}
+	}
+
+	var errs []error
+	for _, sub := range subs {
+		if err := sub.replay(ctx, codec, rec); err != nil {
+			r.log.Errorf("replay message on [%s] failed: %s", rec.Topic, err.Error())
+			errs = append(errs, fmt.Errorf("record: replay [%s] failed: %w", rec.Topic, err))
+		}
+	}
+	return errs
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type replaySubscriber struct {
+	broker.Subscriber
+
+	r *Replayer
+
+	topic   string
+	handler broker.Handler
+	binder  broker.Binder
+}
+
+func (s *replaySubscriber) replay(ctx context.Context, codec encoding.Codec, rec *Record) error {
+	m := &broker.Message{Headers: rec.Headers}
+
+	if s.binder != nil {
+		m.Body = s.binder()
+		if err := broker.Unmarshal(codec, rec.Data, &m.Body); err != nil {
+			return err
+		}
+	} else {
+		m.Body = rec.Data
+	}
+
+	return s.handler(ctx, &replayEvent{topic: rec.Topic, m: m, data: rec.Data})
+}
+
+func (s *replaySubscriber) Unsubscribe(removeFromManager bool) error {
+	s.r.removeSubscriber(s)
+	return s.Subscriber.Unsubscribe(removeFromManager)
+}
+
+// replayEvent 回放的消息，无需确认
+type replayEvent struct {
+	topic string
+	m     *broker.Message
+	data  []byte
+}
+
+func (e *replayEvent) Topic() string            { return e.topic }
+func (e *replayEvent) Message() *broker.Message { return e.m }
+func (e *replayEvent) RawMessage() interface{}  { return e.data }
+func (e *replayEvent) Ack() error               { return nil }
+func (e *replayEvent) Error() error             { return nil }
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tx7do/kratos-transport/broker/claimcheck"
+)
+
+var _ claimcheck.Store = (*ClaimCheckStore)(nil)
+
+// ClaimCheckStore 基于 Redis 的 Claim-Check 对象存储，过期时间使用 Redis 的键过期实现
+type ClaimCheckStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewClaimCheckStore 使用客户端创建对象存储，prefix 为键的前缀
+func NewClaimCheckStore(client redis.UniversalClient, prefix string) *ClaimCheckStore {
+	return &ClaimCheckStore{client: client, prefix: prefix}
+}
+
+func (s *ClaimCheckStore) Put(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	// ttl 为 0 时不过期
+	return s.client.Set(ctx, s.prefix+key, data, ttl).Err()
+}
+
+func (s *ClaimCheckStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, claimcheck.ErrNotFound
+	}
+	return data, err
+}
+
+func (s *ClaimCheckStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
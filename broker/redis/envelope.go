@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnvelopeFormat 发布订阅模式下消息的封装格式，封装后的消息可以携带消息头、消息 ID 和发布时间
+type EnvelopeFormat string
+
+const (
+	// EnvelopeNone 不封装，直接发布编码后的消息体
+	EnvelopeNone EnvelopeFormat = ""
+	// EnvelopeJSON JSON 对象，消息体是合法的 JSON 时直接嵌入（空白会被压缩），否则使用 base64
+	EnvelopeJSON EnvelopeFormat = "json"
+	// EnvelopeBinary 紧凑的二进制帧
+	EnvelopeBinary EnvelopeFormat = "binary"
+)
+
+const envelopeVersion = 1
+
+var (
+	// binaryEnvelopeMagic 二进制帧的开头，以 0 字节开始，不会与文本或 JSON 消息混淆
+	binaryEnvelopeMagic = []byte{0x00, 'K', 'T', 'E', envelopeVersion}
+	// jsonEnvelopePrefix JSON 封装的开头，字段顺序固定
+	jsonEnvelopePrefix = []byte(`{"kte":`)
+)
+
+var errInvalidEnvelope = errors.New("redis: invalid envelope")
+
+// envelope 封装后的消息
+type envelope struct {
+	id        string
+	timestamp time.Time
+	headers   map[string]string
+	body      []byte
+}
+
+func newEnvelope(headers map[string]string, body []byte) envelope {
+	return envelope{
+		id:        uuid.New().String(),
+		timestamp: time.Now(),
+		headers:   headers,
+		body:      body,
+	}
+}
+
+type jsonEnvelope struct {
+	Version   int               `json:"kte"`
+	ID        string            `json:"id,omitempty"`
+	Timestamp int64             `json:"ts,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	Data      []byte            `json:"data,omitempty"`
+}
+
+func encodeEnvelope(format EnvelopeFormat, e envelope) ([]byte, error) {
+	switch format {
+	case EnvelopeNone:
+		return e.body, nil
+	case EnvelopeJSON:
+		return encodeJSONEnvelope(e)
+	case EnvelopeBinary:
+		return encodeBinaryEnvelope(e), nil
+	default:
+		return nil, fmt.Errorf("redis: unknown envelope format %q", format)
+	}
+}
+
+// decodeEnvelope 识别并解析封装后的消息，不是封装格式时返回 false，由调用方按原始消息处理
+func decodeEnvelope(data []byte) (envelope, bool) {
+	var (
+		e   envelope
+		err error
+	)
+	switch {
+	case bytes.HasPrefix(data, binaryEnvelopeMagic):
+		e, err = decodeBinaryEnvelope(data[len(binaryEnvelopeMagic):])
+	case bytes.HasPrefix(data, jsonEnvelopePrefix):
+		e, err = decodeJSONEnvelope(data)
+	default:
+		return envelope{}, false
+	}
+	if err != nil {
+		return envelope{}, false
+	}
+	return e, true
+}
+
+func encodeJSONEnvelope(e envelope) ([]byte, error) {
+	je := jsonEnvelope{
+		Version: envelopeVersion,
+		ID:      e.id,
+		Headers: e.headers,
+	}
+	if !e.timestamp.IsZero() {
+		je.Timestamp = e.timestamp.UnixMilli()
+	}
+	if json.Valid(e.body) {
+		je.Body = e.body
+	} else {
+		je.Data = e.body
+	}
+	return json.Marshal(je)
+}
+
+func decodeJSONEnvelope(data []byte) (envelope, error) {
+	var je jsonEnvelope
+	if err := json.Unmarshal(data, &je); err != nil {
+		return envelope{}, err
+	}
+	if je.Version != envelopeVersion {
+		return envelope{}, errInvalidEnvelope
+	}
+
+	e := envelope{
+		id:      je.ID,
+		headers: je.Headers,
+		body:    je.Data,
+	}
+	if len(je.Body) > 0 {
+		e.body = je.Body
+	}
+	if je.Timestamp > 0 {
+		e.timestamp = time.UnixMilli(je.Timestamp)
+	}
+	return e, nil
+}
+
+// encodeBinaryEnvelope 帧格式：magic | id | timestamp | 消息头数量 | (key | value)... | 消息体，
+// 字符串为 uvarint 长度加内容，timestamp 为毫秒的 varint，消息体占据剩余部分
+func encodeBinaryEnvelope(e envelope) []byte {
+	size := len(binaryEnvelopeMagic) + len(e.id) + len(e.body) + 3*binary.MaxVarintLen64
+	for k, v := range e.headers {
+		size += len(k) + len(v) + 2*binary.MaxVarintLen64
+	}
+
+	buf := make([]byte, 0, size)
+	buf = append(buf, binaryEnvelopeMagic...)
+	buf = appendString(buf, e.id)
+	var ts int64
+	if !e.timestamp.IsZero() {
+		ts = e.timestamp.UnixMilli()
+	}
+	buf = binary.AppendVarint(buf, ts)
+	buf = binary.AppendUvarint(buf, uint64(len(e.headers)))
+	for k, v := range e.headers {
+		buf = appendString(buf, k)
+		buf = appendString(buf, v)
+	}
+	return append(buf, e.body...)
+}
+
+func decodeBinaryEnvelope(data []byte) (envelope, error) {
+	var e envelope
+
+	id, data, err := readString(data)
+	if err != nil {
+		return e, err
+	}
+	e.id = id
+
+	ts, n := binary.Varint(data)
+	if n <= 0 {
+		return e, errInvalidEnvelope
+	}
+	data = data[n:]
+	if ts > 0 {
+		e.timestamp = time.UnixMilli(ts)
+	}
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return e, errInvalidEnvelope
+	}
+	data = data[n:]
+
+	if count > 0 {
+		e.headers = make(map[string]string, count)
+	}
+	for i := uint64(0); i < count; i++ {
+		var k, v string
+		if k, data, err = readString(data); err != nil {
+			return e, err
+		}
+		if v, data, err = readString(data); err != nil {
+			return e, err
+		}
+		e.headers[k] = v
+	}
+
+	e.body = data
+	return e, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(data []byte) (string, []byte, error) {
+	l, n := binary.Uvarint(data)
+	if n <= 0 || l > uint64(len(data)-n) {
+		return "", nil, errInvalidEnvelope
+	}
+	data = data[n:]
+	return string(data[:l]), data[l:], nil
+}
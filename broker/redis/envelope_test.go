@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	sdkTrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	bodies := [][]byte{
+		[]byte(`{"humidity":50}`),
+		[]byte("plain text"),
+		{0x00, 0x01, 0xff},
+		nil,
+	}
+
+	for _, format := range []EnvelopeFormat{EnvelopeJSON, EnvelopeBinary} {
+		for _, body := range bodies {
+			e := newEnvelope(map[string]string{"traceparent": "00-abc-def-01", "type": "created"}, body)
+
+			data, err := encodeEnvelope(format, e)
+			assert.Nil(t, err)
+
+			got, ok := decodeEnvelope(data)
+			assert.True(t, ok, "format %s", format)
+			assert.Equal(t, e.id, got.id)
+			assert.Equal(t, e.headers, got.headers)
+			assert.Equal(t, e.timestamp.UnixMilli(), got.timestamp.UnixMilli())
+			assert.Equal(t, string(body), string(got.body))
+		}
+	}
+}
+
+func TestEnvelopeRawPayload(t *testing.T) {
+	data, err := encodeEnvelope(EnvelopeNone, newEnvelope(map[string]string{"a": "b"}, []byte("raw")))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("raw"), data)
+
+	// 未封装的消息和损坏的封装按原始消息处理
+	for _, payload := range [][]byte{
+		[]byte("raw"),
+		[]byte(`{"humidity":50}`),
+		[]byte(`{"kte":2,"body":1}`),
+		[]byte(`{"kte":`),
+		append(append([]byte(nil), binaryEnvelopeMagic...), 0xff),
+	} {
+		_, ok := decodeEnvelope(payload)
+		assert.False(t, ok, "payload %q", payload)
+	}
+
+	_, err = encodeEnvelope("xml", envelope{})
+	assert.NotNil(t, err)
+}
+
+func TestSubscriberEnvelope(t *testing.T) {
+	b := NewBroker(WithEnvelope(EnvelopeBinary)).(*redisBroker)
+	assert.Nil(t, b.Init())
+
+	filter, err := broker.ParseFilter("type = 'created'")
+	assert.Nil(t, err)
+
+	var received []*broker.Message
+	s := &subscriber{
+		b:     b,
+		topic: "orders",
+		handler: func(_ context.Context, event broker.Event) error {
+			received = append(received, event.Message())
+			return nil
+		},
+		filter:  filter,
+		options: broker.NewSubscribeOptions(),
+		log:     b.log,
+	}
+
+	e := newEnvelope(map[string]string{"type": "created"}, []byte("order-1"))
+	data, err := encodeEnvelope(EnvelopeBinary, e)
+	assert.Nil(t, err)
+	s.onMessage("orders", data)
+
+	data, err = encodeEnvelope(EnvelopeJSON, newEnvelope(map[string]string{"type": "deleted"}, []byte("order-2")))
+	assert.Nil(t, err)
+	s.onMessage("orders", data)
+
+	// 原始消息没有消息头，不满足过滤条件
+	s.onMessage("orders", []byte("order-3"))
+
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, broker.Headers{"type": "created"}, received[0].Headers)
+		assert.Equal(t, []byte("order-1"), received[0].Body)
+		assert.Equal(t, e.id, received[0].Msg)
+	}
+
+	s.filter = nil
+	s.handler = func(_ context.Context, event broker.Event) error {
+		received = append(received, event.Message())
+		return errors.New("failed")
+	}
+	s.onMessage("orders", []byte("order-3"))
+	assert.Len(t, received, 2)
+	assert.Nil(t, received[1].Headers)
+	assert.Equal(t, []byte("order-3"), received[1].Body)
+}
+
+func TestEnvelopeTracePropagation(t *testing.T) {
+	b := NewBroker(
+		WithEnvelope(EnvelopeJSON),
+		broker.WithTracerProvider(sdkTrace.NewTracerProvider(), "redis-test"),
+		broker.WithPropagator(propagation.TraceContext{}),
+	).(*redisBroker)
+	assert.Nil(t, b.Init())
+
+	headers := map[string]string{}
+	span := b.startProducerSpan(context.Background(), "orders", headers)
+	b.finishProducerSpan(span, nil)
+	assert.NotEmpty(t, headers["traceparent"])
+
+	data, err := encodeEnvelope(b.envelope, newEnvelope(headers, []byte("order-1")))
+	assert.Nil(t, err)
+	e, ok := decodeEnvelope(data)
+	assert.True(t, ok)
+
+	ctx, consumerSpan := b.startConsumerSpan(context.Background(), "orders", e.headers)
+	b.finishConsumerSpan(consumerSpan, nil)
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+}
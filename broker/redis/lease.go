@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.Lease = (*Lease)(nil)
+
+var (
+	// renewScript 只有持有者才能续约
+	renewScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`)
+	// releaseScript 只有持有者才能释放
+	releaseScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`)
+)
+
+// Lease 基于 Redis 键的租约：SET NX PX 获取，持有期间每隔 ttl/3 续约，续约失败超过 ttl 视为丢失。
+// 每个订阅使用独立的 Lease 实例。
+//
+//	srv.RegisterSubscriber(ctx, "projector", "projector", false, handler, binder,
+//		broker.WithSingleActiveConsumer(redis.NewLease(redis.Client(b), "lease:projector", 10*time.Second)))
+type Lease struct {
+	client redis.UniversalClient
+	key    string
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	token string
+	stop  chan struct{}
+}
+
+func NewLease(client redis.UniversalClient, key string, ttl time.Duration) *Lease {
+	return &Lease{client: client, key: key, ttl: ttl}
+}
+
+func newLeaseToken() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+func (l *Lease) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	token := newLeaseToken()
+
+	for {
+		ok, err := l.tryAcquire(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+
+		timer := time.NewTimer(l.ttl / 3)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	lost := make(chan struct{})
+	stop := make(chan struct{})
+
+	l.mu.Lock()
+	l.token = token
+	l.stop = stop
+	l.mu.Unlock()
+
+	go l.renew(token, stop, lost)
+
+	return lost, nil
+}
+
+func (l *Lease) tryAcquire(ctx context.Context, token string) (bool, error) {
+	err := l.client.SetArgs(ctx, l.key, token, redis.SetArgs{Mode: "NX", TTL: l.ttl}).Err()
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, redis.Nil):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// renew 定期续约，键被其他持有者占用或者超过 ttl 没有续约成功时关闭 lost
+func (l *Lease) renew(token string, stop <-chan struct{}, lost chan<- struct{}) {
+	defer close(lost)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(l.ttl)
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		held, err := l.extend(token)
+		switch {
+		case err == nil && !held:
+			return
+		case err == nil:
+			deadline = time.Now().Add(l.ttl)
+		case time.Now().After(deadline):
+			return
+		}
+	}
+}
+
+func (l *Lease) extend(token string) (bool, error) {
+	n, err := renewScript.Run(context.Background(), l.client, []string{l.key}, token, l.ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	token, stop := l.token, l.stop
+	l.token, l.stop = "", nil
+	l.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+
+	return releaseScript.Run(ctx, l.client, []string{l.key}, token).Err()
+}
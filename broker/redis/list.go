@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// 列表队列使用的键，除队列本身外都以 {topic} 为哈希标签，集群模式下与队列位于同一个槽，可以在脚本中一起操作
+func listProcessingKey(topic, consumer string) string {
+	return "{" + topic + "}:processing:" + consumer
+}
+
+func listHeartbeatKey(topic, consumer string) string {
+	return "{" + topic + "}:consumer:" + consumer
+}
+
+func listConsumersKey(topic string) string {
+	return "{" + topic + "}:consumers"
+}
+
+func listDelayedKey(topic string) string {
+	return "{" + topic + "}:delayed"
+}
+
+var (
+	// promoteScript 将到期的延迟消息移入队列：KEYS = [delayed, queue]，ARGV = [now, limit]
+	promoteScript = redis.NewScript(`
+local items = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, v in ipairs(items) do
+	redis.call("ZREM", KEYS[1], v)
+	redis.call("LPUSH", KEYS[2], v)
+end
+return #items`)
+
+	// requeueScript 将处理列表中的一条消息替换为 next 放入延迟集合，到期后重新投递：
+	// KEYS = [processing, delayed]，ARGV = [payload, next, deliverAt]，消息已不在处理列表中时返回 0
+	requeueScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call("ZADD", KEYS[2], ARGV[3], ARGV[2])
+	return 1
+end
+return 0`)
+
+	// reclaimScript 消费者的心跳过期后将其处理列表中的消息按原顺序移回队列的出队端：
+	// KEYS = [processing, queue, heartbeat, consumers]，ARGV = [consumer]，消费者仍然存活时返回 -1
+	reclaimScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[3]) == 1 then
+	return -1
+end
+local n = 0
+while redis.call("LMOVE", KEYS[1], KEYS[2], "LEFT", "RIGHT") do
+	n = n + 1
+end
+redis.call("SREM", KEYS[4], ARGV[1])
+return n`)
+)
+
+// encodeListPayload 列表队列按值确认（LREM），消息必须唯一，不封装时也使用二进制封装以带上消息 ID
+func (b *redisBroker) encodeListPayload(e envelope) ([]byte, error) {
+	format := b.envelope
+	if format == EnvelopeNone {
+		format = EnvelopeBinary
+	}
+	return encodeEnvelope(format, e)
+}
+
+// enqueue 将消息写入队列，deliverAt 晚于当前时间时写入延迟集合
+func (b *redisBroker) enqueue(ctx context.Context, topic string, e envelope, deliverAt time.Time) error {
+	if b.client == nil {
+		return errors.New("redis: not connected")
+	}
+
+	payload, err := b.encodeListPayload(e)
+	if err != nil {
+		return err
+	}
+
+	if deliverAt.After(time.Now()) {
+		return b.client.ZAdd(ctx, listDelayedKey(topic), redis.Z{
+			Score:  float64(deliverAt.UnixMilli()),
+			Member: payload,
+		}).Err()
+	}
+
+	return b.client.LPush(ctx, topic, payload).Err()
+}
+
+func (b *redisBroker) publishList(ctx context.Context, topic string, body []byte, opts ...broker.PublishOption) error {
+	options := broker.PublishOptions{
+		Context: ctx,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	headers := make(map[string]string)
+	if v, ok := options.Context.Value(publishHeadersKey{}).(map[string]string); ok {
+		for k, val := range v {
+			headers[k] = val
+		}
+	}
+	deliverAt, _ := options.Context.Value(deliverAtKey{}).(time.Time)
+
+	span := b.startProducerSpan(options.Context, topic, headers)
+
+	err := b.enqueue(ctx, topic, newEnvelope(headers, body), deliverAt)
+
+	b.finishProducerSpan(span, err)
+
+	return err
+}
+
+func (b *redisBroker) subscribeList(topic string, handler broker.Handler, binder broker.Binder, options broker.SubscribeOptions) (broker.Subscriber, error) {
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := newListSubscriber(b, topic, handler, binder, filter, options)
+
+	if err = sub.register(); err != nil {
+		sub.cancel()
+		return nil, err
+	}
+
+	b.subscribers.Add(topic, sub)
+
+	sub.start()
+
+	return sub, nil
+}
@@ -0,0 +1,458 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// listPromoteInterval 检查到期延迟消息的间隔
+const listPromoteInterval = time.Second
+
+// listSubscriber 列表队列模式的订阅者，同一个队列的多个订阅者竞争消费。
+// 每个订阅者有自己的处理列表和心跳键，确认前消息保存在处理列表中；
+// 心跳过期的消费者被认为已经失效，其处理列表中的消息由其他订阅者移回队列。
+type listSubscriber struct {
+	sync.RWMutex
+
+	b *redisBroker
+
+	topic    string
+	consumer string
+
+	processingKey string
+	heartbeatKey  string
+	consumersKey  string
+	delayedKey    string
+
+	handler broker.Handler
+	binder  broker.Binder
+	filter  *broker.Filter
+	options broker.SubscribeOptions
+
+	blockTimeout      time.Duration
+	visibilityTimeout time.Duration
+	reapInterval      time.Duration
+	maxDeliveries     int
+	redeliveryMin     time.Duration
+	redeliveryMax     time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	closed bool
+
+	log *log.Helper
+}
+
+func newListSubscriber(b *redisBroker, topic string, handler broker.Handler, binder broker.Binder, filter *broker.Filter, options broker.SubscribeOptions) *listSubscriber {
+	ctx, cancel := context.WithCancel(options.Context)
+
+	s := &listSubscriber{
+		b:                 b,
+		topic:             topic,
+		consumer:          defaultConsumerName(),
+		handler:           handler,
+		binder:            binder,
+		filter:            filter,
+		options:           options,
+		blockTimeout:      DefaultListBlockTimeout,
+		visibilityTimeout: DefaultListVisibilityTimeout,
+		reapInterval:      DefaultListReapInterval,
+		maxDeliveries:     DefaultListMaxDeliveries,
+		redeliveryMin:     DefaultListRedeliveryMin,
+		redeliveryMax:     DefaultListRedeliveryMax,
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+
+	if v, ok := options.Context.Value(consumerNameKey{}).(string); ok && v != "" {
+		s.consumer = v
+	}
+	if v, ok := options.Context.Value(blockTimeoutKey{}).(time.Duration); ok && v > 0 {
+		s.blockTimeout = v
+	}
+	if v, ok := options.Context.Value(visibilityTimeoutKey{}).(time.Duration); ok && v > 0 {
+		s.visibilityTimeout = v
+	}
+	if v, ok := options.Context.Value(reapIntervalKey{}).(time.Duration); ok {
+		s.reapInterval = v
+	}
+	if v, ok := options.Context.Value(maxDeliveriesKey{}).(int); ok {
+		s.maxDeliveries = v
+	}
+	if v, ok := options.Context.Value(redeliveryMinKey{}).(time.Duration); ok && v > 0 {
+		s.redeliveryMin = v
+	}
+	if v, ok := options.Context.Value(redeliveryMaxKey{}).(time.Duration); ok && v > 0 {
+		s.redeliveryMax = v
+	}
+
+	s.processingKey = listProcessingKey(topic, s.consumer)
+	s.heartbeatKey = listHeartbeatKey(topic, s.consumer)
+	s.consumersKey = listConsumersKey(topic)
+	s.delayedKey = listDelayedKey(topic)
+
+	s.log = log.NewHelper(log.With(b.log.Logger(), "queue", topic, "consumer", s.consumer))
+
+	return s
+}
+
+// register 写入心跳并登记消费者，其他订阅者据此找到失效消费者的处理列表
+func (s *listSubscriber) register() error {
+	if err := s.b.client.Set(s.ctx, s.heartbeatKey, time.Now().UnixMilli(), s.visibilityTimeout).Err(); err != nil {
+		return err
+	}
+	return s.b.client.SAdd(s.ctx, s.consumersKey, s.consumer).Err()
+}
+
+func (s *listSubscriber) start() {
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.heartbeat()
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.run()
+	}()
+}
+
+// heartbeat 每隔 visibilityTimeout/3 续期心跳键，与消息处理分开，处理时间较长的消息不会被其他订阅者接管
+func (s *listSubscriber) heartbeat() {
+	ticker := time.NewTicker(s.visibilityTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.b.client.Set(s.ctx, s.heartbeatKey, time.Now().UnixMilli(), s.visibilityTimeout).Err(); err != nil && s.ctx.Err() == nil {
+			s.log.Errorf("renew heartbeat error: %s", err.Error())
+		}
+	}
+}
+
+func (s *listSubscriber) run() {
+	// 使用固定的消费者名称时，先处理上次留在处理列表中的消息
+	if !s.processPending() {
+		return
+	}
+
+	var lastReap, lastPromote time.Time
+	for s.ctx.Err() == nil {
+		if time.Since(lastPromote) >= listPromoteInterval {
+			s.promote()
+			lastPromote = time.Now()
+		}
+		if s.reapInterval > 0 && time.Since(lastReap) >= s.reapInterval {
+			s.reap()
+			lastReap = time.Now()
+		}
+
+		payload, err := s.b.client.BLMove(s.ctx, s.topic, s.processingKey, "RIGHT", "LEFT", s.blockTimeout).Result()
+		if err == nil {
+			s.handle(payload)
+			continue
+		}
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.log.Errorf("move message error: %s", err.Error())
+		if !s.wait(time.Second) {
+			return
+		}
+	}
+}
+
+// processPending 按进入顺序处理处理列表中已有的消息，订阅关闭时返回 false
+func (s *listSubscriber) processPending() bool {
+	payloads, err := s.b.client.LRange(s.ctx, s.processingKey, 0, -1).Result()
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return false
+		}
+		s.log.Errorf("read processing list error: %s", err.Error())
+		return true
+	}
+
+	if len(payloads) > 0 {
+		s.log.Infof("recovered %d unacknowledged messages", len(payloads))
+	}
+
+	for i := len(payloads) - 1; i >= 0; i-- {
+		if s.ctx.Err() != nil {
+			return false
+		}
+		s.handle(payloads[i])
+	}
+	return s.ctx.Err() == nil
+}
+
+// promote 将到期的延迟消息移入队列
+func (s *listSubscriber) promote() {
+	for s.ctx.Err() == nil {
+		n, err := promoteScript.Run(s.ctx, s.b.client, []string{s.delayedKey, s.topic},
+			time.Now().UnixMilli(), DefaultListDelayedBatch).Int()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.log.Errorf("promote delayed messages error: %s", err.Error())
+			}
+			return
+		}
+		if n < DefaultListDelayedBatch {
+			return
+		}
+	}
+}
+
+// reap 将心跳过期的消费者处理列表中的消息移回队列
+func (s *listSubscriber) reap() {
+	consumers, err := s.b.client.SMembers(s.ctx, s.consumersKey).Result()
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.log.Errorf("list consumers error: %s", err.Error())
+		}
+		return
+	}
+
+	for _, consumer := range consumers {
+		if consumer == s.consumer || s.ctx.Err() != nil {
+			continue
+		}
+
+		n, err := s.reclaim(s.ctx, consumer)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.log.Errorf("requeue messages of consumer %s error: %s", consumer, err.Error())
+			}
+			continue
+		}
+		if n > 0 {
+			s.log.Infof("requeued %d messages of abandoned consumer %s", n, consumer)
+		}
+	}
+}
+
+// reclaim 消费者的心跳已过期时将其处理列表移回队列，返回移动的消息数，消费者仍然存活时返回 -1
+func (s *listSubscriber) reclaim(ctx context.Context, consumer string) (int, error) {
+	return reclaimScript.Run(ctx, s.b.client, []string{
+		listProcessingKey(s.topic, consumer),
+		s.topic,
+		listHeartbeatKey(s.topic, consumer),
+		s.consumersKey,
+	}, consumer).Int()
+}
+
+func (s *listSubscriber) handle(payload string) {
+	e, ok := decodeEnvelope([]byte(payload))
+	if !ok {
+		// 其他客户端直接 LPUSH 的原始消息
+		e = envelope{body: []byte(payload)}
+	}
+
+	p := &publication{
+		topic: s.topic,
+		message: &broker.Message{
+			Headers: e.headers,
+		},
+		ack: func() error { return s.ack(payload) },
+	}
+	if e.id != "" {
+		p.message.Msg = e.id
+	}
+
+	if !s.filter.Match(e.headers) {
+		if err := p.Ack(); err != nil {
+			s.log.Errorf("unable to ack filtered message: %v", err)
+		}
+		return
+	}
+
+	if s.binder != nil {
+		p.message.Body = s.binder()
+		if err := broker.Unmarshal(s.b.options.Codec, e.body, &p.message.Body); err != nil {
+			s.handleError(p, payload, e, broker.ErrorStageDecode, err)
+			return
+		}
+	} else {
+		p.message.Body = e.body
+	}
+
+	headers := make(map[string]string, len(e.headers))
+	for k, v := range e.headers {
+		headers[k] = v
+	}
+	ctx, span := s.b.startConsumerSpan(s.ctx, s.topic, headers)
+
+	err := s.handler(ctx, p)
+	s.b.finishConsumerSpan(span, err)
+	if err != nil {
+		s.handleError(p, payload, e, broker.ErrorStageHandle, err)
+		return
+	}
+
+	if s.options.AutoAck {
+		if err = p.Ack(); err != nil {
+			s.handleError(p, payload, e, broker.ErrorStageAck, err)
+		}
+	}
+}
+
+func (s *listSubscriber) ack(payload string) error {
+	return s.b.client.LRem(context.Background(), s.processingKey, 1, payload).Err()
+}
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 默认行为和 Nack 相同：消息从处理列表移入延迟集合，按指数退避等待后重新投递，投递次数达到 maxDeliveries 时投递到死信队列。
+func (s *listSubscriber) handleError(p *publication, payload string, e envelope, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	s.log.Errorw("msg", "message failed", "stage", stage, "id", e.id, "error", err)
+
+	switch broker.HandleError(s.b.options.ErrorHandler, s.ctx, p, stage, err) {
+	case broker.ErrorActionAck:
+		if err = p.Ack(); err != nil {
+			s.log.Errorf("unable to ack message %s: %v", e.id, err)
+		}
+
+	case broker.ErrorActionDeadLetter:
+		s.deadLetter(p, e, stage, err)
+
+	default:
+		count, _ := strconv.Atoi(e.headers[HeaderRedeliveryCount])
+		if s.maxDeliveries > 0 && count+1 >= s.maxDeliveries {
+			s.log.Warnf("message %s failed %d times, move to dead letter queue", e.id, count+1)
+			s.deadLetter(p, e, stage, err)
+			return
+		}
+		if err = s.requeue(payload, e, count+1, time.Now().Add(s.redeliveryBackoff(count))); err != nil {
+			s.log.Errorf("unable to requeue message %s: %v", e.id, err)
+		}
+	}
+}
+
+// deadLetter 将消息投递到死信队列后确认
+func (s *listSubscriber) deadLetter(p *publication, e envelope, stage broker.ErrorStage, cause error) {
+	dead := newEnvelope(broker.DeadLetterHeaders(e.headers, s.topic, stage, cause), e.body)
+	if err := s.b.enqueue(context.Background(), broker.DeadLetterTopic(s.topic, s.options), dead, time.Time{}); err != nil {
+		s.log.Errorf("publish dead letter failed: %v", err)
+		return
+	}
+	if err := p.Ack(); err != nil {
+		s.log.Errorf("unable to ack message %s: %v", e.id, err)
+	}
+}
+
+// redeliveryBackoff 第 count+1 次重新投递之前的等待时间
+func (s *listSubscriber) redeliveryBackoff(count int) time.Duration {
+	d := s.redeliveryMin
+	for i := 0; i < count && d < s.redeliveryMax; i++ {
+		d *= 2
+	}
+	if d > s.redeliveryMax {
+		d = s.redeliveryMax
+	}
+	return d
+}
+
+// requeue 带上重新投递次数后将消息从处理列表移入延迟集合，消息 ID 保持不变
+func (s *listSubscriber) requeue(payload string, e envelope, count int, deliverAt time.Time) error {
+	headers := make(map[string]string, len(e.headers)+1)
+	for k, v := range e.headers {
+		headers[k] = v
+	}
+	headers[HeaderRedeliveryCount] = strconv.Itoa(count)
+
+	next := newEnvelope(headers, e.body)
+	if e.id != "" {
+		next.id = e.id
+	}
+	if !e.timestamp.IsZero() {
+		next.timestamp = e.timestamp
+	}
+
+	data, err := s.b.encodeListPayload(next)
+	if err != nil {
+		return err
+	}
+
+	return requeueScript.Run(context.Background(), s.b.client, []string{s.processingKey, s.delayedKey},
+		payload, data, deliverAt.UnixMilli()).Err()
+}
+
+// wait 等待 d，订阅关闭时返回 false
+func (s *listSubscriber) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *listSubscriber) Options() broker.SubscribeOptions {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.options
+}
+
+func (s *listSubscriber) Topic() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.topic
+}
+
+// Unsubscribe 停止消费并等待正在处理的消息完成，处理列表中尚未确认的消息移回队列
+func (s *listSubscriber) Unsubscribe(removeFromManager bool) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+
+	var err error
+	if s.b.client != nil {
+		ctx := context.Background()
+		if err = s.b.client.Del(ctx, s.heartbeatKey).Err(); err == nil {
+			_, err = s.reclaim(ctx, s.consumer)
+		}
+	}
+
+	if s.b != nil && s.b.subscribers != nil && removeFromManager {
+		_ = s.b.subscribers.RemoveOnly(s.topic)
+	}
+
+	return err
+}
+
+func (s *listSubscriber) IsClosed() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.closed
+}
@@ -0,0 +1,208 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goRedis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestListKeys(t *testing.T) {
+	// 哈希标签与队列键相同，集群模式下位于同一个槽
+	assert.Equal(t, "{jobs}:processing:worker-1", listProcessingKey("jobs", "worker-1"))
+	assert.Equal(t, "{jobs}:consumer:worker-1", listHeartbeatKey("jobs", "worker-1"))
+	assert.Equal(t, "{jobs}:consumers", listConsumersKey("jobs"))
+	assert.Equal(t, "{jobs}:delayed", listDelayedKey("jobs"))
+}
+
+func TestListPayloadIsUnique(t *testing.T) {
+	b := NewBroker(WithMode(ModeList)).(*redisBroker)
+	assert.Nil(t, b.Init())
+
+	// 不封装时也使用二进制封装，相同的消息体产生不同的值
+	first, err := b.encodeListPayload(newEnvelope(nil, []byte("job")))
+	assert.Nil(t, err)
+	second, err := b.encodeListPayload(newEnvelope(nil, []byte("job")))
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+
+	e, ok := decodeEnvelope(first)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("job"), e.body)
+}
+
+func TestListSubscriberOptions(t *testing.T) {
+	b := NewBroker(WithMode(ModeList)).(*redisBroker)
+	assert.Nil(t, b.Init())
+
+	s := newListSubscriber(b, "jobs", nil, nil, nil, broker.NewSubscribeOptions(
+		WithConsumerName("worker-1"),
+		WithVisibilityTimeout(time.Minute),
+		WithReapInterval(0),
+	))
+	assert.Equal(t, "worker-1", s.consumer)
+	assert.Equal(t, "{jobs}:processing:worker-1", s.processingKey)
+	assert.Equal(t, time.Minute, s.visibilityTimeout)
+	assert.Equal(t, time.Duration(0), s.reapInterval)
+	assert.Equal(t, DefaultListBlockTimeout, s.blockTimeout)
+
+	s = newListSubscriber(b, "jobs", nil, nil, nil, broker.NewSubscribeOptions())
+	assert.NotEmpty(t, s.consumer)
+	assert.Equal(t, DefaultListVisibilityTimeout, s.visibilityTimeout)
+	assert.Equal(t, DefaultListReapInterval, s.reapInterval)
+}
+
+func TestDeliverAtOption(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	options := broker.NewPublishOptions(WithDeliverAt(at))
+	v, _ := options.Context.Value(deliverAtKey{}).(time.Time)
+	assert.Equal(t, at, v)
+
+	options = broker.NewPublishOptions(WithDeliverAfter(time.Minute))
+	v, _ = options.Context.Value(deliverAtKey{}).(time.Time)
+	assert.True(t, v.After(time.Now().Add(59*time.Second)))
+}
+
+func TestListPromoteScript(t *testing.T) {
+	b, _ := newMiniredisBroker(t, WithMode(ModeList))
+	ctx := context.Background()
+
+	now := time.Now().UnixMilli()
+	assert.Nil(t, b.client.ZAdd(ctx, listDelayedKey("jobs"),
+		goRedis.Z{Score: float64(now - 2000), Member: "a"},
+		goRedis.Z{Score: float64(now - 1000), Member: "b"},
+		goRedis.Z{Score: float64(now + 60000), Member: "later"},
+	).Err())
+
+	n, err := promoteScript.Run(ctx, b.client, []string{listDelayedKey("jobs"), "jobs"}, now, 1).Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = promoteScript.Run(ctx, b.client, []string{listDelayedKey("jobs"), "jobs"}, now, 10).Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	// 先到期的消息先出队
+	assert.Equal(t, []string{"b", "a"}, b.client.LRange(ctx, "jobs", 0, -1).Val())
+	assert.Equal(t, []string{"later"}, b.client.ZRange(ctx, listDelayedKey("jobs"), 0, -1).Val())
+}
+
+func TestListRequeueScript(t *testing.T) {
+	b, _ := newMiniredisBroker(t, WithMode(ModeList))
+	ctx := context.Background()
+
+	processing := listProcessingKey("jobs", "worker-1")
+	assert.Nil(t, b.client.LPush(ctx, processing, "old", "other").Err())
+
+	keys := []string{processing, listDelayedKey("jobs")}
+	n, err := requeueScript.Run(ctx, b.client, keys, "old", "new", 12345).Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"other"}, b.client.LRange(ctx, processing, 0, -1).Val())
+	assert.Equal(t, []goRedis.Z{{Score: 12345, Member: "new"}}, b.client.ZRangeWithScores(ctx, listDelayedKey("jobs"), 0, -1).Val())
+
+	// 已经被确认或接管的消息不再放回
+	n, err = requeueScript.Run(ctx, b.client, keys, "old", "again", 12345).Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, int64(1), b.client.ZCard(ctx, listDelayedKey("jobs")).Val())
+}
+
+func TestListReclaimScript(t *testing.T) {
+	b, _ := newMiniredisBroker(t, WithMode(ModeList))
+	ctx := context.Background()
+
+	s := newListSubscriber(b, "jobs", nil, nil, nil, broker.NewSubscribeOptions(WithConsumerName("worker-2")))
+	defer s.cancel()
+
+	// worker-1 按 BLMOVE 的顺序先后取出 a、b，队列中还有 c
+	assert.Nil(t, b.client.LPush(ctx, listProcessingKey("jobs", "worker-1"), "a", "b").Err())
+	assert.Nil(t, b.client.LPush(ctx, "jobs", "c").Err())
+	assert.Nil(t, b.client.SAdd(ctx, listConsumersKey("jobs"), "worker-1").Err())
+	assert.Nil(t, b.client.Set(ctx, listHeartbeatKey("jobs", "worker-1"), 1, time.Minute).Err())
+
+	n, err := s.reclaim(ctx, "worker-1")
+	assert.Nil(t, err)
+	assert.Equal(t, -1, n)
+
+	assert.Nil(t, b.client.Del(ctx, listHeartbeatKey("jobs", "worker-1")).Err())
+	n, err = s.reclaim(ctx, "worker-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	// 接管的消息回到出队端，a 最先被重新取出
+	assert.Equal(t, []string{"c", "b", "a"}, b.client.LRange(ctx, "jobs", 0, -1).Val())
+	assert.Zero(t, b.client.Exists(ctx, listProcessingKey("jobs", "worker-1")).Val())
+	assert.False(t, b.client.SIsMember(ctx, listConsumersKey("jobs"), "worker-1").Val())
+}
+
+func TestListProcessPending(t *testing.T) {
+	b, _ := newMiniredisBroker(t, WithMode(ModeList))
+	ctx := context.Background()
+
+	var got []string
+	handler := func(_ context.Context, evt broker.Event) error {
+		body := string(evt.Message().Body.([]byte))
+		got = append(got, body)
+		if body == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	s := newListSubscriber(b, "jobs", handler, nil, nil, broker.NewSubscribeOptions(
+		WithConsumerName("worker-1"),
+		WithMaxDeliveries(2),
+		WithRedeliveryBackoffMin(time.Minute),
+	))
+	defer s.cancel()
+
+	for _, body := range []string{"first", "fail", "second"} {
+		payload, err := b.encodeListPayload(newEnvelope(nil, []byte(body)))
+		assert.Nil(t, err)
+		assert.Nil(t, b.client.LPush(ctx, s.processingKey, payload).Err())
+	}
+
+	assert.True(t, s.processPending())
+	assert.Equal(t, []string{"first", "fail", "second"}, got)
+	assert.Zero(t, b.client.LLen(ctx, s.processingKey).Val())
+
+	// 失败的消息带上重新投递次数进入延迟集合，约一分钟后到期
+	delayed := b.client.ZRangeWithScores(ctx, s.delayedKey, 0, -1).Val()
+	assert.Len(t, delayed, 1)
+	assert.InDelta(t, float64(time.Now().Add(time.Minute).UnixMilli()), delayed[0].Score, 5000)
+
+	e, ok := decodeEnvelope([]byte(delayed[0].Member.(string)))
+	assert.True(t, ok)
+	assert.Equal(t, "1", e.headers[HeaderRedeliveryCount])
+
+	// 第二次失败达到最大投递次数，进入死信队列
+	assert.Nil(t, b.client.ZRem(ctx, s.delayedKey, delayed[0].Member).Err())
+	assert.Nil(t, b.client.LPush(ctx, s.processingKey, delayed[0].Member).Err())
+	assert.True(t, s.processPending())
+
+	assert.Zero(t, b.client.ZCard(ctx, s.delayedKey).Val())
+	assert.Zero(t, b.client.LLen(ctx, s.processingKey).Val())
+
+	dead := b.client.LRange(ctx, "jobs"+broker.DefaultDeadLetterSuffix, 0, -1).Val()
+	assert.Len(t, dead, 1)
+	e, ok = decodeEnvelope([]byte(dead[0]))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("fail"), e.body)
+	assert.Equal(t, "jobs", e.headers[broker.HeaderDeadLetterOriginalTopic])
+}
+
+func TestListRedeliveryBackoff(t *testing.T) {
+	s := &listSubscriber{redeliveryMin: time.Second, redeliveryMax: 5 * time.Second}
+
+	var got []time.Duration
+	for i := 0; i < 5; i++ {
+		got = append(got, s.redeliveryBackoff(i))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, got)
+}
@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var _ propagation.TextMapCarrier = (*MessageCarrier)(nil)
+
+// MessageCarrier 在消息头中传递链路追踪上下文
+type MessageCarrier struct {
+	headers map[string]string
+}
+
+func NewMessageCarrier(headers map[string]string) MessageCarrier {
+	return MessageCarrier{headers: headers}
+}
+
+func (c MessageCarrier) Get(key string) string {
+	return c.headers[key]
+}
+
+func (c MessageCarrier) Set(key, val string) {
+	c.headers[key] = val
+}
+
+func (c MessageCarrier) Keys() []string {
+	out := make([]string, 0, len(c.headers))
+	for k := range c.headers {
+		out = append(out, k)
+	}
+	return out
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
 	"github.com/tx7do/kratos-transport/broker"
 )
 
@@ -30,6 +32,18 @@ type commonOptions struct {
 	writeTimeout   time.Duration
 }
 
+// newCommonOptions 创建默认配置，单独设置某一项时其余项保持默认值
+func newCommonOptions() *commonOptions {
+	return &commonOptions{
+		maxIdle:        DefaultMaxIdle,
+		maxActive:      DefaultMaxActive,
+		idleTimeout:    DefaultIdleTimeout,
+		connectTimeout: DefaultConnectTimeout,
+		readTimeout:    DefaultReadTimeout,
+		writeTimeout:   DefaultWriteTimeout,
+	}
+}
+
 // WithConnectTimeout 连接Redis超时时间
 func WithConnectTimeout(d time.Duration) broker.Option {
 	return func(o *broker.Options) {
@@ -40,7 +54,9 @@ func WithConnectTimeout(d time.Duration) broker.Option {
 		if x != nil {
 			x.(*commonOptions).connectTimeout = d
 		} else {
-			o.Context = context.WithValue(o.Context, optionsKey, &commonOptions{connectTimeout: d})
+			opts := newCommonOptions()
+			opts.connectTimeout = d
+			o.Context = context.WithValue(o.Context, optionsKey, opts)
 		}
 	}
 }
@@ -55,7 +71,9 @@ func WithReadTimeout(d time.Duration) broker.Option {
 		if x != nil {
 			x.(*commonOptions).readTimeout = d
 		} else {
-			o.Context = context.WithValue(o.Context, optionsKey, &commonOptions{readTimeout: d})
+			opts := newCommonOptions()
+			opts.readTimeout = d
+			o.Context = context.WithValue(o.Context, optionsKey, opts)
 		}
 	}
 }
@@ -70,7 +88,9 @@ func WithWriteTimeout(d time.Duration) broker.Option {
 		if x != nil {
 			x.(*commonOptions).writeTimeout = d
 		} else {
-			o.Context = context.WithValue(o.Context, optionsKey, &commonOptions{writeTimeout: d})
+			opts := newCommonOptions()
+			opts.writeTimeout = d
+			o.Context = context.WithValue(o.Context, optionsKey, opts)
 		}
 	}
 }
@@ -85,7 +105,9 @@ func WithIdleTimeout(d time.Duration) broker.Option {
 		if x != nil {
 			x.(*commonOptions).idleTimeout = d
 		} else {
-			o.Context = context.WithValue(o.Context, optionsKey, &commonOptions{idleTimeout: d})
+			opts := newCommonOptions()
+			opts.idleTimeout = d
+			o.Context = context.WithValue(o.Context, optionsKey, opts)
 		}
 	}
 }
@@ -100,7 +122,9 @@ func WithMaxIdle(n int) broker.Option {
 		if x != nil {
 			x.(*commonOptions).maxIdle = n
 		} else {
-			o.Context = context.WithValue(o.Context, optionsKey, &commonOptions{maxIdle: n})
+			opts := newCommonOptions()
+			opts.maxIdle = n
+			o.Context = context.WithValue(o.Context, optionsKey, opts)
 		}
 	}
 }
@@ -115,7 +139,9 @@ func WithMaxActive(n int) broker.Option {
 		if x != nil {
 			x.(*commonOptions).maxActive = n
 		} else {
-			o.Context = context.WithValue(o.Context, optionsKey, &commonOptions{maxActive: n})
+			opts := newCommonOptions()
+			opts.maxActive = n
+			o.Context = context.WithValue(o.Context, optionsKey, opts)
 		}
 	}
 }
@@ -128,14 +154,7 @@ func WithDefaultOptions() broker.Option {
 		}
 		x := o.Context.Value(optionsKey)
 
-		opts := &commonOptions{
-			maxIdle:        DefaultMaxIdle,
-			maxActive:      DefaultMaxActive,
-			idleTimeout:    DefaultIdleTimeout,
-			connectTimeout: DefaultConnectTimeout,
-			readTimeout:    DefaultReadTimeout,
-			writeTimeout:   DefaultWriteTimeout,
-		}
+		opts := newCommonOptions()
 
 		if x != nil {
 			x = opts
@@ -144,3 +163,219 @@ func WithDefaultOptions() broker.Option {
 		}
 	}
 }
+
+// Mode Publish 和 Subscribe 使用的 Redis 数据结构
+type Mode string
+
+const (
+	// ModePubSub 发布订阅，消息不持久化，没有确认机制
+	ModePubSub Mode = "pubsub"
+	// ModeStreams Redis Streams：XADD 发布，消费组 XREADGROUP 消费，确认后 XACK
+	ModeStreams Mode = "streams"
+	// ModeList 可靠的列表队列：LPUSH 发布，BLMOVE 移入消费者的处理列表，确认后 LREM，多个订阅者竞争消费
+	ModeList Mode = "list"
+)
+
+var (
+	DefaultStreamReadCount     = 10
+	DefaultStreamBlockTimeout  = 2 * time.Second
+	DefaultStreamStartID       = "0"
+	DefaultStreamClaimMinIdle  = time.Minute
+	DefaultStreamClaimInterval = 30 * time.Second
+
+	DefaultListBlockTimeout      = 2 * time.Second
+	DefaultListVisibilityTimeout = 30 * time.Second
+	DefaultListReapInterval      = 10 * time.Second
+	DefaultListDelayedBatch      = 100
+	DefaultListMaxDeliveries     = 5
+	DefaultListRedeliveryMin     = time.Second
+	DefaultListRedeliveryMax     = time.Minute
+)
+
+// HeaderRedeliveryCount 列表队列模式下消息因处理失败被重新投递的次数
+const HeaderRedeliveryCount = "x-redelivery-count"
+
+const (
+	RedisTypeSingle   = "single"
+	RedisTypeCluster  = "cluster"
+	RedisTypeSentinel = "sentinel"
+)
+
+type modeKey struct{}
+type streamMaxLenKey struct{}
+type redisTypeKey struct{}
+type masterNameKey struct{}
+type usernameKey struct{}
+type passwordKey struct{}
+type sentinelAuthKey struct{}
+type dbKey struct{}
+type shardedPubSubKey struct{}
+type clientKey struct{}
+type envelopeKey struct{}
+
+type sentinelAuth struct {
+	username string
+	password string
+}
+
+type publishMaxLenKey struct{}
+type publishHeadersKey struct{}
+type deliverAtKey struct{}
+
+type consumerNameKey struct{}
+type readCountKey struct{}
+type blockTimeoutKey struct{}
+type startIDKey struct{}
+type claimMinIdleKey struct{}
+type claimIntervalKey struct{}
+type visibilityTimeoutKey struct{}
+type reapIntervalKey struct{}
+type maxDeliveriesKey struct{}
+type redeliveryMinKey struct{}
+type redeliveryMaxKey struct{}
+
+///
+/// Option
+///
+
+// WithMode 选择 Publish 和 Subscribe 使用的模式，默认为 ModePubSub
+func WithMode(mode Mode) broker.Option {
+	return broker.OptionContextWithValue(modeKey{}, mode)
+}
+
+// WithRedisType 部署类型：RedisTypeSingle、RedisTypeCluster 或 RedisTypeSentinel。
+// 不设置时按地址自动选择：设置了 WithMasterName 时为哨兵，多个地址时为集群，否则为单节点。
+func WithRedisType(redisType string) broker.Option {
+	return broker.OptionContextWithValue(redisTypeKey{}, redisType)
+}
+
+// WithMasterName 哨兵模式的主节点名称，此时地址为哨兵的地址
+func WithMasterName(name string) broker.Option {
+	return broker.OptionContextWithValue(masterNameKey{}, name)
+}
+
+// WithAuth ACL 用户名和密码，只使用密码认证时 username 为空。优先于地址 URL 中的用户信息
+func WithAuth(username, password string) broker.Option {
+	return func(o *broker.Options) {
+		broker.OptionContextWithValue(usernameKey{}, username)(o)
+		broker.OptionContextWithValue(passwordKey{}, password)(o)
+	}
+}
+
+// WithSentinelAuth 哨兵节点的用户名和密码
+func WithSentinelAuth(username, password string) broker.Option {
+	return broker.OptionContextWithValue(sentinelAuthKey{}, sentinelAuth{username: username, password: password})
+}
+
+// WithDB 数据库编号，集群模式下不生效。优先于地址 URL 中的数据库编号
+func WithDB(db int) broker.Option {
+	return broker.OptionContextWithValue(dbKey{}, db)
+}
+
+// WithShardedPubSub 发布订阅使用 SPUBLISH 和 SSUBSCRIBE（Redis 7），集群模式下消息只在频道所在的分片内传播
+func WithShardedPubSub(enable bool) broker.Option {
+	return broker.OptionContextWithValue(shardedPubSubKey{}, enable)
+}
+
+// WithClient 使用外部创建的 go-redis 客户端，忽略地址和连接相关的选项，Disconnect 时不关闭该客户端
+func WithClient(client redis.UniversalClient) broker.Option {
+	return broker.OptionContextWithValue(clientKey{}, client)
+}
+
+// WithEnvelope 发布订阅模式下消息的封装格式，默认不封装。
+// 封装后消息头（包括链路追踪上下文）随消息传递；订阅者总是能够识别封装后的消息，同时兼容未封装的原始消息
+func WithEnvelope(format EnvelopeFormat) broker.Option {
+	return broker.OptionContextWithValue(envelopeKey{}, format)
+}
+
+// WithStreamMaxLen Streams 模式下 XADD 的默认 MAXLEN，使用近似裁剪（~），小于等于 0 时不裁剪
+func WithStreamMaxLen(n int64) broker.Option {
+	return broker.OptionContextWithValue(streamMaxLenKey{}, n)
+}
+
+///
+/// PublishOption
+///
+
+// WithMaxLen 单次发布的 MAXLEN，覆盖 WithStreamMaxLen
+func WithMaxLen(n int64) broker.PublishOption {
+	return broker.PublishContextWithValue(publishMaxLenKey{}, n)
+}
+
+// WithHeaders 消息头，Streams 模式或者设置了 WithEnvelope 时与消息体一起发布
+func WithHeaders(headers map[string]string) broker.PublishOption {
+	return broker.PublishContextWithValue(publishHeadersKey{}, headers)
+}
+
+// WithDeliverAfter 列表队列模式下延迟投递，消息先写入有序集合，到期后由订阅者移入队列
+func WithDeliverAfter(delay time.Duration) broker.PublishOption {
+	return WithDeliverAt(time.Now().Add(delay))
+}
+
+// WithDeliverAt 列表队列模式下在指定时间投递
+func WithDeliverAt(tm time.Time) broker.PublishOption {
+	return broker.PublishContextWithValue(deliverAtKey{}, tm)
+}
+
+///
+/// SubscribeOption
+///
+
+// WithConsumerName 消费组中的消费者名称，默认为主机名加随机后缀。
+// 使用固定的名称时，重启后会先处理上次未确认的消息。默认名称每次启动都不同，旧消费者的消息由 XAUTOCLAIM 接管后，
+// 空闲的旧消费者被删除，关闭接管（WithClaimInterval 为 0）时旧消费者会一直留在消费组中，应当使用固定的名称。
+// 列表队列模式下为处理列表的名称，同一时间只能有一个订阅者使用。
+func WithConsumerName(name string) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(consumerNameKey{}, name)
+}
+
+// WithReadCount 每次 XREADGROUP 和 XAUTOCLAIM 读取的最大消息数，默认为10
+func WithReadCount(n int) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(readCountKey{}, n)
+}
+
+// WithBlockTimeout XREADGROUP 或 BLMOVE 的阻塞时间，默认为2秒，需要小于连接的读超时
+func WithBlockTimeout(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(blockTimeoutKey{}, d)
+}
+
+// WithStartID 创建消费组时的起始 ID，默认为 "0" 从头消费，"$" 只消费之后的消息。消费组已存在时不生效
+func WithStartID(id string) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(startIDKey{}, id)
+}
+
+// WithClaimMinIdle 未确认超过该时间的消息被认为所属的消费者已经失效，由其他消费者通过 XAUTOCLAIM 接管，默认为1分钟
+func WithClaimMinIdle(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(claimMinIdleKey{}, d)
+}
+
+// WithClaimInterval 执行 XAUTOCLAIM 的间隔，默认为30秒，小于等于0时不接管
+func WithClaimInterval(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(claimIntervalKey{}, d)
+}
+
+// WithVisibilityTimeout 列表队列模式下消费者的存活时间，消费者超过该时间没有心跳时，
+// 其处理列表中的消息被其他订阅者移回队列重新投递，默认为30秒
+func WithVisibilityTimeout(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(visibilityTimeoutKey{}, d)
+}
+
+// WithReapInterval 列表队列模式下检查失效消费者和到期延迟消息的间隔，默认为10秒，小于等于0时不检查
+func WithReapInterval(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(reapIntervalKey{}, d)
+}
+
+// WithMaxDeliveries 列表队列模式下一条消息的最大投递次数，处理失败达到该次数后投递到死信队列，默认为5次，小于等于0时不限制
+func WithMaxDeliveries(n int) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(maxDeliveriesKey{}, n)
+}
+
+// WithRedeliveryBackoffMin 列表队列模式下处理失败的消息第一次重新投递之前的等待时间，之后每次翻倍，默认为1秒
+func WithRedeliveryBackoffMin(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(redeliveryMinKey{}, d)
+}
+
+// WithRedeliveryBackoffMax 列表队列模式下重新投递的最大等待时间，默认为1分钟
+func WithRedeliveryBackoffMax(d time.Duration) broker.SubscribeOption {
+	return broker.SubscribeContextWithValue(redeliveryMaxKey{}, d)
+}
@@ -6,6 +6,7 @@ type publication struct {
 	topic   string
 	message *broker.Message
 	err     error
+	ack     func() error
 }
 
 func (p *publication) Topic() string {
@@ -21,7 +22,10 @@ func (p *publication) RawMessage() interface{} {
 }
 
 func (p *publication) Ack() error {
-	return nil
+	if p.ack == nil {
+		return nil
+	}
+	return p.ack()
 }
 
 func (p *publication) Error() error {
@@ -0,0 +1,421 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// deleteConsumerScript 消费者没有未确认的消息时将其从消费组中删除：KEYS = [stream]，ARGV = [group, consumer]，仍有未确认的消息时返回 -1
+var deleteConsumerScript = redis.NewScript(`
+if #redis.call("XPENDING", KEYS[1], ARGV[1], "-", "+", 1, ARGV[2]) > 0 then
+	return -1
+end
+return redis.call("XGROUP", "DELCONSUMER", KEYS[1], ARGV[1], ARGV[2])`)
+
+// streamSubscriber Streams 模式的订阅者，SubscribeOptions.Queue 对应消费组。
+// 没有设置 Queue 时使用随机的消费组从订阅时刻开始消费，取消订阅时删除该消费组，相当于广播。
+type streamSubscriber struct {
+	sync.RWMutex
+
+	b *redisBroker
+
+	stream   string
+	group    string
+	consumer string
+	// temporary 消费组在取消订阅时删除
+	temporary bool
+
+	handler broker.Handler
+	binder  broker.Binder
+	filter  *broker.Filter
+	options broker.SubscribeOptions
+
+	readCount     int
+	blockTimeout  time.Duration
+	startID       string
+	claimMinIdle  time.Duration
+	claimInterval time.Duration
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	closed  bool
+
+	log *log.Helper
+}
+
+func newStreamSubscriber(b *redisBroker, stream string, handler broker.Handler, binder broker.Binder, filter *broker.Filter, options broker.SubscribeOptions) *streamSubscriber {
+	ctx, cancel := context.WithCancel(options.Context)
+
+	s := &streamSubscriber{
+		b:             b,
+		stream:        stream,
+		group:         options.Queue,
+		consumer:      defaultConsumerName(),
+		handler:       handler,
+		binder:        binder,
+		filter:        filter,
+		options:       options,
+		readCount:     DefaultStreamReadCount,
+		blockTimeout:  DefaultStreamBlockTimeout,
+		startID:       DefaultStreamStartID,
+		claimMinIdle:  DefaultStreamClaimMinIdle,
+		claimInterval: DefaultStreamClaimInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+		stopped:       make(chan struct{}),
+	}
+
+	if s.group == "" {
+		s.group = "broadcast-" + uuid.New().String()
+		s.startID = "$"
+		s.temporary = true
+	}
+
+	if v, ok := options.Context.Value(consumerNameKey{}).(string); ok && v != "" {
+		s.consumer = v
+	}
+	if v, ok := options.Context.Value(readCountKey{}).(int); ok && v > 0 {
+		s.readCount = v
+	}
+	if v, ok := options.Context.Value(blockTimeoutKey{}).(time.Duration); ok && v > 0 {
+		s.blockTimeout = v
+	}
+	if v, ok := options.Context.Value(startIDKey{}).(string); ok && v != "" {
+		s.startID = v
+	}
+	if v, ok := options.Context.Value(claimMinIdleKey{}).(time.Duration); ok {
+		s.claimMinIdle = v
+	}
+	if v, ok := options.Context.Value(claimIntervalKey{}).(time.Duration); ok {
+		s.claimInterval = v
+	}
+
+	s.log = log.NewHelper(log.With(b.log.Logger(), "stream", stream, "group", s.group, "consumer", s.consumer))
+
+	return s
+}
+
+// createGroup 创建消费组，stream 不存在时一并创建
+func (s *streamSubscriber) createGroup() error {
+	err := s.b.client.XGroupCreateMkStream(s.ctx, s.stream, s.group, s.startID).Err()
+	if isBusyGroup(err) {
+		return nil
+	}
+	return err
+}
+
+// readGroup 读取消息，block 为负数时不阻塞；没有消息时返回空
+func (s *streamSubscriber) readGroup(id string, block time.Duration) ([]streamEntry, error) {
+	streams, err := s.b.client.XReadGroup(s.ctx, &redis.XReadGroupArgs{
+		Group:    s.group,
+		Consumer: s.consumer,
+		Streams:  []string{s.stream, id},
+		Count:    int64(s.readCount),
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []streamEntry
+	for _, st := range streams {
+		items, err := parseStreamMessages(st.Messages)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, items...)
+	}
+	return entries, nil
+}
+
+func (s *streamSubscriber) run() {
+	defer close(s.stopped)
+
+	// 使用固定的消费者名称时，先处理上次未确认的消息
+	if !s.processPending() {
+		return
+	}
+
+	var lastClaim time.Time
+	for s.ctx.Err() == nil {
+		if s.claimInterval > 0 && time.Since(lastClaim) >= s.claimInterval {
+			s.claim()
+			lastClaim = time.Now()
+		}
+
+		entries, err := s.readGroup(">", s.blockTimeout)
+		if err == nil {
+			s.process(entries)
+			continue
+		}
+
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.log.Errorf("read stream error: %s", err.Error())
+		if !s.wait(time.Second) {
+			return
+		}
+	}
+}
+
+// processPending 读取并处理本消费者名下所有未确认的消息，订阅关闭时返回 false
+func (s *streamSubscriber) processPending() bool {
+	id := "0"
+	for s.ctx.Err() == nil {
+		entries, err := s.readGroup(id, -1)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return false
+			}
+			s.log.Errorf("read pending entries error: %s", err.Error())
+			return true
+		}
+		if len(entries) == 0 {
+			return true
+		}
+
+		s.process(entries)
+		id = entries[len(entries)-1].id
+	}
+	return false
+}
+
+// claim 通过 XAUTOCLAIM 接管空闲超过 claimMinIdle 的消息，这些消息所属的消费者可能已经失效
+func (s *streamSubscriber) claim() {
+	cursor := "0-0"
+	for s.ctx.Err() == nil {
+		messages, next, err := s.b.client.XAutoClaim(s.ctx, &redis.XAutoClaimArgs{
+			Stream:   s.stream,
+			Group:    s.group,
+			Consumer: s.consumer,
+			MinIdle:  s.claimMinIdle,
+			Start:    cursor,
+			Count:    int64(s.readCount),
+		}).Result()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.log.Errorf("claim pending entries error: %s", err.Error())
+			}
+			return
+		}
+
+		entries, err := parseStreamMessages(messages)
+		if err != nil {
+			s.log.Errorf("claim pending entries error: %s", err.Error())
+			return
+		}
+		if len(entries) > 0 {
+			s.log.Infof("claimed %d pending entries", len(entries))
+		}
+		s.process(entries)
+
+		cursor = next
+		if cursor == "0-0" {
+			s.pruneConsumers()
+			return
+		}
+	}
+}
+
+// pruneConsumers 删除空闲超过 claimMinIdle 且没有未确认消息的其他消费者。
+// 默认的消费者名称每次启动都不同，已退出的消费者在其消息被接管后由此清理；
+// 仍在运行的消费者每次读取都会刷新空闲时间，即使被删除也会在下次读取时自动重建。
+func (s *streamSubscriber) pruneConsumers() {
+	consumers, err := s.b.client.XInfoConsumers(s.ctx, s.stream, s.group).Result()
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.log.Errorf("list consumers error: %s", err.Error())
+		}
+		return
+	}
+
+	for _, c := range consumers {
+		if c.Name == s.consumer || c.Pending > 0 || (s.claimMinIdle > 0 && c.Idle < s.claimMinIdle) {
+			continue
+		}
+
+		n, err := deleteConsumerScript.Run(s.ctx, s.b.client, []string{s.stream}, s.group, c.Name).Int64()
+		if err != nil {
+			s.log.Errorf("delete consumer %s error: %s", c.Name, err.Error())
+			continue
+		}
+		if n >= 0 {
+			s.log.Infof("deleted idle consumer %s", c.Name)
+		}
+	}
+}
+
+func (s *streamSubscriber) process(entries []streamEntry) {
+	for _, e := range entries {
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.handle(e)
+	}
+}
+
+func (s *streamSubscriber) handle(e streamEntry) {
+	p := &publication{
+		topic: s.stream,
+		message: &broker.Message{
+			Headers: e.headers,
+			Msg:     e.id,
+		},
+		ack: func() error { return s.ack(e.id) },
+	}
+
+	// 消息已被删除或裁剪，只能确认
+	if e.deleted {
+		if err := p.Ack(); err != nil {
+			s.log.Errorf("unable to ack deleted entry %s: %v", e.id, err)
+		}
+		return
+	}
+
+	if !s.filter.Match(e.headers) {
+		if err := p.Ack(); err != nil {
+			s.log.Errorf("unable to ack filtered entry %s: %v", e.id, err)
+		}
+		return
+	}
+
+	if s.binder != nil {
+		p.message.Body = s.binder()
+		if err := broker.Unmarshal(s.b.options.Codec, e.body, &p.message.Body); err != nil {
+			s.handleError(p, e, broker.ErrorStageDecode, err)
+			return
+		}
+	} else {
+		p.message.Body = e.body
+	}
+
+	headers := make(map[string]string, len(e.headers))
+	for k, v := range e.headers {
+		headers[k] = v
+	}
+	ctx, span := s.b.startConsumerSpan(s.ctx, s.stream, headers)
+
+	err := s.handler(ctx, p)
+	s.b.finishConsumerSpan(span, err)
+	if err != nil {
+		s.handleError(p, e, broker.ErrorStageHandle, err)
+		return
+	}
+
+	if s.options.AutoAck {
+		if err := p.Ack(); err != nil {
+			s.handleError(p, e, broker.ErrorStageAck, err)
+		}
+	}
+}
+
+func (s *streamSubscriber) ack(id string) error {
+	return s.b.client.XAck(context.Background(), s.stream, s.group, id).Err()
+}
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 默认行为和 Nack 相同：消息保留在待确认列表中，空闲超过 claimMinIdle 后被重新投递。
+func (s *streamSubscriber) handleError(p *publication, e streamEntry, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	s.log.Errorw("msg", "message failed", "stage", stage, "id", e.id, "error", err)
+
+	switch broker.HandleError(s.b.options.ErrorHandler, s.ctx, p, stage, err) {
+	case broker.ErrorActionAck:
+		if err = p.Ack(); err != nil {
+			s.log.Errorf("unable to ack entry %s: %v", e.id, err)
+		}
+
+	case broker.ErrorActionDeadLetter:
+		headers := broker.DeadLetterHeaders(e.headers, s.stream, stage, err)
+		if _, err = s.b.xadd(s.ctx, broker.DeadLetterTopic(s.stream, s.options), e.body, headers, s.b.streamMaxLen); err != nil {
+			s.log.Errorf("publish dead letter failed: %v", err)
+			return
+		}
+		if err = p.Ack(); err != nil {
+			s.log.Errorf("unable to ack entry %s: %v", e.id, err)
+		}
+
+	default:
+	}
+}
+
+// wait 等待 d，订阅关闭时返回 false
+func (s *streamSubscriber) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *streamSubscriber) Options() broker.SubscribeOptions {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.options
+}
+
+func (s *streamSubscriber) Topic() string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.stream
+}
+
+// Unsubscribe 停止读取并等待正在处理的消息完成，未确认的消息保留在消费组的待确认列表中
+func (s *streamSubscriber) Unsubscribe(removeFromManager bool) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.Unlock()
+
+	s.cancel()
+	<-s.stopped
+
+	var err error
+	if s.temporary {
+		err = s.destroyGroup()
+	}
+
+	if s.b != nil && s.b.subscribers != nil && removeFromManager {
+		_ = s.b.subscribers.RemoveOnly(s.stream)
+	}
+
+	return err
+}
+
+func (s *streamSubscriber) destroyGroup() error {
+	if s.b.client == nil {
+		return errors.New("redis: not connected")
+	}
+
+	return s.b.client.XGroupDestroy(context.Background(), s.stream, s.group).Err()
+}
+
+func (s *streamSubscriber) IsClosed() bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.closed
+}
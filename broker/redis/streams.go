@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	streamFieldBody    = "body"
+	streamFieldHeaders = "headers"
+)
+
+// streamEntry Streams 中的一条消息
+type streamEntry struct {
+	id      string
+	body    []byte
+	headers broker.Headers
+	deleted bool // XAUTOCLAIM 或读取待确认消息时，消息已被 XDEL 或裁剪
+}
+
+// xadd 将消息追加到 stream，headers 编码为 JSON 写入单独的字段
+func (b *redisBroker) xadd(ctx context.Context, stream string, body []byte, headers map[string]string, maxLen int64) (string, error) {
+	if b.client == nil {
+		return "", errors.New("redis: not connected")
+	}
+
+	values := []interface{}{streamFieldBody, body}
+	if len(headers) > 0 {
+		buf, err := json.Marshal(headers)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, streamFieldHeaders, buf)
+	}
+
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Result()
+}
+
+func (b *redisBroker) publishStream(ctx context.Context, stream string, body []byte, opts ...broker.PublishOption) error {
+	options := broker.PublishOptions{
+		Context: ctx,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	maxLen := b.streamMaxLen
+	if v, ok := options.Context.Value(publishMaxLenKey{}).(int64); ok {
+		maxLen = v
+	}
+	headers := make(map[string]string)
+	if v, ok := options.Context.Value(publishHeadersKey{}).(map[string]string); ok {
+		for k, val := range v {
+			headers[k] = val
+		}
+	}
+
+	span := b.startProducerSpan(options.Context, stream, headers)
+
+	_, err := b.xadd(ctx, stream, body, headers, maxLen)
+
+	b.finishProducerSpan(span, err)
+
+	return err
+}
+
+func (b *redisBroker) subscribeStream(stream string, handler broker.Handler, binder broker.Binder, options broker.SubscribeOptions) (broker.Subscriber, error) {
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := newStreamSubscriber(b, stream, handler, binder, filter, options)
+
+	if err = sub.createGroup(); err != nil {
+		sub.cancel()
+		return nil, err
+	}
+
+	b.subscribers.Add(stream, sub)
+
+	go sub.run()
+
+	return sub, nil
+}
+
+func defaultConsumerName() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "consumer"
+	}
+	return host + "-" + uuid.New().String()[:8]
+}
+
+// parseStreamMessages 转换 XREADGROUP、XAUTOCLAIM 返回的消息，已删除的消息没有字段
+func parseStreamMessages(messages []redis.XMessage) ([]streamEntry, error) {
+	entries := make([]streamEntry, 0, len(messages))
+	for _, m := range messages {
+		entry := streamEntry{id: m.ID}
+
+		if m.Values == nil {
+			entry.deleted = true
+			entries = append(entries, entry)
+			continue
+		}
+
+		if v, ok := m.Values[streamFieldBody]; ok {
+			entry.body = streamValueBytes(v)
+		}
+		if v, ok := m.Values[streamFieldHeaders]; ok {
+			if err := json.Unmarshal(streamValueBytes(v), &entry.headers); err != nil {
+				return nil, fmt.Errorf("redis: decode headers of %s: %w", m.ID, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func streamValueBytes(v interface{}) []byte {
+	switch x := v.(type) {
+	case string:
+		return []byte(x)
+	case []byte:
+		return x
+	default:
+		return []byte(fmt.Sprint(x))
+	}
+}
+
+// isBusyGroup 消费组已经存在
+func isBusyGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP")
+}
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestParseStreamMessages(t *testing.T) {
+	entries, err := parseStreamMessages([]redis.XMessage{
+		{ID: "1-0", Values: map[string]interface{}{"body": "a"}},
+		{ID: "2-0", Values: map[string]interface{}{"body": "b", "headers": `{"type":"created"}`}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []streamEntry{
+		{id: "1-0", body: []byte("a")},
+		{id: "2-0", body: []byte("b"), headers: broker.Headers{"type": "created"}},
+	}, entries)
+
+	entries, err = parseStreamMessages(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestParseStreamMessagesDeleted(t *testing.T) {
+	// XAUTOCLAIM 在 Redis 6.2 中对已删除的消息返回空的字段
+	entries, err := parseStreamMessages([]redis.XMessage{{ID: "3-0"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []streamEntry{{id: "3-0", deleted: true}}, entries)
+
+	_, err = parseStreamMessages([]redis.XMessage{
+		{ID: "4-0", Values: map[string]interface{}{"headers": "not json"}},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestIsBusyGroup(t *testing.T) {
+	assert.True(t, isBusyGroup(errors.New("BUSYGROUP Consumer Group name already exists")))
+	assert.False(t, isBusyGroup(errors.New("ERR no such key")))
+	assert.False(t, isBusyGroup(nil))
+}
+
+func TestStreamSubscriberOptions(t *testing.T) {
+	b := NewBroker().(*redisBroker)
+
+	s := newStreamSubscriber(b, "orders", nil, nil, nil, broker.NewSubscribeOptions(
+		broker.WithQueueName("billing"),
+		WithConsumerName("worker-1"),
+		WithReadCount(50),
+		WithStartID("$"),
+	))
+	assert.Equal(t, "billing", s.group)
+	assert.Equal(t, "worker-1", s.consumer)
+	assert.Equal(t, 50, s.readCount)
+	assert.Equal(t, "$", s.startID)
+	assert.False(t, s.temporary)
+	assert.Equal(t, DefaultStreamClaimMinIdle, s.claimMinIdle)
+
+	s = newStreamSubscriber(b, "orders", nil, nil, nil, broker.NewSubscribeOptions())
+	assert.True(t, s.temporary)
+	assert.Equal(t, "$", s.startID)
+}
+
+// newMiniredisBroker 连接到内嵌的 miniredis，测试结束时断开
+func newMiniredisBroker(t *testing.T, opts ...broker.Option) (*redisBroker, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+
+	b := NewBroker(append([]broker.Option{broker.WithAddress(mr.Addr())}, opts...)...).(*redisBroker)
+	assert.Nil(t, b.Init())
+	assert.Nil(t, b.Connect())
+	t.Cleanup(func() { _ = b.Disconnect() })
+
+	return b, mr
+}
+
+func TestStreamPruneConsumers(t *testing.T) {
+	b, _ := newMiniredisBroker(t, WithMode(ModeStreams))
+	ctx := context.Background()
+
+	// miniredis 不记录读取时间，空闲时间无法模拟，这里只验证未确认的消息不会随消费者一起被删除
+	s := newStreamSubscriber(b, "orders", nil, nil, nil, broker.NewSubscribeOptions(
+		broker.WithQueueName("billing"),
+		WithConsumerName("current"),
+		WithClaimMinIdle(0),
+	))
+	defer s.cancel()
+	assert.Nil(t, s.createGroup())
+
+	_, err := b.client.XAdd(ctx, &redis.XAddArgs{Stream: "orders", Values: map[string]interface{}{streamFieldBody: "1"}}).Result()
+	assert.Nil(t, err)
+
+	_, err = b.client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "billing", Consumer: "stale-busy", Streams: []string{"orders", ">"}, Count: 1, Block: -1}).Result()
+	assert.Nil(t, err)
+	for _, name := range []string{"current", "stale-idle"} {
+		assert.Nil(t, b.client.XGroupCreateConsumer(ctx, "orders", "billing", name).Err())
+	}
+
+	s.pruneConsumers()
+
+	consumers, err := b.client.XInfoConsumers(ctx, "orders", "billing").Result()
+	assert.Nil(t, err)
+	var names []string
+	for _, c := range consumers {
+		names = append(names, c.Name)
+	}
+	assert.ElementsMatch(t, []string{"current", "stale-busy"}, names)
+
+	pending, err := b.client.XPending(ctx, "orders", "billing").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), pending.Count)
+}
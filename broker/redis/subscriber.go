@@ -1,11 +1,10 @@
 package redis
 
 import (
-	"errors"
 	"sync"
-	"time"
 
-	"github.com/gomodule/redigo/redis"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
 	"github.com/tx7do/kratos-transport/broker"
 )
 
@@ -20,111 +19,112 @@ type subscriber struct {
 
 	handler broker.Handler
 	binder  broker.Binder
+	filter  *broker.Filter
 
 	options broker.SubscribeOptions
 
-	conn *redis.PubSubConn
+	conn *redis.PubSub
+
+	log *log.Helper
 }
 
 func (s *subscriber) onStart() error {
 	return nil
 }
 
-func (s *subscriber) onMessage(channel string, data []byte) error {
-	var m broker.Message
+func (s *subscriber) onMessage(channel string, payload []byte) {
+	e, ok := decodeEnvelope(payload)
+	if !ok {
+		e = envelope{body: payload}
+	}
+
+	m := broker.Message{
+		Headers: e.headers,
+	}
+	if e.id != "" {
+		m.Msg = e.id
+	}
+
+	p := publication{
+		topic:   channel,
+		message: &m,
+	}
+
+	if !s.filter.Match(e.headers) {
+		return
+	}
 
 	if s.binder != nil {
 		m.Body = s.binder()
 
-		if err := broker.Unmarshal(s.b.options.Codec, data, &m.Body); err != nil {
-			//LogError(err)
-			return err
+		if err := broker.Unmarshal(s.b.options.Codec, e.body, &m.Body); err != nil {
+			s.handleError(&p, e, broker.ErrorStageDecode, err)
+			return
 		}
 	} else {
-		m.Body = data
+		m.Body = e.body
 	}
 
-	p := publication{
-		topic:   channel,
-		message: &m,
+	headers := make(map[string]string, len(e.headers))
+	for k, v := range e.headers {
+		headers[k] = v
 	}
+	ctx, span := s.b.startConsumerSpan(s.options.Context, channel, headers)
 
-	if p.err = s.handler(s.options.Context, &p); p.err != nil {
-		return p.err
+	err := s.handler(ctx, &p)
+	s.b.finishConsumerSpan(span, err)
+	if err != nil {
+		s.handleError(&p, e, broker.ErrorStageHandle, err)
+		return
 	}
 
 	if s.options.AutoAck {
-		if p.err = p.Ack(); p.err != nil {
-			return p.err
+		if err = p.Ack(); err != nil {
+			s.handleError(&p, e, broker.ErrorStageAck, err)
 		}
 	}
-
-	return nil
 }
 
-func (s *subscriber) ping() error {
-	if s.conn == nil {
-		return errors.New("cannot ping")
-	}
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// Redis 发布订阅没有确认机制，只有死信动作会产生实际效果；设置了 WithEnvelope 时死信消息带有失败原因的消息头。
+func (s *subscriber) handleError(p *publication, e envelope, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	s.log.Errorw("msg", "message failed", "stage", stage, "channel", p.topic, "error", err)
+
+	switch action := broker.HandleError(s.b.options.ErrorHandler, s.options.Context, p, stage, err); action {
+	case broker.ErrorActionDeadLetter:
+		dead := newEnvelope(broker.DeadLetterHeaders(e.headers, s.topic, stage, err), e.body)
+		if err = s.b.publishPayload(s.options.Context, broker.DeadLetterTopic(s.topic, s.options), dead); err != nil {
+			s.log.Errorf("publish dead letter failed: %v", err)
+		}
 
-	if err := s.conn.Ping(""); err != nil {
-		return err
+	case broker.ErrorActionNack:
+		s.log.Warnf("error action %s is not supported by redis pub/sub, message cannot be redelivered", action)
+
+	default:
 	}
-	return nil
 }
 
 func (s *subscriber) recv() {
-	defer func(conn *redis.PubSubConn) {
-		err := conn.Close()
-		if err != nil {
-			LogError(" close pubsub connection error: ", err)
-		}
-	}(s.conn)
-
 	s.done = make(chan error, 1)
 
-	ticker := time.NewTicker(DefaultHealthCheckPeriod)
-	defer ticker.Stop()
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				if err := s.ping(); err != nil {
-					s.done <- err
-					return
-				}
-			case <-s.options.Context.Done():
-				s.done <- nil
-				return
-			}
-		}
-	}()
-
-	_ = s.ping()
+	// Channel 定期发送 PING 检查连接，断开后自动重连并重新订阅
+	ch := s.conn.Channel(redis.WithChannelHealthCheckInterval(DefaultHealthCheckPeriod))
 
 	for {
-		switch x := s.conn.Receive().(type) {
-		case error:
-			LogErrorf(" recv error: %s\n", x.Error())
-			s.done <- x
+		select {
+		case <-s.options.Context.Done():
+			_ = s.conn.Close()
+			s.done <- nil
 			return
 
-		case redis.Message:
-			if err := s.onMessage(x.Channel, x.Data); err != nil {
-				s.done <- err
-				break
-			}
-
-		case redis.Subscription:
-			switch x.Count {
-			case 0:
+		case msg, ok := <-ch:
+			if !ok {
 				s.done <- nil
 				return
 			}
-
-		case redis.Pong:
-			LogDebug(" pong")
+			s.onMessage(msg.Channel, []byte(msg.Payload))
 		}
 	}
 }
@@ -151,7 +151,7 @@ func (s *subscriber) Unsubscribe(removeFromManager bool) error {
 
 	var err error
 	if s.conn != nil {
-		err = s.conn.Unsubscribe()
+		err = s.conn.Close()
 	}
 
 	if s.b != nil && s.b.subscribers != nil && removeFromManager {
@@ -0,0 +1,203 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+// Factory 根据连接 URL 创建 broker 实例。
+// 驱动应当先应用 URL 解析出的选项，再应用 opts，使代码中显式传入的选项优先。
+type Factory func(u *url.URL, opts ...Option) (Broker, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register 注册 URL scheme 对应的 broker 工厂，通常在驱动包的 init 中调用。
+// 重复注册同一个 scheme 或者 factory 为空时会 panic。
+func Register(scheme string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("broker: Register factory is nil for scheme " + scheme)
+	}
+	if _, dup := factories[scheme]; dup {
+		panic("broker: Register called twice for scheme " + scheme)
+	}
+	factories[scheme] = factory
+}
+
+// Schemes 返回已注册的 scheme 列表
+func Schemes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	list := make([]string, 0, len(factories))
+	for scheme := range factories {
+		list = append(list, scheme)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Open 根据连接 URL 创建 broker 实例，例如：
+//
+//	kafka://host1:9092,host2:9092/?codec=json&group=x
+//
+// 对应驱动必须已经被导入（驱动在 init 中自行注册）。返回的 broker 尚未 Init 与 Connect。
+func Open(rawURL string, opts ...Option) (Broker, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("broker: invalid url: %w", err)
+	}
+	if u.Scheme == "" {
+		return nil, errors.New("broker: url scheme is empty")
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[strings.ToLower(u.Scheme)]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("broker: unknown scheme %q (forgotten import?)", u.Scheme)
+	}
+
+	return factory(u, opts...)
+}
+
+///
+/// URL 参数
+///
+
+// URLParam 将一个 URL 查询参数的值转换为选项
+type URLParam func(value string) (Option, error)
+
+// URLParams 驱动支持的查询参数，key 为参数名
+type URLParams map[string]URLParam
+
+// URLHosts 返回 URL 中以逗号分隔的主机列表
+func URLHosts(u *url.URL) []string {
+	var hosts []string
+	for _, host := range strings.Split(u.Host, ",") {
+		if host = strings.TrimSpace(host); len(host) > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// ParseURL 将 URL 中的主机列表、通用查询参数以及驱动参数转换为选项。
+// 通用参数：codec（编解码器名称）、secure（是否启用 TLS）。
+// 出现未知参数时返回错误，以便尽早发现配置中的拼写错误。
+func ParseURL(u *url.URL, params URLParams) ([]Option, error) {
+	var opts []Option
+
+	if hosts := URLHosts(u); len(hosts) > 0 {
+		opts = append(opts, WithAddress(hosts...))
+	}
+
+	query := u.Query()
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := query.Get(key)
+
+		var opt Option
+		var err error
+		switch key {
+		case "codec":
+			if encoding.GetCodec(value) == nil {
+				err = fmt.Errorf("codec %q not registered", value)
+			} else {
+				opt = WithCodec(value)
+			}
+		case "secure":
+			var enable bool
+			if enable, err = strconv.ParseBool(value); err == nil {
+				opt = WithEnableSecure(enable)
+			}
+		default:
+			param, ok := params[key]
+			if !ok {
+				return nil, fmt.Errorf("broker: unknown url parameter %q for scheme %q", key, u.Scheme)
+			}
+			opt, err = param(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("broker: invalid url parameter %q: %w", key, err)
+		}
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+	}
+
+	return opts, nil
+}
+
+// URLString 字符串参数
+func URLString(fn func(string) Option) URLParam {
+	return func(value string) (Option, error) {
+		return fn(value), nil
+	}
+}
+
+// URLBool 布尔参数
+func URLBool(fn func(bool) Option) URLParam {
+	return func(value string) (Option, error) {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		return fn(v), nil
+	}
+}
+
+// URLInt 整数参数
+func URLInt(fn func(int) Option) URLParam {
+	return func(value string) (Option, error) {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		return fn(v), nil
+	}
+}
+
+// URLDuration 时长参数，格式同 time.ParseDuration，例如 500ms、10s
+func URLDuration(fn func(time.Duration) Option) URLParam {
+	return func(value string) (Option, error) {
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		return fn(v), nil
+	}
+}
+
+// URLStrings 逗号分隔的字符串列表参数
+func URLStrings(fn func([]string) Option) URLParam {
+	return func(value string) (Option, error) {
+		var list []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); len(v) > 0 {
+				list = append(list, v)
+			}
+		}
+		return fn(list), nil
+	}
+}
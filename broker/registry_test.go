@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/go-kratos/kratos/v2/encoding/json"
+)
+
+type timeoutKey struct{}
+
+type fakeBroker struct {
+	options Options
+}
+
+func (b *fakeBroker) Name() string         { return "fake" }
+func (b *fakeBroker) Options() Options     { return b.options }
+func (b *fakeBroker) Address() string      { return "" }
+func (b *fakeBroker) Init(...Option) error { return nil }
+func (b *fakeBroker) Connect() error       { return nil }
+func (b *fakeBroker) Disconnect() error    { return nil }
+func (b *fakeBroker) Publish(context.Context, string, Any, ...PublishOption) error {
+	return nil
+}
+func (b *fakeBroker) Subscribe(string, Handler, Binder, ...SubscribeOption) (Subscriber, error) {
+	return nil, nil
+}
+func (b *fakeBroker) Request(context.Context, string, Any, ...RequestOption) (Any, error) {
+	return nil, nil
+}
+
+func init() {
+	Register("fake", func(u *url.URL, opts ...Option) (Broker, error) {
+		urlOpts, err := ParseURL(u, URLParams{
+			"timeout": URLDuration(func(d time.Duration) Option {
+				return OptionContextWithValue(timeoutKey{}, d)
+			}),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &fakeBroker{options: NewOptionsAndApply(append(urlOpts, opts...)...)}, nil
+	})
+}
+
+func TestOpen(t *testing.T) {
+	b, err := Open("fake://host1:1234,host2:1234/?codec=json&secure=true&timeout=3s")
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	options := b.Options()
+	if !reflect.DeepEqual(options.Addrs, []string{"host1:1234", "host2:1234"}) {
+		t.Errorf("unexpected addrs: %v", options.Addrs)
+	}
+	if options.Codec == nil || options.Codec.Name() != "json" {
+		t.Errorf("unexpected codec: %v", options.Codec)
+	}
+	if !options.Secure {
+		t.Error("secure not applied")
+	}
+	if v, _ := options.Context.Value(timeoutKey{}).(time.Duration); v != 3*time.Second {
+		t.Errorf("unexpected timeout: %v", v)
+	}
+}
+
+func TestOpenOptionsOverrideURL(t *testing.T) {
+	b, err := Open("fake://host1:1234", WithAddress("host3:1234"))
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if !reflect.DeepEqual(b.Options().Addrs, []string{"host3:1234"}) {
+		t.Errorf("unexpected addrs: %v", b.Options().Addrs)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	for _, rawURL := range []string{
+		"host1:1234",
+		"unknown://host1:1234",
+		"fake://host1:1234/?unknown=1",
+		"fake://host1:1234/?timeout=abc",
+		"fake://host1:1234/?codec=not-exist",
+	} {
+		if _, err := Open(rawURL); err == nil {
+			t.Errorf("expected error for %s", rawURL)
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate register")
+		}
+	}()
+	Register("FAKE", func(*url.URL, ...Option) (Broker, error) { return nil, nil })
+}
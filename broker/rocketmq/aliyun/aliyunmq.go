@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"go.opentelemetry.io/otel/attribute"
 	semConv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"go.opentelemetry.io/otel/trace"
@@ -41,6 +42,8 @@ type aliyunmqBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -50,6 +53,8 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 		options:     options,
 		retryCount:  2,
 		subscribers: broker.NewSubscriberSyncMap(),
+
+		log: newLoggerHelper(options.Logger),
 	}
 }
 
@@ -73,6 +78,8 @@ func (r *aliyunmqBroker) Options() broker.Options {
 func (r *aliyunmqBroker) Init(opts ...broker.Option) error {
 	r.options.Apply(opts...)
 
+	r.log = newLoggerHelper(r.options.Logger)
+
 	if v, ok := r.options.Context.Value(rocketmqOption.NameServersKey{}).([]string); ok {
 		r.nameServers = v
 	}
@@ -216,7 +223,7 @@ func (r *aliyunmqBroker) publish(ctx context.Context, topic string, msg []byte,
 
 	ret, err := p.PublishMessage(aMsg)
 	if err != nil {
-		LogErrorf("send message error: %s\n", err)
+		r.log.Errorf("send message error: %s\n", err)
 	}
 
 	r.finishProducerSpan(span, ret.MessageId, err)
@@ -238,13 +245,23 @@ func (r *aliyunmqBroker) Subscribe(topic string, handler broker.Handler, binder
 		o(&options)
 	}
 
-	mqConsumer := r.client.GetConsumer(r.instanceName, topic, options.Queue, "")
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(r, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	mqConsumer := r.client.GetConsumer(r.instanceName, topic, options.Queue, messageTag(filter))
 
 	sub := &Subscriber{
 		options: options,
 		topic:   topic,
 		handler: handler,
 		binder:  binder,
+		filter:  filter,
 		reader:  mqConsumer,
 		done:    make(chan struct{}),
 	}
@@ -254,6 +271,29 @@ func (r *aliyunmqBroker) Subscribe(topic string, handler broker.Handler, binder
 	return sub, nil
 }
 
+// messageTag HTTP 接口只支持按标签过滤，选择器为 TAGS = 'x' 时交给服务端过滤，其余只在客户端过滤
+func messageTag(filter *broker.Filter) string {
+	eqs, _, ok := filter.Equalities()
+	if tag, found := eqs["TAGS"]; ok && found && len(eqs) == 1 {
+		return tag
+	}
+	return ""
+}
+
+// filterHeaders 选择器可以通过 TAGS 引用消息标签
+func filterHeaders(msg *aliyun.ConsumeMessageEntry) broker.Headers {
+	if len(msg.MessageTag) == 0 {
+		return msg.Properties
+	}
+
+	headers := make(broker.Headers, len(msg.Properties)+1)
+	for k, v := range msg.Properties {
+		headers[k] = v
+	}
+	headers["TAGS"] = msg.MessageTag
+	return headers
+}
+
 func (r *aliyunmqBroker) doConsume(sub *Subscriber) {
 	for {
 		endChan := make(chan int)
@@ -279,11 +319,20 @@ func (r *aliyunmqBroker) doConsume(sub *Subscriber) {
 
 						m.Headers = msg.Properties
 
+						// 不满足选择器的消息直接确认，不解码也不调用处理器
+						if !sub.filter.Match(filterHeaders(&msg)) {
+							if err = p.Ack(); err != nil {
+								r.log.Errorf("unable to ack filtered msg: %v", err)
+							}
+							r.finishConsumerSpan(span, nil)
+							continue
+						}
+
 						if sub.binder != nil {
 							m.Body = sub.binder()
 
 							if err = broker.Unmarshal(r.options.Codec, []byte(msg.MessageBody), &m.Body); err != nil {
-								LogError(err)
+								r.handleError(ctx, sub, p, &msg, broker.ErrorStageDecode, err)
 								r.finishConsumerSpan(span, err)
 								continue
 							}
@@ -292,7 +341,7 @@ func (r *aliyunmqBroker) doConsume(sub *Subscriber) {
 						}
 
 						if err = sub.handler(ctx, p); err != nil {
-							LogErrorf("process message failed: %v", err)
+							r.handleError(ctx, sub, p, &msg, broker.ErrorStageHandle, err)
 							r.finishConsumerSpan(span, err)
 							continue
 						}
@@ -302,12 +351,11 @@ func (r *aliyunmqBroker) doConsume(sub *Subscriber) {
 								// 某些消息的句柄可能超时，会导致消息消费状态确认不成功。
 								if errAckItems, ok := err.(errors.ErrCode).Context()["Detail"].([]aliyun.ErrAckItem); ok {
 									for _, errAckItem := range errAckItems {
-										LogErrorf("ErrorHandle:%s, ErrorCode:%s, ErrorMsg:%s\n",
+										r.log.Errorf("ErrorHandle:%s, ErrorCode:%s, ErrorMsg:%s\n",
 											errAckItem.ErrorHandle, errAckItem.ErrorCode, errAckItem.ErrorMsg)
 									}
-								} else {
-									LogError("ack err =", err)
 								}
+								r.handleError(ctx, sub, p, &msg, broker.ErrorStageAck, err)
 								time.Sleep(time.Duration(3) * time.Second)
 							}
 						}
@@ -323,7 +371,7 @@ func (r *aliyunmqBroker) doConsume(sub *Subscriber) {
 					if strings.Contains(err.(errors.ErrCode).Error(), "MessageNotExist") {
 						//LogDebug("No new message, continue!")
 					} else {
-						LogError(err)
+						r.log.Error(err)
 						time.Sleep(time.Duration(3) * time.Second)
 					}
 					endChan <- 1
@@ -349,6 +397,33 @@ func (r *aliyunmqBroker) doConsume(sub *Subscriber) {
 	}
 }
 
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 未确认的消息会在下次长轮询时重新投递，因此 Nack 与默认行为一致。
+func (r *aliyunmqBroker) handleError(ctx context.Context, sub *Subscriber, p *Publication, msg *aliyun.ConsumeMessageEntry, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	r.log.Errorw("msg", "message failed", "stage", stage, "topic", sub.topic, "group", sub.options.Queue, "error", err)
+
+	switch broker.HandleError(r.options.ErrorHandler, ctx, p, stage, err) {
+	case broker.ErrorActionAck:
+		if err = p.Ack(); err != nil {
+			r.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	case broker.ErrorActionDeadLetter:
+		headers := broker.DeadLetterHeaders(msg.Properties, sub.topic, stage, err)
+		if err = r.publish(ctx, broker.DeadLetterTopic(sub.topic, sub.options), []byte(msg.MessageBody), rocketmqOption.WithProperties(map[string]string(headers))); err != nil {
+			r.log.Errorw("msg", "publish dead letter failed", "topic", sub.topic, "error", err)
+			return
+		}
+		if err = p.Ack(); err != nil {
+			r.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	default:
+	}
+}
+
 func (r *aliyunmqBroker) startProducerSpan(ctx context.Context, topicName string, msg *aliyun.PublishMessageRequest) trace.Span {
 	if r.producerTracer == nil {
 		return nil
@@ -0,0 +1,22 @@
+package aliyun
+
+import (
+	"net/url"
+
+	"github.com/tx7do/kratos-transport/broker"
+
+	rocketmqOption "github.com/tx7do/kratos-transport/broker/rocketmq/option"
+)
+
+func init() {
+	broker.Register("rocketmq+aliyun", newBrokerFromURL)
+}
+
+func newBrokerFromURL(u *url.URL, opts ...broker.Option) (broker.Broker, error) {
+	urlOpts, err := rocketmqOption.ParseURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBroker(append(urlOpts, opts...)...), nil
+}
@@ -15,6 +15,7 @@ type Subscriber struct {
 	options broker.SubscribeOptions
 	handler broker.Handler
 	binder  broker.Binder
+	filter  *broker.Filter
 	reader  aliyun.MQConsumer
 	closed  bool
 	done    chan struct{}
@@ -0,0 +1,57 @@
+package rocketmqOption
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		nameServers []string
+		accessKey   string
+		secretKey   string
+		group       string
+		wantErr     bool
+	}{
+		{name: "name servers and group", url: "rocketmq://host1:9876,host2:9876/?group=kratos",
+			nameServers: []string{"host1:9876", "host2:9876"}, group: "kratos"},
+		{name: "credentials", url: "rocketmq+aliyun://ak:sk@host1:8080", nameServers: []string{"host1:8080"}, accessKey: "ak", secretKey: "sk"},
+		{name: "access key only", url: "rocketmq+v5://ak@host1:8081", nameServers: []string{"host1:8081"}, accessKey: "ak"},
+		{name: "invalid retry", url: "rocketmq://host1:9876/?retry=x", wantErr: true},
+		{name: "unknown parameter", url: "rocketmq://host1:9876/?unknown=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			assert.Nil(t, err)
+
+			opts, err := ParseURL(u)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			options := broker.NewOptionsAndApply(opts...)
+
+			nameServers, _ := options.Context.Value(NameServersKey{}).([]string)
+			assert.Equal(t, tt.nameServers, nameServers)
+
+			accessKey, _ := options.Context.Value(AccessKey{}).(string)
+			assert.Equal(t, tt.accessKey, accessKey)
+
+			secretKey, _ := options.Context.Value(SecretKey{}).(string)
+			assert.Equal(t, tt.secretKey, secretKey)
+
+			group, _ := options.Context.Value(GroupNameKey{}).(string)
+			assert.Equal(t, tt.group, group)
+		})
+	}
+}
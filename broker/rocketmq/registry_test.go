@@ -0,0 +1,32 @@
+package rocketmq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+func TestOpenSchemes(t *testing.T) {
+	tests := []struct {
+		url  string
+		name string
+	}{
+		{url: "rocketmq://127.0.0.1:9876", name: "rocketmqV2"},
+		{url: "rocketmq+v2://127.0.0.1:9876", name: "rocketmqV2"},
+		{url: "rocketmq+v5://127.0.0.1:8081", name: "rocketmqV5"},
+		{url: "rocketmq+aliyun://127.0.0.1:8080", name: "rocketmq-http"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			b, err := broker.Open(tt.url)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.name, b.Name())
+		})
+	}
+
+	_, err := broker.Open("rocketmq+v3://127.0.0.1:9876")
+	assert.NotNil(t, err)
+}
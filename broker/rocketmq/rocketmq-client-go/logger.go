@@ -3,16 +3,33 @@ package rocketmqClientGo
 import (
 	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/apache/rocketmq-client-go/v2/rlog"
 	"github.com/go-kratos/kratos/v2/log"
-)
 
-const (
-	loggerKey = "[rocketmq] "
+	"github.com/tx7do/kratos-transport/broker"
 )
 
+var installRlogOnce sync.Once
+
+// installRlog rocketmq-client-go 的内部日志器是进程级的全局变量，只由第一个初始化的 broker 安装，避免多个实例互相覆盖。
+func installRlog(l *logger) {
+	installRlogOnce.Do(func() {
+		rlog.SetLogger(l)
+	})
+}
+
 type logger struct {
-	level log.Level
+	level  log.Level
+	logger log.Logger
+}
+
+func newLogger(l log.Logger) *logger {
+	return &logger{
+		level:  log.LevelInfo,
+		logger: broker.NewLoggerHelper(l, "broker", "rocketmqV2").Logger(),
+	}
 }
 
 func toKeyVals(fields map[string]interface{}) (keyVals []interface{}) {
@@ -28,17 +45,15 @@ func (l *logger) Log(level log.Level, msg string, fields map[string]interface{})
 		return
 	}
 
-	keyVals := toKeyVals(fields)
-	log.Log(level, loggerKey+msg, keyVals)
+	keyVals := append([]interface{}{log.DefaultMessageKey, msg}, toKeyVals(fields)...)
+	_ = l.logger.Log(level, keyVals...)
 }
 
 func (l *logger) Logf(level log.Level, format string, a ...interface{}) {
 	if l.level > level {
 		return
 	}
-	var keyVals []interface{}
-	keyVals = append(keyVals, loggerKey)
-	log.Log(level, fmt.Sprintf(format, a...))
+	_ = l.logger.Log(level, log.DefaultMessageKey, fmt.Sprintf(format, a...))
 }
 
 func (l *logger) Debug(msg string, fields map[string]interface{}) {
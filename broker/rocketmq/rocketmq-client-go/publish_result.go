@@ -0,0 +1,36 @@
+package rocketmqClientGo
+
+import (
+	"context"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.ResultPublisher = (*rocketmqBroker)(nil)
+
+// PublishWithResult 同步发布消息，回执的 MessageID 为 MsgId，Partition 为队列 ID，Offset 为队列位移
+func (r *rocketmqBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	buf, err := broker.Marshal(r.options.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := r.publish(ctx, topic, buf, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &broker.PublishResult{
+		Topic:     topic,
+		Partition: -1,
+		Offset:    ret.QueueOffset,
+		MessageID: ret.MsgID,
+		Timestamp: time.Now(),
+	}
+	if ret.MessageQueue != nil {
+		result.Partition = ret.MessageQueue.QueueId
+	}
+
+	return result, nil
+}
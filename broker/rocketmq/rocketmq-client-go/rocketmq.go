@@ -9,7 +9,6 @@ import (
 	"github.com/apache/rocketmq-client-go/v2/consumer"
 	"github.com/apache/rocketmq-client-go/v2/primitive"
 	"github.com/apache/rocketmq-client-go/v2/producer"
-	"github.com/apache/rocketmq-client-go/v2/rlog"
 
 	"go.opentelemetry.io/otel/attribute"
 	semConv "go.opentelemetry.io/otel/semconv/v1.12.0"
@@ -59,9 +58,7 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 		retryCount:  2,
 		producers:   make(map[string]rocketmq.Producer),
 		subscribers: broker.NewSubscriberSyncMap(),
-		logger: &logger{
-			level: log.LevelInfo,
-		},
+		logger:      newLogger(options.Logger),
 	}
 }
 
@@ -85,7 +82,10 @@ func (r *rocketmqBroker) Options() broker.Options {
 func (r *rocketmqBroker) Init(opts ...broker.Option) error {
 	r.options.Apply(opts...)
 
-	rlog.SetLogger(r.logger)
+	level := r.logger.level
+	r.logger = newLogger(r.options.Logger)
+	r.logger.level = level
+	installRlog(r.logger)
 
 	if v, ok := r.options.Context.Value(rocketmqOption.NameServersKey{}).([]string); ok {
 		r.nameServers = v
@@ -307,10 +307,11 @@ func (r *rocketmqBroker) Publish(ctx context.Context, topic string, msg broker.A
 		return err
 	}
 
-	return r.publish(ctx, topic, buf, opts...)
+	_, err = r.publish(ctx, topic, buf, opts...)
+	return err
 }
 
-func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte, opts ...broker.PublishOption) error {
+func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte, opts ...broker.PublishOption) (*primitive.SendResult, error) {
 	options := broker.PublishOptions{
 		Context: ctx,
 	}
@@ -327,7 +328,7 @@ func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte,
 		p, err = r.createProducer()
 		if err != nil {
 			r.Unlock()
-			return err
+			return nil, err
 		}
 
 		r.producers[topic] = p
@@ -399,7 +400,7 @@ func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte,
 
 	r.finishProducerSpan(span, messageId, err)
 
-	return err
+	return ret, err
 }
 
 func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -412,6 +413,21 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(r, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 选择器作为 SQL92 表达式交给服务端过滤，服务端需要开启 enablePropertyFilter
+	selector := consumer.MessageSelector{}
+	if filter != nil {
+		selector = consumer.MessageSelector{Type: consumer.SQL92, Expression: filter.String()}
+	}
+
 	c, err := r.createConsumer(&options)
 	if err != nil {
 		return nil, err
@@ -424,12 +440,13 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 		reader:  c,
 	}
 
-	if err = c.Subscribe(topic, consumer.MessageSelector{},
+	if err = c.Subscribe(topic, selector,
 		func(ctx context.Context, msgs ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
 			//r.logger.Infof("[rocketmq] subscribe callback: %v \n", msgs)
 
 			var errSub error
 			var m broker.Message
+			result := consumer.ConsumeSuccess
 			for _, msg := range msgs {
 				p := &publication{topic: msg.Topic, reader: sub.reader, m: &m, rm: &msg.Message, ctx: options.Context}
 
@@ -437,12 +454,18 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 
 				m.Headers = msg.GetProperties()
 
+				if !filter.Match(m.Headers) {
+					r.finishConsumerSpan(span, nil)
+					continue
+				}
+
 				if binder != nil {
 					m.Body = binder()
 
 					if errSub = broker.Unmarshal(r.options.Codec, msg.Body, &m.Body); errSub != nil {
-						p.err = errSub
-						r.logger.Errorf("%s", errSub.Error())
+						if r.handleError(newCtx, topic, options, p, broker.ErrorStageDecode, errSub) {
+							result = consumer.ConsumeRetryLater
+						}
 						r.finishConsumerSpan(span, errSub)
 						continue
 					}
@@ -451,21 +474,25 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 				}
 
 				if errSub = sub.handler(newCtx, p); errSub != nil {
-					r.logger.Errorf("process message failed: %v", errSub)
+					if r.handleError(newCtx, topic, options, p, broker.ErrorStageHandle, errSub) {
+						result = consumer.ConsumeRetryLater
+					}
 					r.finishConsumerSpan(span, errSub)
 					continue
 				}
 
 				if sub.options.AutoAck {
 					if errSub = p.Ack(); errSub != nil {
-						r.logger.Errorf("unable to commit msg: %v", errSub)
+						if r.handleError(newCtx, topic, options, p, broker.ErrorStageAck, errSub) {
+							result = consumer.ConsumeRetryLater
+						}
 					}
 				}
 
 				r.finishConsumerSpan(span, errSub)
 			}
 
-			return consumer.ConsumeSuccess, nil
+			return result, nil
 		}); err != nil {
 		r.logger.Errorf("%s", err.Error())
 		return nil, err
@@ -479,6 +506,37 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 	return sub, nil
 }
 
+// handleError 将失败的消息交给 ErrorHandler 处置，返回 true 表示整批消息需要稍后重投。
+func (r *rocketmqBroker) handleError(ctx context.Context, topic string, options broker.SubscribeOptions, p *publication, stage broker.ErrorStage, err error) bool {
+	p.err = err
+
+	r.logger.Error("message failed", map[string]interface{}{
+		"stage": stage,
+		"topic": p.topic,
+		"group": options.Queue,
+		"error": err,
+	})
+
+	switch broker.HandleError(r.options.ErrorHandler, ctx, p, stage, err) {
+	case broker.ErrorActionNack:
+		return true
+
+	case broker.ErrorActionDeadLetter:
+		headers := broker.DeadLetterHeaders(p.rm.GetProperties(), p.topic, stage, err)
+		if _, err = r.publish(ctx, broker.DeadLetterTopic(topic, options), p.rm.Body, rocketmqOption.WithProperties(map[string]string(headers))); err != nil {
+			r.logger.Error("publish dead letter failed", map[string]interface{}{
+				"topic": p.topic,
+				"error": err,
+			})
+			return true
+		}
+		return false
+
+	default:
+		return false
+	}
+}
+
 func (r *rocketmqBroker) startProducerSpan(ctx context.Context, msg *primitive.Message) trace.Span {
 	if r.producerTracer == nil {
 		return nil
@@ -0,0 +1,37 @@
+package rocketmqClients
+
+import (
+	"context"
+	"time"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var _ broker.ResultPublisher = (*rocketmqBroker)(nil)
+
+// PublishWithResult 发布消息并返回回执，MessageID 为服务端分配的消息 ID，Offset 为队列位移。
+// 通过 WithSendAsync 异步发送时不等待回执，返回的 MessageID 为空，Offset 为 -1。
+func (r *rocketmqBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	buf, err := broker.Marshal(r.options.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := r.publish(ctx, topic, buf, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &broker.PublishResult{
+		Topic:     topic,
+		Partition: -1,
+		Offset:    -1,
+		Timestamp: time.Now(),
+	}
+	if receipt != nil {
+		result.MessageID = receipt.MessageID
+		result.Offset = receipt.Offset
+	}
+
+	return result, nil
+}
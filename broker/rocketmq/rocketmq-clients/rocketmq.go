@@ -54,6 +54,8 @@ type rocketmqBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -69,6 +71,8 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 		producers:         make(map[string]rmqClient.Producer),
 		subscribers:       broker.NewSubscriberSyncMap(),
 		credentials:       rocketmqOption.Credentials{},
+
+		log: newLoggerHelper(rocketmqOptions.Logger),
 	}
 }
 
@@ -92,6 +96,8 @@ func (r *rocketmqBroker) Options() broker.Options {
 func (r *rocketmqBroker) Init(opts ...broker.Option) error {
 	r.options.Apply(opts...)
 
+	r.log = newLoggerHelper(r.options.Logger)
+
 	// init logger
 	rmqClient.ResetLogger()
 	_ = os.Setenv(rmqClient.ENABLE_CONSOLE_APPENDER, "true")
@@ -227,10 +233,12 @@ func (r *rocketmqBroker) Publish(ctx context.Context, topic string, msg broker.A
 		return err
 	}
 
-	return r.publish(ctx, topic, buf, opts...)
+	_, err = r.publish(ctx, topic, buf, opts...)
+	return err
 }
 
-func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte, opts ...broker.PublishOption) error {
+// publish 发送消息，异步发送时返回的回执为 nil
+func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte, opts ...broker.PublishOption) (*rmqClient.SendReceipt, error) {
 	rocketmqOptions := broker.PublishOptions{
 		Context: ctx,
 	}
@@ -245,7 +253,7 @@ func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte,
 		producer, err = r.createProducer()
 		if err != nil {
 			r.Unlock()
-			return err
+			return nil, err
 		}
 
 		r.producers[topic] = producer
@@ -284,31 +292,30 @@ func (r *rocketmqBroker) publish(ctx context.Context, topic string, msg []byte,
 		sendWithTransaction = v
 	}
 
-	var err error
 	if sendWithTransaction {
-		err = r.doSendTransaction(rocketmqOptions.Context, producer, rMsg)
+		return r.doSendTransaction(rocketmqOptions.Context, producer, rMsg)
 	} else if sendAsync {
-		err = r.doSendAsync(rocketmqOptions.Context, producer, rMsg)
+		return nil, r.doSendAsync(rocketmqOptions.Context, producer, rMsg)
 	} else {
-		err = r.doSend(rocketmqOptions.Context, producer, rMsg)
+		return r.doSend(rocketmqOptions.Context, producer, rMsg)
 	}
-
-	return err
 }
 
-func (r *rocketmqBroker) doSend(ctx context.Context, producer rmqClient.Producer, rMsg *rmqClient.Message) error {
+func (r *rocketmqBroker) doSend(ctx context.Context, producer rmqClient.Producer, rMsg *rmqClient.Message) (*rmqClient.SendReceipt, error) {
 	span := r.startProducerSpan(ctx, rMsg, false)
 
 	var err error
 	var receipts []*rmqClient.SendReceipt
 	receipts, err = producer.Send(r.options.Context, rMsg)
 	if err != nil {
-		LogErrorf("send message error: %s\n", err)
+		r.log.Errorf("send message error: %s\n", err)
 		r.finishProducerSpan(ctx, span, nil, err)
-	} else {
-		r.finishProducerSpan(ctx, span, receipts[0], nil)
+		return nil, err
 	}
-	return err
+
+	r.finishProducerSpan(ctx, span, receipts[0], nil)
+
+	return receipts[0], nil
 }
 
 func (r *rocketmqBroker) doSendAsync(ctx context.Context, producer rmqClient.Producer, rMsg *rmqClient.Message) error {
@@ -316,7 +323,7 @@ func (r *rocketmqBroker) doSendAsync(ctx context.Context, producer rmqClient.Pro
 
 	producer.SendAsync(ctx, rMsg, func(ctx context.Context, receipts []*rmqClient.SendReceipt, err error) {
 		if err != nil {
-			LogErrorf("send async message error: %s\n", err)
+			r.log.Errorf("send async message error: %s\n", err)
 			r.finishProducerSpan(ctx, span, nil, err)
 		} else {
 			r.finishProducerSpan(ctx, span, receipts[0], nil)
@@ -326,7 +333,7 @@ func (r *rocketmqBroker) doSendAsync(ctx context.Context, producer rmqClient.Pro
 	return nil
 }
 
-func (r *rocketmqBroker) doSendTransaction(ctx context.Context, producer rmqClient.Producer, rMsg *rmqClient.Message) error {
+func (r *rocketmqBroker) doSendTransaction(ctx context.Context, producer rmqClient.Producer, rMsg *rmqClient.Message) (*rmqClient.SendReceipt, error) {
 	span := r.startProducerSpan(ctx, rMsg, true)
 
 	transaction := producer.BeginTransaction()
@@ -334,18 +341,18 @@ func (r *rocketmqBroker) doSendTransaction(ctx context.Context, producer rmqClie
 	var err error
 	var receipts []*rmqClient.SendReceipt
 	if receipts, err = producer.SendWithTransaction(ctx, rMsg, transaction); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err = transaction.Commit(); err != nil {
-		LogErrorf("send transaction message error: %s\n", err)
+		r.log.Errorf("send transaction message error: %s\n", err)
 		r.finishProducerSpan(ctx, span, nil, err)
-		return err
+		return nil, err
 	}
 
 	r.finishProducerSpan(ctx, span, receipts[0], nil)
 
-	return nil
+	return receipts[0], nil
 }
 
 func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -358,6 +365,15 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 		o(rocketmqOptions)
 	}
 
+	if rocketmqOptions.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(r, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(rocketmqOptions.Filter)
+	if err != nil {
+		return nil, err
+	}
+
 	if r.consumer == nil {
 		c, err := r.createConsumer(rocketmqOptions)
 		if err != nil {
@@ -372,13 +388,18 @@ func (r *rocketmqBroker) Subscribe(topic string, handler broker.Handler, binder
 		topic:   topic,
 		handler: handler,
 		binder:  binder,
+		filter:  filter,
 		reader:  r.consumer,
 		done:    make(chan error),
+		log:     log.NewHelper(log.With(r.log.Logger(), "topic", topic, "group", rocketmqOptions.Queue)),
 	}
 
+	// 未指定过滤表达式时，选择器作为 SQL92 表达式交给服务端过滤
 	var filterExpression *rmqClient.FilterExpression
 	if v, ok := rocketmqOptions.Context.Value(rocketmqOption.SubscriptionFilterExpressionKey{}).(*rmqClient.FilterExpression); ok {
 		filterExpression = v
+	} else if filter != nil {
+		filterExpression = rmqClient.NewFilterExpressionWithType(filter.String(), rmqClient.SQL92)
 	} else {
 		filterExpression = rmqClient.SUB_ALL
 	}
@@ -470,7 +491,7 @@ func (r *rocketmqBroker) run() {
 		// process message
 		for _, mv := range messages {
 			if mv == nil {
-				LogErrorf("received message is nil")
+				r.log.Errorf("received message is nil")
 				continue
 			}
 
@@ -479,7 +500,7 @@ func (r *rocketmqBroker) run() {
 			sub := r.subscribers.Get(mv.GetTopic())
 			if sub == nil {
 				err = errors.New(fmt.Sprintf("[%s] subscriber not found", mv.GetTopic()))
-				LogErrorf(err.Error())
+				r.log.Errorf(err.Error())
 				r.finishConsumerSpan(span, err)
 				continue
 			}
@@ -487,7 +508,6 @@ func (r *rocketmqBroker) run() {
 			aSub := sub.(*subscriber)
 
 			if err = aSub.onMessage(newCtx, mv); err != nil {
-				LogErrorf("[%s] subscriber not found", mv.GetTopic())
 				r.finishConsumerSpan(span, err)
 				continue
 			}
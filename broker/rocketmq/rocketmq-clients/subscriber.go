@@ -6,7 +6,9 @@ import (
 	"sync"
 
 	rmqClient "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/tx7do/kratos-transport/broker"
+	rocketmqOption "github.com/tx7do/kratos-transport/broker/rocketmq/option"
 )
 
 type subscriber struct {
@@ -16,6 +18,7 @@ type subscriber struct {
 	options broker.SubscribeOptions
 	handler broker.Handler
 	binder  broker.Binder
+	filter  *broker.Filter
 
 	topic string
 
@@ -23,6 +26,8 @@ type subscriber struct {
 	done   chan error
 
 	reader rmqClient.SimpleConsumer
+
+	log *log.Helper
 }
 
 func (s *subscriber) Options() broker.SubscribeOptions {
@@ -76,36 +81,73 @@ func (s *subscriber) onMessage(ctx context.Context, msg *rmqClient.MessageView)
 
 	outMessage := broker.Message{}
 
+	p := publication{
+		ctx:        ctx,
+		topic:      msg.GetTopic(),
+		message:    &outMessage,
+		reader:     s.reader,
+		rmqMessage: msg,
+	}
+
+	outMessage.Headers = msg.GetProperties()
+
+	// 不满足选择器的消息直接确认，不解码也不调用处理器
+	if !s.filter.Match(outMessage.Headers) {
+		if err := p.Ack(); err != nil {
+			s.log.Errorf("unable to ack filtered msg: %v", err)
+		}
+		return nil
+	}
+
 	if s.binder != nil {
 		outMessage.Body = s.binder()
 
 		if err := broker.Unmarshal(s.r.options.Codec, msg.GetBody(), &outMessage.Body); err != nil {
-			//LogError(err)
+			s.handleError(ctx, &p, broker.ErrorStageDecode, err)
 			return err
 		}
 	} else {
 		outMessage.Body = msg.GetBody()
 	}
 
-	outMessage.Headers = msg.GetProperties()
-
-	p := publication{
-		ctx:        ctx,
-		topic:      msg.GetTopic(),
-		message:    &outMessage,
-		reader:     s.reader,
-		rmqMessage: msg,
-	}
-
-	if p.err = s.handler(ctx, &p); p.err != nil {
-		return p.err
+	if err := s.handler(ctx, &p); err != nil {
+		s.handleError(ctx, &p, broker.ErrorStageHandle, err)
+		return err
 	}
 
 	if s.options.AutoAck {
-		if p.err = p.Ack(); p.err != nil {
-			return p.err
+		if err := p.Ack(); err != nil {
+			s.handleError(ctx, &p, broker.ErrorStageAck, err)
+			return err
 		}
 	}
 
 	return nil
 }
+
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 未确认的消息会在不可见时间结束后重新投递，因此 Nack 与默认行为一致。
+func (s *subscriber) handleError(ctx context.Context, p *publication, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	s.log.Errorw("msg", "message failed", "stage", stage, "error", err)
+
+	switch broker.HandleError(s.r.options.ErrorHandler, ctx, p, stage, err) {
+	case broker.ErrorActionAck:
+		if err = p.Ack(); err != nil {
+			s.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	case broker.ErrorActionDeadLetter:
+		headers := broker.DeadLetterHeaders(p.rmqMessage.GetProperties(), p.topic, stage, err)
+		if _, err = s.r.publish(ctx, broker.DeadLetterTopic(s.topic, s.options), p.rmqMessage.GetBody(), rocketmqOption.WithProperties(map[string]string(headers))); err != nil {
+			s.log.Errorw("msg", "publish dead letter failed", "error", err)
+			return
+		}
+		if err = p.Ack(); err != nil {
+			s.log.Errorf("unable to commit msg: %v", err)
+		}
+
+	default:
+	}
+}
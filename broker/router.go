@@ -0,0 +1,188 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+// DefaultTypeHeader Router 默认从该消息头读取消息类型
+const DefaultTypeHeader = "type"
+
+var (
+	ErrUnknownMessageType = errors.New("unknown message type")
+	ErrMissingMessageType = errors.New("missing message type")
+)
+
+// TypeResolver 从消息头或原始消息体中解析出消息类型
+type TypeResolver func(headers Headers, data []byte) (string, error)
+
+// TypeFromHeader 从消息头 key 中读取消息类型
+func TypeFromHeader(key string) TypeResolver {
+	return func(headers Headers, _ []byte) (string, error) {
+		if typ, ok := headers[key]; ok && len(typ) > 0 {
+			return typ, nil
+		}
+		return "", fmt.Errorf("%w: header %s is empty", ErrMissingMessageType, key)
+	}
+}
+
+// TypeFromJSONField 从 JSON 消息体的顶层字段 field 中读取消息类型，整个消息体依然解码到注册的类型中
+func TypeFromJSONField(field string) TypeResolver {
+	return func(_ Headers, data []byte) (string, error) {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return "", err
+		}
+
+		raw, ok := envelope[field]
+		if !ok {
+			return "", fmt.Errorf("%w: field %s not found", ErrMissingMessageType, field)
+		}
+
+		var typ string
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return "", fmt.Errorf("%w: field %s is not a string", ErrMissingMessageType, field)
+		}
+		if len(typ) == 0 {
+			return "", fmt.Errorf("%w: field %s is empty", ErrMissingMessageType, field)
+		}
+		return typ, nil
+	}
+}
+
+type route struct {
+	handler Handler
+	binder  Binder
+}
+
+type RouterOption func(*Router)
+
+// WithTypeResolver 设置消息类型的解析方式，默认为 TypeFromHeader(DefaultTypeHeader)
+func WithTypeResolver(resolver TypeResolver) RouterOption {
+	return func(r *Router) {
+		r.resolver = resolver
+	}
+}
+
+// WithFallback 未注册的消息类型以及无法解析类型的消息交给 handler 处理，消息体为原始的 []byte。
+// 未设置时返回包装了 ErrUnknownMessageType 或 ErrMissingMessageType 的错误，由驱动交给 ErrorHandler 处置。
+func WithFallback(handler Handler) RouterOption {
+	return func(r *Router) {
+		r.fallback = handler
+	}
+}
+
+// Router 在同一个订阅上按消息类型分发消息。
+// 订阅时不传入 Binder，驱动交付原始的 []byte，Router 解析出类型后再用对应的 Binder 解码。
+type Router struct {
+	sync.RWMutex
+
+	resolver TypeResolver
+	fallback Handler
+	routes   map[string]route
+}
+
+func NewRouter(opts ...RouterOption) *Router {
+	r := &Router{
+		resolver: TypeFromHeader(DefaultTypeHeader),
+		routes:   make(map[string]route),
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// Handle 注册消息类型 typ 的处理器，binder 创建解码的目标对象
+func (r *Router) Handle(typ string, handler Handler, binder Binder) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.routes[typ] = route{handler: handler, binder: binder}
+}
+
+// Route 注册消息类型 typ 的强类型处理器，与 Subscribe[T] 的处理器签名相同
+func Route[T any](r *Router, typ string, handler func(context.Context, string, Headers, *T) error) {
+	r.Handle(typ,
+		func(ctx context.Context, event Event) error {
+			switch t := event.Message().Body.(type) {
+			case *T:
+				return handler(ctx, event.Topic(), event.Message().Headers, t)
+			default:
+				return fmt.Errorf("unsupported type: %T", t)
+			}
+		},
+		func() Any {
+			var t T
+			return &t
+		},
+	)
+}
+
+// Subscribe 使用 broker 的编解码器订阅 topic
+func (r *Router) Subscribe(b Broker, topic string, opts ...SubscribeOption) (Subscriber, error) {
+	return b.Subscribe(topic, r.Handler(b.Options().Codec), nil, opts...)
+}
+
+// Handler 返回分发消息的处理器，用于传输层的 RegisterSubscriber 等场景，注册时 Binder 必须为 nil
+func (r *Router) Handler(codec encoding.Codec) Handler {
+	return func(ctx context.Context, event Event) error {
+		m := event.Message()
+
+		data, ok := m.Body.([]byte)
+		if !ok {
+			return fmt.Errorf("router: raw message expected, got %T, subscribe without binder", m.Body)
+		}
+
+		typ, err := r.resolver(m.Headers, data)
+		if err != nil {
+			return r.unrouted(ctx, event, err)
+		}
+
+		r.RLock()
+		rt, ok := r.routes[typ]
+		r.RUnlock()
+		if !ok {
+			return r.unrouted(ctx, event, fmt.Errorf("%w: %s", ErrUnknownMessageType, typ))
+		}
+
+		body := rt.binder()
+		if err = decode(codec, data, body); err != nil {
+			return fmt.Errorf("router: decode %s failed: %w", typ, err)
+		}
+		m.Body = body
+
+		return rt.handler(ctx, event)
+	}
+}
+
+func (r *Router) unrouted(ctx context.Context, event Event, err error) error {
+	if r.fallback != nil {
+		return r.fallback(ctx, event)
+	}
+	return err
+}
+
+// decode 没有编解码器时只支持 []byte 和 string 类型
+func decode(codec encoding.Codec, data []byte, v Any) error {
+	if codec != nil {
+		return Unmarshal(codec, data, v)
+	}
+
+	switch t := v.(type) {
+	case *[]byte:
+		*t = data
+	case *string:
+		*t = string(data)
+	default:
+		return fmt.Errorf("codec is required to decode %T", v)
+	}
+	return nil
+}
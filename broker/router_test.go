@@ -0,0 +1,142 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+type routerEvent struct {
+	m *Message
+}
+
+func (e *routerEvent) Topic() string           { return "events" }
+func (e *routerEvent) Message() *Message       { return e.m }
+func (e *routerEvent) RawMessage() interface{} { return e.m }
+func (e *routerEvent) Ack() error              { return nil }
+func (e *routerEvent) Error() error            { return nil }
+
+type orderCreated struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type orderCancelled struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func newRouterEvent(headers Headers, body string) *routerEvent {
+	return &routerEvent{m: &Message{Headers: headers, Body: []byte(body)}}
+}
+
+func TestRouterByHeader(t *testing.T) {
+	codec := encoding.GetCodec("json")
+
+	var created *orderCreated
+	var cancelled *orderCancelled
+
+	r := NewRouter()
+	Route(r, "created", func(_ context.Context, _ string, _ Headers, msg *orderCreated) error {
+		created = msg
+		return nil
+	})
+	Route(r, "cancelled", func(_ context.Context, _ string, _ Headers, msg *orderCancelled) error {
+		cancelled = msg
+		return nil
+	})
+
+	h := r.Handler(codec)
+
+	if err := h(context.Background(), newRouterEvent(Headers{"type": "created"}, `{"id":"1"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := h(context.Background(), newRouterEvent(Headers{"type": "cancelled"}, `{"id":"2","reason":"late"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if created == nil || created.ID != "1" {
+		t.Errorf("unexpected created message: %+v", created)
+	}
+	if cancelled == nil || cancelled.ID != "2" || cancelled.Reason != "late" {
+		t.Errorf("unexpected cancelled message: %+v", cancelled)
+	}
+}
+
+func TestRouterByJSONField(t *testing.T) {
+	var got *orderCancelled
+
+	r := NewRouter(WithTypeResolver(TypeFromJSONField("type")))
+	Route(r, "cancelled", func(_ context.Context, _ string, _ Headers, msg *orderCancelled) error {
+		got = msg
+		return nil
+	})
+
+	h := r.Handler(encoding.GetCodec("json"))
+
+	if err := h(context.Background(), newRouterEvent(nil, `{"type":"cancelled","id":"3"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ID != "3" || got.Type != "cancelled" {
+		t.Errorf("unexpected message: %+v", got)
+	}
+
+	if err := h(context.Background(), newRouterEvent(nil, `{"id":"4"}`)); !errors.Is(err, ErrMissingMessageType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRouterUnknownType(t *testing.T) {
+	r := NewRouter()
+	h := r.Handler(encoding.GetCodec("json"))
+
+	if err := h(context.Background(), newRouterEvent(Headers{"type": "deleted"}, `{}`)); !errors.Is(err, ErrUnknownMessageType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := h(context.Background(), newRouterEvent(nil, `{}`)); !errors.Is(err, ErrMissingMessageType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var fallback []byte
+	r = NewRouter(WithFallback(func(_ context.Context, evt Event) error {
+		fallback = evt.Message().Body.([]byte)
+		return nil
+	}))
+	h = r.Handler(encoding.GetCodec("json"))
+
+	if err := h(context.Background(), newRouterEvent(Headers{"type": "deleted"}, `{"id":"5"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if string(fallback) != `{"id":"5"}` {
+		t.Errorf("unexpected fallback body: %s", fallback)
+	}
+}
+
+func TestRouterWithoutCodec(t *testing.T) {
+	var got string
+
+	r := NewRouter()
+	Route(r, "text", func(_ context.Context, _ string, _ Headers, msg *string) error {
+		got = *msg
+		return nil
+	})
+	Route(r, "order", func(_ context.Context, _ string, _ Headers, _ *orderCreated) error {
+		return nil
+	})
+
+	h := r.Handler(nil)
+
+	if err := h(context.Background(), newRouterEvent(Headers{"type": "text"}, "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello" {
+		t.Errorf("unexpected body: %s", got)
+	}
+
+	if err := h(context.Background(), newRouterEvent(Headers{"type": "order"}, `{}`)); err == nil {
+		t.Error("expected decode error without codec")
+	}
+}
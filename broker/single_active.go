@@ -0,0 +1,170 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// DefaultLeaseRetryInterval 获取租约或订阅失败后的重试间隔
+var DefaultLeaseRetryInterval = time.Second
+
+// Lease 单活消费者使用的租约，由 Redis、etcd 等实现。
+// 使用 etcd 时可以基于 concurrency.Election 实现：Acquire 调用 Campaign，会话的 Done 通道即为丢失通知。
+type Lease interface {
+	// Acquire 阻塞直到获得租约或 ctx 被取消，返回的通道在租约丢失（续约失败或过期）时关闭
+	Acquire(ctx context.Context) (<-chan struct{}, error)
+
+	// Release 主动释放租约，其他副本可以立即获得
+	Release(ctx context.Context) error
+}
+
+// NativeLease 由驱动原生实现单活消费的标记，例如 RabbitMQ 的 x-single-active-consumer 队列参数。
+// 驱动识别到自己的 NativeLease 时不进行选主，其他驱动返回 ErrNotSupported。
+type NativeLease interface {
+	Lease
+
+	// Native 返回实现该租约的驱动名称
+	Native() string
+}
+
+// SubscribeSingleActive 只在持有租约时订阅 topic，租约丢失后取消订阅并重新竞争租约，由其他副本自动接管。
+// 驱动在 Subscribe 中发现 SubscribeOptions.SingleActiveConsumer 不为空时调用该函数。
+func SubscribeSingleActive(b Broker, topic string, handler Handler, binder Binder, opts ...SubscribeOption) (Subscriber, error) {
+	options := NewSubscribeOptions(opts...)
+	lease := options.SingleActiveConsumer
+
+	if native, ok := lease.(NativeLease); ok && native.Native() != b.Name() {
+		return nil, ErrNotSupported
+	}
+
+	ctx, cancel := context.WithCancel(options.Context)
+
+	s := &singleActiveSubscriber{
+		b:       b,
+		topic:   topic,
+		handler: handler,
+		binder:  binder,
+		opts:    append(opts, WithSingleActiveConsumer(nil)),
+		options: options,
+		lease:   lease,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		log:     NewLoggerHelper(b.Options().Logger, "broker", b.Name(), "topic", topic),
+	}
+
+	go s.run(ctx)
+
+	return s, nil
+}
+
+type singleActiveSubscriber struct {
+	b       Broker
+	topic   string
+	handler Handler
+	binder  Binder
+	opts    []SubscribeOption
+	options SubscribeOptions
+	lease   Lease
+
+	mu    sync.Mutex
+	inner Subscriber
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	log *log.Helper
+}
+
+func (s *singleActiveSubscriber) Options() SubscribeOptions {
+	return s.options
+}
+
+func (s *singleActiveSubscriber) Topic() string {
+	return s.topic
+}
+
+// Active 返回当前副本是否持有租约并正在消费
+func (s *singleActiveSubscriber) Active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inner != nil
+}
+
+func (s *singleActiveSubscriber) Unsubscribe(removeFromManager bool) error {
+	s.cancel()
+	<-s.done
+
+	return nil
+}
+
+func (s *singleActiveSubscriber) run(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		lost, err := s.lease.Acquire(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.log.Errorf("acquire lease failed: %s", err.Error())
+			if !s.wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		s.log.Infof("lease acquired, start consuming")
+
+		if err = s.consume(ctx, lost); err != nil {
+			s.log.Errorf("subscribe failed: %s", err.Error())
+		}
+
+		if err = s.lease.Release(context.Background()); err != nil {
+			s.log.Errorf("release lease failed: %s", err.Error())
+		}
+
+		if ctx.Err() != nil || !s.wait(ctx) {
+			return
+		}
+	}
+}
+
+// consume 订阅直到租约丢失或 ctx 被取消
+func (s *singleActiveSubscriber) consume(ctx context.Context, lost <-chan struct{}) error {
+	inner, err := s.b.Subscribe(s.topic, s.handler, s.binder, s.opts...)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.inner = inner
+	s.mu.Unlock()
+
+	select {
+	case <-lost:
+		s.log.Warnf("lease lost, stop consuming")
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	s.inner = nil
+	s.mu.Unlock()
+
+	return inner.Unsubscribe(true)
+}
+
+func (s *singleActiveSubscriber) wait(ctx context.Context) bool {
+	timer := time.NewTimer(DefaultLeaseRetryInterval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
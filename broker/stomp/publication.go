@@ -25,6 +25,16 @@ func (p *publication) Ack() error {
 	return p.broker.stompConn.Ack(p.msg)
 }
 
+func (p *publication) Nack() error {
+	if p.broker == nil {
+		return errors.New("broker is nil")
+	}
+	if p.broker.stompConn == nil {
+		return errors.New("stomp connection is nil")
+	}
+	return p.broker.stompConn.Nack(p.msg)
+}
+
 func (p *publication) Error() error {
 	return p.err
 }
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"go.opentelemetry.io/otel/attribute"
 	semConv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"go.opentelemetry.io/otel/trace"
@@ -33,6 +34,8 @@ type stompBroker struct {
 
 	producerTracer *tracing.Tracer
 	consumerTracer *tracing.Tracer
+
+	log *log.Helper
 }
 
 func NewBroker(opts ...broker.Option) broker.Broker {
@@ -41,6 +44,8 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 	b := &stompBroker{
 		options:     options,
 		subscribers: broker.NewSubscriberSyncMap(),
+
+		log: newLoggerHelper(options.Logger),
 	}
 
 	return b
@@ -74,6 +79,8 @@ func (b *stompBroker) Init(opts ...broker.Option) error {
 
 	b.options.Apply(opts...)
 
+	b.log = newLoggerHelper(b.options.Logger)
+
 	var cAddrs []string
 	for _, addr := range b.options.Addrs {
 		if len(addr) == 0 {
@@ -134,7 +141,7 @@ func (b *stompBroker) Connect() error {
 		}
 	}
 	if host, ok := b.options.Context.Value(vHostKey{}).(string); ok {
-		LogInfof("Adding host: %s", host)
+		b.log.Infof("Adding host: %s", host)
 		stompOpts = append(stompOpts, stompV3.ConnOpt.Host(host))
 	}
 	if v, ok := b.options.Context.Value(heartBeatKey{}).(*heartbeatTimeout); ok {
@@ -232,8 +239,22 @@ func (b *stompBroker) Subscribe(topic string, handler broker.Handler, binder bro
 		o(&options)
 	}
 
+	if options.SingleActiveConsumer != nil {
+		return broker.SubscribeSingleActive(b, topic, handler, binder, opts...)
+	}
+
+	filter, err := broker.ParseFilter(options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
 	stompOpt := make([]func(*frameV3.Frame) error, 0, len(opts))
 
+	// ActiveMQ 和 Artemis 通过 selector 头在服务端过滤，其他服务端忽略该头，由客户端过滤
+	if filter != nil {
+		stompOpt = append(stompOpt, stompV3.SubscribeOpt.Header("selector", filter.String()))
+	}
+
 	if durableQueue, ok := options.Context.Value(durableQueueKey{}).(bool); ok && durableQueue {
 		stompOpt = append(stompOpt, stompV3.SubscribeOpt.Header("persistent", "true"))
 	}
@@ -273,12 +294,22 @@ func (b *stompBroker) Subscribe(topic string, handler broker.Handler, binder bro
 
 				ctx, span := b.startConsumerSpan(options.Context, msg)
 
+				// 不满足选择器的消息直接确认，不解码也不调用处理器
+				if !filter.Match(m.Headers) {
+					if !options.AutoAck {
+						if err := msg.Conn.Ack(msg); err != nil {
+							b.log.Errorf("unable to ack filtered msg: %v", err)
+						}
+					}
+					b.finishConsumerSpan(span, nil)
+					return
+				}
+
 				if binder != nil {
 					m.Body = binder()
 
-					if err = broker.Unmarshal(b.options.Codec, msg.Body, &m.Body); err != nil {
-						p.err = err
-						LogError(err)
+					if err := broker.Unmarshal(b.options.Codec, msg.Body, &m.Body); err != nil {
+						b.handleError(ctx, topic, options, p, broker.ErrorStageDecode, err)
 						b.finishConsumerSpan(span, p.err)
 						return
 					}
@@ -286,18 +317,19 @@ func (b *stompBroker) Subscribe(topic string, handler broker.Handler, binder bro
 					m.Body = msg.Body
 				}
 
-				if err = handler(ctx, p); p.err != nil {
-					p.err = err
+				if err := handler(ctx, p); err != nil {
+					b.handleError(ctx, topic, options, p, broker.ErrorStageHandle, err)
 					b.finishConsumerSpan(span, p.err)
 					return
 				}
 
-				if options.AutoAck || ackSuccess {
-					err = msg.Conn.Ack(msg)
-					p.err = err
+				if !options.AutoAck && ackSuccess {
+					if err := msg.Conn.Ack(msg); err != nil {
+						b.handleError(ctx, topic, options, p, broker.ErrorStageAck, err)
+					}
 				}
 
-				b.finishConsumerSpan(span, err)
+				b.finishConsumerSpan(span, p.err)
 			}(msg)
 		}
 	}()
@@ -314,6 +346,44 @@ func (b *stompBroker) Subscribe(topic string, handler broker.Handler, binder bro
 	return subs, nil
 }
 
+// handleError 将失败的消息交给 ErrorHandler 处置。
+// 自动确认模式下服务端已确认消息，只有死信动作会产生实际效果。
+func (b *stompBroker) handleError(ctx context.Context, topic string, options broker.SubscribeOptions, p *publication, stage broker.ErrorStage, err error) {
+	p.err = err
+
+	b.log.Errorw("msg", "message failed", "stage", stage, "destination", topic, "error", err)
+
+	action := broker.HandleError(b.options.ErrorHandler, ctx, p, stage, err)
+
+	if action == broker.ErrorActionDeadLetter {
+		headers := broker.DeadLetterHeaders(stompHeaderToMap(p.msg.Header), topic, stage, err)
+		if err = b.publish(ctx, broker.DeadLetterTopic(topic, options), p.msg.Body, WithHeaders(map[string]string(headers))); err != nil {
+			b.log.Errorf("publish dead letter failed: %v", err)
+			return
+		}
+		action = broker.ErrorActionAck
+	}
+
+	if options.AutoAck {
+		if action == broker.ErrorActionNack {
+			b.log.Warnf("error action %s cannot be applied in auto-ack mode, message is already acknowledged", action)
+		}
+		return
+	}
+
+	switch action {
+	case broker.ErrorActionAck:
+		err = p.Ack()
+	case broker.ErrorActionNack:
+		err = p.Nack()
+	default:
+		return
+	}
+	if err != nil {
+		b.log.Errorf("unable to settle msg: %v", err)
+	}
+}
+
 func (b *stompBroker) startProducerSpan(ctx context.Context, topic string, msg *[]func(*frameV3.Frame) error) trace.Span {
 	if b.producerTracer == nil {
 		return nil
@@ -0,0 +1,18 @@
+package tenant
+
+import (
+	"context"
+)
+
+type tenantKey struct{}
+
+// NewContext 返回携带租户标识的上下文
+func NewContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// FromContext 读取通过 NewContext 设置的租户标识
+func FromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && len(tenant) > 0
+}
@@ -0,0 +1,78 @@
+package tenant
+
+import (
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+const (
+	defaultSeparator   = "."
+	defaultHeader      = "x-tenant-id"
+	defaultMetadataKey = "x-md-global-tenant-id"
+)
+
+// Placement 租户标识在主题和队列名中的位置
+type Placement int
+
+const (
+	Prefix Placement = iota // <tenant><sep><topic>
+	Suffix                  // <topic><sep><tenant>
+)
+
+// HeaderOption 将消息头转换为驱动的发布选项，例如：
+//
+//	func(h broker.Headers) broker.PublishOption { return pulsar.WithHeaders(h) }
+type HeaderOption func(headers broker.Headers) broker.PublishOption
+
+type options struct {
+	placement    Placement
+	separator    string
+	header       string
+	metadataKey  string
+	headerOption HeaderOption
+	tenant       string
+}
+
+type Option func(*options)
+
+// WithPlacement 租户标识作为前缀还是后缀，默认为前缀
+func WithPlacement(p Placement) Option {
+	return func(o *options) {
+		o.placement = p
+	}
+}
+
+// WithSeparator 租户标识与主题之间的分隔符，默认为 "."，租户标识中不能包含分隔符
+func WithSeparator(sep string) Option {
+	return func(o *options) {
+		o.separator = sep
+	}
+}
+
+// WithHeader 注入租户标识的消息头，默认为 x-tenant-id
+func WithHeader(key string) Option {
+	return func(o *options) {
+		o.header = key
+	}
+}
+
+// WithMetadataKey 从 kratos 元数据中读取租户标识的键，默认为 x-md-global-tenant-id
+func WithMetadataKey(key string) Option {
+	return func(o *options) {
+		o.metadataKey = key
+	}
+}
+
+// WithHeaderOption 发布时通过消息头携带租户标识，各驱动设置消息头的发布选项不同，未设置时不注入发布端消息头
+func WithHeaderOption(fn HeaderOption) Option {
+	return func(o *options) {
+		o.headerOption = fn
+	}
+}
+
+// WithTenant 将 broker 绑定到一个租户，适用于每个租户单独部署的服务：
+// 上下文中没有租户标识时使用该租户，上下文中的租户与之不同时返回 ErrCrossTenant
+func WithTenant(tenant string) Option {
+	return func(o *options) {
+		o.tenant = tenant
+	}
+}
@@ -0,0 +1,226 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+var (
+	ErrMissingTenant = errors.New("tenant: tenant not found in context")
+	ErrInvalidTenant = errors.New("tenant: invalid tenant")
+	ErrCrossTenant   = errors.New("tenant: cross-tenant access rejected")
+)
+
+// tenantBroker 为主题和队列名加上租户前缀或后缀，使多个租户共用一套 Kafka、RabbitMQ 等集群。
+//
+// 发布时依次从 NewContext、kratos 服务端元数据、客户端元数据和 WithTenant 中取得租户；
+// 订阅时从 broker.WithSubscribeContext 设置的上下文中取得租户。
+// 消费时从实际投递的主题中解析租户，注入消息头、上下文和 kratos 服务端元数据，
+// 处理器看到的主题为去掉租户标识之后的主题；主题或消息头中的租户与订阅的租户不一致时返回 ErrCrossTenant。
+//
+//	b := tenant.NewBroker(kafka.NewBroker(...), tenant.WithSeparator("-"))
+//	_ = b.Publish(tenant.NewContext(ctx, "acme"), "orders", msg) // 发布到 acme-orders
+type tenantBroker struct {
+	broker.Broker
+
+	options options
+}
+
+func NewBroker(b broker.Broker, opts ...Option) broker.Broker {
+	o := options{
+		placement:   Prefix,
+		separator:   defaultSeparator,
+		header:      defaultHeader,
+		metadataKey: defaultMetadataKey,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &tenantBroker{
+		Broker:  b,
+		options: o,
+	}
+}
+
+// Unwrap 返回被装饰的 broker
+func (b *tenantBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+// fromContext 依次从 NewContext、kratos 服务端元数据和客户端元数据中读取租户
+func (b *tenantBroker) fromContext(ctx context.Context) (string, bool) {
+	if t, ok := FromContext(ctx); ok {
+		return t, true
+	}
+	if md, ok := metadata.FromServerContext(ctx); ok {
+		if t := md.Get(b.options.metadataKey); len(t) > 0 {
+			return t, true
+		}
+	}
+	if md, ok := metadata.FromClientContext(ctx); ok {
+		if t := md.Get(b.options.metadataKey); len(t) > 0 {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+func (b *tenantBroker) resolve(ctx context.Context) (string, error) {
+	t, ok := b.fromContext(ctx)
+	if !ok {
+		t = b.options.tenant
+	} else if len(b.options.tenant) > 0 && t != b.options.tenant {
+		return "", fmt.Errorf("%w: %s", ErrCrossTenant, t)
+	}
+
+	if len(t) == 0 {
+		return "", ErrMissingTenant
+	}
+	if strings.Contains(t, b.options.separator) {
+		return "", fmt.Errorf("%w: %q contains separator %q", ErrInvalidTenant, t, b.options.separator)
+	}
+	return t, nil
+}
+
+// name 为主题或队列名加上租户标识
+func (b *tenantBroker) name(tenant, name string) string {
+	if b.options.placement == Suffix {
+		return name + b.options.separator + tenant
+	}
+	return tenant + b.options.separator + name
+}
+
+// split 从带有租户标识的名称中拆出租户和原始名称
+func (b *tenantBroker) split(name string) (string, string, bool) {
+	sep := b.options.separator
+	if b.options.placement == Suffix {
+		i := strings.LastIndex(name, sep)
+		if i < 0 {
+			return "", "", false
+		}
+		return name[i+len(sep):], name[:i], true
+	}
+
+	t, rest, ok := strings.Cut(name, sep)
+	return t, rest, ok
+}
+
+// publishArgs 返回当前租户的主题和追加了租户消息头的发布选项
+func (b *tenantBroker) publishArgs(ctx context.Context, topic string, opts []broker.PublishOption) (string, []broker.PublishOption, error) {
+	t, err := b.resolve(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if b.options.headerOption != nil {
+		opts = append(opts, b.options.headerOption(broker.Headers{b.options.header: t}))
+	}
+
+	return b.name(t, topic), opts, nil
+}
+
+func (b *tenantBroker) Publish(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) error {
+	name, opts, err := b.publishArgs(ctx, topic, opts)
+	if err != nil {
+		return err
+	}
+
+	return b.Broker.Publish(ctx, name, msg, opts...)
+}
+
+// PublishWithResult 发布到当前租户的主题，回执中的主题为去掉租户标识之后的主题
+func (b *tenantBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	name, opts, err := b.publishArgs(ctx, topic, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := broker.PublishWithResult(ctx, b.Broker, name, msg, opts...)
+	if result != nil {
+		r := *result
+		r.Topic = topic
+		result = &r
+	}
+	return result, err
+}
+
+func (b *tenantBroker) Request(ctx context.Context, topic string, msg broker.Any, opts ...broker.RequestOption) (broker.Any, error) {
+	t, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Broker.Request(ctx, b.name(t, topic), msg, opts...)
+}
+
+// Subscribe 订阅当前租户的主题，队列名和死信队列名同样加上租户标识
+func (b *tenantBroker) Subscribe(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.NewSubscribeOptions(opts...)
+
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	t, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(options.Queue) > 0 {
+		opts = append(opts, broker.WithQueueName(b.name(t, options.Queue)))
+	}
+	if len(options.DeadLetterQueue) > 0 {
+		opts = append(opts, broker.WithDeadLetterQueue(b.name(t, options.DeadLetterQueue)))
+	}
+
+	return b.Broker.Subscribe(b.name(t, topic), func(ctx context.Context, evt broker.Event) error {
+		return b.handle(ctx, evt, t, topic, handler)
+	}, binder, opts...)
+}
+
+func (b *tenantBroker) handle(ctx context.Context, evt broker.Event, t, topic string, handler broker.Handler) error {
+	if got, _, ok := b.split(evt.Topic()); ok && got != t {
+		return fmt.Errorf("%w: message from tenant %s delivered to %s", ErrCrossTenant, got, t)
+	}
+
+	if m := evt.Message(); m != nil {
+		if got, ok := m.Headers[b.options.header]; ok && got != t {
+			return fmt.Errorf("%w: message header tenant %s delivered to %s", ErrCrossTenant, got, t)
+		}
+		if m.Headers == nil {
+			m.Headers = broker.Headers{}
+		}
+		m.Headers[b.options.header] = t
+	}
+
+	ctx = NewContext(ctx, t)
+	md, ok := metadata.FromServerContext(ctx)
+	if ok {
+		md = md.Clone()
+	} else {
+		md = metadata.New()
+	}
+	md.Set(b.options.metadataKey, t)
+	ctx = metadata.NewServerContext(ctx, md)
+
+	return handler(ctx, &event{Event: evt, topic: topic})
+}
+
+// event 返回去掉租户标识之后的主题
+type event struct {
+	broker.Event
+
+	topic string
+}
+
+func (e *event) Topic() string {
+	return e.topic
+}
@@ -0,0 +1,168 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/memory"
+)
+
+func newTestBroker(t *testing.T, opts ...Option) (broker.Broker, broker.Broker) {
+	inner := memory.NewBroker()
+	if err := inner.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return NewBroker(inner, opts...), inner
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b, inner := newTestBroker(t, WithHeaderOption(func(h broker.Headers) broker.PublishOption {
+		return memory.WithHeaders(h)
+	}))
+
+	var rawTopics []string
+	_, _ = inner.Subscribe("acme.orders", func(_ context.Context, evt broker.Event) error {
+		rawTopics = append(rawTopics, evt.Topic())
+		return nil
+	}, nil)
+
+	var got []string
+	_, err := b.Subscribe("orders", func(ctx context.Context, evt broker.Event) error {
+		tenant, _ := FromContext(ctx)
+		md, _ := metadata.FromServerContext(ctx)
+		if tenant != "acme" || md.Get(defaultMetadataKey) != "acme" || evt.Message().Headers[defaultHeader] != "acme" {
+			t.Errorf("tenant not injected: %s %v %v", tenant, md, evt.Message().Headers)
+		}
+		got = append(got, evt.Topic()+":"+string(evt.Message().Body.([]byte)))
+		return nil
+	}, nil, broker.WithSubscribeContext(NewContext(context.Background(), "acme")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Publish(NewContext(context.Background(), "acme"), "orders", "a"); err != nil {
+		t.Fatal(err)
+	}
+	md := metadata.New(map[string][]string{defaultMetadataKey: {"acme"}})
+	if err = b.Publish(metadata.NewServerContext(context.Background(), md), "orders", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.Publish(NewContext(context.Background(), "globex"), "orders", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[0] != "orders:a" || got[1] != "orders:b" {
+		t.Errorf("unexpected deliveries: %v", got)
+	}
+	if len(rawTopics) != 2 || rawTopics[0] != "acme.orders" {
+		t.Errorf("unexpected raw topics: %v", rawTopics)
+	}
+}
+
+func TestMissingTenant(t *testing.T) {
+	b, _ := newTestBroker(t)
+
+	if err := b.Publish(context.Background(), "orders", "a"); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("unexpected publish error: %v", err)
+	}
+	if _, err := b.Subscribe("orders", func(context.Context, broker.Event) error { return nil }, nil); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("unexpected subscribe error: %v", err)
+	}
+	if err := b.Publish(NewContext(context.Background(), "a.b"), "orders", "a"); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("unexpected publish error: %v", err)
+	}
+}
+
+func TestPinnedTenant(t *testing.T) {
+	b, _ := newTestBroker(t, WithTenant("acme"), WithPlacement(Suffix), WithSeparator("_"))
+
+	sub, err := b.Subscribe("orders", func(context.Context, broker.Event) error { return nil }, nil, broker.WithQueueName("billing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Topic() != "orders_acme" || sub.Options().Queue != "billing_acme" {
+		t.Errorf("unexpected subscription: %s %s", sub.Topic(), sub.Options().Queue)
+	}
+
+	if _, err = b.Subscribe("orders", func(context.Context, broker.Event) error { return nil }, nil,
+		broker.WithSubscribeContext(NewContext(context.Background(), "globex"))); !errors.Is(err, ErrCrossTenant) {
+		t.Errorf("unexpected subscribe error: %v", err)
+	}
+	if err = b.Publish(NewContext(context.Background(), "globex"), "orders", "a"); !errors.Is(err, ErrCrossTenant) {
+		t.Errorf("unexpected publish error: %v", err)
+	}
+	if err = b.Publish(context.Background(), "orders", "a"); err != nil {
+		t.Errorf("unexpected publish error: %v", err)
+	}
+}
+
+func TestRejectCrossTenantMessage(t *testing.T) {
+	var gotErr error
+	inner := memory.NewBroker(broker.WithErrorHandler(func(_ context.Context, _ broker.Event, _ broker.ErrorStage, err error) broker.ErrorAction {
+		gotErr = err
+		return broker.ErrorActionAck
+	}))
+	_ = inner.Connect()
+	b := NewBroker(inner, WithTenant("acme"))
+
+	called := false
+	if _, err := b.Subscribe("orders", func(context.Context, broker.Event) error {
+		called = true
+		return nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = inner.Publish(context.Background(), "acme.orders", "a", memory.WithHeaders(broker.Headers{defaultHeader: "globex"}))
+
+	if called || !errors.Is(gotErr, ErrCrossTenant) {
+		t.Errorf("cross-tenant message not rejected: called=%v err=%v", called, gotErr)
+	}
+}
+
+// resultBroker 为 memory broker 补上发布回执，记录实际发布的主题
+type resultBroker struct {
+	broker.Broker
+}
+
+func (b *resultBroker) PublishWithResult(ctx context.Context, topic string, msg broker.Any, opts ...broker.PublishOption) (*broker.PublishResult, error) {
+	if err := b.Publish(ctx, topic, msg, opts...); err != nil {
+		return nil, err
+	}
+	return &broker.PublishResult{Topic: topic, Offset: 7}, nil
+}
+
+func TestPublishWithResult(t *testing.T) {
+	_, inner := newTestBroker(t)
+	b := NewBroker(&resultBroker{Broker: inner})
+
+	var rawTopics []string
+	_, _ = inner.Subscribe("acme.orders", func(_ context.Context, evt broker.Event) error {
+		rawTopics = append(rawTopics, evt.Topic())
+		return nil
+	}, nil)
+
+	result, err := broker.PublishWithResult(NewContext(context.Background(), "acme"), b, "orders", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Topic != "orders" || result.Offset != 7 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(rawTopics) != 1 || rawTopics[0] != "acme.orders" {
+		t.Errorf("unexpected raw topics: %v", rawTopics)
+	}
+
+	if _, err = broker.PublishWithResult(context.Background(), b, "orders", "a"); !errors.Is(err, ErrMissingTenant) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	plain, _ := newTestBroker(t)
+	if _, err = broker.PublishWithResult(NewContext(context.Background(), "acme"), plain, "orders", "a"); !errors.Is(err, broker.ErrNotSupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package activemq
 import (
 	"crypto/tls"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
@@ -62,3 +63,11 @@ func WithPropagator(propagators propagation.TextMapPropagator) ServerOption {
 		s.brokerOpts = append(s.brokerOpts, broker.WithPropagator(propagators))
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+		s.brokerOpts = append(s.brokerOpts, broker.WithLogger(logger))
+	}
+}
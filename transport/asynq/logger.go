@@ -4,33 +4,44 @@ import (
 	"fmt"
 
 	"github.com/go-kratos/kratos/v2/log"
+
 	"github.com/hibiken/asynq"
+	"github.com/tx7do/kratos-transport/transport"
 )
 
 const (
 	logKey = "[" + KindAsynq + "]"
 )
 
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return transport.NewLoggerHelper(logger, "transport", KindAsynq)
+}
+
 ///
 /// logger
 ///
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogDebug(args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogInfo(args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogWarn(args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogError(args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogFatal(args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprint(args...))
 }
@@ -39,22 +50,27 @@ func LogFatal(args ...interface{}) {
 /// logger
 ///
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogDebugf(format string, args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogInfof(format string, args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogWarnf(format string, args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogErrorf(format string, args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogFatalf(format string, args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
@@ -64,28 +80,29 @@ func LogFatalf(format string, args ...interface{}) {
 ///
 
 type logger struct {
+	log *log.Helper
 }
 
-func newLogger() asynq.Logger {
-	return &logger{}
+func newLogger(helper *log.Helper) asynq.Logger {
+	return &logger{log: helper}
 }
 
 func (l logger) Debug(args ...interface{}) {
-	LogDebug(args...)
+	l.log.Debug(args...)
 }
 
 func (l logger) Info(args ...interface{}) {
-	LogInfo(args...)
+	l.log.Info(args...)
 }
 
 func (l logger) Warn(args ...interface{}) {
-	LogWarn(args...)
+	l.log.Warn(args...)
 }
 
 func (l logger) Error(args ...interface{}) {
-	LogError(args...)
+	l.log.Error(args...)
 }
 
 func (l logger) Fatal(args ...interface{}) {
-	LogFatal(args...)
+	l.log.Fatal(args...)
 }
@@ -0,0 +1,33 @@
+package asynq
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	sync.Mutex
+	records [][]interface{}
+}
+
+func (l *recordLogger) Log(_ log.Level, keyvals ...interface{}) error {
+	l.Lock()
+	defer l.Unlock()
+	l.records = append(l.records, keyvals)
+	return nil
+}
+
+func TestWithLogger(t *testing.T) {
+	l := &recordLogger{}
+
+	srv := NewServer(WithLogger(l))
+
+	srv.asynqConfig.Logger.Info("from asynq server")
+	srv.schedulerOpts.Logger.Info("from asynq scheduler")
+
+	assert.Len(t, l.records, 2)
+	assert.Contains(t, l.records[0], KindAsynq)
+}
@@ -272,9 +272,12 @@ func WithLocation(name string) ServerOption {
 	}
 }
 
-func WithLogger(log *log.Helper) ServerOption {
+// WithLogger 日志器，同时作用于 asynq 的服务端与调度器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
 	return func(s *Server) {
-		s.schedulerOpts.Logger = log
+		s.log = newLoggerHelper(logger)
+		s.asynqConfig.Logger = newLogger(s.log)
+		s.schedulerOpts.Logger = newLogger(s.log)
 	}
 }
 
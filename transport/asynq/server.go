@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/hibiken/asynq"
 
 	"github.com/go-kratos/kratos/v2/encoding"
@@ -66,19 +67,25 @@ type Server struct {
 	mtxEntryIDs sync.RWMutex
 
 	keepaliveServer *keepalive.Server
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
+	l := newLoggerHelper(nil)
+
 	srv := &Server{
 		baseCtx:      context.Background(),
 		started:      atomic.Bool{},
 		redisConnOpt: newRedisClientOpt(),
 		asynqConfig: asynq.Config{
 			Concurrency: defaultConcurrency,
-			Logger:      newLogger(),
+			Logger:      newLogger(l),
 		},
-		schedulerOpts: &asynq.SchedulerOpts{},
-		mux:           asynq.NewServeMux(),
+		schedulerOpts: &asynq.SchedulerOpts{
+			Logger: newLogger(l),
+		},
+		mux: asynq.NewServeMux(),
 
 		codec: encoding.GetCodec("json"),
 
@@ -86,6 +93,8 @@ func NewServer(opts ...ServerOption) *Server {
 		mtxEntryIDs: sync.RWMutex{},
 
 		gracefullyShutdown: false,
+
+		log: l,
 	}
 
 	srv.init(opts...)
@@ -221,19 +230,19 @@ func (s *Server) init(opts ...ServerOption) {
 	var err error
 	if err = s.createAsynqServer(); err != nil {
 		s.err = err
-		LogError("create asynq server failed:", err)
+		s.log.Error("create asynq server failed:", err)
 	}
 	if err = s.createAsynqClient(); err != nil {
 		s.err = err
-		LogError("create asynq client failed:", err)
+		s.log.Error("create asynq client failed:", err)
 	}
 	if err = s.createAsynqScheduler(); err != nil {
 		s.err = err
-		LogError("create asynq scheduler failed:", err)
+		s.log.Error("create asynq scheduler failed:", err)
 	}
 	if err = s.createAsynqInspector(); err != nil {
 		s.err = err
-		LogError("create asynq inspector failed:", err)
+		s.log.Error("create asynq inspector failed:", err)
 	}
 }
 
@@ -250,7 +259,7 @@ func (s *Server) RegisterSubscriber(taskType string, handler MessageHandler, cre
 			payload = creator()
 
 			if err := broker.Unmarshal(s.codec, task.Payload(), &payload); err != nil {
-				LogErrorf("unmarshal message failed: %s", err)
+				s.log.Errorf("unmarshal message failed: %s", err)
 				return err
 			}
 		} else {
@@ -258,7 +267,7 @@ func (s *Server) RegisterSubscriber(taskType string, handler MessageHandler, cre
 		}
 
 		if err := handler(task.Type(), payload); err != nil {
-			LogErrorf("handle message failed: %s", err)
+			s.log.Errorf("handle message failed: %s", err)
 			return err
 		}
 
@@ -274,7 +283,7 @@ func RegisterSubscriber[T any](srv *Server, taskType string, handler func(string
 			case *T:
 				return handler(taskType, t)
 			default:
-				LogError("invalid payload struct type:", t)
+				srv.log.Error("invalid payload struct type:", t)
 				return errors.New("invalid payload struct type")
 			}
 		},
@@ -294,7 +303,7 @@ func (s *Server) RegisterSubscriberWithCtx(taskType string,
 			payload = creator()
 
 			if err := broker.Unmarshal(s.codec, task.Payload(), &payload); err != nil {
-				LogErrorf("unmarshal message failed: %s", err)
+				s.log.Errorf("unmarshal message failed: %s", err)
 				return err
 			}
 		} else {
@@ -302,7 +311,7 @@ func (s *Server) RegisterSubscriberWithCtx(taskType string,
 		}
 
 		if err := handler(ctx, task.Type(), payload); err != nil {
-			LogErrorf("handle message failed: %s", err)
+			s.log.Errorf("handle message failed: %s", err)
 			return err
 		}
 
@@ -319,7 +328,7 @@ func RegisterSubscriberWithCtx[T any](srv *Server, taskType string,
 			case *T:
 				return handler(ctx, taskType, t)
 			default:
-				LogError("invalid payload struct type:", t)
+				srv.log.Error("invalid payload struct type:", t)
 				return errors.New("invalid payload struct type")
 			}
 		},
@@ -332,7 +341,7 @@ func RegisterSubscriberWithCtx[T any](srv *Server, taskType string,
 
 func (s *Server) handleFunc(pattern string, handler func(context.Context, *asynq.Task) error) error {
 	if s.started.Load() {
-		LogErrorf("handleFunc [%s] failed", pattern)
+		s.log.Errorf("handleFunc [%s] failed", pattern)
 		return errors.New("cannot handle func, server already started")
 	}
 	s.mux.HandleFunc(pattern, handler)
@@ -369,11 +378,11 @@ func (s *Server) NewTask(typeName string, msg broker.Any, opts ...asynq.Option)
 
 	taskInfo, err := s.client.Enqueue(task, opts...)
 	if err != nil {
-		LogErrorf("[%s] Enqueue failed: %s", typeName, err.Error())
+		s.log.Errorf("[%s] Enqueue failed: %s", typeName, err.Error())
 		return err
 	}
 
-	LogDebugf("[%s] enqueued task: id=%s queue=%s", typeName, taskInfo.ID, taskInfo.Queue)
+	s.log.Debugf("[%s] enqueued task: id=%s queue=%s", typeName, taskInfo.ID, taskInfo.Queue)
 
 	return nil
 }
@@ -408,7 +417,7 @@ func (s *Server) NewWaitResultTask(typeName string, msg broker.Any, opts ...asyn
 
 	taskInfo, err := s.client.Enqueue(task, opts...)
 	if err != nil {
-		LogErrorf("[%s] Enqueue failed: %s", typeName, err.Error())
+		s.log.Errorf("[%s] Enqueue failed: %s", typeName, err.Error())
 		return err
 	}
 
@@ -420,11 +429,11 @@ func (s *Server) NewWaitResultTask(typeName string, msg broker.Any, opts ...asyn
 
 	_, err = waitResult(s.inspector, taskInfo)
 	if err != nil {
-		LogErrorf("[%s] wait result failed: %s", typeName, err.Error())
+		s.log.Errorf("[%s] wait result failed: %s", typeName, err.Error())
 		return err
 	}
 
-	LogDebugf("[%s] enqueued task: id=%s queue=%s", typeName, taskInfo.ID, taskInfo.Queue)
+	s.log.Debugf("[%s] enqueued task: id=%s queue=%s", typeName, taskInfo.ID, taskInfo.Queue)
 
 	return nil
 }
@@ -491,13 +500,13 @@ func (s *Server) NewPeriodicTask(cronSpec, taskId, typeName string, msg broker.A
 
 	entryID, err := s.scheduler.Register(cronSpec, task, opts...)
 	if err != nil {
-		LogErrorf("[%s] enqueue periodic task failed: %s", typeName, err.Error())
+		s.log.Errorf("[%s] enqueue periodic task failed: %s", typeName, err.Error())
 		return "", err
 	}
 
 	s.addPeriodicTaskEntryID(typeName, entryID)
 
-	LogDebugf("[%s]  registered an entry: id=%q", typeName, entryID)
+	s.log.Debugf("[%s]  registered an entry: id=%q", typeName, entryID)
 
 	return entryID, nil
 }
@@ -510,7 +519,7 @@ func (s *Server) RemovePeriodicTask(taskId string) error {
 	}
 
 	if err := s.unregisterPeriodicTask(entryId); err != nil {
-		LogErrorf("[%s] dequeue periodic task failed: %s", entryId, err.Error())
+		s.log.Errorf("[%s] dequeue periodic task failed: %s", entryId, err.Error())
 		return err
 	}
 
@@ -536,7 +545,7 @@ func (s *Server) unregisterPeriodicTask(entryId string) error {
 	}
 
 	if err := s.scheduler.Unregister(entryId); err != nil {
-		LogErrorf("[%s] dequeue periodic task failed: %s", entryId, err.Error())
+		s.log.Errorf("[%s] dequeue periodic task failed: %s", entryId, err.Error())
 		return err
 	}
 
@@ -581,18 +590,18 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.keepaliveServer != nil {
 		go func() {
 			if s.err = s.keepaliveServer.Start(ctx); s.err != nil {
-				LogErrorf("keepalive server start failed: %s", s.err.Error())
+				s.log.Errorf("keepalive server start failed: %s", s.err.Error())
 			}
 		}()
 	}
 
 	if s.err = s.runAsynqScheduler(); s.err != nil {
-		LogError("run asynq scheduler failed", s.err)
+		s.log.Error("run asynq scheduler failed", s.err)
 		return s.err
 	}
 
 	if s.err = s.runAsynqServer(); s.err != nil {
-		LogError("run asynq server failed", s.err)
+		s.log.Error("run asynq server failed", s.err)
 		return s.err
 	}
 
@@ -608,7 +617,7 @@ func (s *Server) Stop(ctx context.Context) error {
 	//	return nil
 	//}
 
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	s.started.Store(false)
 
@@ -619,7 +628,7 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	if s.server != nil {
 		if s.gracefullyShutdown {
-			LogInfo("server gracefully shutdown")
+			s.log.Info("server gracefully shutdown")
 			s.server.Shutdown()
 		} else {
 			s.server.Stop()
@@ -640,12 +649,12 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	if s.keepaliveServer != nil {
 		if err := s.keepaliveServer.Stop(ctx); err != nil {
-			LogError("keepalive server stop failed", s.err)
+			s.log.Error("keepalive server stop failed", s.err)
 		}
 		s.keepaliveServer = nil
 	}
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return nil
 }
@@ -658,7 +667,7 @@ func (s *Server) createAsynqServer() error {
 
 	s.server = asynq.NewServer(s.redisConnOpt, s.asynqConfig)
 	if s.server == nil {
-		LogErrorf("create asynq server failed")
+		s.log.Errorf("create asynq server failed")
 		return errors.New("create asynq server failed")
 	}
 	return nil
@@ -667,18 +676,18 @@ func (s *Server) createAsynqServer() error {
 // runAsynqServer run asynq server
 func (s *Server) runAsynqServer() error {
 	if s.server == nil {
-		LogErrorf("asynq server is nil")
+		s.log.Errorf("asynq server is nil")
 		return errors.New("asynq server is nil")
 	}
 
 	go func() {
 		if s.err = s.server.Run(s.mux); s.err != nil {
-			LogErrorf("asynq server run failed: %s", s.err.Error())
+			s.log.Errorf("asynq server run failed: %s", s.err.Error())
 			return
 		}
 	}()
 
-	LogInfo("asynq server started")
+	s.log.Info("asynq server started")
 
 	return nil
 }
@@ -691,7 +700,7 @@ func (s *Server) createAsynqClient() error {
 
 	s.client = asynq.NewClient(s.redisConnOpt)
 	if s.client == nil {
-		LogErrorf("create asynq client failed")
+		s.log.Errorf("create asynq client failed")
 		return errors.New("create asynq client failed")
 	}
 
@@ -706,7 +715,7 @@ func (s *Server) createAsynqScheduler() error {
 
 	s.scheduler = asynq.NewScheduler(s.redisConnOpt, s.schedulerOpts)
 	if s.scheduler == nil {
-		LogErrorf("create asynq scheduler failed")
+		s.log.Errorf("create asynq scheduler failed")
 		return errors.New("create asynq scheduler failed")
 	}
 
@@ -716,12 +725,12 @@ func (s *Server) createAsynqScheduler() error {
 // runAsynqScheduler run asynq scheduler
 func (s *Server) runAsynqScheduler() error {
 	if s.scheduler == nil {
-		LogErrorf("asynq scheduler is nil")
+		s.log.Errorf("asynq scheduler is nil")
 		return errors.New("asynq scheduler is nil")
 	}
 
 	if err := s.scheduler.Start(); err != nil {
-		LogErrorf("asynq scheduler start failed: %s", err.Error())
+		s.log.Errorf("asynq scheduler start failed: %s", err.Error())
 		return err
 	}
 
@@ -736,7 +745,7 @@ func (s *Server) createAsynqInspector() error {
 
 	s.inspector = asynq.NewInspector(s.redisConnOpt)
 	if s.inspector == nil {
-		LogErrorf("create asynq inspector failed")
+		s.log.Errorf("create asynq inspector failed")
 		return errors.New("create asynq inspector failed")
 	}
 	return nil
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	kHttp "github.com/go-kratos/kratos/v2/transport/http"
 )
@@ -62,3 +63,10 @@ func WithStrictSlash(strictSlash bool) ServerOption {
 	return func(o *Server) {
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
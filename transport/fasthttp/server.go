@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/fasthttp/router"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/valyala/fasthttp"
 
 	"github.com/go-kratos/kratos/v2/errors"
@@ -45,6 +46,8 @@ type Server struct {
 
 	strictSlash bool
 	router      *router.Router
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -56,6 +59,8 @@ func NewServer(opts ...ServerOption) *Server {
 		ene:         kHttp.DefaultErrorEncoder,
 		strictSlash: true,
 		router:      router.New(),
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -111,7 +116,7 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	LogInfof("server listening on: %s", s.address)
+	s.log.Infof("server listening on: %s", s.address)
 
 	var err error
 	if s.tlsConf != nil {
@@ -127,12 +132,12 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Server.Shutdown()
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
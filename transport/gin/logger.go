@@ -3,33 +3,45 @@ package gin
 import (
 	"fmt"
 
+	"github.com/gin-gonic/gin"
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/transport"
 )
 
 const (
 	logKey = "[" + KindGin + "]"
 )
 
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return transport.NewLoggerHelper(logger, "transport", KindGin)
+}
+
 ///
 /// logger
 ///
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogDebug(args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogInfo(args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogWarn(args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogError(args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogFatal(args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprint(args...))
 }
@@ -38,26 +50,38 @@ func LogFatal(args ...interface{}) {
 /// logger
 ///
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogDebugf(format string, args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogInfof(format string, args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogWarnf(format string, args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogErrorf(format string, args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogFatalf(format string, args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// SetGinDefaultWriter 将 gin 的全局输出（gin.DefaultWriter、gin.DefaultErrorWriter）重定向到指定的日志器。
+// 这两个变量是进程级的，会影响进程内所有 gin 实例，因此需要显式调用。
+func SetGinDefaultWriter(l log.Logger) {
+	gin.DefaultWriter = &infoLogger{Logger: l}
+	gin.DefaultErrorWriter = &errLogger{Logger: l}
+}
+
 type infoLogger struct {
 	Logger log.Logger
 }
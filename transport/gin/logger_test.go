@@ -0,0 +1,46 @@
+package gin
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	sync.Mutex
+	records [][]interface{}
+}
+
+func (l *recordLogger) Log(_ log.Level, keyvals ...interface{}) error {
+	l.Lock()
+	defer l.Unlock()
+	l.records = append(l.records, keyvals)
+	return nil
+}
+
+func TestWithLoggerKeepsGinDefaultWriter(t *testing.T) {
+	writer, errWriter := gin.DefaultWriter, gin.DefaultErrorWriter
+
+	_ = NewServer(WithLogger(&recordLogger{}))
+
+	assert.Equal(t, writer, gin.DefaultWriter)
+	assert.Equal(t, errWriter, gin.DefaultErrorWriter)
+}
+
+func TestSetGinDefaultWriter(t *testing.T) {
+	writer, errWriter := gin.DefaultWriter, gin.DefaultErrorWriter
+	defer func() {
+		gin.DefaultWriter, gin.DefaultErrorWriter = writer, errWriter
+	}()
+
+	l := &recordLogger{}
+	SetGinDefaultWriter(l)
+
+	_, _ = gin.DefaultWriter.Write([]byte("info"))
+	_, _ = gin.DefaultErrorWriter.Write([]byte("error"))
+
+	assert.Len(t, l.records, 2)
+}
@@ -4,8 +4,6 @@ import (
 	"crypto/tls"
 	"time"
 
-	"github.com/gin-gonic/gin"
-
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	kHttp "github.com/go-kratos/kratos/v2/transport/http"
@@ -72,11 +70,10 @@ func WithStrictSlash(strictSlash bool) ServerOption {
 	}
 }
 
-// WithLogger inject info logger
+// WithLogger inject logger, used by the server itself and the access log / recovery middlewares
 func WithLogger(l log.Logger) ServerOption {
 	return func(s *Server) {
-		gin.DefaultWriter = &infoLogger{Logger: l}
-		gin.DefaultErrorWriter = &errLogger{Logger: l}
+		s.log = newLoggerHelper(l)
 		s.Engine.Use(GinLogger(l), GinRecovery(l, true))
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/middleware"
@@ -42,6 +43,8 @@ type Server struct {
 	dec     kHttp.DecodeRequestFunc
 	enc     kHttp.EncodeResponseFunc
 	ene     kHttp.EncodeErrorFunc
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -51,6 +54,8 @@ func NewServer(opts ...ServerOption) *Server {
 		dec:     kHttp.DefaultRequestDecoder,
 		enc:     kHttp.DefaultResponseEncoder,
 		ene:     kHttp.DefaultErrorEncoder,
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -107,7 +112,7 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	LogInfof("server listening on: %s", s.address)
+	s.log.Infof("server listening on: %s", s.address)
 
 	var err error
 	if s.tlsConf != nil {
@@ -123,12 +128,12 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.server.Shutdown(ctx)
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
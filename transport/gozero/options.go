@@ -4,6 +4,8 @@ import (
 	"net"
 	"strconv"
 	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
 )
 
 type ServerOption func(*Server)
@@ -40,3 +42,10 @@ func WithMaxConnections(cnt int) ServerOption {
 		s.cfg.MaxConns = cnt
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
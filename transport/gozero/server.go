@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/zeromicro/go-zero/core/logx"
@@ -28,10 +29,14 @@ type Server struct {
 	err error
 
 	endpoint *url.URL
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
-	srv := &Server{}
+	srv := &Server{
+		log: newLoggerHelper(nil),
+	}
 
 	srv.init(opts...)
 
@@ -75,7 +80,7 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	LogInfof("server listening on: %d", s.cfg.Port)
+	s.log.Infof("server listening on: %d", s.cfg.Port)
 
 	s.Server.Start()
 
@@ -83,12 +88,12 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	s.Server.Stop()
 	s.err = nil
 
-	LogInfo("server stopped")
+	s.log.Info("server stopped")
 
 	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"crypto/tls"
 	"net"
 	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
 )
 
 type ServerOption func(o *Server)
@@ -43,3 +45,10 @@ func WithStrictSlash(strictSlash bool) ServerOption {
 		o.strictSlash = strictSlash
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
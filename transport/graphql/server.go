@@ -10,6 +10,7 @@ import (
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/gorilla/mux"
 
@@ -41,6 +42,8 @@ type Server struct {
 	timeout     time.Duration
 
 	err error
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -49,6 +52,8 @@ func NewServer(opts ...ServerOption) *Server {
 		address:     ":0",
 		timeout:     1 * time.Second,
 		strictSlash: true,
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -122,7 +127,7 @@ func (s *Server) Start(ctx context.Context) error {
 	s.BaseContext = func(net.Listener) context.Context {
 		return ctx
 	}
-	LogInfof("server listening on: %s", s.lis.Addr().String())
+	s.log.Infof("server listening on: %s", s.lis.Addr().String())
 	var err error
 	if s.tlsConf != nil {
 		err = s.ServeTLS(s.lis, "", "")
@@ -136,12 +141,12 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Shutdown(ctx)
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	hertz "github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/go-kratos/kratos/v2/middleware"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
@@ -37,6 +38,8 @@ type Server struct {
 	dec     kHttp.DecodeRequestFunc
 	enc     kHttp.EncodeResponseFunc
 	ene     kHttp.EncodeErrorFunc
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -45,6 +48,8 @@ func NewServer(opts ...ServerOption) *Server {
 		dec:     kHttp.DefaultRequestDecoder,
 		enc:     kHttp.DefaultResponseEncoder,
 		ene:     kHttp.DefaultErrorEncoder,
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -91,18 +96,18 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	LogInfof("server listening on: %s", s.addr)
+	s.log.Infof("server listening on: %s", s.addr)
 
 	return s.Hertz.Run()
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Hertz.Shutdown(ctx)
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -14,6 +14,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 	kHttp "github.com/go-kratos/kratos/v2/transport/http"
@@ -46,6 +47,8 @@ type Server struct {
 
 	router      *mux.Router
 	strictSlash bool
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -55,6 +58,8 @@ func NewServer(opts ...ServerOption) *Server {
 		enc:         kHttp.DefaultResponseEncoder,
 		ene:         kHttp.DefaultErrorEncoder,
 		strictSlash: true,
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -114,10 +119,10 @@ func (s *Server) Start(_ context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.Addr)
+	s.log.Infof("server listening on: %s", s.Addr)
 
 	if err := s.ListenAndServe(); err != nil {
-		LogErrorf("start server failed: %s", err.Error())
+		s.log.Errorf("start server failed: %s", err.Error())
 		return err
 	}
 
@@ -125,12 +130,12 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Close()
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
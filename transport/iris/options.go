@@ -2,6 +2,8 @@ package iris
 
 import (
 	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
 )
 
 type ServerOption func(*Server)
@@ -24,3 +26,10 @@ func WithTimeout(timeout time.Duration) ServerOption {
 		s.timeout = timeout
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
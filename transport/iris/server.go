@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/kataras/iris/v12"
 
 	"github.com/go-kratos/kratos/v2/errors"
@@ -31,12 +32,16 @@ type Server struct {
 	certFile, keyFile string
 
 	err error
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{
 		timeout:     1 * time.Second,
 		Application: iris.New(),
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -85,7 +90,7 @@ func (s *Server) Start(_ context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.addr)
+	s.log.Infof("server listening on: %s", s.addr)
 
 	var err error
 	if len(s.certFile) != 0 && len(s.keyFile) != 0 {
@@ -101,12 +106,12 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Application.Shutdown(ctx)
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	_ "github.com/go-kratos/kratos/v2/encoding/yaml"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/conf"
+)
+
+func newTestConfig(t *testing.T, content string) config.Config {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+
+	c := config.New(config.WithSource(file.NewSource(path)))
+	assert.Nil(t, c.Load())
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+type fakeSubscriber struct {
+	topic   string
+	options broker.SubscribeOptions
+	handler broker.Handler
+	closed  bool
+}
+
+func (s *fakeSubscriber) Options() broker.SubscribeOptions { return s.options }
+func (s *fakeSubscriber) Topic() string                    { return s.topic }
+func (s *fakeSubscriber) Unsubscribe(bool) error {
+	s.closed = true
+	return nil
+}
+
+// fakeBroker 替换服务内嵌的 kafka broker，记录订阅
+type fakeBroker struct {
+	broker.Broker
+	subs map[string]*fakeSubscriber
+}
+
+func (b *fakeBroker) Subscribe(topic string, handler broker.Handler, _ broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	sub := &fakeSubscriber{topic: topic, options: broker.NewSubscribeOptions(opts...), handler: handler}
+	b.subs[topic] = sub
+	return sub, nil
+}
+
+type ctxKey struct{}
+
+func TestNewServerFromConfig(t *testing.T) {
+	c := newTestConfig(t, `
+data:
+  broker:
+    driver: kafka
+    addrs: [ "127.0.0.1:9092" ]
+    codec: json
+    subscriptions:
+      - topic: test.topic
+        handler: test
+        queue: group
+`)
+
+	var calls []string
+	routes := map[string]conf.Route{
+		"test": {Handler: func(context.Context, broker.Event) error {
+			calls = append(calls, "handler")
+			return nil
+		}},
+	}
+
+	srv, err := NewServerFromConfig(c, "data.broker", routes, WithMiddleware(func(next broker.Handler) broker.Handler {
+		return func(ctx context.Context, evt broker.Event) error {
+			calls = append(calls, "middleware")
+			return next(ctx, evt)
+		}
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9092"}, srv.Options().Addrs)
+	assert.Equal(t, "json", srv.Options().Codec.Name())
+
+	fb := &fakeBroker{Broker: srv.Broker, subs: make(map[string]*fakeSubscriber)}
+	srv.Broker = fb
+	srv.baseCtx = context.WithValue(context.Background(), ctxKey{}, "server")
+
+	assert.Nil(t, srv.subscriptionManager.Start())
+
+	// 配置中的订阅与 RegisterSubscriber 一样经过中间件、带有服务的上下文，并记录在 subscribers 中
+	sub, ok := fb.subs["test.topic"]
+	assert.True(t, ok)
+	assert.Equal(t, "group", sub.options.Queue)
+	assert.Equal(t, "server", sub.options.Context.Value(ctxKey{}))
+	assert.Nil(t, sub.handler(context.Background(), nil))
+	assert.Equal(t, []string{"middleware", "handler"}, calls)
+	assert.Equal(t, sub, srv.subscribers["test.topic"])
+
+	assert.Nil(t, srv.subscriptionManager.Stop())
+	assert.True(t, sub.closed)
+	assert.Empty(t, srv.subscribers)
+
+	_, err = NewServerFromConfig(c, "data.broker", nil)
+	assert.NotNil(t, err, "handler not registered")
+}
+
+func TestNewServerFromConfigDriverMismatch(t *testing.T) {
+	c := newTestConfig(t, `
+data:
+  broker:
+    driver: amqp
+    addrs: [ "127.0.0.1:9092" ]
+`)
+
+	_, err := NewServerFromConfig(c, "data.broker", nil)
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"crypto/tls"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 
@@ -93,3 +94,11 @@ func WithMiddleware(m ...broker.MiddlewareFunc) ServerOption {
 		s.mws = append(s.mws, m...)
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+		s.brokerOpts = append(s.brokerOpts, broker.WithLogger(logger))
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/conf"
 	"github.com/tx7do/kratos-transport/broker/kafka"
 
 	"github.com/tx7do/kratos-transport/transport"
@@ -38,6 +40,10 @@ type Server struct {
 	mws []broker.MiddlewareFunc
 
 	keepaliveServer *keepalive.Server
+
+	subscriptionManager *conf.SubscriptionManager
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -47,6 +53,8 @@ func NewServer(opts ...ServerOption) *Server {
 		subscriberOpts: make(transport.SubscribeOptionMap),
 		brokerOpts:     []broker.Option{},
 		started:        atomic.Bool{},
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -83,13 +91,13 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.keepaliveServer != nil {
 		go func() {
 			if s.err = s.keepaliveServer.Start(ctx); s.err != nil {
-				LogErrorf("keepalive server start failed: %s", s.err.Error())
+				s.log.Errorf("keepalive server start failed: %s", s.err.Error())
 			}
 		}()
 	}
 
 	if s.err = s.Init(); s.err != nil {
-		LogErrorf("init broker failed: [%s]", s.err.Error())
+		s.log.Errorf("init broker failed: [%s]", s.err.Error())
 		return s.err
 	}
 
@@ -97,13 +105,19 @@ func (s *Server) Start(ctx context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.Address())
+	s.log.Infof("server listening on: %s", s.Address())
 
 	if s.err = s.doRegisterSubscriberMap(); s.err != nil {
 		return s.err
 	}
 
 	s.baseCtx = ctx
+
+	if s.subscriptionManager != nil {
+		if s.err = s.subscriptionManager.Start(); s.err != nil {
+			return s.err
+		}
+	}
 	s.started.Store(true)
 
 	return nil
@@ -114,7 +128,13 @@ func (s *Server) Stop(ctx context.Context) error {
 		return nil
 	}
 
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
+
+	if s.subscriptionManager != nil {
+		if err := s.subscriptionManager.Stop(); err != nil {
+			s.log.Errorf("unsubscribe config subscriptions failed: %s", err.Error())
+		}
+	}
 
 	for _, v := range s.subscribers {
 		_ = v.Unsubscribe(false)
@@ -128,12 +148,12 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	if s.keepaliveServer != nil {
 		if err := s.keepaliveServer.Stop(ctx); err != nil {
-			LogError("keepalive server stop failed", s.err)
+			s.log.Error("keepalive server stop failed", s.err)
 		}
 		s.keepaliveServer = nil
 	}
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -0,0 +1,15 @@
+package transport
+
+import (
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/broker"
+)
+
+// NewLoggerHelper 创建带有结构化字段的日志助手，logger 为空时写入 kratos 的全局日志器。
+//
+// 各传输包中的 LogDebug、LogInfof 等包级日志函数已废弃：它们总是写入全局日志器，
+// 请通过各服务的 WithLogger 选项注入日志器，服务内部使用由本函数创建的实例日志器。
+func NewLoggerHelper(logger log.Logger, kv ...interface{}) *log.Helper {
+	return broker.NewLoggerHelper(logger, kv...)
+}
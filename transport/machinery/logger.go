@@ -5,32 +5,43 @@ import (
 
 	"github.com/RichardKnop/logging"
 	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/tx7do/kratos-transport/transport"
 )
 
 const (
 	logKey = "[" + KindMachinery + "]"
 )
 
+func newLoggerHelper(logger log.Logger) *log.Helper {
+	return transport.NewLoggerHelper(logger, "transport", KindMachinery)
+}
+
 ///
 /// logger
 ///
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogDebug(args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogInfo(args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogWarn(args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogError(args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprint(args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogFatal(args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprint(args...))
 }
@@ -39,22 +50,27 @@ func LogFatal(args ...interface{}) {
 /// logger
 ///
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogDebugf(format string, args ...interface{}) {
 	log.Debugf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogInfof(format string, args ...interface{}) {
 	log.Infof("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogWarnf(format string, args ...interface{}) {
 	log.Warnf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogErrorf(format string, args ...interface{}) {
 	log.Errorf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
 
+// Deprecated: 见 transport.NewLoggerHelper。
 func LogFatalf(format string, args ...interface{}) {
 	log.Fatalf("%s %s", logKey, fmt.Sprintf(format, args...))
 }
@@ -65,40 +81,42 @@ func LogFatalf(format string, args ...interface{}) {
 
 type logger struct {
 	level log.Level
+	log   *log.Helper
 }
 
-func newLogger(level log.Level) logging.LoggerInterface {
+func newLogger(helper *log.Helper, level log.Level) logging.LoggerInterface {
 	return &logger{
 		level: level,
+		log:   helper,
 	}
 }
 
 func (l *logger) Print(args ...interface{}) {
-	LogInfo(args...)
+	l.log.Log(l.level, log.DefaultMessageKey, fmt.Sprint(args...))
 }
 func (l *logger) Printf(format string, args ...interface{}) {
-	LogInfof(format, args...)
+	l.log.Log(l.level, log.DefaultMessageKey, fmt.Sprintf(format, args...))
 }
 func (l *logger) Println(args ...interface{}) {
-	LogInfo(args...)
+	l.log.Log(l.level, log.DefaultMessageKey, fmt.Sprint(args...))
 }
 
 func (l *logger) Fatal(args ...interface{}) {
-	LogFatal(args...)
+	l.log.Fatal(args...)
 }
 func (l *logger) Fatalf(format string, args ...interface{}) {
-	LogFatalf(format, args...)
+	l.log.Fatalf(format, args...)
 }
 func (l *logger) Fatalln(args ...interface{}) {
-	LogFatal(args...)
+	l.log.Fatal(args...)
 }
 
 func (l *logger) Panic(args ...interface{}) {
-	LogError(args...)
+	l.log.Error(args...)
 }
 func (l *logger) Panicf(format string, args ...interface{}) {
-	LogErrorf(format, args...)
+	l.log.Errorf(format, args...)
 }
 func (l *logger) Panicln(args ...interface{}) {
-	LogError(args...)
+	l.log.Error(args...)
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/RichardKnop/machinery/v2/config"
 	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/go-kratos/kratos/v2/log"
 )
 
 type BrokerType int
@@ -67,7 +68,7 @@ func WithYamlConfig(cnfPath string, keepReloading bool) ServerOption {
 	return func(s *Server) {
 		cnf, err := config.NewFromYaml(cnfPath, keepReloading)
 		if err != nil {
-			LogErrorf("load yaml config [%s] failed: %s", cnfPath, err.Error())
+			s.log.Errorf("load yaml config [%s] failed: %s", cnfPath, err.Error())
 		}
 		s.cfg = cnf
 	}
@@ -78,7 +79,7 @@ func WithEnvironmentConfig() ServerOption {
 	return func(s *Server) {
 		cnf, err := config.NewFromEnvironment()
 		if err != nil {
-			LogErrorf("load environment config failed: %s", err.Error())
+			s.log.Errorf("load environment config failed: %s", err.Error())
 		}
 		s.cfg = cnf
 	}
@@ -248,3 +249,10 @@ func WithTask(typeName string, opts ...TaskOption) TasksOption {
 		*s = append(*s, signature)
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
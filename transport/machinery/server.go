@@ -67,6 +67,8 @@ type Server struct {
 	consumerTracer *tracing.Tracer
 
 	keepaliveServer *keepalive.Server
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -111,6 +113,8 @@ func NewServer(opts ...ServerOption) *Server {
 			db:       0,
 			retries:  1,
 		},
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -197,7 +201,7 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.keepaliveServer != nil {
 		go func() {
 			if s.err = s.keepaliveServer.Start(ctx); s.err != nil {
-				LogErrorf("keepalive server start failed: %s", s.err.Error())
+				s.log.Errorf("keepalive server start failed: %s", s.err.Error())
 			}
 		}()
 	}
@@ -210,7 +214,7 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 
-	LogInfof("server started")
+	s.log.Infof("server started")
 
 	s.baseCtx = ctx
 	s.started.Store(true)
@@ -219,7 +223,7 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	s.started.Store(false)
 
@@ -228,23 +232,28 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	if s.keepaliveServer != nil {
 		if err := s.keepaliveServer.Stop(ctx); err != nil {
-			LogError("keepalive server stop failed", s.err)
+			s.log.Error("keepalive server stop failed", s.err)
 		}
 		s.keepaliveServer = nil
 	}
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return nil
 }
 
+var installLoggerOnce sync.Once
+
 // installLogger 安装日志记录器
+// machinery 的日志器是进程级的全局变量，只由第一个启动的 Server 安装，避免多个实例互相覆盖。
 func (s *Server) installLogger() {
-	machineryLog.SetDebug(newLogger(log.LevelDebug))
-	machineryLog.SetInfo(newLogger(log.LevelInfo))
-	machineryLog.SetWarning(newLogger(log.LevelWarn))
-	machineryLog.SetError(newLogger(log.LevelError))
-	machineryLog.SetFatal(newLogger(log.LevelFatal))
+	installLoggerOnce.Do(func() {
+		machineryLog.SetDebug(newLogger(s.log, log.LevelDebug))
+		machineryLog.SetInfo(newLogger(s.log, log.LevelInfo))
+		machineryLog.SetWarning(newLogger(s.log, log.LevelWarn))
+		machineryLog.SetError(newLogger(s.log, log.LevelError))
+		machineryLog.SetFatal(newLogger(s.log, log.LevelFatal))
+	})
 }
 
 func (s *Server) createMachineryServer() {
@@ -264,7 +273,7 @@ func (s *Server) createMachineryServer() {
 			break
 		case BrokerTypeGcpPubSub:
 			if broker, err = gcppubsubBroker.New(s.cfg, s.brokerOption.projectID, s.brokerOption.subscriptionName); err != nil {
-				LogError("create GCP PubSub broker error:", err)
+				s.log.Error("create GCP PubSub broker error:", err)
 			}
 			break
 		case BrokerTypeSQS:
@@ -286,7 +295,7 @@ func (s *Server) createMachineryServer() {
 			break
 		case BackendTypeMongoDB:
 			if backend, err = mongoBackend.New(s.cfg); err != nil {
-				LogError("create mongo backend error:", err)
+				s.log.Error("create mongo backend error:", err)
 			}
 			break
 		case BackendTypeDynamoDB:
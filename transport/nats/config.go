@@ -0,0 +1,47 @@
+package nats
+
+import (
+	"github.com/go-kratos/kratos/v2/config"
+
+	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/conf"
+	"github.com/tx7do/kratos-transport/broker/nats"
+)
+
+// NewServerFromConfig 从 kratos 配置中读取 key 对应的 broker 配置并创建服务，opts 在配置之后应用。
+// driver 必须是 nats。routes 为订阅处理器，配置中的订阅列表在服务启动时订阅，并随配置变化在线增删。
+func NewServerFromConfig(c config.Config, key string, routes map[string]conf.Route, opts ...ServerOption) (*Server, error) {
+	bc, brokerOpts, err := conf.LoadOptions(c, key, nats.URLOptions, "nats")
+	if err != nil {
+		return nil, err
+	}
+
+	srv := NewServer(append([]ServerOption{WithBrokerOptions(brokerOpts...)}, opts...)...)
+	if srv.subscriptionManager, err = conf.NewServerSubscriptions(c, key, bc, routes, srv.subscribeFromConfig, srv.Options().Logger); err != nil {
+		return nil, err
+	}
+
+	return srv, nil
+}
+
+// subscribeFromConfig 配置中的订阅与 RegisterSubscriber 一样注入订阅上下文，订阅者记录在 subscribers 中
+func (s *Server) subscribeFromConfig(topic string, handler broker.Handler, binder broker.Binder, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	opts = append([]broker.SubscribeOption{broker.WithSubscribeContext(s.baseCtx)}, opts...)
+
+	if err := s.doRegisterSubscriber(topic, handler, binder, opts...); err != nil {
+		return nil, err
+	}
+
+	sub := s.subscribers[topic]
+	return conf.TrackSubscriber(sub, func() {
+		s.Lock()
+		defer s.Unlock()
+
+		if s.subscribers[topic] == sub {
+			delete(s.subscribers, topic)
+		}
+	}), nil
+}
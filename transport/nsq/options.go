@@ -3,6 +3,7 @@ package nsq
 import (
 	"crypto/tls"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/tx7do/kratos-transport/broker"
 	"github.com/tx7do/kratos-transport/broker/nsq"
 )
@@ -48,3 +49,11 @@ func WithConsumerOptions(opts []string) ServerOption {
 		s.brokerOpts = append(s.brokerOpts, nsq.WithConsumerOptions(opts))
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+		s.brokerOpts = append(s.brokerOpts, broker.WithLogger(logger))
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/tx7do/kratos-transport/broker"
+	"github.com/tx7do/kratos-transport/broker/conf"
 	"github.com/tx7do/kratos-transport/broker/nsq"
 
 	"github.com/tx7do/kratos-transport/transport"
@@ -36,6 +38,10 @@ type Server struct {
 	err     error
 
 	keepaliveServer *keepalive.Server
+
+	subscriptionManager *conf.SubscriptionManager
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -45,6 +51,8 @@ func NewServer(opts ...ServerOption) *Server {
 		subscriberOpts: make(transport.SubscribeOptionMap),
 		brokerOpts:     []broker.Option{},
 		started:        atomic.Bool{},
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -81,13 +89,13 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.keepaliveServer != nil {
 		go func() {
 			if s.err = s.keepaliveServer.Start(ctx); s.err != nil {
-				LogErrorf("keepalive server start failed: %s", s.err.Error())
+				s.log.Errorf("keepalive server start failed: %s", s.err.Error())
 			}
 		}()
 	}
 
 	if s.err = s.Init(); s.err != nil {
-		LogErrorf("init broker failed: [%s]", s.err.Error())
+		s.log.Errorf("init broker failed: [%s]", s.err.Error())
 		return s.err
 	}
 
@@ -95,20 +103,32 @@ func (s *Server) Start(ctx context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.Address())
+	s.log.Infof("server listening on: %s", s.Address())
 
 	if s.err = s.doRegisterSubscriberMap(); s.err != nil {
 		return s.err
 	}
 
 	s.baseCtx = ctx
+
+	if s.subscriptionManager != nil {
+		if s.err = s.subscriptionManager.Start(); s.err != nil {
+			return s.err
+		}
+	}
 	s.started.Store(true)
 
 	return nil
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
+
+	if s.subscriptionManager != nil {
+		if err := s.subscriptionManager.Stop(); err != nil {
+			s.log.Errorf("unsubscribe config subscriptions failed: %s", err.Error())
+		}
+	}
 
 	s.started.Store(false)
 	err := s.Disconnect()
@@ -116,12 +136,12 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	if s.keepaliveServer != nil {
 		if err := s.keepaliveServer.Stop(ctx); err != nil {
-			LogError("keepalive server stop failed", s.err)
+			s.log.Error("keepalive server stop failed", s.err)
 		}
 		s.keepaliveServer = nil
 	}
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -3,6 +3,7 @@ package rocketmq
 import (
 	"crypto/tls"
 
+	"github.com/go-kratos/kratos/v2/log"
 	rocketmqOption "github.com/tx7do/kratos-transport/broker/rocketmq/option"
 
 	"go.opentelemetry.io/otel/propagation"
@@ -106,3 +107,11 @@ func WithPropagator(propagators propagation.TextMapPropagator) ServerOption {
 		s.brokerOpts = append(s.brokerOpts, broker.WithPropagator(propagators))
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+		s.brokerOpts = append(s.brokerOpts, broker.WithLogger(logger))
+	}
+}
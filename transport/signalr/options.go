@@ -2,6 +2,7 @@ package signalr
 
 import (
 	"crypto/tls"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/philippseith/signalr"
 	"time"
 
@@ -65,3 +66,10 @@ func WithHub(hub signalr.HubInterface) ServerOption {
 }
 
 ////////////////////////////////////////////////////////////////////////////////
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/philippseith/signalr"
@@ -45,6 +46,8 @@ type Server struct {
 	hub signalr.HubInterface
 
 	router *http.ServeMux
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -56,6 +59,8 @@ func NewServer(opts ...ServerOption) *Server {
 		chanReceiveTimeout:   200 * time.Millisecond,
 		streamBufferCapacity: 5,
 		debug:                false,
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -76,7 +81,7 @@ func (s *Server) Start(_ context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.lis.Addr().String())
+	s.log.Infof("server listening on: %s", s.lis.Addr().String())
 
 	//handler := handlers.CORS(
 	//	handlers.AllowedMethods([]string{"GET", "POST", "OPTIONS"}),
@@ -102,12 +107,12 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.lis.Close()
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	socketIo "github.com/googollee/go-socket.io"
 )
 
@@ -64,3 +65,10 @@ func WithEventHandler(namespace, event string, f interface{}) ServerOption {
 }
 
 ////////////////////////////////////////////////////////////////////////////////
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
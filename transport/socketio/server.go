@@ -9,6 +9,7 @@ import (
 	"net/url"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	socketIo "github.com/googollee/go-socket.io"
@@ -43,6 +44,8 @@ type Server struct {
 	codec encoding.Codec
 
 	router *mux.Router
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -51,6 +54,8 @@ func NewServer(opts ...ServerOption) *Server {
 		address: ":0",
 		router:  mux.NewRouter(),
 		path:    "/socket.io/",
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -71,11 +76,11 @@ func (s *Server) Start(_ context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.address)
+	s.log.Infof("server listening on: %s", s.address)
 
 	go func() {
 		if err := s.Server.Serve(); err != nil {
-			LogFatalf("socketio listen error: %s\n", err)
+			s.log.Fatalf("socketio listen error: %s\n", err)
 		}
 	}()
 
@@ -94,13 +99,13 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	//_ = s.lis.Close()
 	err := s.Server.Close()
 	s.err = nil
 
-	LogInfo("server stopped")
+	s.log.Info("server stopped")
 
 	return err
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-kratos/kratos/v2/encoding"
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/gorilla/mux"
@@ -59,6 +60,8 @@ type Server struct {
 	unsubscribeFunc SubscriberFunction
 
 	streamMgr *StreamManager
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -79,6 +82,8 @@ func NewServer(opts ...ServerOption) *Server {
 		headers:    map[string]string{},
 
 		streamMgr: NewStreamManager(),
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -105,7 +110,7 @@ func (s *Server) Start(ctx context.Context) error {
 		return ctx
 	}
 
-	LogInfof("server listening on: %s", s.lis.Addr().String())
+	s.log.Infof("server listening on: %s", s.lis.Addr().String())
 
 	s.HandleServeHTTP(s.path)
 
@@ -123,14 +128,14 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	s.streamMgr.Clean()
 
 	err := s.Shutdown(ctx)
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/tx7do/kratos-transport/broker"
 )
 
@@ -31,6 +32,8 @@ type Client struct {
 	rawMessageHandler ClientRawMessageHandler
 
 	timeout time.Duration
+
+	log *log.Helper
 }
 
 func NewClient(opts ...ClientOption) *Client {
@@ -39,6 +42,8 @@ func NewClient(opts ...ClientOption) *Client {
 		timeout:         1 * time.Second,
 		codec:           encoding.GetCodec("json"),
 		messageHandlers: make(ClientMessageHandlerMap),
+
+		log: newLoggerHelper(nil),
 	}
 
 	cli.init(opts...)
@@ -67,11 +72,11 @@ func (c *Client) Connect() error {
 		return errors.New("endpoint is nil")
 	}
 
-	LogInfof("connecting to %s", c.endpoint.String())
+	c.log.Infof("connecting to %s", c.endpoint.String())
 
 	conn, err := net.Dial("tcp", c.endpoint.String())
 	if err != nil {
-		LogErrorf("cant connect to server: %s", err.Error())
+		c.log.Errorf("cant connect to server: %s", err.Error())
 		return err
 	}
 
@@ -85,7 +90,7 @@ func (c *Client) Connect() error {
 func (c *Client) Disconnect() {
 	if c.conn != nil {
 		if err := c.conn.Close(); err != nil {
-			LogErrorf("disconnect error: %s", err.Error())
+			c.log.Errorf("disconnect error: %s", err.Error())
 		}
 		c.conn = nil
 	}
@@ -106,7 +111,7 @@ func RegisterClientMessageHandler[T any](cli *Client, messageType NetMessageType
 			case *T:
 				return handler(t)
 			default:
-				LogError("invalid payload struct type:", t)
+				cli.log.Error("invalid payload struct type:", t)
 				return errors.New("invalid payload struct type")
 			}
 		},
@@ -153,20 +158,20 @@ func (c *Client) run() {
 
 	for {
 		if readLen, err = c.conn.Read(buf); err != nil {
-			LogErrorf("read message error: %v", err)
+			c.log.Errorf("read message error: %v", err)
 			return
 		}
 
 		if c.rawMessageHandler != nil {
 			if err := c.rawMessageHandler(buf[:readLen]); err != nil {
-				LogErrorf("raw data handler exception: %s", err)
+				c.log.Errorf("raw data handler exception: %s", err)
 				continue
 			}
 			continue
 		}
 
 		if err = c.messageHandler(buf[:readLen]); err != nil {
-			LogErrorf("process message error: %v", err)
+			c.log.Errorf("process message error: %v", err)
 		}
 	}
 }
@@ -174,13 +179,13 @@ func (c *Client) run() {
 func (c *Client) messageHandler(buf []byte) error {
 	var msg NetPacket
 	if err := msg.Unmarshal(buf); err != nil {
-		LogErrorf("decode message exception: %s", err)
+		c.log.Errorf("decode message exception: %s", err)
 		return err
 	}
 
 	handlerData, ok := c.messageHandlers[msg.Type]
 	if !ok {
-		LogError("message type not found:", msg.Type)
+		c.log.Error("message type not found:", msg.Type)
 		return errors.New("message handler not found")
 	}
 
@@ -190,7 +195,7 @@ func (c *Client) messageHandler(buf []byte) error {
 		payload = handlerData.Creator()
 
 		if err := broker.Unmarshal(c.codec, msg.Payload, &payload); err != nil {
-			LogErrorf("unmarshal message exception: %s", err)
+			c.log.Errorf("unmarshal message exception: %s", err)
 			return err
 		}
 	} else {
@@ -198,7 +203,7 @@ func (c *Client) messageHandler(buf []byte) error {
 	}
 
 	if err := handlerData.Handler(payload); err != nil {
-		LogErrorf("message handler exception: %s", err)
+		c.log.Errorf("message handler exception: %s", err)
 		return err
 	}
 
@@ -1,6 +1,9 @@
 package tcp
 
-import "github.com/go-kratos/kratos/v2/encoding"
+import (
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
+)
 
 type ClientOption func(o *Client)
 
@@ -21,3 +24,10 @@ func WithClientRawDataHandler(h ClientRawMessageHandler) ClientOption {
 		c.rawMessageHandler = h
 	}
 }
+
+// WithClientLogger 日志器，默认使用 kratos 的全局日志器
+func WithClientLogger(logger log.Logger) ClientOption {
+	return func(c *Client) {
+		c.log = newLoggerHelper(logger)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/tx7do/kratos-transport/broker"
@@ -45,6 +46,8 @@ type Server struct {
 	sessions   SessionMap
 	register   chan *Session
 	unregister chan *Session
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -59,6 +62,8 @@ func NewServer(opts ...ServerOption) *Server {
 
 		register:   make(chan *Session),
 		unregister: make(chan *Session),
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -145,7 +150,7 @@ func RegisterServerMessageHandler[T any](srv *Server, messageType NetMessageType
 			case *T:
 				return handler(sessionId, t)
 			default:
-				LogError("invalid payload struct type:", t)
+				srv.log.Error("invalid payload struct type:", t)
 				return errors.New("invalid payload struct type")
 			}
 		},
@@ -165,7 +170,7 @@ func (s *Server) GetMessageHandler(msgType NetMessageType) (error, *MessageHandl
 	handlerData, ok := s.messageHandlers[msgType]
 	if !ok {
 		errMsg := fmt.Sprintf("[%d] message handler not found", msgType)
-		LogError(errMsg)
+		s.log.Error(errMsg)
 		return errors.New(errMsg), nil
 	}
 
@@ -176,7 +181,7 @@ func (s *Server) GetMessageHandler(msgType NetMessageType) (error, *MessageHandl
 func (s *Server) SendRawData(sessionId SessionID, message []byte) error {
 	session := s.getSession(sessionId)
 	if session == nil {
-		LogError("session not found:", sessionId)
+		s.log.Error("session not found:", sessionId)
 		return errors.New(fmt.Sprintf("session not found: %s", sessionId))
 	}
 
@@ -197,7 +202,7 @@ func (s *Server) BroadcastRawData(message []byte) {
 func (s *Server) SendMessage(sessionId SessionID, messageType NetMessageType, message NetMessagePayload) error {
 	buf, err := s.marshalNetPacket(messageType, message)
 	if err != nil {
-		LogError("marshal message exception:", err)
+		s.log.Error("marshal message exception:", err)
 		return errors.New(fmt.Sprintf("marshal message exception: %s", err.Error()))
 	}
 
@@ -207,7 +212,7 @@ func (s *Server) SendMessage(sessionId SessionID, messageType NetMessageType, me
 func (s *Server) Broadcast(messageType NetMessageType, message NetMessagePayload) {
 	buf, err := s.marshalNetPacket(messageType, message)
 	if err != nil {
-		LogError(" marshal message exception:", err)
+		s.log.Error(" marshal message exception:", err)
 		return
 	}
 
@@ -219,7 +224,7 @@ func (s *Server) Start(_ context.Context) error {
 		return s.err
 	}
 
-	LogInfof("server listening on: %s", s.lis.Addr().String())
+	s.log.Infof("server listening on: %s", s.lis.Addr().String())
 
 	go s.run()
 
@@ -229,7 +234,7 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping ...")
+	s.log.Info("server stopping ...")
 
 	var err error
 
@@ -239,7 +244,7 @@ func (s *Server) Stop(_ context.Context) error {
 	}
 	s.err = nil
 
-	LogInfo("server stopped")
+	s.log.Info("server stopped")
 
 	return err
 }
@@ -280,7 +285,7 @@ func (s *Server) unmarshalNetPacket(buf []byte) (*MessageHandlerData, NetMessage
 func (s *Server) defaultUnmarshalNetPacket(buf []byte) (handler *MessageHandlerData, payload NetMessagePayload, err error) {
 	var msg NetPacket
 	if err = msg.Unmarshal(buf); err != nil {
-		LogErrorf("decode message exception: %s", err)
+		s.log.Errorf("decode message exception: %s", err)
 		return
 	}
 
@@ -292,7 +297,7 @@ func (s *Server) defaultUnmarshalNetPacket(buf []byte) (handler *MessageHandlerD
 		payload = msg.Payload
 	} else {
 		if err = broker.Unmarshal(s.codec, msg.Payload, &payload); err != nil {
-			LogErrorf("unmarshal message exception: %s", err)
+			s.log.Errorf("unmarshal message exception: %s", err)
 			return
 		}
 	}
@@ -315,13 +320,13 @@ func (s *Server) defaultHandleSocketRawData(sessionId SessionID, buf []byte) err
 	var payload NetMessagePayload
 
 	if handler, payload, err = s.unmarshalNetPacket(buf); err != nil {
-		LogErrorf("unmarshal message failed: %s", err)
+		s.log.Errorf("unmarshal message failed: %s", err)
 		return err
 	}
 	//LogDebug(payload)
 
 	if err = handler.Handler(sessionId, payload); err != nil {
-		LogErrorf("message handler failed: %s", err)
+		s.log.Errorf("message handler failed: %s", err)
 		return err
 	}
 
@@ -348,7 +353,7 @@ func (s *Server) doAccept() {
 
 		conn, err := s.lis.Accept()
 		if err != nil {
-			LogError("accept exception:", err)
+			s.log.Error("accept exception:", err)
 			continue
 		}
 
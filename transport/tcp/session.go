@@ -3,6 +3,7 @@ package tcp
 import (
 	"net"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
 )
 
@@ -16,6 +17,8 @@ type Session struct {
 	conn   net.Conn
 	send   chan []byte
 	server *Server
+
+	log *log.Helper
 }
 
 type SessionMap map[SessionID]*Session
@@ -26,12 +29,15 @@ func NewSession(conn net.Conn, server *Server) *Session {
 	}
 
 	u1, _ := uuid.NewUUID()
+	id := SessionID(u1.String())
 
 	c := &Session{
-		id:     SessionID(u1.String()),
+		id:     id,
 		conn:   conn,
 		send:   make(chan []byte, channelBufSize),
 		server: server,
+
+		log: log.NewHelper(log.With(server.log.Logger(), "session_id", id)),
 	}
 
 	return c
@@ -65,7 +71,7 @@ func (c *Session) closeConnect() {
 	//LogInfo(c.SessionID(), " connection closed")
 	if c.conn != nil {
 		if err := c.conn.Close(); err != nil {
-			LogErrorf("disconnect error: %s", err.Error())
+			c.log.Errorf("disconnect error: %s", err.Error())
 		}
 		c.conn = nil
 	}
@@ -83,7 +89,7 @@ func (c *Session) writePump() {
 			}
 			var err error
 			if _, err = c.conn.Write(msg); err != nil {
-				LogError("write message error: ", err)
+				c.log.Error("write message error: ", err)
 				return
 			}
 		}
@@ -103,12 +109,12 @@ func (c *Session) readPump() {
 		}
 
 		if readLen, err = c.conn.Read(buf); err != nil {
-			LogErrorf("read message error: %v", err)
+			c.log.Errorf("read message error: %v", err)
 			return
 		}
 
 		if err = c.server.handleSocketRawData(c.SessionID(), buf[:readLen]); err != nil {
-			LogErrorf("process message error: %v", err)
+			c.log.Errorf("process message error: %v", err)
 		}
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 )
 
@@ -22,18 +23,22 @@ type clientOptions struct {
 	bufferSize int
 
 	secure bool
+
+	logger log.Logger
 }
 
 type Connection struct {
 	Client    *thrift.TStandardClient
 	Transport thrift.TTransport
+
+	log *log.Helper
 }
 
 func (c *Connection) Close() {
 	if c.Transport != nil {
 		err := c.Transport.Close()
 		if err != nil {
-			LogError("failed to close transport: %v", err)
+			c.log.Errorf("failed to close transport: %v", err)
 		}
 	}
 }
@@ -82,5 +87,6 @@ func dial(opts ...ClientOption) (*Connection, error) {
 	return &Connection{
 		Client:    thrift.NewTStandardClient(iProto, oProto),
 		Transport: clientTransport,
+		log:       newLoggerHelper(cli.logger),
 	}, nil
 }
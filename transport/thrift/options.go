@@ -3,6 +3,7 @@ package thrift
 import (
 	"crypto/tls"
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 )
 
@@ -40,6 +41,13 @@ func WithTransportConfig(buffered, framed bool, bufferSize int) ServerOption {
 	}
 }
 
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type ClientOption func(o *clientOptions)
@@ -78,3 +86,10 @@ func WithClientTransportConfig(buffered, framed bool, bufferSize int) ClientOpti
 		o.bufferSize = bufferSize
 	}
 }
+
+// WithClientLogger 客户端日志器，默认使用 kratos 的全局日志器
+func WithClientLogger(logger log.Logger) ClientOption {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
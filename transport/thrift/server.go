@@ -9,6 +9,7 @@ import (
 	"net/url"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/go-kratos/kratos/v2/log"
 
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
@@ -42,6 +43,8 @@ type Server struct {
 	processor thrift.TProcessor
 
 	err error
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -50,6 +53,8 @@ func NewServer(opts ...ServerOption) *Server {
 		buffered:   false,
 		framed:     false,
 		protocol:   "binary",
+
+		log: newLoggerHelper(nil),
 	}
 
 	srv.init(opts...)
@@ -123,7 +128,7 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	LogInfof("server listening on: %s", s.address)
+	s.log.Infof("server listening on: %s", s.address)
 
 	s.Server = thrift.NewTSimpleServer4(s.processor, serverTransport, transportFactory, protocolFactory)
 	if err := s.Server.Serve(); err != nil {
@@ -134,7 +139,7 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	var err error
 
@@ -142,7 +147,7 @@ func (s *Server) Stop(_ context.Context) error {
 		err = s.Server.Stop()
 	}
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
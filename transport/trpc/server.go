@@ -9,6 +9,7 @@ import (
 	trpcGo "trpc.group/trpc-go/trpc-go"
 	trpcServer "trpc.group/trpc-go/trpc-go/server"
 
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 
 	"github.com/tx7do/kratos-transport/transport"
@@ -28,10 +29,14 @@ type Server struct {
 	err error
 
 	endpoint *url.URL
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
-	srv := &Server{}
+	srv := &Server{
+		log: newLoggerHelper(nil),
+	}
 
 	srv.init(opts...)
 
@@ -86,7 +91,7 @@ func (s *Server) Start(_ context.Context) error {
 	}
 
 	if s.err = s.Server.Serve(); s.err != nil {
-		LogErrorf("server serve error: %v", s.err)
+		s.log.Errorf("server serve error: %v", s.err)
 		return s.err
 	}
 
@@ -94,18 +99,18 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Server.Close(nil)
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
 
 func (s *Server) AddService(serviceName string, service trpcServer.Service) {
 	if s.Server == nil {
-		LogErrorf("server is not initialized, cannot add service %s", serviceName)
+		s.log.Errorf("server is not initialized, cannot add service %s", serviceName)
 		return
 	}
 
@@ -1,6 +1,8 @@
 package trpc
 
 import (
+	"github.com/go-kratos/kratos/v2/log"
+
 	trpcServer "trpc.group/trpc-go/trpc-go/server"
 )
 
@@ -42,3 +44,10 @@ func WithServiceName(name string) ServerOption {
 		s.trpcOptions = append(s.trpcOptions, trpcServer.WithServiceName(name))
 	}
 }
+
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 
 	ws "github.com/gorilla/websocket"
 
@@ -33,6 +34,8 @@ type Client struct {
 	timeout time.Duration
 
 	payloadType PayloadType
+
+	log *log.Helper
 }
 
 func NewClient(opts ...ClientOption) *Client {
@@ -42,6 +45,8 @@ func NewClient(opts ...ClientOption) *Client {
 		codec:           encoding.GetCodec("json"),
 		messageHandlers: make(ClientMessageHandlerMap),
 		payloadType:     PayloadTypeBinary,
+
+		log: newLoggerHelper(nil),
 	}
 
 	cli.init(opts...)
@@ -62,11 +67,11 @@ func (c *Client) Connect() error {
 		return errors.New("endpoint is nil")
 	}
 
-	LogInfof("connecting to %s", c.endpoint.String())
+	c.log.Infof("connecting to %s", c.endpoint.String())
 
 	conn, resp, err := ws.DefaultDialer.Dial(c.endpoint.String(), nil)
 	if err != nil {
-		LogErrorf("%s [%v]", err.Error(), resp)
+		c.log.Errorf("%s [%v]", err.Error(), resp)
 		return err
 	}
 	c.conn = conn
@@ -79,7 +84,7 @@ func (c *Client) Connect() error {
 func (c *Client) Disconnect() {
 	if c.conn != nil {
 		if err := c.conn.Close(); err != nil {
-			LogErrorf("disconnect error: %s", err.Error())
+			c.log.Errorf("disconnect error: %s", err.Error())
 		}
 		c.conn = nil
 	}
@@ -100,7 +105,7 @@ func RegisterClientMessageHandler[T any](cli *Client, messageType NetMessageType
 			case *T:
 				return handler(t)
 			default:
-				LogError("invalid payload struct type:", t)
+				cli.log.Error("invalid payload struct type:", t)
 				return errors.New("invalid payload struct type")
 			}
 		},
@@ -157,7 +162,7 @@ func (c *Client) marshalMessage(messageType NetMessageType, message MessagePaylo
 func (c *Client) SendMessage(messageType NetMessageType, message interface{}) error {
 	buff, err := c.marshalMessage(messageType, message)
 	if err != nil {
-		LogError("marshal message exception:", err)
+		c.log.Error("marshal message exception:", err)
 		return err
 	}
 
@@ -201,7 +206,7 @@ func (c *Client) run() {
 		messageType, data, err := c.conn.ReadMessage()
 		if err != nil {
 			if ws.IsUnexpectedCloseError(err, ws.CloseNormalClosure, ws.CloseGoingAway, ws.CloseAbnormalClosure) {
-				LogErrorf("read message error: %v", err)
+				c.log.Errorf("read message error: %v", err)
 			}
 			return
 		}
@@ -220,7 +225,7 @@ func (c *Client) run() {
 
 		case ws.PingMessage:
 			if err := c.sendPongMessage(""); err != nil {
-				LogError("write pong message error: ", err)
+				c.log.Error("write pong message error: ", err)
 				return
 			}
 			break
@@ -240,14 +245,14 @@ func (c *Client) unmarshalMessage(buf []byte) (*ClientHandlerData, MessagePayloa
 	case PayloadTypeBinary:
 		var msg BinaryNetPacket
 		if err := msg.Unmarshal(buf); err != nil {
-			LogErrorf("decode message exception: %s", err)
+			c.log.Errorf("decode message exception: %s", err)
 			return nil, nil, err
 		}
 
 		var ok bool
 		handler, ok = c.messageHandlers[msg.Type]
 		if !ok {
-			LogError("message handler not found:", msg.Type)
+			c.log.Error("message handler not found:", msg.Type)
 			return nil, nil, errors.New("message handler not found")
 		}
 
@@ -255,7 +260,7 @@ func (c *Client) unmarshalMessage(buf []byte) (*ClientHandlerData, MessagePayloa
 			payload = handler.Creator()
 
 			if err := broker.Unmarshal(c.codec, msg.Payload, &payload); err != nil {
-				LogErrorf("unmarshal message exception: %s", err)
+				c.log.Errorf("unmarshal message exception: %s", err)
 				return nil, nil, err
 			}
 		} else {
@@ -267,14 +272,14 @@ func (c *Client) unmarshalMessage(buf []byte) (*ClientHandlerData, MessagePayloa
 	case PayloadTypeText:
 		var msg TextNetPacket
 		if err := msg.Unmarshal(buf); err != nil {
-			LogErrorf("decode message exception: %s", err)
+			c.log.Errorf("decode message exception: %s", err)
 			return nil, nil, err
 		}
 
 		var ok bool
 		handler, ok = c.messageHandlers[msg.Type]
 		if !ok {
-			LogError("message handler not found:", msg.Type)
+			c.log.Error("message handler not found:", msg.Type)
 			return nil, nil, errors.New("message handler not found")
 		}
 
@@ -282,7 +287,7 @@ func (c *Client) unmarshalMessage(buf []byte) (*ClientHandlerData, MessagePayloa
 			payload = handler.Creator()
 
 			if err := broker.Unmarshal(c.codec, []byte(msg.Payload), &payload); err != nil {
-				LogErrorf("unmarshal message exception: %s", err)
+				c.log.Errorf("unmarshal message exception: %s", err)
 				return nil, nil, err
 			}
 		} else {
@@ -301,13 +306,13 @@ func (c *Client) messageHandler(buf []byte) error {
 	var payload MessagePayload
 
 	if handler, payload, err = c.unmarshalMessage(buf); err != nil {
-		LogErrorf("unmarshal message failed: %s", err)
+		c.log.Errorf("unmarshal message failed: %s", err)
 		return err
 	}
 	//LogDebug(payload)
 
 	if err = handler.Handler(payload); err != nil {
-		LogErrorf("message handler exception: %s", err)
+		c.log.Errorf("message handler exception: %s", err)
 		return err
 	}
 
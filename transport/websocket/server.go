@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	kratosTransport "github.com/go-kratos/kratos/v2/transport"
 	"net"
 	"net/http"
@@ -53,6 +54,8 @@ type Server struct {
 	netPacketUnmarshaler NetPacketUnmarshaler
 
 	socketRawDataHandler SocketRawDataHandler
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -77,10 +80,12 @@ func NewServer(opts ...ServerOption) *Server {
 		unregister: make(chan *Session),
 
 		payloadType: PayloadTypeBinary,
+
+		log: newLoggerHelper(nil),
 	}
 
 	if err := srv.init(opts...); err != nil {
-		LogError("websocket server init error:", err)
+		srv.log.Error("websocket server init error:", err)
 		return nil
 	}
 
@@ -144,7 +149,7 @@ func RegisterServerMessageHandler[T any](srv *Server, messageType NetMessageType
 			case *T:
 				return handler(sessionId, t)
 			default:
-				LogError("invalid payload struct type:", t)
+				srv.log.Error("invalid payload struct type:", t)
 				return errors.New("invalid payload struct type")
 			}
 		},
@@ -213,7 +218,7 @@ func (s *Server) defaultMarshalNetPacket(messageType NetMessageType, message Mes
 func (s *Server) SendRawMessage(sessionId SessionID, message []byte) error {
 	c, ok := s.sessionMgr.Get(sessionId)
 	if !ok {
-		LogError("session not found:", sessionId)
+		s.log.Error("session not found:", sessionId)
 		return errors.New("session not found")
 	}
 
@@ -230,7 +235,7 @@ func (s *Server) SendMessage(sessionId SessionID, messageType NetMessageType, me
 	case PayloadTypeBinary:
 		buf, err = s.marshalMessage(messageType, message)
 		if err != nil {
-			LogError("marshal binary message error:", err)
+			s.log.Error("marshal binary message error:", err)
 			return err
 		}
 
@@ -239,7 +244,7 @@ func (s *Server) SendMessage(sessionId SessionID, messageType NetMessageType, me
 	case PayloadTypeText:
 		buf, err = s.codec.Marshal(message)
 		if err != nil {
-			LogError("marshal text message error:", err)
+			s.log.Error("marshal text message error:", err)
 			return err
 		}
 
@@ -252,7 +257,7 @@ func (s *Server) SendMessage(sessionId SessionID, messageType NetMessageType, me
 func (s *Server) Broadcast(messageType NetMessageType, message MessagePayload) {
 	buf, err := s.marshalMessage(messageType, message)
 	if err != nil {
-		LogError(" marshal message error:", err)
+		s.log.Error(" marshal message error:", err)
 		return
 	}
 
@@ -277,7 +282,7 @@ func (s *Server) defaultUnmarshalNetPacket(buf []byte) (handler *MessageHandlerD
 	case PayloadTypeBinary:
 		var msg BinaryNetPacket
 		if err = msg.Unmarshal(buf); err != nil {
-			LogErrorf("decode message exception: %s", err)
+			s.log.Errorf("decode message exception: %s", err)
 			return nil, nil, err
 		}
 		messageType = msg.Type
@@ -286,7 +291,7 @@ func (s *Server) defaultUnmarshalNetPacket(buf []byte) (handler *MessageHandlerD
 	case PayloadTypeText:
 		var msg TextNetPacket
 		if err = msg.Unmarshal(buf); err != nil {
-			LogErrorf("decode message exception: %s", err)
+			s.log.Errorf("decode message exception: %s", err)
 			return nil, nil, err
 		}
 		messageType = msg.Type
@@ -294,7 +299,7 @@ func (s *Server) defaultUnmarshalNetPacket(buf []byte) (handler *MessageHandlerD
 	}
 
 	if handler = s.GetMessageHandler(messageType); handler == nil {
-		LogError("message handler not found:", messageType)
+		s.log.Error("message handler not found:", messageType)
 		return nil, nil, errors.New("message handler not found")
 	}
 
@@ -302,7 +307,7 @@ func (s *Server) defaultUnmarshalNetPacket(buf []byte) (handler *MessageHandlerD
 		payload = rawPayload
 	} else {
 		if err = broker.Unmarshal(s.codec, rawPayload, &payload); err != nil {
-			LogErrorf("unmarshal message exception: %s", err)
+			s.log.Errorf("unmarshal message exception: %s", err)
 			return nil, nil, err
 		}
 	}
@@ -327,13 +332,13 @@ func (s *Server) defaultHandleSocketRawData(sessionId SessionID, buf []byte) err
 	var payload MessagePayload
 
 	if handler, payload, err = s.unmarshalNetPacket(buf); err != nil {
-		LogErrorf("unmarshal message failed: %s", err)
+		s.log.Errorf("unmarshal message failed: %s", err)
 		return err
 	}
 	//LogDebug(payload)
 
 	if err = handler.Handler(sessionId, payload); err != nil {
-		LogErrorf("message handler failed: %s", err)
+		s.log.Errorf("message handler failed: %s", err)
 		return err
 	}
 
@@ -352,7 +357,7 @@ func (s *Server) wsHandler(res http.ResponseWriter, req *http.Request) {
 
 	conn, err := s.upgrader.Upgrade(res, req, nil)
 	if err != nil {
-		LogError("upgrade exception:", err)
+		s.log.Error("upgrade exception:", err)
 		return
 	}
 
@@ -423,7 +428,7 @@ func (s *Server) Start(ctx context.Context) error {
 	s.BaseContext = func(net.Listener) context.Context {
 		return ctx
 	}
-	LogInfof("server listening on: %s", s.lis.Addr().String())
+	s.log.Infof("server listening on: %s", s.lis.Addr().String())
 
 	go s.run()
 
@@ -440,12 +445,12 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	err := s.Shutdown(ctx)
 	s.err = nil
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
 	ws "github.com/gorilla/websocket"
 )
@@ -21,6 +22,8 @@ type Session struct {
 
 	lastReadMessageTime  time.Time // 最后一次读取消息的时间
 	lastWriteMessageTime time.Time // 最后一次发送消息的时间
+
+	log *log.Helper
 }
 
 func NewSession(server *Server, conn *ws.Conn, vars url.Values) *Session {
@@ -29,13 +32,16 @@ func NewSession(server *Server, conn *ws.Conn, vars url.Values) *Session {
 	}
 
 	u1, _ := uuid.NewUUID()
+	id := SessionID(u1.String())
 
 	c := &Session{
-		id:      SessionID(u1.String()),
+		id:      id,
 		conn:    conn,
 		queries: vars,
 		send:    make(chan []byte, channelBufSize),
 		server:  server,
+
+		log: log.NewHelper(log.With(server.log.Logger(), "session_id", id)),
 	}
 
 	return c
@@ -73,7 +79,7 @@ func (s *Session) closeConnect() {
 	//LogInfo(s.SessionID(), " connection closed")
 	if s.conn != nil {
 		if err := s.conn.Close(); err != nil {
-			LogErrorf("disconnect error: %s", err.Error())
+			s.log.Errorf("disconnect error: %s", err.Error())
 		}
 		s.conn = nil
 	}
@@ -120,14 +126,14 @@ func (s *Session) writePump() {
 			switch s.server.payloadType {
 			case PayloadTypeBinary:
 				if err = s.sendBinaryMessage(msg); err != nil {
-					LogError("write binary message error: ", err)
+					s.log.Error("write binary message error: ", err)
 					return
 				}
 				break
 
 			case PayloadTypeText:
 				if err = s.sendTextMessage(string(msg)); err != nil {
-					LogError("write text message error: ", err)
+					s.log.Error("write text message error: ", err)
 					return
 				}
 				break
@@ -148,7 +154,7 @@ func (s *Session) readPump() {
 		messageType, data, err := s.conn.ReadMessage()
 		if err != nil {
 			if ws.IsUnexpectedCloseError(err, ws.CloseNormalClosure, ws.CloseGoingAway, ws.CloseAbnormalClosure) {
-				LogErrorf("read message error: %v", err)
+				s.log.Errorf("read message error: %v", err)
 			}
 			return
 		}
@@ -169,7 +175,7 @@ func (s *Session) readPump() {
 
 		case ws.PingMessage:
 			if err = s.sendPongMessage(""); err != nil {
-				LogError("write pong message error: ", err)
+				s.log.Error("write pong message error: ", err)
 				return
 			}
 			break
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/http3"
 	"github.com/tx7do/kratos-transport/broker"
@@ -36,6 +37,8 @@ type Client struct {
 
 	codec           encoding.Codec
 	messageHandlers ClientMessageHandlerMap
+
+	log *log.Helper
 }
 
 func NewClient(opts ...ClientOption) *Client {
@@ -43,6 +46,8 @@ func NewClient(opts ...ClientOption) *Client {
 		transport:       &http3.Transport{},
 		codec:           encoding.GetCodec("json"),
 		messageHandlers: make(ClientMessageHandlerMap),
+
+		log: newLoggerHelper(nil),
 	}
 	cli.init(opts...)
 	return cli
@@ -100,13 +105,13 @@ func (c *Client) Connect() error {
 		return fmt.Errorf("received status %d", rsp.StatusCode)
 	}
 
-	LogInfof("client connected to: %s", c.url)
+	c.log.Infof("client connected to: %s", c.url)
 
 	return nil
 }
 
 func (c *Client) Disconnect() error {
-	LogInfo("client stopping")
+	c.log.Info("client stopping")
 	return nil
 }
 
@@ -167,13 +172,13 @@ func (c *Client) newWebTransportRequest() (*http.Request, error) {
 func (c *Client) messageHandler(buf []byte) error {
 	var msg Message
 	if err := msg.Unmarshal(buf); err != nil {
-		LogErrorf("decode message exception: %s", err)
+		c.log.Errorf("decode message exception: %s", err)
 		return err
 	}
 
 	handlerData, ok := c.messageHandlers[msg.Type]
 	if !ok {
-		LogError("message type not found:", msg.Type)
+		c.log.Error("message type not found:", msg.Type)
 		return errors.New("message handler not found")
 	}
 
@@ -183,7 +188,7 @@ func (c *Client) messageHandler(buf []byte) error {
 		payload = handlerData.Binder()
 
 		if err := broker.Unmarshal(c.codec, msg.Body, &payload); err != nil {
-			LogErrorf("unmarshal message exception: %s", err)
+			c.log.Errorf("unmarshal message exception: %s", err)
 			return err
 		}
 	} else {
@@ -191,7 +196,7 @@ func (c *Client) messageHandler(buf []byte) error {
 	}
 
 	if err := handlerData.Handler(payload); err != nil {
-		LogErrorf("message handler exception: %s", err)
+		c.log.Errorf("message handler exception: %s", err)
 		return err
 	}
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/go-kratos/kratos/v2/log"
 
 	"github.com/quic-go/quic-go"
 )
@@ -65,6 +66,13 @@ func WithCodec(c string) ServerOption {
 	}
 }
 
+// WithLogger 日志器，默认使用 kratos 的全局日志器
+func WithLogger(logger log.Logger) ServerOption {
+	return func(s *Server) {
+		s.log = newLoggerHelper(logger)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type ClientOption func(*Client)
@@ -104,3 +112,10 @@ func WithClientKeepAlivePeriod(timeout time.Duration) ClientOption {
 		s.transport.QUICConfig.KeepAlivePeriod = timeout
 	}
 }
+
+// WithClientLogger 日志器，默认使用 kratos 的全局日志器
+func WithClientLogger(logger log.Logger) ClientOption {
+	return func(c *Client) {
+		c.log = newLoggerHelper(logger)
+	}
+}
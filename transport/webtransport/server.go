@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/quic-go/quic-go"
 
 	"github.com/go-kratos/kratos/v2/encoding"
@@ -49,6 +50,8 @@ type Server struct {
 	messageHandlers MessageHandlerMap
 	connectHandler  ConnectHandler
 	codec           encoding.Codec
+
+	log *log.Helper
 }
 
 func NewServer(opts ...ServerOption) *Server {
@@ -60,6 +63,8 @@ func NewServer(opts ...ServerOption) *Server {
 
 		messageHandlers: make(MessageHandlerMap),
 		codec:           encoding.GetCodec("json"),
+
+		log: newLoggerHelper(nil),
 	}
 	srv.init(opts...)
 	return srv
@@ -129,10 +134,10 @@ func (s *Server) Start(_ context.Context) error {
 		return err
 	}
 
-	LogInfof("server listening on: %s", s.Addr)
+	s.log.Infof("server listening on: %s", s.Addr)
 
 	if err := s.ListenAndServe(); err != nil {
-		LogErrorf("start server failed: %s", err.Error())
+		s.log.Errorf("start server failed: %s", err.Error())
 		return err
 	}
 
@@ -140,7 +145,7 @@ func (s *Server) Start(_ context.Context) error {
 }
 
 func (s *Server) Stop(_ context.Context) error {
-	LogInfo("server stopping...")
+	s.log.Info("server stopping...")
 
 	if s.ctxCancel != nil {
 		s.ctxCancel()
@@ -149,7 +154,7 @@ func (s *Server) Stop(_ context.Context) error {
 	err := s.Server.Close()
 	s.refCount.Wait()
 
-	LogInfo("server stopped.")
+	s.log.Info("server stopped.")
 
 	return err
 }
@@ -188,13 +193,13 @@ func (s *Server) marshalMessage(messageType MessageType, message MessagePayload)
 func (s *Server) messageHandler(sessionId SessionID, buf []byte) error {
 	var msg Message
 	if err := msg.Unmarshal(buf); err != nil {
-		LogErrorf("decode message exception: %s", err)
+		s.log.Errorf("decode message exception: %s", err)
 		return err
 	}
 
 	handlerData, ok := s.messageHandlers[msg.Type]
 	if !ok {
-		LogError("message type not found:", msg.Type)
+		s.log.Error("message type not found:", msg.Type)
 		return errors.New("message handler not found")
 	}
 
@@ -204,7 +209,7 @@ func (s *Server) messageHandler(sessionId SessionID, buf []byte) error {
 		payload = handlerData.Binder()
 
 		if err := broker.Unmarshal(s.codec, msg.Body, &payload); err != nil {
-			LogErrorf("unmarshal message exception: %s", err)
+			s.log.Errorf("unmarshal message exception: %s", err)
 			return err
 		}
 	} else {
@@ -212,7 +217,7 @@ func (s *Server) messageHandler(sessionId SessionID, buf []byte) error {
 	}
 
 	if err := handlerData.Handler(sessionId, payload); err != nil {
-		LogErrorf("message handler exception: %s", err)
+		s.log.Errorf("message handler exception: %s", err)
 		return err
 	}
 